@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/hybridDB"
+)
+
+const level string = "level"
+const bolt string = "bolt"
+
+func main() {
+	fmt.Println("Usage:")
+	fmt.Println("AddressHistoryIndex level/bolt DBFileLocation")
+	fmt.Println("All factoid and entry credit blocks in the database will be indexed by address, factomd must not be running")
+
+	if len(os.Args) < 3 {
+		fmt.Println("\nNot enough arguments passed")
+		os.Exit(1)
+	}
+	if len(os.Args) > 3 {
+		fmt.Println("\nToo many arguments passed")
+		os.Exit(1)
+	}
+
+	levelBolt := os.Args[1]
+
+	if levelBolt != level && levelBolt != bolt {
+		fmt.Println("\nFirst argument should be `level` or `bolt`")
+		os.Exit(1)
+	}
+	path := os.Args[2]
+
+	var dbase *hybridDB.HybridDB
+	var err error
+	if levelBolt == bolt {
+		dbase = hybridDB.NewBoltMapHybridDB(nil, path)
+	} else {
+		dbase, err = hybridDB.NewLevelMapHybridDB(path, false)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	dbo := databaseOverlay.NewOverlay(dbase)
+	defer dbo.Close()
+
+	start := time.Now()
+	count, err := dbo.RebuildAddressHistoryIndex()
+	if err != nil {
+		fmt.Printf("\nIndexing failed after %d records: %v\n", count, err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nIndexed %d records in %s\n", count, time.Since(start))
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	boltlib "github.com/FactomProject/bolt"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/badgerdb"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/goleveldb/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb/opt"
+)
+
+const level string = "level"
+const bolt string = "bolt"
+
+// batchSize is the number of records written to the Badger database at once
+const batchSize int = 10000
+
+// progressInterval is the number of records between two progress reports
+const progressInterval int = 100000
+
+func main() {
+	fmt.Println("Usage:")
+	fmt.Println("BadgerMigrate level/bolt SourceLocation DestinationLocation")
+	fmt.Println("Every record of the LevelDB or Bolt database is copied into a new Badger database and verified")
+
+	if len(os.Args) < 4 {
+		fmt.Println("\nNot enough arguments passed")
+		os.Exit(1)
+	}
+	if len(os.Args) > 4 {
+		fmt.Println("\nToo many arguments passed")
+		os.Exit(1)
+	}
+
+	levelBolt := os.Args[1]
+	if levelBolt != level && levelBolt != bolt {
+		fmt.Println("\nFirst argument should be `level` or `bolt`")
+		os.Exit(1)
+	}
+
+	if err := Migrate(levelBolt, os.Args[2], os.Args[3]); err != nil {
+		fmt.Printf("\nMigration failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Source iterates over every record of a database
+type Source interface {
+	ForEach(func(bucket, key, value []byte) error) error
+	Close() error
+}
+
+// OpenSource opens the LevelDB or Bolt database read only
+func OpenSource(levelBolt string, path string) (Source, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	switch levelBolt {
+	case level:
+		db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: true, OpenFilesCacheCapacity: 50})
+		if err != nil {
+			return nil, err
+		}
+		return &LevelSource{db: db}, nil
+	case bolt:
+		db, err := boltlib.Open(path, 0600, &boltlib.Options{ReadOnly: true, Timeout: time.Second})
+		if err != nil {
+			return nil, err
+		}
+		return &BoltSource{db: db}, nil
+	}
+	return nil, fmt.Errorf("unknown database type %s", levelBolt)
+}
+
+type LevelSource struct {
+	db *leveldb.DB
+}
+
+func (s *LevelSource) ForEach(f func(bucket, key, value []byte) error) error {
+	iter := s.db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		bucket, key, err := SplitLevelKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		if err := f(bucket, key, iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (s *LevelSource) Close() error {
+	return s.db.Close()
+}
+
+type BoltSource struct {
+	db *boltlib.DB
+}
+
+func (s *BoltSource) ForEach(f func(bucket, key, value []byte) error) error {
+	return s.db.View(func(tx *boltlib.Tx) error {
+		return tx.ForEach(func(bucket []byte, b *boltlib.Bucket) error {
+			return b.ForEach(func(key, value []byte) error {
+				return f(bucket, key, value)
+			})
+		})
+	})
+}
+
+func (s *BoltSource) Close() error {
+	return s.db.Close()
+}
+
+// bucketFamilies are the buckets whose names are a constant followed by a fixed length suffix
+var bucketFamilies = []struct {
+	prefix []byte
+	suffix int
+}{
+	{databaseOverlay.ENTRYBLOCK_CHAIN_NUMBER, 32},
+	{databaseOverlay.EXTID_INDEX, 32},
+	{databaseOverlay.ADDRESS_HISTORY, 32},
+	{databaseOverlay.BALANCE_CHECKPOINT, 5},
+}
+
+// SplitLevelKey splits a LevelDB key into the bucket and the key. LevelDB joins them with a ';', which can also
+// appear in the bucket, so the buckets of the database overlay are matched instead: the constant buckets, the
+// families of buckets above and the 32 byte chain IDs of the entry buckets.
+func SplitLevelKey(ldbKey []byte) ([]byte, []byte, error) {
+	split := func(n int) ([]byte, []byte) {
+		bucket := make([]byte, n)
+		copy(bucket, ldbKey[:n])
+		key := make([]byte, len(ldbKey)-n-1)
+		copy(key, ldbKey[n+1:])
+		return bucket, key
+	}
+
+	for _, family := range bucketFamilies {
+		n := len(family.prefix) + family.suffix
+		if len(ldbKey) > n && ldbKey[n] == ';' && bytes.HasPrefix(ldbKey, family.prefix) {
+			bucket, key := split(n)
+			return bucket, key, nil
+		}
+	}
+	for name := range databaseOverlay.ConstantNamesMap {
+		n := len(name)
+		if len(ldbKey) > n && ldbKey[n] == ';' && string(ldbKey[:n]) == name {
+			bucket, key := split(n)
+			return bucket, key, nil
+		}
+	}
+	if len(ldbKey) > 32 && ldbKey[32] == ';' {
+		bucket, key := split(32)
+		return bucket, key, nil
+	}
+	return nil, nil, fmt.Errorf("unknown bucket of key %x", ldbKey)
+}
+
+// Migrate copies the source database into a new Badger database at the destination, verifies the copy against the
+// source and compacts it
+func Migrate(levelBolt string, sourcePath string, destinationPath string) error {
+	if _, err := os.Stat(destinationPath); err == nil {
+		return fmt.Errorf("destination %s already exists", destinationPath)
+	}
+
+	src, err := OpenSource(levelBolt, sourcePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := badgerdb.NewBadgerDB(destinationPath, true)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	start := time.Now()
+	copied, err := CopyRecords(src, dst)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Copied %d records in %v\n", copied, time.Since(start).Round(time.Second))
+
+	start = time.Now()
+	if err := Verify(src, dst, copied); err != nil {
+		return err
+	}
+	fmt.Printf("Verified %d records in %v\n", copied, time.Since(start).Round(time.Second))
+
+	dbo := databaseOverlay.NewOverlay(dst)
+	head, err := dbo.FetchDBlockHead()
+	if err != nil {
+		return err
+	}
+	if head != nil {
+		fmt.Printf("Directory block head %d %s\n", head.GetDatabaseHeight(), head.GetKeyMR().String())
+	}
+
+	fmt.Println("Compacting")
+	return dst.Compact()
+}
+
+// CopyRecords writes every record of the source into the destination in batches and returns the number of records
+func CopyRecords(src Source, dst interfaces.IDatabase) (int, error) {
+	copied := 0
+	size := 0
+	batch := make([]interfaces.Record, 0, batchSize)
+	flush := func() error {
+		if err := dst.PutInBatch(batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err := src.ForEach(func(bucket, key, value []byte) error {
+		// the iterators reuse their slices
+		bs := new(primitives.ByteSlice)
+		bs.Bytes = append([]byte{}, value...)
+		batch = append(batch, interfaces.Record{Bucket: append([]byte{}, bucket...), Key: append([]byte{}, key...), Data: bs})
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		copied++
+		size += len(bucket) + len(key) + len(value)
+		if copied%progressInterval == 0 {
+			fmt.Printf("Copied %d records, %d MB\n", copied, size>>20)
+		}
+		return nil
+	})
+	if err != nil {
+		return copied, err
+	}
+	return copied, flush()
+}
+
+// Verify compares every record of the source with the destination and checks that the destination has no other records
+func Verify(src Source, dst interfaces.IDatabase, count int) error {
+	verified := 0
+	err := src.ForEach(func(bucket, key, value []byte) error {
+		bs := new(primitives.ByteSlice)
+		got, err := dst.Get(bucket, key, bs)
+		if err != nil {
+			return err
+		}
+		if got == nil {
+			return fmt.Errorf("record %x of bucket %x is missing", key, bucket)
+		}
+		if !bytes.Equal(bs.Bytes, value) {
+			return fmt.Errorf("record %x of bucket %x differs", key, bucket)
+		}
+
+		verified++
+		if verified%progressInterval == 0 {
+			fmt.Printf("Verified %d/%d records\n", verified, count)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	buckets, err := dst.ListAllBuckets()
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, bucket := range buckets {
+		keys, err := dst.ListAllKeys(bucket)
+		if err != nil {
+			return err
+		}
+		total += len(keys)
+	}
+	if total != verified {
+		return fmt.Errorf("destination has %d records, source has %d", total, verified)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/badgerdb"
+	"github.com/FactomProject/factomd/database/boltdb"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestSplitLevelKey(t *testing.T) {
+	chainID := bytes.Repeat([]byte{';'}, 32)
+	sha := bytes.Repeat([]byte{0xaa}, 32)
+	for _, c := range []struct {
+		bucket []byte
+		key    []byte
+	}{
+		{databaseOverlay.ENTRY, []byte("key;with;separators")},
+		{databaseOverlay.ENTRYBLOCK, []byte{1}},
+		{databaseOverlay.ENTRYBLOCK_SECONDARYINDEX, []byte{2}},
+		{append(databaseOverlay.ENTRYBLOCK_CHAIN_NUMBER, chainID...), []byte{0, 0, 0, 1}},
+		{append(databaseOverlay.EXTID_INDEX, sha...), sha},
+		{databaseOverlay.BalanceCheckpointBucket(1000, true), sha},
+		{chainID, sha},
+	} {
+		bucket, key, err := SplitLevelKey(leveldb.CombineBucketAndKey(c.bucket, c.key))
+		if err != nil {
+			t.Errorf("%x: %v", c.bucket, err)
+			continue
+		}
+		if !bytes.Equal(bucket, c.bucket) || !bytes.Equal(key, c.key) {
+			t.Errorf("split into %x %x, want %x %x", bucket, key, c.bucket, c.key)
+		}
+	}
+
+	if _, _, err := SplitLevelKey([]byte("Unknown;key")); err == nil {
+		t.Errorf("split a key of an unknown bucket")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	levelPath := filepath.Join(dir, "level")
+	boltPath := filepath.Join(dir, "bolt.db")
+
+	ldb, err := leveldb.NewLevelDB(levelPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb := boltdb.NewBoltDB(nil, boltPath)
+	for _, db := range []interfaces.IDatabase{ldb, bdb} {
+		dbo := databaseOverlay.NewOverlay(db)
+		dbo.SetExtIDIndex(true)
+		testHelper.PopulateTestDatabaseOverlay(dbo)
+		dbo.Close()
+	}
+
+	for _, c := range []struct {
+		levelBolt string
+		path      string
+	}{{level, levelPath}, {bolt, boltPath}} {
+		destination := filepath.Join(dir, c.levelBolt+"-badger")
+		if err := Migrate(c.levelBolt, c.path, destination); err != nil {
+			t.Fatalf("%s: %v", c.levelBolt, err)
+		}
+		if err := Migrate(c.levelBolt, c.path, destination); err == nil {
+			t.Errorf("%s: migrated into an existing database", c.levelBolt)
+		}
+
+		db, err := badgerdb.NewBadgerDB(destination, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dbo := databaseOverlay.NewOverlay(db)
+		for _, block := range testHelper.CreateFullTestBlockSet() {
+			dblock, err := dbo.FetchDBlockByHeight(uint32(block.Height))
+			if err != nil || dblock == nil || !dblock.GetKeyMR().IsSameAs(block.DBlock.GetKeyMR()) {
+				t.Errorf("%s: directory block %d = %v, %v", c.levelBolt, block.Height, dblock, err)
+			}
+			for _, entry := range block.Entries {
+				got, err := dbo.FetchEntry(entry.GetHash())
+				if err != nil || got == nil {
+					t.Errorf("%s: entry %s = %v, %v", c.levelBolt, entry.GetHash(), got, err)
+				}
+			}
+		}
+		heads, _, err := db.GetAll(databaseOverlay.CHAIN_HEAD, primitives.NewZeroHash())
+		if err != nil || len(heads) == 0 {
+			t.Errorf("%s: %d chain heads, %v", c.levelBolt, len(heads), err)
+		}
+		db.Close()
+	}
+}
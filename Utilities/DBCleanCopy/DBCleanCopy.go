@@ -71,12 +71,8 @@ func CopyDB(dbase1, dbase2 interfaces.DBOverlay) {
 		}
 	}()
 
-	dBlocks, err := dbase1.FetchAllDBlocks()
-	if err != nil {
-		panic(err)
-	}
 	prevECHash := primitives.NewZeroHash()
-	for _, dBlock := range dBlocks {
+	err := dbase1.ForEachDBlock(func(dBlock interfaces.IDirectoryBlock) error {
 		dbase2.StartMultiBatch()
 
 		err := dbase2.ProcessDBlockMultiBatch(dBlock)
@@ -158,5 +154,9 @@ func CopyDB(dbase1, dbase2 interfaces.DBOverlay) {
 		if dBlock.GetDatabaseHeight()%1000 == 0 {
 			fmt.Printf("Processed block #%v\n", dBlock.GetDatabaseHeight())
 		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
 	}
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// The gossip simulation models the p2p package's structured gossip. Every node pushes a
+// message to its eager peers and announces it to its lazy peers. A node that receives
+// a duplicate prunes the sender to the lazy set, a node that received an announcement
+// but not the message after LazyDelay steps grafts the announcer into the eager set.
+// Running several messages over the same network shows the tree converging.
+
+const (
+	Messages  = 10 // Number of messages broadcast over the same network
+	LazyDelay = 10 // Steps to wait for an announced message before requesting it
+)
+
+type event struct {
+	from, to *Node
+}
+
+type GossipStats struct {
+	Steps     int
+	Seen      int
+	Pushed    int
+	Duplicate int
+	Announced int
+	Grafted   int
+}
+
+// initGossip puts the first Broadcast connections of every node into its eager set
+func initGossip() {
+	for _, n := range Nodes {
+		n.Eager = make(map[int]bool)
+		for _, i := range rand.Perm(len(n.Connections)) {
+			if len(n.Eager) >= Broadcast {
+				break
+			}
+			n.Eager[n.Connections[i].id] = true
+		}
+	}
+}
+
+// forward pushes the message to the eager set and announces it to the lazy set
+func forward(n *Node, from *Node, pushes, haves *[]event, stats *GossipStats) {
+	for _, c := range n.Connections {
+		if c == from {
+			continue
+		}
+		if n.Eager[c.id] {
+			*pushes = append(*pushes, event{n, c})
+			stats.Pushed++
+		} else {
+			*haves = append(*haves, event{n, c})
+			stats.Announced++
+		}
+	}
+}
+
+func OneGossipMessage(origin int) (stats GossipStats) {
+	for _, n := range Nodes {
+		n.MsgSeen = 0
+		n.Announcers = n.Announcers[:0]
+		n.Timer = 0
+	}
+
+	var pushes, haves []event
+	Nodes[origin].MsgSeen = 1
+	forward(Nodes[origin], nil, &pushes, &haves, &stats)
+
+	for step := 2; len(pushes) > 0 || len(haves) > 0 || waiting(); step++ {
+		var nextPushes, nextHaves []event
+
+		for _, e := range pushes {
+			if e.to.MsgSeen > 0 {
+				// duplicate, both sides move each other to the lazy set
+				stats.Duplicate++
+				delete(e.to.Eager, e.from.id)
+				delete(e.from.Eager, e.to.id)
+				continue
+			}
+			e.to.MsgSeen = step
+			e.to.Timer = 0
+			forward(e.to, e.from, &nextPushes, &nextHaves, &stats)
+		}
+
+		for _, e := range haves {
+			if e.to.MsgSeen > 0 {
+				continue
+			}
+			e.to.Announcers = append(e.to.Announcers, e.from)
+			if e.to.Timer == 0 {
+				e.to.Timer = step + LazyDelay
+			}
+		}
+
+		for _, n := range Nodes {
+			if n.MsgSeen > 0 || n.Timer == 0 || n.Timer > step {
+				continue
+			}
+			if len(n.Announcers) == 0 {
+				n.Timer = 0
+				continue
+			}
+			// graft the first announcer, which sends the message right away
+			a := n.Announcers[0]
+			n.Announcers = n.Announcers[1:]
+			n.Eager[a.id] = true
+			a.Eager[n.id] = true
+			nextPushes = append(nextPushes, event{a, n})
+			stats.Grafted++
+			stats.Pushed++
+			n.Timer = step + LazyDelay
+		}
+
+		pushes, haves = nextPushes, nextHaves
+		stats.Steps = step - 1
+	}
+
+	seen, _ := Stats()
+	stats.Seen = seen
+	return
+}
+
+// waiting returns true if a node is still waiting for an announced message
+func waiting() bool {
+	for _, n := range Nodes {
+		if n.MsgSeen == 0 && n.Timer > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func GossipTest() {
+	BuildNetwork()
+	initGossip()
+
+	for m := 0; m < Messages; m++ {
+		s := OneGossipMessage(rand.Intn(len(Nodes)))
+		fmt.Printf("Message %2d: Steps %4d Seen %4d Pushed %6d Duplicate %6d Announced %7d Grafted %4d\n",
+			m, s.Steps, s.Seen, s.Pushed, s.Duplicate, s.Announced, s.Grafted)
+	}
+}
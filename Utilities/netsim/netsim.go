@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
 )
@@ -10,6 +11,11 @@ type Node struct {
 	MsgSeen     int
 	MsgSent     bool
 	Connections []*Node
+
+	// gossip simulation
+	Eager      map[int]bool // ids of connections in the eager set
+	Announcers []*Node      // connections that announced the message
+	Timer      int          // step at which to request the message
 }
 
 const (
@@ -41,7 +47,7 @@ func Member(cs []*Node, id int) bool {
 	return false
 }
 
-func OneTest() {
+func BuildNetwork() {
 	Nodes = Nodes[:0]
 	for i := 0; i < NumNodes; i++ {
 		n := new(Node)
@@ -74,6 +80,10 @@ func OneTest() {
 		}
 		fmt.Println("]")
 	}
+}
+
+func OneTest() {
+	BuildNetwork()
 
 	Nodes[0].MsgSeen = 1
 
@@ -129,7 +139,14 @@ func OneTest() {
 }
 
 func main() {
+	gossip := flag.Bool("gossip", false, "Simulate the structured gossip instead of random broadcasts")
+	flag.Parse()
+
 	for i := 0; i < 10; i++ {
-		OneTest()
+		if *gossip {
+			GossipTest()
+		} else {
+			OneTest()
+		}
 	}
 }
@@ -0,0 +1,106 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FactomProject/factomd/database/backup"
+	"github.com/FactomProject/factomd/wsapi"
+)
+
+const backupUsage = `Usage: factomd backup [flags] <path>
+       factomd backup [flags] status
+
+Back up the database and the fast boot save state of a running factomd node through its debug API.
+The backup is written by the node to a directory, or to a tarball if the path ends in .tar, .tar.gz
+or .tgz. A relative path is made absolute with the current directory. The node needs an rpc user.
+
+Restore a backup into the empty database of a stopped node, which also checks its integrity, with:
+  factomd -backup-restore=<path>
+
+Flags:
+`
+
+// runBackup implements the "backup" subcommand and returns the exit code
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	server := fs.String("s", "localhost:8088", "The host:port of the factomd API")
+	user := fs.String("rpcuser", "", "Username of the factomd API")
+	pass := fs.String("rpcpass", "", "Password of the factomd API")
+	useTLS := fs.Bool("tls", false, "Use https to connect to the factomd API")
+	wait := fs.Bool("wait", true, "Wait until the backup is complete")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, backupUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	scheme := "http"
+	if *useTLS {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s/debug", scheme, *server)
+	call := func(method string, params interface{}) (*backup.Status, error) {
+		result, err := callDebug(url, *user, *pass, method, params)
+		if err != nil {
+			return nil, err
+		}
+		status := new(backup.Status)
+		if err := json.Unmarshal(result, status); err != nil {
+			return nil, err
+		}
+		return status, nil
+	}
+
+	var status *backup.Status
+	var err error
+	if fs.Arg(0) == "status" {
+		status, err = call("backup-status", nil)
+	} else {
+		var path string
+		if path, err = filepath.Abs(fs.Arg(0)); err == nil {
+			status, err = call("backup", wsapi.BackupRequest{Path: path})
+		}
+		for err == nil && *wait && status.Running {
+			time.Sleep(5 * time.Second)
+			if status, err = call("backup-status", nil); err == nil && status.Running {
+				fmt.Printf("Backed up %d records\n", status.Records)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return printBackupStatus(status)
+}
+
+// printBackupStatus prints the status and returns 1 if the backup failed
+func printBackupStatus(status *backup.Status) int {
+	switch {
+	case status.Path == "":
+		fmt.Println("No backup has been started")
+	case status.Running:
+		fmt.Printf("Backing up to %s since %s, %d records so far\n", status.Path, status.Started.Format(time.RFC3339), status.Records)
+	case status.Error != "":
+		fmt.Fprintf(os.Stderr, "Backup to %s failed: %s\n", status.Path, status.Error)
+		return 1
+	default:
+		fmt.Printf("Backed up %d records up to directory block %d to %s in %v\n", status.Records, status.Height, status.Path, status.Finished.Sub(status.Started).Round(time.Second))
+	}
+	return 0
+}
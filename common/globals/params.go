@@ -77,6 +77,10 @@ type FactomParams struct {
 	FullHashesLog            bool // Log all unique full hashes
 	DebugLogLocation         string
 	ReparseAnchorChains      bool
+	SnapshotExport           string // Write a snapshot to this file and exit
+	SnapshotImport           string // Import a snapshot from this file and exit
+	SnapshotKeyMR            string // Trusted KeyMR of the highest directory block of the imported snapshot
+	BackupRestore            string // Restore a backup from this directory or tarball and exit
 
 	// LiveFeed API params
 	EnableLiveFeedAPI        bool
@@ -89,6 +93,7 @@ type FactomParams struct {
 	EventBroadcastContent    string
 	EventReplayDuringStartup bool
 	PersistentReconnect      bool
+	EventDurableDelivery     bool
 }
 
 // PrettyPrint will print all the struct fields and their values to Stdout
@@ -0,0 +1,24 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package interfaces
+
+// The kinds of address history records
+const (
+	AddressHistoryInput       uint8 = iota + 1 // Factoids spent by a transaction input
+	AddressHistoryOutput                       // Factoids received by a transaction output
+	AddressHistoryECPurchase                   // Entry credits bought for the address
+	AddressHistoryCommitChain                  // Entry credits paid for a chain commit
+	AddressHistoryCommitEntry                  // Entry credits paid for an entry commit
+)
+
+// AddressHistoryRecord is a single change of the balance of a factoid or entry credit address
+type AddressHistoryRecord struct {
+	Address   IHash  // The RCD hash of a factoid address, or the public key of an entry credit address
+	Kind      uint8  // One of the AddressHistory kinds
+	Height    uint32 // The directory block height of the factoid or entry credit block
+	TxID      IHash  // The factoid transaction id, also for purchases, or the commit id of a commit
+	Amount    uint64 // Factoshis for factoid addresses, entry credits for entry credit addresses
+	EntryHash IHash  // The committed entry for commits, nil otherwise
+}
@@ -16,6 +16,39 @@ type IDatabase interface {
 	ListAllBuckets() ([][]byte, error)
 	Trim()
 	DoesKeyExist(bucket, key []byte) (bool, error)
+	// Iterate returns an iterator over the records of the bucket with keys from start (inclusive) to end (exclusive),
+	// in ascending order of the keys or descending if reverse is set. A nil start or end leaves the range open.
+	Iterate(bucket, start, end []byte, reverse bool) IIterator
+	// Snapshot returns a consistent view of all records of the database, which later writes don't change. It has to
+	// be released once it is no longer used.
+	Snapshot() (IDatabaseSnapshot, error)
+}
+
+// IIterator is a cursor over the records of a bucket that doesn't load the bucket into memory. It starts before the
+// first record and has to be released once it is no longer used. Key and Value are only valid until the next call
+// to Next.
+type IIterator interface {
+	// Next moves to the next record and returns false once there are no more records or an error occurred
+	Next() bool
+	Key() []byte
+	Value() []byte
+	// Error returns the error that stopped the iteration, if any
+	Error() error
+	Release()
+}
+
+// IDatabaseSnapshot is a read only view of a whole database at the time the snapshot was taken
+type IDatabaseSnapshot interface {
+	// ForEach calls f for every record, stopping at the first error. The slices are only valid during the call.
+	// Databases that don't keep the buckets apart from the keys pass a nil bucket and the key as it is stored.
+	ForEach(f func(bucket, key, value []byte) error) error
+	Release()
+}
+
+// IRawDatabase is a database that stores the bucket as part of the key, it writes back the records of its snapshots
+type IRawDatabase interface {
+	// PutRawInBatch writes the records by their key as it is stored, the buckets of the records are not used
+	PutRawInBatch(records []Record) error
 }
 
 type Record struct {
@@ -32,6 +32,9 @@ type DBOverlaySimple interface {
 	FetchIncludedIn(hash IHash) (IHash, error)
 	FetchPaidFor(hash IHash) (IHash, error)
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
+	ForEachEBlockByChain(chainID IHash, f func(IEntryBlock) error) error
+	FetchEBlockHeightsByChain(chainID IHash, from, to uint32, reverse bool, limit int) ([]uint32, error)
+	FetchEBlockByChainHeight(chainID IHash, height uint32) (IEntryBlock, error)
 	InsertEntryMultiBatch(entry IEBEntry) error
 	InsertEntry(entry IEBEntry) error
 	ProcessABlockMultiBatch(block DatabaseBatchable) error
@@ -50,6 +53,21 @@ type DBOverlaySimple interface {
 	FetchKeyValueStore(key []byte, dst BinaryMarshallable) (BinaryMarshallable, error)
 	SaveDatabaseEntryHeight(height uint32) error
 	FetchDatabaseEntryHeight() (uint32, error)
+	SetExtIDIndex(enabled bool)
+	IsExtIDIndexEnabled() bool
+	FetchEntryHashesByExtID(chainID IHash, extIDs [][]byte, after IHash, limit int) ([]IHash, bool, error)
+	SetAddressHistoryIndex(enabled bool)
+	IsAddressHistoryIndexEnabled() bool
+	FetchAddressHistory(address IHash, after []byte, limit int) ([]*AddressHistoryRecord, []byte, error)
+	SetBalanceCheckpoints(enabled bool)
+	IsBalanceCheckpointsEnabled() bool
+	UpdateBalanceCheckpoints() (uint32, error)
+	FetchBalancesAtHeight(height uint32, fctAddresses []IHash, ecAddresses []IHash) ([]int64, []int64, error)
+	SetPruning(depth uint32, keep []IHash)
+	GetPruneDepth() uint32
+	IsChainKept(chainID IHash) bool
+	FetchPrunedHeight() (uint32, error)
+	PruneEntries() (uint32, error)
 }
 
 // Db defines a generic interface that is used to request and insert data into db
@@ -80,6 +98,9 @@ type DBOverlay interface {
 
 	FetchAllEntryIDs() ([]IHash, error)
 
+	// ForEachEntryID streams the hashes of all entries
+	ForEachEntryID(f func(IHash) error) error
+
 	//**********************************EBlock**********************************//
 
 	// ProcessEBlockBatche inserts the EBlock and update all it's ebentries in DB
@@ -102,6 +123,15 @@ type DBOverlay interface {
 	// FetchAllEBlocksByChain gets all of the blocks by chain id
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
 
+	// ForEachEBlockByChain streams the blocks of the chain in order of height
+	ForEachEBlockByChain(chainID IHash, f func(IEntryBlock) error) error
+
+	// FetchEBlockHeightsByChain gets the directory block heights with an entry block of the chain
+	FetchEBlockHeightsByChain(chainID IHash, from, to uint32, reverse bool, limit int) ([]uint32, error)
+
+	// FetchEBlockByChainHeight gets the entry block of the chain at the directory block height
+	FetchEBlockByChainHeight(chainID IHash, height uint32) (IEntryBlock, error)
+
 	SaveEBlockHead(block DatabaseBlockWithEntries, checkForDuplicateEntries bool) error
 
 	FetchEBlockHead(chainID IHash) (IEntryBlock, error)
@@ -148,6 +178,9 @@ type DBOverlay interface {
 	FetchAllDBlocks() ([]IDirectoryBlock, error)
 	FetchAllDBlockKeys() ([]IHash, error)
 
+	// ForEachDBlock streams the directory blocks in order of height
+	ForEachDBlock(f func(IDirectoryBlock) error) error
+
 	SaveDirectoryBlockHead(DatabaseBlockWithEntries) error
 
 	FetchDirectoryBlockHead() (IDirectoryBlock, error)
@@ -266,6 +299,31 @@ type DBOverlay interface {
 	FetchKeyValueStore(key []byte, dst BinaryMarshallable) (BinaryMarshallable, error)
 	SaveDatabaseEntryHeight(height uint32) error
 	FetchDatabaseEntryHeight() (uint32, error)
+
+	//******************************ExtIDIndex**********************************//
+	SetExtIDIndex(enabled bool)
+	IsExtIDIndexEnabled() bool
+	FetchEntryHashesByExtID(chainID IHash, extIDs [][]byte, after IHash, limit int) ([]IHash, bool, error)
+	RebuildExtIDIndex() (int, error)
+
+	//******************************AddressHistory**********************************//
+	SetAddressHistoryIndex(enabled bool)
+	IsAddressHistoryIndexEnabled() bool
+	FetchAddressHistory(address IHash, after []byte, limit int) ([]*AddressHistoryRecord, []byte, error)
+	RebuildAddressHistoryIndex() (int, error)
+
+	//******************************BalanceCheckpoint**********************************//
+	SetBalanceCheckpoints(enabled bool)
+	IsBalanceCheckpointsEnabled() bool
+	UpdateBalanceCheckpoints() (uint32, error)
+	FetchBalancesAtHeight(height uint32, fctAddresses []IHash, ecAddresses []IHash) ([]int64, []int64, error)
+
+	//******************************Pruning**********************************//
+	SetPruning(depth uint32, keep []IHash)
+	GetPruneDepth() uint32
+	IsChainKept(chainID IHash) bool
+	FetchPrunedHeight() (uint32, error)
+	PruneEntries() (uint32, error)
 }
 
 type ISCDatabaseOverlay interface {
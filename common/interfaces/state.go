@@ -86,6 +86,7 @@ type IState interface {
 	GetTlsInfo() (bool, string, string)
 	GetFactomdLocations() string
 	GetCorsDomains() []string
+	GetRequestLimit() int
 
 	// Routine for handling the syncroniztion of the leader and follower processes
 	// and how they process messages.
@@ -344,6 +344,23 @@ ValidSignatures: // Goto here if signatures pass
 	return m.ValidateData(state)
 }
 
+// HasPrunedEBlocks returns true if entry blocks of the directory block are missing because the database pruned
+// their chain. A node can load such a DBState from its database, but can't share it with other nodes.
+func (m *DBStateMsg) HasPrunedEBlocks(db interfaces.DBOverlaySimple) bool {
+	have := make(map[[32]byte]bool)
+	for _, eb := range m.EBlocks {
+		if keymr, err := eb.KeyMR(); err == nil {
+			have[keymr.Fixed()] = true
+		}
+	}
+	for _, ebe := range m.DirectoryBlock.GetEBlockDBEntries() {
+		if !have[ebe.GetKeyMR().Fixed()] && !db.IsChainKept(ebe.GetChainID()) {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateData will check the data attached to the DBState against the directory block it contains.
 // This is ensure no additional junk is attached to a valid DBState
 func (m *DBStateMsg) ValidateData(state interfaces.IState) int {
@@ -128,6 +128,9 @@ func (m *DBStateMissing) send(dbheight uint32, state interfaces.IState) (msglen
 		}
 
 		dbstatemsg := msg.(*DBStateMsg)
+		if dbstatemsg.HasPrunedEBlocks(state.GetDB()) {
+			return // a pruned node can't send the blocks it pruned
+		}
 		dbstatemsg.IsInDB = false // else validateSignatures would approve it automatically
 		if dbstatemsg.ValidateSignatures(state) != 1 {
 			return // the last DBState we have saved may not have any or all the signatures so we can't share
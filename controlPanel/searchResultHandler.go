@@ -827,13 +827,7 @@ func getAllChainEntries(chainIDString string) []SearchedStruct {
 	entries := make([]interfaces.IEBEntry, 0)
 
 	dbase = StatePointer.GetDB()
-	eblks, err := dbase.FetchAllEBlocksByChain(chainID)
-	if err != nil {
-
-		return nil
-	}
-
-	for _, eblk := range eblks {
+	err = dbase.ForEachEBlockByChain(chainID, func(eblk interfaces.IEntryBlock) error {
 		hashes := eblk.GetEntryHashes()
 		for _, hash := range hashes {
 			entry, err := dbase.FetchEntry(hash)
@@ -842,6 +836,11 @@ func getAllChainEntries(chainIDString string) []SearchedStruct {
 			}
 			entries = append(entries, entry)
 		}
+		return nil
+	})
+	if err != nil {
+
+		return nil
 	}
 	//entries, err := dbase.FetchAllEntriesByChainID(chainID)
 
@@ -0,0 +1,502 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package backup writes a snapshot of a database and a save state to a directory or a tarball, and restores them.
+//
+// A backup has the save state, the records of the database in chunks and a manifest.json with the sha256 of every
+// file. Chunks are written at once, so memory use is bounded by ChunkSize. The records of a chunk each have a
+// bucket, key and value, all prefixed by their length (uint32). Records of a LevelDB have no bucket and the key as
+// it is stored, they can only be restored to a LevelDB.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/leveldb"
+)
+
+// Version is the version of the backup format
+const Version = 1
+
+// ChunkSize is the size at which a chunk of records is written
+var ChunkSize = 64 << 20
+
+// batchSize is the number of records restored at once
+const batchSize = 10000
+
+const manifestName = "manifest.json"
+const stateName = "savestate"
+
+// Manifest describes the content of a backup
+type Manifest struct {
+	Version int       `json:"version"`
+	Network string    `json:"network"`
+	DBType  string    `json:"dbtype"`
+	Time    time.Time `json:"time"`
+
+	Height      uint32 `json:"height"` // Height of the highest directory block in the database
+	KeyMR       string `json:"keymr"`
+	StateHeight uint32 `json:"stateheight"` // Height of the save state, if there is one
+
+	State   *File  `json:"state,omitempty"`
+	Records int    `json:"records"`
+	Chunks  []File `json:"chunks"`
+}
+
+// File is a file of the backup
+type File struct {
+	Name    string `json:"name"`
+	Records int    `json:"records,omitempty"`
+	Hash    string `json:"hash"` // sha256
+}
+
+// Status is the progress of a backup that runs in the background
+type Status struct {
+	Running  bool      `json:"running"`
+	Path     string    `json:"path,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Records  int       `json:"records"`
+	Height   uint32    `json:"height"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// IsTarball is true if the path ends in .tar, .tar.gz or .tgz, otherwise the backup is a directory
+func IsTarball(path string) bool {
+	return strings.HasSuffix(path, ".tar") || strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+func isGzip(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// writer writes the files of a backup to a temporary location, which is renamed to the path once it is complete
+type writer interface {
+	write(name string, data []byte) error
+	close() error
+}
+
+type dirWriter struct {
+	dir string
+}
+
+func (w *dirWriter) write(name string, data []byte) error {
+	return ioutil.WriteFile(filepath.Join(w.dir, name), data, 0644)
+}
+
+func (w *dirWriter) close() error {
+	return nil
+}
+
+type tarWriter struct {
+	f  *os.File
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (w *tarWriter) write(name string, data []byte) error {
+	h := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := w.tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *tarWriter) close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := w.f.Sync(); err != nil {
+		return err
+	}
+	return w.f.Close()
+}
+
+// Write writes the save state and every record of the snapshot to a new directory or tarball at the path. The
+// manifest has to have the network, database type and save state height set. progress is called with the number of
+// records after every chunk.
+func Write(path string, m *Manifest, snap interfaces.IDatabaseSnapshot, state []byte, progress func(records int)) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	tmp := path + ".tmp"
+	if _, err := os.Stat(tmp); err == nil {
+		return fmt.Errorf("%s already exists, another backup may be running", tmp)
+	}
+
+	var w writer
+	if IsTarball(path) {
+		f, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		tw := &tarWriter{f: f}
+		if isGzip(path) {
+			tw.gz = gzip.NewWriter(f)
+			tw.tw = tar.NewWriter(tw.gz)
+		} else {
+			tw.tw = tar.NewWriter(f)
+		}
+		w = tw
+	} else {
+		if err := os.MkdirAll(tmp, 0755); err != nil {
+			return err
+		}
+		w = &dirWriter{dir: tmp}
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := write(w, m, snap, state, progress); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func write(w writer, m *Manifest, snap interfaces.IDatabaseSnapshot, state []byte, progress func(records int)) error {
+	m.Version = Version
+	m.Time = time.Now()
+	m.Records = 0
+	m.Chunks = nil
+
+	if state != nil {
+		m.State = &File{Name: stateName, Hash: primitives.Sha(state).String()}
+		if err := w.write(stateName, state); err != nil {
+			return err
+		}
+	}
+
+	var chunk bytes.Buffer
+	records := 0
+	flush := func() error {
+		if records == 0 {
+			return nil
+		}
+		f := File{Name: fmt.Sprintf("records-%06d", len(m.Chunks)), Records: records, Hash: primitives.Sha(chunk.Bytes()).String()}
+		if err := w.write(f.Name, chunk.Bytes()); err != nil {
+			return err
+		}
+		m.Chunks = append(m.Chunks, f)
+		m.Records += records
+		chunk.Reset()
+		records = 0
+		if progress != nil {
+			progress(m.Records)
+		}
+		return nil
+	}
+
+	var head []byte
+	var l [4]byte
+	err := snap.ForEach(func(bucket, key, value []byte) error {
+		for _, b := range [][]byte{bucket, key, value} {
+			binary.BigEndian.PutUint32(l[:], uint32(len(b)))
+			chunk.Write(l[:])
+			chunk.Write(b)
+		}
+		records++
+
+		// the directory block heights are big endian, so the last one is the highest
+		if height := dblockHeight(bucket, key); height != nil && bytes.Compare(height, head) > 0 {
+			head = append(head[:0], height...)
+			m.Height = binary.BigEndian.Uint32(height)
+			m.KeyMR = fmt.Sprintf("%x", value)
+		}
+
+		if chunk.Len() >= ChunkSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if head == nil {
+		return fmt.Errorf("database has no directory blocks")
+	}
+	if m.State != nil && m.StateHeight > m.Height {
+		return fmt.Errorf("save state at height %d is newer than the database head %d", m.StateHeight, m.Height)
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return w.write(manifestName, data)
+}
+
+// dblockHeight returns the height of a record of the directory block numbers, nil for other records. Records without
+// a bucket have the key as LevelDB stores it, which starts with the bucket and a ';'.
+func dblockHeight(bucket, key []byte) []byte {
+	if bucket == nil {
+		prefix := leveldb.CombineBucketAndKey(append([]byte(nil), databaseOverlay.DIRECTORYBLOCK_NUMBER...), nil)
+		if !bytes.HasPrefix(key, prefix) {
+			return nil
+		}
+		bucket, key = databaseOverlay.DIRECTORYBLOCK_NUMBER, key[len(prefix):]
+	}
+	if !bytes.Equal(bucket, databaseOverlay.DIRECTORYBLOCK_NUMBER) || len(key) != 4 {
+		return nil
+	}
+	return key
+}
+
+// forEachFile calls f with the name and content of the files of the backup except the manifest. For a directory,
+// these are the files in the manifest, for a tarball all files in the order they were written.
+func forEachFile(path string, m *Manifest, f func(name string, data []byte) error) error {
+	if !IsTarball(path) {
+		names := []string{}
+		if m.State != nil {
+			names = append(names, m.State.Name)
+		}
+		for _, c := range m.Chunks {
+			names = append(names, c.Name)
+		}
+		for _, name := range names {
+			data, err := ioutil.ReadFile(filepath.Join(path, filepath.Base(name)))
+			if err != nil {
+				return err
+			}
+			if err := f(name, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if isGzip(path) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Name == manifestName {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := f(h.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadManifest reads the manifest of a backup. The manifest is the last file of a tarball, so the whole tarball
+// is read.
+func ReadManifest(path string) (*Manifest, error) {
+	var data []byte
+	if !IsTarball(path) {
+		var err error
+		if data, err = ioutil.ReadFile(filepath.Join(path, manifestName)); err != nil {
+			return nil, err
+		}
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		var r io.Reader = file
+		if isGzip(path) {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return nil, err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if h.Name == manifestName {
+				if data, err = ioutil.ReadAll(io.LimitReader(tr, 64<<20)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if data == nil {
+			return nil, fmt.Errorf("%s has no manifest, the backup is incomplete", path)
+		}
+	}
+
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("backup version %d is not supported", m.Version)
+	}
+	return m, nil
+}
+
+// Restore writes the records of the backup to the database and returns the save state, nil if the backup has none.
+// Every file is checked against the manifest before it is used, but a failure can leave part of the records in the
+// database.
+func Restore(path string, m *Manifest, db interfaces.IDatabase, progress func(records int)) ([]byte, error) {
+	chunks := map[string]File{}
+	for _, c := range m.Chunks {
+		chunks[c.Name] = c
+	}
+
+	var state []byte
+	restored := 0
+	seen := 0
+	err := forEachFile(path, m, func(name string, data []byte) error {
+		hash := primitives.Sha(data).String()
+		if m.State != nil && name == m.State.Name {
+			if hash != m.State.Hash {
+				return fmt.Errorf("save state does not match the manifest")
+			}
+			state = data
+			return nil
+		}
+		c, ok := chunks[name]
+		if !ok {
+			return fmt.Errorf("file %s is not in the manifest", name)
+		}
+		if hash != c.Hash {
+			return fmt.Errorf("%s does not match the manifest", name)
+		}
+		delete(chunks, name)
+
+		n, err := restoreChunk(data, db)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if n != c.Records {
+			return fmt.Errorf("%s has %d records, the manifest %d", name, n, c.Records)
+		}
+		restored += n
+		seen++
+		if progress != nil {
+			progress(restored)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) > 0 || seen != len(m.Chunks) {
+		return nil, fmt.Errorf("backup is missing %d chunks of records", len(chunks))
+	}
+	if restored != m.Records {
+		return nil, fmt.Errorf("restored %d records, the manifest has %d", restored, m.Records)
+	}
+	if m.State != nil && state == nil {
+		return nil, fmt.Errorf("backup is missing the save state")
+	}
+	return state, nil
+}
+
+// restoreChunk writes the records of a chunk to the database in batches
+func restoreChunk(data []byte, db interfaces.IDatabase) (int, error) {
+	next := func() ([]byte, error) {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("record is truncated")
+		}
+		l := binary.BigEndian.Uint32(data)
+		if uint64(len(data)-4) < uint64(l) {
+			return nil, fmt.Errorf("record is truncated")
+		}
+		b := data[4 : 4+l]
+		data = data[4+l:]
+		return b, nil
+	}
+
+	// records without a bucket are written by their key as it is stored
+	put := func(batch []interfaces.Record) error {
+		if len(batch[0].Bucket) > 0 {
+			return db.PutInBatch(batch)
+		}
+		raw, ok := db.(interfaces.IRawDatabase)
+		if !ok {
+			return fmt.Errorf("records without a bucket can only be restored to a LevelDB")
+		}
+		return raw.PutRawInBatch(batch)
+	}
+
+	count := 0
+	batch := make([]interfaces.Record, 0, batchSize)
+	for len(data) > 0 {
+		var fields [3][]byte
+		for i := range fields {
+			b, err := next()
+			if err != nil {
+				return count, err
+			}
+			fields[i] = b
+		}
+		if len(batch) > 0 && (len(batch[0].Bucket) > 0) != (len(fields[0]) > 0) {
+			return count, fmt.Errorf("records with and without a bucket are mixed")
+		}
+		value := new(primitives.ByteSlice)
+		value.Bytes = fields[2]
+		batch = append(batch, interfaces.Record{Bucket: fields[0], Key: fields[1], Data: value})
+		count++
+
+		if len(batch) == batchSize {
+			if err := put(batch); err != nil {
+				return count, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := put(batch); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
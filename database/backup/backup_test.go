@@ -0,0 +1,224 @@
+package backup_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/backup"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+// records returns every record of the database as bucket/key -> value
+func records(t *testing.T, db interfaces.IDatabase) map[string]string {
+	snap, err := db.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	all := map[string]string{}
+	err = snap.ForEach(func(bucket, key, value []byte) error {
+		all[string(bucket)+"/"+string(key)] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
+
+func TestWriteAndRestore(t *testing.T) {
+	defer func(size int) { ChunkSize = size }(ChunkSize)
+	ChunkSize = 4096
+
+	source := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	want := records(t, source)
+	state := []byte("save state")
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"backup", "backup.tar", "backup.tar.gz", "backup.tgz"} {
+		path := filepath.Join(dir, name)
+		snap, err := source.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &Manifest{Network: "LOCAL", DBType: "Map", StateHeight: 5}
+		err = Write(path, m, snap, state, nil)
+		snap.Release()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(m.Chunks) < 2 || m.Records != len(want) || m.Height != uint32(testHelper.BlockCount-1) {
+			t.Errorf("%s: %d chunks, %d records, height %d", name, len(m.Chunks), m.Records, m.Height)
+		}
+		if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+			t.Errorf("%s: temporary file was not removed", name)
+		}
+
+		read, err := ReadManifest(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if read.Records != m.Records || read.KeyMR != m.KeyMR || len(read.Chunks) != len(m.Chunks) {
+			t.Errorf("%s: read manifest %+v, wrote %+v", name, read, m)
+		}
+
+		db := new(mapdb.MapDB)
+		db.Init(nil)
+		restored, err := Restore(path, read, db, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(restored) != string(state) {
+			t.Errorf("%s: restored save state %q", name, restored)
+		}
+		got := records(t, db)
+		if len(got) != len(want) {
+			t.Errorf("%s: restored %d records, want %d", name, len(got), len(want))
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("%s: record %x differs", name, k)
+				break
+			}
+		}
+	}
+}
+
+func TestRestoreDamaged(t *testing.T) {
+	source := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup")
+	snap, err := source.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{Network: "LOCAL", DBType: "Map"}
+	err = Write(path, m, snap, nil, nil)
+	snap.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunk := filepath.Join(path, m.Chunks[0].Name)
+	data, err := ioutil.ReadFile(chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1]++
+	if err := ioutil.WriteFile(chunk, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := new(mapdb.MapDB)
+	db.Init(nil)
+	if _, err := Restore(path, m, db, nil); err == nil {
+		t.Errorf("restored a damaged chunk")
+	}
+
+	os.Remove(chunk)
+	if _, err := Restore(path, m, db, nil); err == nil {
+		t.Errorf("restored a backup with a missing chunk")
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	db := testHelper.CreateEmptyTestDatabaseOverlay()
+	snap, err := db.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup")
+	if err := Write(path, new(Manifest), snap, nil, nil); err == nil {
+		t.Errorf("backed up an empty database")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty backup was written")
+	}
+}
+
+func TestWriteAndRestoreLevelDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ldb, err := leveldb.NewLevelDB(filepath.Join(dir, "source"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := databaseOverlay.NewOverlay(ldb)
+	defer source.Close()
+	testHelper.PopulateTestDatabaseOverlay(source)
+	// binary buckets and keys can contain the ';' that LevelDB puts between them
+	if err := source.Put([]byte("a;b"), []byte(";c"), &primitives.ByteSlice{Bytes: []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	want := records(t, source)
+
+	path := filepath.Join(dir, "backup")
+	snap, err := source.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{Network: "LOCAL", DBType: "LDB"}
+	err = Write(path, m, snap, nil, nil)
+	snap.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Records != len(want) || m.Height != uint32(testHelper.BlockCount-1) {
+		t.Errorf("%d records, height %d", m.Records, m.Height)
+	}
+
+	ldb, err = leveldb.NewLevelDB(filepath.Join(dir, "target"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := databaseOverlay.NewOverlay(ldb)
+	defer target.Close()
+	if _, err := Restore(path, m, target, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := records(t, target)
+	if len(got) != len(want) {
+		t.Errorf("restored %d records, want %d", len(got), len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("record %x differs", k)
+			break
+		}
+	}
+	value, err := target.Get([]byte("a;b"), []byte(";c"), new(primitives.ByteSlice))
+	if err != nil || value == nil || string(value.(*primitives.ByteSlice).Bytes) != "value" {
+		t.Errorf("Get() = %v, %v", value, err)
+	}
+
+	db := new(mapdb.MapDB)
+	db.Init(nil)
+	if _, err := Restore(path, m, db, nil); err == nil {
+		t.Errorf("restored the records of a LevelDB to another database")
+	}
+}
@@ -0,0 +1,539 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package badgerdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/dgraph-io/badger"
+)
+
+// BadgerDB stores the buckets in a Badger LSM tree. Keys are prefixed with the length of their bucket, so unlike
+// LevelDB the buckets can be listed and any bucket is a single range of the tree.
+type BadgerDB struct {
+	db      *badger.DB
+	options Options
+
+	closer    chan struct{}
+	done      sync.WaitGroup
+	closeOnce sync.Once
+}
+
+var _ interfaces.IDatabase = (*BadgerDB)(nil)
+
+// Options control the compactions of the database
+type Options struct {
+	// NumCompactors is the number of concurrent compactions of the LSM tree
+	NumCompactors int
+	// CompactL0OnClose compacts the first level of the tree when the database is closed, which speeds up the next open
+	CompactL0OnClose bool
+	// GCInterval is the interval of the value log garbage collection, 0 disables it
+	GCInterval time.Duration
+	// GCDiscardRatio is the fraction of a value log file that has to be stale before the file is rewritten
+	GCDiscardRatio float64
+}
+
+func DefaultOptions() Options {
+	return Options{
+		NumCompactors:    2,
+		CompactL0OnClose: true,
+		GCInterval:       time.Minute * 10,
+		GCDiscardRatio:   0.5,
+	}
+}
+
+// NewBadgerDB opens the database in the directory with the default options
+func NewBadgerDB(dir string, create bool) (*BadgerDB, error) {
+	return NewBadgerDBWithOptions(dir, create, DefaultOptions())
+}
+
+func NewBadgerDBWithOptions(dir string, create bool, options Options) (*BadgerDB, error) {
+	if create {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	opts := badger.DefaultOptions(dir).
+		WithNumCompactors(options.NumCompactors).
+		WithCompactL0OnClose(options.CompactL0OnClose).
+		WithTruncate(true).
+		WithLogger(nil)
+	bdb, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	db := new(BadgerDB)
+	db.db = bdb
+	db.options = options
+	db.closer = make(chan struct{})
+	if options.GCInterval > 0 {
+		db.done.Add(1)
+		go db.collectGarbage()
+	}
+	return db, nil
+}
+
+// collectGarbage rewrites the value log files that are mostly stale
+func (db *BadgerDB) collectGarbage() {
+	defer db.done.Done()
+	ticker := time.NewTicker(db.options.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-db.closer:
+			return
+		case <-ticker.C:
+			for db.db.RunValueLogGC(db.options.GCDiscardRatio) == nil {
+			}
+		}
+	}
+}
+
+// Compact merges all levels of the LSM tree and rewrites the stale value log files, eg after a large import
+func (db *BadgerDB) Compact() error {
+	if err := db.db.Flatten(db.options.NumCompactors); err != nil {
+		return err
+	}
+	for {
+		err := db.db.RunValueLogGC(db.options.GCDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func bucketPrefix(bucket []byte) []byte {
+	prefix := make([]byte, 2, 2+len(bucket))
+	binary.BigEndian.PutUint16(prefix, uint16(len(bucket)))
+	return append(prefix, bucket...)
+}
+
+func bucketKey(bucket []byte, key []byte) []byte {
+	return append(bucketPrefix(bucket), key...)
+}
+
+func (db *BadgerDB) Close() error {
+	var err error
+	db.closeOnce.Do(func() {
+		close(db.closer)
+		db.done.Wait()
+		err = db.db.Close()
+	})
+	return err
+}
+
+func (db *BadgerDB) Put(bucket []byte, key []byte, data interfaces.BinaryMarshallable) error {
+	value, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	BadgerDBPuts.Inc()
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(bucketKey(bucket, key), value)
+	})
+}
+
+// chainHeadBucket is the bucket of the chain heads of the database overlay, the directory block head is one of them
+var chainHeadBucket = []byte("ChainHead")
+
+// PutInBatch writes the records in a single transaction. A batch that doesn't fit into one, like a large multi batch,
+// is written in several transactions and the chain heads are written in the last one. If the node stops halfway, the
+// heads still point to blocks that were written completely and the other records are written again with the blocks.
+func (db *BadgerDB) PutInBatch(records []interfaces.Record) error {
+	err := db.putInTransaction(records)
+	if err != badger.ErrTxnTooBig {
+		return err
+	}
+
+	var others, heads []interfaces.Record
+	for _, v := range records {
+		if bytes.Equal(v.Bucket, chainHeadBucket) {
+			heads = append(heads, v)
+		} else {
+			others = append(others, v)
+		}
+	}
+	if err := db.putInTransactions(others); err != nil {
+		return err
+	}
+	return db.putInTransaction(heads)
+}
+
+// putInTransaction writes the records in a single transaction, nothing is written if they don't fit into it
+func (db *BadgerDB) putInTransaction(records []interfaces.Record) error {
+	txn := db.db.NewTransaction(true)
+	defer txn.Discard()
+
+	for _, v := range records {
+		value, err := v.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := txn.Set(bucketKey(v.Bucket, v.Key), value); err != nil {
+			return err
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	BadgerDBPuts.Add(float64(len(records)))
+	return nil
+}
+
+// putInTransactions writes the records, a transaction is committed whenever the next record doesn't fit into it
+func (db *BadgerDB) putInTransactions(records []interfaces.Record) error {
+	txn := db.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+
+	for _, v := range records {
+		value, err := v.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		k := bucketKey(v.Bucket, v.Key)
+		err = txn.Set(k, value)
+		if err == badger.ErrTxnTooBig {
+			if err = txn.Commit(); err != nil {
+				return err
+			}
+			txn = db.db.NewTransaction(true)
+			err = txn.Set(k, value)
+		}
+		if err != nil {
+			return err
+		}
+		BadgerDBPuts.Inc()
+	}
+	return txn.Commit()
+}
+
+func (db *BadgerDB) Get(bucket []byte, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	BadgerDBGets.Inc()
+
+	var value []byte
+	err := db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(bucketKey(bucket, key))
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := destination.UnmarshalBinaryData(value); err != nil {
+		return nil, err
+	}
+	return destination, nil
+}
+
+func (db *BadgerDB) Delete(bucket []byte, key []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(bucketKey(bucket, key))
+	})
+}
+
+func (db *BadgerDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	err := db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(bucketKey(bucket, key))
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (db *BadgerDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	prefix := bucketPrefix(bucket)
+	keys := [][]byte{}
+	err := db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil)[len(prefix):])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (db *BadgerDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	prefix := bucketPrefix(bucket)
+	answer := []interfaces.BinaryMarshallableAndCopyable{}
+	keys := [][]byte{}
+	err := db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			value, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			tmp := sample.New()
+			if err := tmp.UnmarshalBinary(value); err != nil {
+				return err
+			}
+			keys = append(keys, it.Item().KeyCopy(nil)[len(prefix):])
+			answer = append(answer, tmp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return answer, keys, nil
+}
+
+func (db *BadgerDB) Clear(bucket []byte) error {
+	keys, err := db.ListAllKeys(bucket)
+	if err != nil {
+		return err
+	}
+
+	txn := db.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+	for _, key := range keys {
+		k := bucketKey(bucket, key)
+		err := txn.Delete(k)
+		if err == badger.ErrTxnTooBig {
+			if err = txn.Commit(); err != nil {
+				return err
+			}
+			txn = db.db.NewTransaction(true)
+			err = txn.Delete(k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+// ListAllBuckets seeks from one bucket to the next, so only the first key of every bucket is read
+func (db *BadgerDB) ListAllBuckets() ([][]byte, error) {
+	buckets := [][]byte{}
+	err := db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); {
+			key := it.Item().Key()
+			if len(key) < 2 {
+				return fmt.Errorf("invalid key %x", key)
+			}
+			size := int(binary.BigEndian.Uint16(key))
+			if len(key) < 2+size {
+				return fmt.Errorf("invalid key %x", key)
+			}
+			bucket := make([]byte, size)
+			copy(bucket, key[2:2+size])
+			buckets = append(buckets, bucket)
+
+			next, ok := prefixEnd(bucketPrefix(bucket))
+			if !ok {
+				break
+			}
+			it.Seek(next)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// prefixEnd returns the first key after all keys with the prefix, false if there is none
+func prefixEnd(prefix []byte) ([]byte, bool) {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1], true
+		}
+	}
+	return nil, false
+}
+
+// Trim updates the size metrics, Badger manages its own memory
+func (db *BadgerDB) Trim() {
+	lsm, vlog := db.db.Size()
+	BadgerDBLSMSize.Set(float64(lsm))
+	BadgerDBValueLogSize.Set(float64(vlog))
+}
+
+// Iterate reads the range in a read transaction, so the iterator sees a snapshot of the database
+func (db *BadgerDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	prefix := bucketPrefix(bucket)
+
+	it := new(Iterator)
+	it.prefix = len(prefix)
+	it.reverse = reverse
+	it.lower = bucketKey(bucket, start)
+	if end != nil {
+		it.upper = bucketKey(bucket, end)
+	} else {
+		// nil if the bucket is the last possible one
+		it.upper, _ = prefixEnd(prefix)
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = reverse
+	it.txn = db.db.NewTransaction(false)
+	it.iter = it.txn.NewIterator(opts)
+	return it
+}
+
+// Iterator walks over the keys from lower (inclusive) to upper (exclusive)
+type Iterator struct {
+	txn  *badger.Txn
+	iter *badger.Iterator
+
+	prefix  int
+	lower   []byte
+	upper   []byte
+	reverse bool
+	started bool
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	if it.iter == nil || it.err != nil {
+		return false
+	}
+
+	switch {
+	case it.started:
+		it.iter.Next()
+	case !it.reverse:
+		it.iter.Seek(it.lower)
+	case it.upper == nil:
+		it.iter.Rewind()
+	default:
+		// a reverse Seek finds the last key at or before the upper bound, which is excluded
+		it.iter.Seek(it.upper)
+		if it.iter.Valid() && bytes.Equal(it.iter.Item().Key(), it.upper) {
+			it.iter.Next()
+		}
+	}
+	it.started = true
+
+	if !it.iter.Valid() {
+		return it.stop()
+	}
+	item := it.iter.Item()
+	k := item.Key()
+	if bytes.Compare(k, it.lower) < 0 || (it.upper != nil && bytes.Compare(k, it.upper) >= 0) {
+		return it.stop()
+	}
+
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		it.err = err
+		return it.stop()
+	}
+	it.key = k[it.prefix:]
+	it.value = value
+	return true
+}
+
+func (it *Iterator) stop() bool {
+	it.key, it.value = nil, nil
+	it.Release()
+	return false
+}
+
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+func (it *Iterator) Error() error {
+	return it.err
+}
+
+func (it *Iterator) Release() {
+	if it.iter != nil {
+		it.iter.Close()
+		it.iter = nil
+		it.txn.Discard()
+	}
+}
+
+// Snapshot opens a read transaction, which sees the database as it was when the transaction started
+func (db *BadgerDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	return &Snapshot{txn: db.db.NewTransaction(false)}, nil
+}
+
+// Snapshot reads all records in a read transaction
+type Snapshot struct {
+	txn *badger.Txn
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+func (s *Snapshot) ForEach(f func(bucket, key, value []byte) error) error {
+	it := s.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		k := item.Key()
+		if len(k) < 2 || len(k) < 2+int(binary.BigEndian.Uint16(k)) {
+			return fmt.Errorf("invalid key %x", k)
+		}
+		size := 2 + int(binary.BigEndian.Uint16(k))
+		err := item.Value(func(value []byte) error {
+			return f(k[2:size], k[size:], value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Snapshot) Release() {
+	if s.txn != nil {
+		s.txn.Discard()
+		s.txn = nil
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package badgerdb_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/badgerdb"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+type TestData struct {
+	Str string
+}
+
+func (t *TestData) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(TestData)
+}
+
+func (t *TestData) MarshalBinary() ([]byte, error) {
+	return []byte(t.Str), nil
+}
+
+func (t *TestData) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	t.Str = string(data)
+	return nil, nil
+}
+
+func (t *TestData) UnmarshalBinary(data []byte) error {
+	_, err := t.UnmarshalBinaryData(data)
+	return err
+}
+
+// testBadgerDB creates a database in a new temporary directory, which the caller removes
+func testBadgerDB(t *testing.T) (*BadgerDB, string) {
+	dir, err := ioutil.TempDir("", "badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewBadgerDB(dir, true)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return m, dir
+}
+
+func TestPutGetDelete(t *testing.T) {
+	m, dir := testBadgerDB(t)
+	defer os.RemoveAll(dir)
+	defer m.Close()
+
+	key := []byte("key")
+	bucket := []byte("bucket")
+
+	if err := m.Put(bucket, key, &TestData{Str: "testtest"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := m.Get(bucket, key, new(TestData))
+	if err != nil || resp == nil || resp.(*TestData).Str != "testtest" {
+		t.Errorf("get = %v, %v", resp, err)
+	}
+	if exists, err := m.DoesKeyExist(bucket, key); !exists || err != nil {
+		t.Errorf("key does not exist: %v", err)
+	}
+	// the same key in a bucket that is a prefix of the other
+	if resp, err := m.Get([]byte("bucke"), []byte("tkey"), new(TestData)); resp != nil || err != nil {
+		t.Errorf("get from another bucket = %v, %v", resp, err)
+	}
+
+	if err := m.Delete(bucket, key); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := m.Get(bucket, key, new(TestData)); resp != nil || err != nil {
+		t.Errorf("get after delete = %v, %v", resp, err)
+	}
+	if exists, err := m.DoesKeyExist(bucket, key); exists || err != nil {
+		t.Errorf("key exists after delete: %v", err)
+	}
+}
+
+func TestMultiValue(t *testing.T) {
+	m, dir := testBadgerDB(t)
+	defer os.RemoveAll(dir)
+	defer m.Close()
+
+	bucket := []byte("bucket")
+	batch := []interfaces.Record{}
+	for i := 0; i < 10; i++ {
+		batch = append(batch, interfaces.Record{Bucket: bucket, Key: []byte(fmt.Sprintf("%v", i)), Data: &TestData{Str: fmt.Sprintf("Data %v", i)}})
+	}
+	batch = append(batch, interfaces.Record{Bucket: []byte("bucket2"), Key: []byte("0"), Data: &TestData{Str: "other"}})
+	if err := m.PutInBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := m.ListAllKeys(bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 10 {
+		t.Fatalf("Invalid length of keys - %v vs %v", len(keys), 10)
+	}
+	for i := range keys {
+		if string(keys[i]) != fmt.Sprintf("%v", i) {
+			t.Errorf("Wrong key returned - %v", string(keys[i]))
+		}
+	}
+
+	all, allKeys, err := m.GetAll(bucket, new(TestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 || len(allKeys) != 10 {
+		t.Fatalf("GetAll returned %d values and %d keys", len(all), len(allKeys))
+	}
+	for i := range all {
+		if v := all[i].(*TestData); v.Str != fmt.Sprintf("Data %v", i) || string(allKeys[i]) != fmt.Sprintf("%v", i) {
+			t.Errorf("Wrong data returned - %s: %s", allKeys[i], v.Str)
+		}
+	}
+
+	if err := m.Clear(bucket); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := m.ListAllKeys(bucket); len(keys) != 0 {
+		t.Error("Keys not cleared from database properly")
+	}
+	if keys, _ := m.ListAllKeys([]byte("bucket2")); len(keys) != 1 {
+		t.Error("Clear removed the keys of another bucket")
+	}
+}
+
+func TestListAllBuckets(t *testing.T) {
+	m, dir := testBadgerDB(t)
+	defer os.RemoveAll(dir)
+	defer m.Close()
+
+	// buckets that contain the LevelDB separator or are prefixes of each other
+	expected := []string{"a", "a;b", "ab", "\xff\xff", "", "Entry"}
+	for _, bucket := range expected {
+		for i := 0; i < 3; i++ {
+			if err := m.Put([]byte(bucket), []byte{byte(i)}, &TestData{Str: bucket}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	buckets, err := m.ListAllBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, b := range buckets {
+		got = append(got, string(b))
+	}
+	sort.Strings(got)
+	sort.Strings(expected)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("buckets = %q, want %q", got, expected)
+	}
+}
+
+func TestPutInBatchTooBig(t *testing.T) {
+	m, dir := testBadgerDB(t)
+	defer os.RemoveAll(dir)
+	defer m.Close()
+
+	// the batch doesn't fit into one transaction, the chain head is written with the last part
+	head := []byte("ChainHead")
+	padding := strings.Repeat("k", 1024)
+	batch := []interfaces.Record{{Bucket: head, Key: []byte("chain"), Data: &TestData{Str: "head"}}}
+	for i := 0; i < 20000; i++ {
+		batch = append(batch, interfaces.Record{Bucket: []byte("bucket"), Key: []byte(fmt.Sprintf("%05d%s", i, padding)), Data: &TestData{Str: "value"}})
+	}
+	if err := m.PutInBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := m.ListAllKeys([]byte("bucket"))
+	if err != nil || len(keys) != 20000 {
+		t.Fatalf("%d keys written: %v", len(keys), err)
+	}
+	resp, err := m.Get(head, []byte("chain"), new(TestData))
+	if err != nil || resp == nil || resp.(*TestData).Str != "head" {
+		t.Errorf("chain head was not written: %v", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m, err := NewBadgerDB(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbo := databaseOverlay.NewOverlay(m)
+	testHelper.PopulateTestDatabaseOverlay(dbo)
+	head, _ := dbo.FetchDBlockHead()
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("second close returned %v", err)
+	}
+
+	if _, err := NewBadgerDB(dir+"/missing", false); err == nil {
+		t.Errorf("opened a database that doesn't exist without creating it")
+	}
+	m, err = NewBadgerDB(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Compact(); err != nil {
+		t.Errorf("compact failed: %v", err)
+	}
+
+	dbo = databaseOverlay.NewOverlay(m)
+	reopened, err := dbo.FetchDBlockHead()
+	if err != nil || reopened == nil || !reopened.GetKeyMR().IsSameAs(head.GetKeyMR()) {
+		t.Fatalf("head after reopening = %v, %v", reopened, err)
+	}
+	_, keys, err := dbo.GetAll(databaseOverlay.INCLUDED_IN, primitives.NewZeroHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 150 {
+		t.Errorf("Invalid amount of keys returned - expected 150, got %v", len(keys))
+	}
+}
@@ -0,0 +1,40 @@
+package badgerdb
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	BadgerDBGets = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_badgerdb_gets",
+		Help: "Counts gets from the database",
+	})
+	BadgerDBPuts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_badgerdb_puts",
+		Help: "Count puts to the database",
+	})
+	BadgerDBLSMSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_database_badgerdb_lsm_size",
+		Help: "Size of the LSM tree of the database in bytes",
+	})
+	BadgerDBValueLogSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_database_badgerdb_vlog_size",
+		Help: "Size of the value log of the database in bytes",
+	})
+)
+
+var registered = false
+
+// RegisterPrometheus registers the variables to be exposed. This can only be run once, hence the
+// boolean flag to prevent panics if launched more than once. This is called in NetStart
+func RegisterPrometheus() {
+	if registered {
+		return
+	}
+	registered = true
+
+	prometheus.MustRegister(BadgerDBGets)
+	prometheus.MustRegister(BadgerDBPuts)
+	prometheus.MustRegister(BadgerDBLSMSize)
+	prometheus.MustRegister(BadgerDBValueLogSize)
+}
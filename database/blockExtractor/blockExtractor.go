@@ -139,14 +139,9 @@ func (be *BlockExtractor) ExportEChain(chainID string, db interfaces.DBOverlay)
 	if err != nil {
 		return err
 	}
-	eBlocks, err := db.FetchAllEBlocksByChain(id)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("Fetched %v blocks\n", len(eBlocks))
-	sort.Sort(util.ByEBlockIDAscending(eBlocks))
-
-	for _, block := range eBlocks {
+	count := 0
+	err = db.ForEachEBlockByChain(id, func(block interfaces.IEntryBlock) error {
+		count++
 		be.SaveBinary(block.(interfaces.DatabaseBatchable))
 		be.SaveJSON(block.(interfaces.DatabaseBatchable))
 		height := block.GetDatabaseHeight()
@@ -164,29 +159,24 @@ func (be *BlockExtractor) ExportEChain(chainID string, db interfaces.DBOverlay)
 				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	fmt.Printf("Exported %v blocks\n", count)
 	return nil
 }
 
 func (be *BlockExtractor) ExportDChain(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportDChain\n")
-	// get all ecBlocks from db
-	dBlocks, err := db.FetchAllDBlocks()
-	if err != nil {
-		return err
-	}
-	sort.Sort(util.ByDBlockIDAscending(dBlocks))
-
-	for _, block := range dBlocks {
+	// stream the dBlocks from db
+	return db.ForEachDBlock(func(block interfaces.IDirectoryBlock) error {
 		//Making sure Hash and KeyMR are set for the JSON export
 		block.GetFullHash()
 		block.GetKeyMR()
-		err = be.ExportBlock(block.(interfaces.DatabaseBatchable))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+		return be.ExportBlock(block.(interfaces.DatabaseBatchable))
+	})
 }
 
 func (be *BlockExtractor) ExportECChain(db interfaces.DBOverlay) error {
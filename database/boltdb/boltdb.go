@@ -5,7 +5,9 @@
 package boltdb
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"sync"
 
 	"os"
@@ -303,3 +305,211 @@ func (db *BoltDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 
 	return true, nil
 }
+
+// IterateBatch is the number of records the Iterator reads in one read transaction
+var IterateBatch = 256
+
+func (db *BoltDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	it := new(Iterator)
+	it.db = db
+	it.bucket = bucket
+	it.start = start
+	it.end = end
+	it.reverse = reverse
+	return it
+}
+
+// Iterator walks over a range of the keys of a bucket. It reads IterateBatch records at a time, each batch in its own
+// read transaction, so no transaction is open between calls to Next. Bolt can't grow the file while a read
+// transaction is open, and a write waiting for that while it holds the lock of the database would block the reads
+// of the caller. Records written during the iteration may or may not be seen.
+type Iterator struct {
+	db     *BoltDB
+	bucket []byte
+
+	start   []byte
+	end     []byte
+	reverse bool
+
+	keys   [][]byte
+	values [][]byte
+	pos    int
+	last   []byte // the last key read, the next batch continues behind it
+	done   bool
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	it.pos++
+	if it.pos >= len(it.keys) && !it.done {
+		it.read()
+	}
+	if it.pos >= len(it.keys) {
+		it.key, it.value = nil, nil
+		return false
+	}
+	it.key, it.value = it.keys[it.pos], it.values[it.pos]
+	return true
+}
+
+// read loads the next batch of records
+func (it *Iterator) read() {
+	it.keys, it.values, it.pos = nil, nil, 0
+
+	it.db.Sem.RLock()
+	defer it.db.Sem.RUnlock()
+
+	it.err = it.db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(it.bucket)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := it.seek(c)
+		for ; k != nil && it.inRange(k); k, v = it.step(c) {
+			if len(it.keys) == IterateBatch {
+				return nil
+			}
+			// the slices are only valid during the transaction
+			it.keys = append(it.keys, append([]byte(nil), k...))
+			it.values = append(it.values, append([]byte(nil), v...))
+		}
+		it.done = true
+		return nil
+	})
+	if it.err != nil || it.keys == nil {
+		it.done = true
+		return
+	}
+	it.last = it.keys[len(it.keys)-1]
+}
+
+// seek moves the cursor to the first record of the next batch
+func (it *Iterator) seek(c *bolt.Cursor) ([]byte, []byte) {
+	if !it.reverse {
+		switch {
+		case it.last != nil:
+			k, v := c.Seek(it.last)
+			if bytes.Equal(k, it.last) {
+				return c.Next()
+			}
+			return k, v
+		case it.start == nil:
+			return c.First()
+		default:
+			return c.Seek(it.start)
+		}
+	}
+
+	end := it.end
+	if it.last != nil {
+		end = it.last
+	}
+	if end == nil {
+		return c.Last()
+	}
+	// Seek finds the first key at or after the end, the keys before it are below the end
+	if k, _ := c.Seek(end); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+func (it *Iterator) step(c *bolt.Cursor) ([]byte, []byte) {
+	if it.reverse {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+func (it *Iterator) inRange(k []byte) bool {
+	if it.reverse {
+		return it.start == nil || bytes.Compare(k, it.start) >= 0
+	}
+	return it.end == nil || bytes.Compare(k, it.end) < 0
+}
+
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+func (it *Iterator) Error() error {
+	return it.err
+}
+
+func (it *Iterator) Release() {
+	it.done = true
+	it.keys, it.values, it.pos = nil, nil, 0
+	it.key, it.value = nil, nil
+}
+
+// Snapshot copies the database file in a read transaction and reads the records from the copy, which needs as much
+// free disk space as the database. Bolt can't grow the file while the copy is made, so a write that has to grow it
+// waits, and holding the lock of the database, makes the reads wait as well. The copy is made without the lock and
+// the records are read without a transaction on the database, so nothing waits for the reader of the snapshot.
+func (db *BoltDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.Sem.RLock()
+	tx, err := db.db.Begin(false)
+	db.Sem.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(db.db.Path()), filepath.Base(db.db.Path())+".snapshot")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	path := f.Name()
+	_, err = tx.WriteTo(f)
+	tx.Rollback()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	copied, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &Snapshot{db: copied, path: path}, nil
+}
+
+// Snapshot is a read only copy of the database file, which is removed when the snapshot is released
+type Snapshot struct {
+	db   *bolt.DB
+	path string
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+func (s *Snapshot) ForEach(f func(bucket, key, value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(bucket []byte, b *bolt.Bucket) error {
+			return b.ForEach(func(key, value []byte) error {
+				return f(bucket, key, value)
+			})
+		})
+	})
+}
+
+func (s *Snapshot) Release() {
+	if s.db != nil {
+		s.db.Close()
+		os.Remove(s.path)
+		s.db = nil
+	}
+}
@@ -7,7 +7,10 @@ package boltdb_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
@@ -226,3 +229,116 @@ func TestGetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestIterateConcurrentWrite(t *testing.T) {
+	defer func(batch int) { IterateBatch = batch }(IterateBatch)
+	IterateBatch = 7
+
+	m := NewBoltDB(nil, dbFilename)
+	bucket := []byte("bucket")
+	batch := []interfaces.Record{}
+	for i := 0; i < 100; i++ {
+		batch = append(batch, interfaces.Record{Bucket: bucket, Key: []byte{byte(i)}, Data: &TestData{Str: fmt.Sprintf("Data %v", i)}})
+	}
+	if err := m.PutInBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []int)
+	go func() {
+		it := m.Iterate(bucket, []byte{10}, []byte{90}, true)
+		defer it.Release()
+
+		got := []int{}
+		for it.Next() {
+			if len(got) == 1 {
+				// a write that grows the file while the iteration is under way, like a block saved during a
+				// search, followed by a read of the iterating goroutine
+				written := make(chan struct{})
+				go func() {
+					defer close(written)
+					if err := m.Put([]byte("large"), []byte("key"), &TestData{Str: strings.Repeat("x", 8<<20)}); err != nil {
+						t.Error(err)
+					}
+				}()
+				time.Sleep(100 * time.Millisecond)
+				if _, err := m.Get(bucket, it.Key(), new(TestData)); err != nil {
+					t.Error(err)
+				}
+				<-written
+			}
+			if string(it.Value()) != fmt.Sprintf("Data %v", it.Key()[0]) {
+				t.Errorf("value of key %d = %q", it.Key()[0], it.Value())
+			}
+			got = append(got, int(it.Key()[0]))
+		}
+		if err := it.Error(); err != nil {
+			t.Error(err)
+		}
+		done <- got
+	}()
+
+	select {
+	case got := <-done:
+		if len(got) != 80 || got[0] != 89 || got[79] != 10 {
+			t.Errorf("iterated over %v", got)
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i] != got[i-1]-1 {
+				t.Errorf("iterated over %v", got)
+				break
+			}
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("iterating deadlocked with a concurrent write")
+	}
+	CleanupTest(t, m)
+}
+
+func TestSnapshotConcurrentWrite(t *testing.T) {
+	m := NewBoltDB(nil, dbFilename)
+	bucket := []byte("bucket")
+	if err := m.Put(bucket, []byte("key"), &TestData{Str: "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a write that grows the file and a read don't wait for the snapshot to be released
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := m.Put(bucket, []byte("large"), &TestData{Str: strings.Repeat("x", 8<<20)}); err != nil {
+			t.Error(err)
+		}
+		if _, err := m.Get(bucket, []byte("key"), new(TestData)); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("write waited for the snapshot")
+	}
+
+	got := map[string]string{}
+	err = snap.ForEach(func(b, key, value []byte) error {
+		got[string(b)+"/"+string(key)] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(got) != 1 || got["bucket/key"] != "before" {
+		t.Errorf("snapshot has records %v", got)
+	}
+
+	snap.Release()
+	if files, _ := filepath.Glob(dbFilename + ".snapshot*"); len(files) != 0 {
+		t.Errorf("snapshot files %v were not removed", files)
+	}
+	CleanupTest(t, m)
+}
@@ -0,0 +1,237 @@
+package databaseOverlay
+
+import (
+	"encoding/binary"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The address history index has a bucket for every address, the keys in that bucket are the height, kind,
+// transaction id and position of the record so that the history of an address is sorted by height.
+const addressHistoryKeyLength = 4 + 1 + 32 + 4
+
+const addressHistoryBatchSize = 1000
+
+// SetAddressHistoryIndex turns the indexing of factoid and entry credit blocks by address on or off
+func (db *Overlay) SetAddressHistoryIndex(enabled bool) {
+	db.AddressHistoryIndex = enabled
+}
+
+func (db *Overlay) IsAddressHistoryIndexEnabled() bool {
+	return db.AddressHistoryIndex
+}
+
+// AddressHistoryBucket returns the bucket with the history of the factoid or entry credit address
+func AddressHistoryBucket(address interfaces.IHash) []byte {
+	bucket := make([]byte, 0, len(ADDRESS_HISTORY)+constants.HASH_LENGTH)
+	bucket = append(bucket, ADDRESS_HISTORY...)
+	return append(bucket, address.Bytes()...)
+}
+
+func addressHistoryKey(record *interfaces.AddressHistoryRecord, position int) []byte {
+	key := make([]byte, 0, addressHistoryKeyLength)
+	key = append(key, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(key, record.Height)
+	key = append(key, record.Kind)
+	key = append(key, record.TxID.Bytes()...)
+	key = append(key, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(key[len(key)-4:], uint32(position))
+	return key
+}
+
+func addressHistoryRecord(record *interfaces.AddressHistoryRecord, position int) interfaces.Record {
+	buf := primitives.NewBuffer(nil)
+	buf.PushUInt8(record.Kind)
+	buf.PushUInt32(record.Height)
+	buf.PushIHash(record.TxID)
+	buf.PushUInt64(record.Amount)
+	buf.PushBool(record.EntryHash != nil)
+	if record.EntryHash != nil {
+		buf.PushIHash(record.EntryHash)
+	}
+	bs := new(primitives.ByteSlice)
+	bs.Bytes = buf.DeepCopyBytes()
+
+	return interfaces.Record{Bucket: AddressHistoryBucket(record.Address), Key: addressHistoryKey(record, position), Data: bs}
+}
+
+func unmarshalAddressHistoryRecord(address interfaces.IHash, data []byte) (*interfaces.AddressHistoryRecord, error) {
+	record := new(interfaces.AddressHistoryRecord)
+	record.Address = address
+
+	var err error
+	buf := primitives.NewBuffer(data)
+	if record.Kind, err = buf.PopUInt8(); err != nil {
+		return nil, err
+	}
+	if record.Height, err = buf.PopUInt32(); err != nil {
+		return nil, err
+	}
+	if record.TxID, err = buf.PopIHash(); err != nil {
+		return nil, err
+	}
+	if record.Amount, err = buf.PopUInt64(); err != nil {
+		return nil, err
+	}
+	hasEntryHash, err := buf.PopBool()
+	if err != nil {
+		return nil, err
+	}
+	if hasEntryHash {
+		if record.EntryHash, err = buf.PopIHash(); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// addressHistoryRecordsFromFBlock returns the index records of the inputs and outputs of the factoid transactions,
+// the entry credit purchases are indexed from the entry credit block.
+func addressHistoryRecordsFromFBlock(block interfaces.IFBlock) []interfaces.Record {
+	records := []interfaces.Record{}
+	for _, tx := range block.GetTransactions() {
+		for i, input := range tx.GetInputs() {
+			record := &interfaces.AddressHistoryRecord{Address: input.GetAddress(), Kind: interfaces.AddressHistoryInput,
+				Height: block.GetDatabaseHeight(), TxID: tx.GetSigHash(), Amount: input.GetAmount()}
+			records = append(records, addressHistoryRecord(record, i))
+		}
+		for i, output := range tx.GetOutputs() {
+			record := &interfaces.AddressHistoryRecord{Address: output.GetAddress(), Kind: interfaces.AddressHistoryOutput,
+				Height: block.GetDatabaseHeight(), TxID: tx.GetSigHash(), Amount: output.GetAmount()}
+			records = append(records, addressHistoryRecord(record, i))
+		}
+	}
+	return records
+}
+
+// addressHistoryRecordsFromECBlock returns the index records of the purchases and commits of the entry credit block
+func addressHistoryRecordsFromECBlock(block interfaces.IEntryCreditBlock) []interfaces.Record {
+	records := []interfaces.Record{}
+	for i, entry := range block.GetBody().GetEntries() {
+		record := &interfaces.AddressHistoryRecord{Height: block.GetDatabaseHeight()}
+		switch e := entry.(type) {
+		case *entryCreditBlock.IncreaseBalance:
+			// a purchase belongs to the factoid transaction that bought the entry credits
+			record.Address = primitives.NewHash(e.ECPubKey[:])
+			record.Kind = interfaces.AddressHistoryECPurchase
+			record.TxID = e.TXID
+			record.Amount = e.NumEC
+		case *entryCreditBlock.CommitChain:
+			record.Address = primitives.NewHash(e.ECPubKey[:])
+			record.Kind = interfaces.AddressHistoryCommitChain
+			record.TxID = e.GetSigHash()
+			record.Amount = uint64(e.Credits)
+			record.EntryHash = e.EntryHash
+		case *entryCreditBlock.CommitEntry:
+			record.Address = primitives.NewHash(e.ECPubKey[:])
+			record.Kind = interfaces.AddressHistoryCommitEntry
+			record.TxID = e.GetSigHash()
+			record.Amount = uint64(e.Credits)
+			record.EntryHash = e.EntryHash
+		default:
+			continue
+		}
+		records = append(records, addressHistoryRecord(record, i))
+	}
+	return records
+}
+
+func (db *Overlay) saveAddressHistory(records []interfaces.Record, multiBatch bool) error {
+	if multiBatch {
+		db.PutInMultiBatch(records)
+		return nil
+	}
+	return db.PutInBatch(records)
+}
+
+func (db *Overlay) SaveAddressHistoryFromFBlock(block interfaces.DatabaseBlockWithEntries, multiBatch bool) error {
+	fblock, ok := block.(interfaces.IFBlock)
+	if !db.AddressHistoryIndex || !ok {
+		return nil
+	}
+	return db.saveAddressHistory(addressHistoryRecordsFromFBlock(fblock), multiBatch)
+}
+
+func (db *Overlay) SaveAddressHistoryFromECBlock(block interfaces.IEntryCreditBlock, multiBatch bool) error {
+	if !db.AddressHistoryIndex {
+		return nil
+	}
+	return db.saveAddressHistory(addressHistoryRecordsFromECBlock(block), multiBatch)
+}
+
+// FetchAddressHistory returns the history of the factoid or entry credit address sorted by height. At most limit
+// records following the after cursor are returned, together with the cursor of the next page when there are more.
+func (db *Overlay) FetchAddressHistory(address interfaces.IHash, after []byte, limit int) ([]*interfaces.AddressHistoryRecord, []byte, error) {
+	var start []byte
+	if after != nil {
+		start = keyAfter(after)
+	}
+	it := db.Iterate(AddressHistoryBucket(address), start, nil, false)
+	defer it.Release()
+
+	records := []*interfaces.AddressHistoryRecord{}
+	var last []byte
+	for it.Next() {
+		if limit > 0 && len(records) == limit {
+			return records, last, nil
+		}
+		record, err := unmarshalAddressHistoryRecord(address, it.Value())
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+		last = append(last[:0], it.Key()...)
+	}
+	if err := it.Error(); err != nil {
+		return nil, nil, err
+	}
+	return records, nil, nil
+}
+
+// RebuildAddressHistoryIndex indexes all factoid and entry credit blocks in the database and returns
+// the number of indexed records, records that are already indexed are overwritten
+func (db *Overlay) RebuildAddressHistoryIndex() (int, error) {
+	count := 0
+	batch := []interfaces.Record{}
+	flush := func(force bool) error {
+		if len(batch) == 0 || (!force && len(batch) < addressHistoryBatchSize) {
+			return nil
+		}
+		count += len(batch)
+		err := db.PutInBatch(batch)
+		batch = []interfaces.Record{}
+		return err
+	}
+
+	for height := uint32(0); ; height++ {
+		fblock, err := db.FetchFBlockByHeight(height)
+		if err != nil {
+			return count, err
+		}
+		if fblock == nil {
+			break
+		}
+		batch = append(batch, addressHistoryRecordsFromFBlock(fblock)...)
+		if err := flush(false); err != nil {
+			return count, err
+		}
+	}
+
+	for height := uint32(0); ; height++ {
+		ecblock, err := db.FetchECBlockByHeight(height)
+		if err != nil {
+			return count, err
+		}
+		if ecblock == nil {
+			break
+		}
+		batch = append(batch, addressHistoryRecordsFromECBlock(ecblock)...)
+		if err := flush(false); err != nil {
+			return count, err
+		}
+	}
+	return count, flush(true)
+}
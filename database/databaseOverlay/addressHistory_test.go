@@ -0,0 +1,176 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+// expectedAddressHistory lists the transaction ids of the records of every address in the test blocks
+func expectedAddressHistory(blocks []*testHelper.BlockSet) map[string][]string {
+	expected := map[string][]string{}
+	add := func(address interfaces.IHash, txid interfaces.IHash) {
+		expected[address.String()] = append(expected[address.String()], txid.String())
+	}
+	for _, block := range blocks {
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, input := range tx.GetInputs() {
+				add(input.GetAddress(), tx.GetSigHash())
+			}
+			for _, output := range tx.GetOutputs() {
+				add(output.GetAddress(), tx.GetSigHash())
+			}
+		}
+		for _, entry := range block.ECBlock.GetEntries() {
+			switch e := entry.(type) {
+			case *entryCreditBlock.IncreaseBalance:
+				add(primitives.NewHash(e.ECPubKey[:]), e.TXID)
+			case *entryCreditBlock.CommitChain:
+				add(primitives.NewHash(e.ECPubKey[:]), e.GetSigHash())
+			case *entryCreditBlock.CommitEntry:
+				add(primitives.NewHash(e.ECPubKey[:]), e.GetSigHash())
+			}
+		}
+	}
+	return expected
+}
+
+func checkAddressHistory(t *testing.T, dbo interfaces.DBOverlay, expected map[string][]string) {
+	if len(expected) == 0 {
+		t.Fatal("no addresses in the test blocks")
+	}
+	for address, txids := range expected {
+		records, next, err := dbo.FetchAddressHistory(addressHash(t, address), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != nil {
+			t.Errorf("unexpected cursor for %s", address)
+		}
+		if len(records) != len(txids) {
+			t.Errorf("expected %d records for %s, got %d", len(txids), address, len(records))
+		}
+		remaining := map[string]int{}
+		for _, txid := range txids {
+			remaining[txid]++
+		}
+		for i, record := range records {
+			if record.Address.String() != address {
+				t.Errorf("wrong address %s", record.Address)
+			}
+			if record.Kind < interfaces.AddressHistoryInput || record.Kind > interfaces.AddressHistoryCommitEntry {
+				t.Errorf("invalid kind %d", record.Kind)
+			}
+			if i > 0 && records[i-1].Height > record.Height {
+				t.Errorf("records of %s are not sorted by height", address)
+			}
+			if remaining[record.TxID.String()] == 0 {
+				t.Errorf("unexpected txid %s for %s", record.TxID, address)
+			}
+			remaining[record.TxID.String()]--
+		}
+	}
+}
+
+func TestFetchAddressHistory(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+	dbo.SetAddressHistoryIndex(true)
+
+	for _, block := range blocks {
+		if err := dbo.ProcessFBlockBatch(block.FBlock); err != nil {
+			t.Fatal(err)
+		}
+		if err := dbo.ProcessECBlockBatch(block.ECBlock, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkAddressHistory(t, dbo, expectedAddressHistory(blocks))
+}
+
+func TestFetchAddressHistoryPagination(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+	dbo.SetAddressHistoryIndex(true)
+
+	dbo.StartMultiBatch()
+	for _, block := range blocks {
+		if err := dbo.ProcessFBlockMultiBatch(block.FBlock); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dbo.ExecuteMultiBatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	for address, txids := range expectedAddressHistory(blocks) {
+		count := len(txids)
+		hash := addressHash(t, address)
+		all, _, err := dbo.FetchAddressHistory(hash, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != count || count < 2 {
+			continue
+		}
+
+		var cursor []byte
+		var paged []*interfaces.AddressHistoryRecord
+		for page := 0; page <= count; page++ {
+			records, next, err := dbo.FetchAddressHistory(hash, cursor, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paged = append(paged, records...)
+			if next == nil {
+				break
+			}
+			cursor = next
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("expected %d paged records, got %d", len(all), len(paged))
+		}
+		for i := range all {
+			if !all[i].TxID.IsSameAs(paged[i].TxID) || all[i].Kind != paged[i].Kind {
+				t.Errorf("paged record %d differs", i)
+			}
+		}
+		return
+	}
+	t.Error("no address with several records found")
+}
+
+func TestRebuildAddressHistoryIndex(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	count, err := dbo.RebuildAddressHistoryIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := expectedAddressHistory(blocks)
+	total := 0
+	for _, txids := range expected {
+		total += len(txids)
+	}
+	if count != total {
+		t.Errorf("expected %d indexed records, got %d", total, count)
+	}
+	checkAddressHistory(t, dbo, expected)
+}
+
+func addressHash(t *testing.T, address string) interfaces.IHash {
+	hash, err := primitives.HexToHash(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
@@ -0,0 +1,300 @@
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// A balance checkpoint holds the balances of all addresses after the blocks below its height, the balance at any
+// height is found by replaying the factoid and entry credit blocks since the checkpoint below it.
+var BalanceCheckpointInterval uint32 = 1000
+
+const balanceCheckpointBatchSize = 10000
+
+var BalanceCheckpointHeightKey = []byte("BalanceCheckpointHeight")
+
+// ErrBalanceCheckpointsNotReady is returned for heights whose checkpoint hasn't been computed yet
+var ErrBalanceCheckpointsNotReady = errors.New("the balance checkpoints are not computed yet")
+
+// SetBalanceCheckpoints turns the balance checkpoints on or off, the checkpoints are computed by UpdateBalanceCheckpoints
+func (db *Overlay) SetBalanceCheckpoints(enabled bool) {
+	db.BalanceCheckpoints = enabled
+}
+
+func (db *Overlay) IsBalanceCheckpointsEnabled() bool {
+	return db.BalanceCheckpoints
+}
+
+// BalanceCheckpointBucket returns the bucket with the factoid or entry credit balances of the checkpoint
+func BalanceCheckpointBucket(height uint32, ec bool) []byte {
+	bucket := make([]byte, 0, len(BALANCE_CHECKPOINT)+5)
+	bucket = append(bucket, BALANCE_CHECKPOINT...)
+	bucket = append(bucket, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(bucket[len(BALANCE_CHECKPOINT):], height)
+	if ec {
+		return append(bucket, 1)
+	}
+	return append(bucket, 0)
+}
+
+func (db *Overlay) SaveBalanceCheckpointHeight(height uint32) error {
+	buf := primitives.NewBuffer(nil)
+	buf.PushUInt32(height)
+	bs := new(primitives.ByteSlice)
+	bs.Bytes = buf.DeepCopyBytes()
+
+	return db.SaveKeyValueStore(bs, BalanceCheckpointHeightKey)
+}
+
+// FetchBalanceCheckpointHeight returns the height of the highest checkpoint, the checkpoint at height 0 holds no balances
+func (db *Overlay) FetchBalanceCheckpointHeight() (uint32, error) {
+	bs := new(primitives.ByteSlice)
+	data, err := db.FetchKeyValueStore(BalanceCheckpointHeightKey, bs)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return 0, nil
+	}
+	buf := primitives.NewBuffer(bs.Bytes)
+	height, err := buf.PopUInt32()
+	if err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+func balanceRecord(bucket []byte, address [32]byte, balance int64) interfaces.Record {
+	buf := primitives.NewBuffer(nil)
+	buf.PushInt64(balance)
+	bs := new(primitives.ByteSlice)
+	bs.Bytes = buf.DeepCopyBytes()
+
+	key := make([]byte, len(address))
+	copy(key, address[:])
+	return interfaces.Record{Bucket: bucket, Key: key, Data: bs}
+}
+
+func (db *Overlay) fetchCheckpointBalance(bucket []byte, address []byte) (int64, error) {
+	bs := new(primitives.ByteSlice)
+	data, err := db.DB.Get(bucket, address, bs)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return 0, nil
+	}
+	return primitives.NewBuffer(bs.Bytes).PopInt64()
+}
+
+func (db *Overlay) fetchCheckpointBalances(bucket []byte) (map[[32]byte]int64, error) {
+	keys, err := db.ListAllKeys(bucket)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[[32]byte]int64, len(keys))
+	for _, key := range keys {
+		balance, err := db.fetchCheckpointBalance(bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		var address [32]byte
+		copy(address[:], key)
+		balances[address] = balance
+	}
+	return balances, nil
+}
+
+// replayBalances applies the factoid and entry credit blocks at the height to the balances
+func (db *Overlay) replayBalances(height uint32, fct map[[32]byte]int64, ec map[[32]byte]int64) error {
+	fblock, err := db.FetchFBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	if fblock == nil {
+		return fmt.Errorf("factoid block %d not found", height)
+	}
+
+	dblock, err := db.FetchDBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	if dblock == nil {
+		return fmt.Errorf("directory block %d not found", height)
+	}
+	ecblock, err := db.FetchECBlock(dblock.GetDBEntries()[1].GetKeyMR())
+	if err != nil {
+		return err
+	}
+	if ecblock == nil {
+		// The entry credit blocks 70386 to 70410 of the main network do not exist
+		if !(dblock.GetHeader().GetNetworkID() == constants.MAIN_NETWORK_ID && height >= 70386 && height <= 70410) {
+			return fmt.Errorf("entry credit block %d not found", height)
+		}
+	}
+	ApplyBlockBalances(fblock, ecblock, fct, ec)
+	return nil
+}
+
+// ApplyBlockBalances applies the transactions of a factoid block and the commits of an entry credit block to the
+// balances. The entry credit block may be nil.
+func ApplyBlockBalances(fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock, fct map[[32]byte]int64, ec map[[32]byte]int64) {
+	for _, tx := range fblock.GetTransactions() {
+		for _, input := range tx.GetInputs() {
+			fct[input.GetAddress().Fixed()] -= int64(input.GetAmount())
+		}
+		for _, output := range tx.GetOutputs() {
+			fct[output.GetAddress().Fixed()] += int64(output.GetAmount())
+		}
+		for _, output := range tx.GetECOutputs() {
+			ec[output.GetAddress().Fixed()] += int64(output.GetAmount() / fblock.GetExchRate())
+		}
+	}
+
+	if ecblock == nil {
+		return
+	}
+	for _, entry := range ecblock.GetBody().GetEntries() {
+		switch entry.ECID() {
+		case constants.ECIDChainCommit:
+			commit := entry.(*entryCreditBlock.CommitChain)
+			ec[commit.ECPubKey.Fixed()] -= int64(commit.Credits)
+		case constants.ECIDEntryCommit:
+			commit := entry.(*entryCreditBlock.CommitEntry)
+			ec[commit.ECPubKey.Fixed()] -= int64(commit.Credits)
+		}
+	}
+}
+
+func (db *Overlay) saveBalanceCheckpoint(height uint32, fct map[[32]byte]int64, ec map[[32]byte]int64) error {
+	for i, balances := range []map[[32]byte]int64{fct, ec} {
+		bucket := BalanceCheckpointBucket(height, i == 1)
+		batch := []interfaces.Record{}
+		for address, balance := range balances {
+			if balance == 0 {
+				continue
+			}
+			batch = append(batch, balanceRecord(bucket, address, balance))
+			if len(batch) >= balanceCheckpointBatchSize {
+				if err := db.PutInBatch(batch); err != nil {
+					return err
+				}
+				batch = []interfaces.Record{}
+			}
+		}
+		if err := db.PutInBatch(batch); err != nil {
+			return err
+		}
+	}
+	return db.SaveBalanceCheckpointHeight(height)
+}
+
+// UpdateBalanceCheckpoints computes the missing checkpoints up to the highest saved directory block and returns
+// the height of the highest checkpoint. Only one update runs at a time, concurrent calls return right away.
+func (db *Overlay) UpdateBalanceCheckpoints() (uint32, error) {
+	if !atomic.CompareAndSwapInt32(&db.updatingBalanceCheckpoints, 0, 1) {
+		return db.FetchBalanceCheckpointHeight()
+	}
+	defer atomic.StoreInt32(&db.updatingBalanceCheckpoints, 0)
+
+	checkpoint, err := db.FetchBalanceCheckpointHeight()
+	if err != nil {
+		return 0, err
+	}
+	head, err := db.FetchDBlockHead()
+	if err != nil {
+		return checkpoint, err
+	}
+	if head == nil || head.GetDatabaseHeight()+1 < checkpoint+BalanceCheckpointInterval {
+		return checkpoint, nil
+	}
+
+	fct, err := db.fetchCheckpointBalances(BalanceCheckpointBucket(checkpoint, false))
+	if err != nil {
+		return checkpoint, err
+	}
+	ec, err := db.fetchCheckpointBalances(BalanceCheckpointBucket(checkpoint, true))
+	if err != nil {
+		return checkpoint, err
+	}
+
+	last := (head.GetDatabaseHeight() + 1) / BalanceCheckpointInterval * BalanceCheckpointInterval
+	for height := checkpoint; height < last; height++ {
+		if err := db.replayBalances(height, fct, ec); err != nil {
+			return checkpoint, err
+		}
+		if (height+1)%BalanceCheckpointInterval == 0 {
+			if err := db.saveBalanceCheckpoint(height+1, fct, ec); err != nil {
+				return checkpoint, err
+			}
+			checkpoint = height + 1
+		}
+	}
+	return checkpoint, nil
+}
+
+// FetchBalancesAtHeight returns the balances of the factoid and entry credit addresses after the blocks at the height,
+// the balances are replayed from the highest checkpoint below the height. While that checkpoint is being computed
+// the one before it is used, heights further above the computed checkpoints return ErrBalanceCheckpointsNotReady.
+func (db *Overlay) FetchBalancesAtHeight(height uint32, fctAddresses []interfaces.IHash, ecAddresses []interfaces.IHash) ([]int64, []int64, error) {
+	head, err := db.FetchDBlockHead()
+	if err != nil {
+		return nil, nil, err
+	}
+	if head == nil || height > head.GetDatabaseHeight() {
+		return nil, nil, fmt.Errorf("directory block %d is not saved", height)
+	}
+
+	checkpoint, err := db.FetchBalanceCheckpointHeight()
+	if err != nil {
+		return nil, nil, err
+	}
+	limit := (height + 1) / BalanceCheckpointInterval * BalanceCheckpointInterval
+	if checkpoint > limit {
+		checkpoint = limit
+	}
+	if limit-checkpoint > BalanceCheckpointInterval {
+		return nil, nil, ErrBalanceCheckpointsNotReady
+	}
+
+	fct := map[[32]byte]int64{}
+	for _, address := range fctAddresses {
+		balance, err := db.fetchCheckpointBalance(BalanceCheckpointBucket(checkpoint, false), address.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		fct[address.Fixed()] = balance
+	}
+	ec := map[[32]byte]int64{}
+	for _, address := range ecAddresses {
+		balance, err := db.fetchCheckpointBalance(BalanceCheckpointBucket(checkpoint, true), address.Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		ec[address.Fixed()] = balance
+	}
+
+	// The replay also adds the other addresses of the blocks to the maps, only the requested ones are returned
+	for h := checkpoint; h <= height; h++ {
+		if err := db.replayBalances(h, fct, ec); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fctBalances := make([]int64, len(fctAddresses))
+	for i, address := range fctAddresses {
+		fctBalances[i] = fct[address.Fixed()]
+	}
+	ecBalances := make([]int64, len(ecAddresses))
+	for i, address := range ecAddresses {
+		ecBalances[i] = ec[address.Fixed()]
+	}
+	return fctBalances, ecBalances, nil
+}
@@ -0,0 +1,151 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+// expectedBalances returns the balances of all addresses after every test block
+func expectedBalances(blocks []*testHelper.BlockSet) ([]map[[32]byte]int64, []map[[32]byte]int64) {
+	var fctHistory, ecHistory []map[[32]byte]int64
+	fct := map[[32]byte]int64{}
+	ec := map[[32]byte]int64{}
+	for _, block := range blocks {
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, input := range tx.GetInputs() {
+				fct[input.GetAddress().Fixed()] -= int64(input.GetAmount())
+			}
+			for _, output := range tx.GetOutputs() {
+				fct[output.GetAddress().Fixed()] += int64(output.GetAmount())
+			}
+			for _, output := range tx.GetECOutputs() {
+				ec[output.GetAddress().Fixed()] += int64(output.GetAmount() / block.FBlock.GetExchRate())
+			}
+		}
+		for _, entry := range block.ECBlock.GetEntries() {
+			switch e := entry.(type) {
+			case *entryCreditBlock.CommitChain:
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			case *entryCreditBlock.CommitEntry:
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			}
+		}
+
+		fctCopy := map[[32]byte]int64{}
+		for k, v := range fct {
+			fctCopy[k] = v
+		}
+		ecCopy := map[[32]byte]int64{}
+		for k, v := range ec {
+			ecCopy[k] = v
+		}
+		fctHistory = append(fctHistory, fctCopy)
+		ecHistory = append(ecHistory, ecCopy)
+	}
+	return fctHistory, ecHistory
+}
+
+func checkBalancesAtHeight(t *testing.T, dbo *Overlay, blocks []*testHelper.BlockSet) {
+	fctHistory, ecHistory := expectedBalances(blocks)
+	last := len(blocks) - 1
+
+	var fctAddresses, ecAddresses []interfaces.IHash
+	for address := range fctHistory[last] {
+		fctAddresses = append(fctAddresses, primitives.NewHash(address[:]))
+	}
+	for address := range ecHistory[last] {
+		ecAddresses = append(ecAddresses, primitives.NewHash(address[:]))
+	}
+	if len(fctAddresses) == 0 || len(ecAddresses) == 0 {
+		t.Fatal("no balances in the test blocks")
+	}
+
+	for height := range blocks {
+		fct, ec, err := dbo.FetchBalancesAtHeight(uint32(height), fctAddresses, ecAddresses)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, address := range fctAddresses {
+			if expected := fctHistory[height][address.Fixed()]; fct[i] != expected {
+				t.Errorf("height %d: expected factoid balance %d for %x, got %d", height, expected, address.Bytes(), fct[i])
+			}
+		}
+		for i, address := range ecAddresses {
+			if expected := ecHistory[height][address.Fixed()]; ec[i] != expected {
+				t.Errorf("height %d: expected entry credit balance %d for %x, got %d", height, expected, address.Bytes(), ec[i])
+			}
+		}
+	}
+
+	if _, _, err := dbo.FetchBalancesAtHeight(uint32(len(blocks)), fctAddresses, ecAddresses); err == nil {
+		t.Error("no error for a height above the head")
+	}
+}
+
+func TestFetchBalancesAtHeight(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	// without checkpoints all blocks are replayed
+	checkBalancesAtHeight(t, dbo, blocks)
+
+	interval := BalanceCheckpointInterval
+	defer func() { BalanceCheckpointInterval = interval }()
+	BalanceCheckpointInterval = 3
+
+	// heights more than one interval above the computed checkpoints are not replayed
+	if _, _, err := dbo.FetchBalancesAtHeight(4, nil, nil); err != nil {
+		t.Errorf("height 4: %v", err)
+	}
+	if _, _, err := dbo.FetchBalancesAtHeight(5, nil, nil); err != ErrBalanceCheckpointsNotReady {
+		t.Errorf("height 5: expected ErrBalanceCheckpointsNotReady, got %v", err)
+	}
+
+	checkpoint, err := dbo.UpdateBalanceCheckpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := uint32(len(blocks)) / 3 * 3; checkpoint != expected {
+		t.Errorf("expected checkpoint %d, got %d", expected, checkpoint)
+	}
+	checkBalancesAtHeight(t, dbo, blocks)
+
+	// the checkpoints are up to date
+	checkpoint, err = dbo.UpdateBalanceCheckpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height, _ := dbo.FetchBalanceCheckpointHeight(); checkpoint != height {
+		t.Errorf("expected checkpoint %d, got %d", height, checkpoint)
+	}
+}
+
+func TestBalanceCheckpointsMissingECBlock(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	dblock, err := dbo.FetchDBlockByHeight(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dbo.DB.Delete(ENTRYCREDITBLOCK, dblock.GetDBEntries()[1].GetKeyMR().Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := dbo.FetchBalancesAtHeight(2, nil, nil); err == nil {
+		t.Error("no error for a missing entry credit block")
+	}
+
+	interval := BalanceCheckpointInterval
+	defer func() { BalanceCheckpointInterval = interval }()
+	BalanceCheckpointInterval = 3
+	if _, err := dbo.UpdateBalanceCheckpoints(); err == nil {
+		t.Error("checkpoint computed without an entry credit block")
+	}
+}
@@ -9,12 +9,9 @@
 package databaseOverlay
 
 import (
-	"sort"
-
 	"github.com/FactomProject/factomd/common/directoryBlock"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
-	"github.com/FactomProject/factomd/util"
 )
 
 // ProcessDBlockBatche inserts the DBlock and update all it's dbentries in DB
@@ -134,24 +131,44 @@ func (db *Overlay) FetchDBKeyMRByHash(hash interfaces.IHash) (interfaces.IHash,
 
 // FetchAllDBlocks gets all of the fbInfo
 func (db *Overlay) FetchAllDBlocks() ([]interfaces.IDirectoryBlock, error) {
-	list, err := db.FetchAllBlocksFromBucket(DIRECTORYBLOCK, new(directoryBlock.DirectoryBlock))
+	list := []interfaces.IDirectoryBlock{}
+	err := db.ForEachDBlock(func(dblock interfaces.IDirectoryBlock) error {
+		list = append(list, dblock)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return toDBlocksList(list), nil
+	return list, nil
 }
 
-func (db *Overlay) FetchAllDBlockKeys() ([]interfaces.IHash, error) {
-	return db.FetchAllBlockKeysFromBucket(DIRECTORYBLOCK)
-}
+// ForEachDBlock calls f with every directory block in ascending order of height, loading one block at a time.
+// An error returned by f stops the iteration and is returned.
+func (db *Overlay) ForEachDBlock(f func(interfaces.IDirectoryBlock) error) error {
+	it := db.Iterate(DIRECTORYBLOCK_NUMBER, nil, nil, false)
+	defer it.Release()
 
-func toDBlocksList(source []interfaces.BinaryMarshallableAndCopyable) []interfaces.IDirectoryBlock {
-	answer := make([]interfaces.IDirectoryBlock, len(source))
-	for i, v := range source {
-		answer[i] = v.(interfaces.IDirectoryBlock)
+	for it.Next() {
+		keyMR, err := primitives.NewShaHash(it.Value())
+		if err != nil {
+			return err
+		}
+		dblock, err := db.FetchDBlock(keyMR)
+		if err != nil {
+			return err
+		}
+		if dblock == nil {
+			continue
+		}
+		if err := f(dblock); err != nil {
+			return err
+		}
 	}
-	sort.Sort(util.ByDBlockIDAscending(answer))
-	return answer
+	return it.Error()
+}
+
+func (db *Overlay) FetchAllDBlockKeys() ([]interfaces.IHash, error) {
+	return db.FetchAllBlockKeysFromBucket(DIRECTORYBLOCK)
 }
 
 func (db *Overlay) SaveDirectoryBlockHead(dblock interfaces.DatabaseBlockWithEntries) error {
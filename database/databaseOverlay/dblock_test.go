@@ -9,9 +9,11 @@
 package databaseOverlay_test
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	. "github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/mapdb"
@@ -191,3 +193,36 @@ func TestLoadUnknownDBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestForEachDBlock(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	height := uint32(0)
+	err := dbo.ForEachDBlock(func(dblock interfaces.IDirectoryBlock) error {
+		if dblock.GetDatabaseHeight() != height {
+			t.Errorf("got block %d, want %d", dblock.GetDatabaseHeight(), height)
+		}
+		height++
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if height != 10 {
+		t.Errorf("iterated over %d blocks, want 10", height)
+	}
+
+	stop := fmt.Errorf("stop")
+	count := 0
+	err = dbo.ForEachDBlock(func(dblock interfaces.IDirectoryBlock) error {
+		count++
+		if dblock.GetDatabaseHeight() == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 4 {
+		t.Errorf("stopped after %d blocks with %v", count, err)
+	}
+}
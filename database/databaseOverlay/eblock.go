@@ -1,6 +1,9 @@
 package databaseOverlay
 
 import (
+	"encoding/binary"
+	"math"
+
 	"github.com/FactomProject/factomd/common/entryBlock"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
@@ -93,23 +96,83 @@ func (db *Overlay) FetchEBKeyMRByHash(hash interfaces.IHash) (interfaces.IHash,
 
 // FetchAllEBlocksByChain gets all of the blocks by chain id
 func (db *Overlay) FetchAllEBlocksByChain(chainID interfaces.IHash) ([]interfaces.IEntryBlock, error) {
-	bucket := append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...)
-	keyList, err := db.FetchAllBlocksFromBucket(bucket, new(primitives.Hash))
+	list := []interfaces.IEntryBlock{}
+	err := db.ForEachEBlockByChain(chainID, func(eblock interfaces.IEntryBlock) error {
+		list = append(list, eblock)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return list, nil
+}
 
-	list := make([]interfaces.IEntryBlock, len(keyList))
+// ForEachEBlockByChain calls f with every entry block of the chain in ascending order of height, loading one block at
+// a time. An error returned by f stops the iteration and is returned.
+func (db *Overlay) ForEachEBlockByChain(chainID interfaces.IHash, f func(interfaces.IEntryBlock) error) error {
+	bucket := append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...)
+	it := db.Iterate(bucket, nil, nil, false)
+	defer it.Release()
 
-	for i, v := range keyList {
-		block, err := db.FetchEBlock(v.(interfaces.IHash))
+	for it.Next() {
+		keyMR, err := primitives.NewShaHash(it.Value())
+		if err != nil {
+			return err
+		}
+		eblock, err := db.FetchEBlock(keyMR)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if eblock == nil {
+			continue
+		}
+		if err := f(eblock); err != nil {
+			return err
 		}
-		list[i] = block
 	}
+	return it.Error()
+}
 
-	return list, nil
+// FetchEBlockHeightsByChain returns the directory block heights between from and to (inclusive) that have an entry
+// block of the chain, in ascending order or descending if reverse is set. At most limit heights are returned,
+// all of them if limit is 0.
+func (db *Overlay) FetchEBlockHeightsByChain(chainID interfaces.IHash, from, to uint32, reverse bool, limit int) ([]uint32, error) {
+	bucket := append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...)
+	start := make([]byte, 4)
+	binary.BigEndian.PutUint32(start, from)
+	var end []byte
+	if to < math.MaxUint32 {
+		end = make([]byte, 4)
+		binary.BigEndian.PutUint32(end, to+1)
+	}
+
+	it := db.Iterate(bucket, start, end, reverse)
+	defer it.Release()
+
+	heights := []uint32{}
+	for (limit <= 0 || len(heights) < limit) && it.Next() {
+		if len(it.Key()) != 4 {
+			continue
+		}
+		heights = append(heights, binary.BigEndian.Uint32(it.Key()))
+	}
+	return heights, it.Error()
+}
+
+// FetchEBlockByChainHeight gets the entry block of the chain at the directory block height
+func (db *Overlay) FetchEBlockByChainHeight(chainID interfaces.IHash, height uint32) (interfaces.IEntryBlock, error) {
+	bucket := append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...)
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, height)
+
+	keyMR, err := db.DB.Get(bucket, key, new(primitives.Hash))
+	if err != nil {
+		return nil, err
+	}
+	if keyMR == nil {
+		return nil, nil
+	}
+	return db.FetchEBlock(keyMR.(interfaces.IHash))
 }
 
 func (db *Overlay) SaveEBlockHead(block interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
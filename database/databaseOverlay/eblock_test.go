@@ -9,6 +9,8 @@
 package databaseOverlay_test
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	. "github.com/FactomProject/factomd/common/entryBlock"
@@ -212,3 +214,69 @@ func TestFetchAllEBlockChainIDs(t *testing.T) {
 		t.Errorf("Got wrong number of chains - %v", len(chains))
 	}
 }
+
+func TestFetchEBlockByChainHeight(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+
+	var prev *EBlock
+	blocks := []*EBlock{}
+	for i := 0; i < 5; i++ {
+		prev, _ = testHelper.CreateTestEntryBlock(prev)
+		blocks = append(blocks, prev)
+		if err := dbo.ProcessEBlockBatch(prev, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain := blocks[0].GetChainID()
+
+	for _, c := range []struct {
+		from, to uint32
+		reverse  bool
+		limit    int
+		expected string
+	}{
+		{1, 3, false, 0, "[1 2 3]"},
+		{1, 3, true, 0, "[3 2 1]"},
+		{0, math.MaxUint32, false, 2, "[0 1]"},
+		{0, math.MaxUint32, true, 2, "[4 3]"},
+		{5, math.MaxUint32, false, 0, "[]"},
+	} {
+		heights, err := dbo.FetchEBlockHeightsByChain(chain, c.from, c.to, c.reverse, c.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(heights) != c.expected {
+			t.Errorf("heights %d to %d (reverse %v, limit %d) = %v, want %s", c.from, c.to, c.reverse, c.limit, heights, c.expected)
+		}
+	}
+
+	heights, err := dbo.FetchEBlockHeightsByChain(primitives.NewZeroHash(), 0, 10, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heights) != 0 {
+		t.Errorf("unexpected heights %v for an unknown chain", heights)
+	}
+
+	for _, block := range blocks {
+		eblock, err := dbo.FetchEBlockByChainHeight(chain, block.GetDatabaseHeight())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eblock == nil {
+			t.Fatalf("entry block %d not found", block.GetDatabaseHeight())
+		}
+		if !eblock.DatabasePrimaryIndex().IsSameAs(block.DatabasePrimaryIndex()) {
+			t.Errorf("wrong entry block at height %d", block.GetDatabaseHeight())
+		}
+	}
+
+	eblock, err := dbo.FetchEBlockByChainHeight(chain, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eblock != nil {
+		t.Error("unexpected entry block")
+	}
+}
@@ -21,7 +21,11 @@ func (db *Overlay) ProcessECBlockBatch(block interfaces.IEntryCreditBlock, check
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromECBlock(block, false)
 }
 
 func (db *Overlay) ProcessECBlockBatchWithoutHead(block interfaces.IEntryCreditBlock, checkForDuplicateEntries bool) error {
@@ -35,7 +39,11 @@ func (db *Overlay) ProcessECBlockBatchWithoutHead(block interfaces.IEntryCreditB
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromECBlock(block, false)
 }
 
 func (db *Overlay) ProcessECBlockMultiBatch(block interfaces.IEntryCreditBlock, checkForDuplicateEntries bool) error {
@@ -49,7 +57,11 @@ func (db *Overlay) ProcessECBlockMultiBatch(block interfaces.IEntryCreditBlock,
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlockMultiBatch(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlockMultiBatch(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromECBlock(block, true)
 }
 
 func (db *Overlay) FetchECBlock(hash interfaces.IHash) (interfaces.IEntryCreditBlock, error) {
@@ -19,6 +19,9 @@ func (db *Overlay) InsertEntry(entry interfaces.IEBEntry) error {
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{Bucket: entry.GetChainID().Bytes(), Key: entry.DatabasePrimaryIndex().Bytes(), Data: entry})
 	batch = append(batch, interfaces.Record{Bucket: ENTRY, Key: entry.DatabasePrimaryIndex().Bytes(), Data: entry.GetChainIDHash()})
+	if db.ExtIDIndex {
+		batch = append(batch, extIDIndexRecords(entry)...)
+	}
 
 	err := db.PutInBatch(batch)
 	if err != nil {
@@ -42,6 +45,9 @@ func (db *Overlay) InsertEntryMultiBatch(entry interfaces.IEBEntry) error {
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{Bucket: entry.GetChainID().Bytes(), Key: entry.DatabasePrimaryIndex().Bytes(), Data: entry})
 	batch = append(batch, interfaces.Record{Bucket: ENTRY, Key: entry.DatabasePrimaryIndex().Bytes(), Data: entry.GetChainIDHash()})
+	if db.ExtIDIndex {
+		batch = append(batch, extIDIndexRecords(entry)...)
+	}
 
 	db.PutInMultiBatch(batch)
 	if _, exists := ValidAnchorChains[entry.GetChainID().String()]; exists {
@@ -84,19 +90,33 @@ func (db *Overlay) FetchAllEntryIDsByChainID(chainID interfaces.IHash) ([]interf
 }
 
 func (db *Overlay) FetchAllEntryIDs() ([]interfaces.IHash, error) {
-	ids, err := db.ListAllKeys(ENTRY)
+	entries := []interfaces.IHash{}
+	err := db.ForEachEntryID(func(id interfaces.IHash) error {
+		entries = append(entries, id)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	entries := []interfaces.IHash{}
-	for _, id := range ids {
-		h, err := primitives.NewShaHash(id)
+	return entries, nil
+}
+
+// ForEachEntryID calls f with the hash of every entry in the database, in ascending order.
+// An error returned by f stops the iteration and is returned.
+func (db *Overlay) ForEachEntryID(f func(interfaces.IHash) error) error {
+	it := db.Iterate(ENTRY, nil, nil, false)
+	defer it.Release()
+
+	for it.Next() {
+		h, err := primitives.NewShaHash(it.Key())
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := f(h); err != nil {
+			return err
 		}
-		entries = append(entries, h)
 	}
-	return entries, nil
+	return it.Error()
 }
 
 func toEntryList(source []interfaces.BinaryMarshallableAndCopyable) []interfaces.IEBEntry {
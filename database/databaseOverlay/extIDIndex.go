@@ -0,0 +1,128 @@
+package databaseOverlay
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The ExtID index has a bucket for every indexed key, the keys in that bucket are the hashes of the matching entries.
+// An entry is indexed by the first ExtIDs within its chain: an entry with ExtIDs [a, b, c] can be found with
+// the prefixes [a], [a, b] and [a, b, c] in its chain. It's also indexed by each of these ExtIDs across all chains.
+const MaxIndexedExtIDs = 4
+
+const extIDIndexBatchSize = 1000
+
+// SetExtIDIndex turns the indexing of entries by ExtID on or off
+func (db *Overlay) SetExtIDIndex(enabled bool) {
+	db.ExtIDIndex = enabled
+}
+
+func (db *Overlay) IsExtIDIndexEnabled() bool {
+	return db.ExtIDIndex
+}
+
+// ExtIDIndexBucket returns the bucket with the entries that have the given ExtID prefix in the chain,
+// or the given single ExtID in any chain when the chain id is nil.
+func ExtIDIndexBucket(chainID interfaces.IHash, extIDs [][]byte) []byte {
+	hash := sha256.New()
+	if chainID != nil {
+		hash.Write(chainID.Bytes())
+	} else {
+		hash.Write(make([]byte, 32))
+	}
+	for _, extID := range extIDs {
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(extID)))
+		hash.Write(size)
+		hash.Write(extID)
+	}
+
+	bucket := make([]byte, 0, len(EXTID_INDEX)+sha256.Size)
+	bucket = append(bucket, EXTID_INDEX...)
+	return append(bucket, hash.Sum(nil)...)
+}
+
+// extIDIndexRecords returns the index records of the entry, the data of the records is the chain id of the entry
+func extIDIndexRecords(entry interfaces.IEBEntry) []interfaces.Record {
+	extIDs := entry.ExternalIDs()
+	if len(extIDs) > MaxIndexedExtIDs {
+		extIDs = extIDs[:MaxIndexedExtIDs]
+	}
+
+	key := entry.GetHash().Bytes()
+	chainID := entry.GetChainID()
+	records := make([]interfaces.Record, 0, 2*len(extIDs))
+	for i := range extIDs {
+		records = append(records, interfaces.Record{Bucket: ExtIDIndexBucket(chainID, extIDs[:i+1]), Key: key, Data: chainID})
+		records = append(records, interfaces.Record{Bucket: ExtIDIndexBucket(nil, extIDs[i:i+1]), Key: key, Data: chainID})
+	}
+	return records
+}
+
+// FetchEntryHashesByExtID returns the hashes of the entries that start with the given ExtIDs in the chain, or that
+// have the single given ExtID in any chain when the chain id is nil. The hashes are sorted, at most limit hashes
+// following the after hash are returned, together with a flag telling whether there are more.
+func (db *Overlay) FetchEntryHashesByExtID(chainID interfaces.IHash, extIDs [][]byte, after interfaces.IHash, limit int) ([]interfaces.IHash, bool, error) {
+	var start []byte
+	if after != nil {
+		start = keyAfter(after.Bytes())
+	}
+	it := db.Iterate(ExtIDIndexBucket(chainID, extIDs), start, nil, false)
+	defer it.Release()
+
+	hashes := []interfaces.IHash{}
+	for it.Next() {
+		if limit > 0 && len(hashes) == limit {
+			return hashes, true, nil
+		}
+		hashes = append(hashes, primitives.NewHash(it.Key()))
+	}
+	return hashes, false, it.Error()
+}
+
+// keyAfter returns the first key that sorts after the key, to start an iteration behind it
+func keyAfter(key []byte) []byte {
+	start := make([]byte, len(key), len(key)+1)
+	copy(start, key)
+	return append(start, 0)
+}
+
+// RebuildExtIDIndex indexes all entries in the database, entries that are already indexed are overwritten
+func (db *Overlay) RebuildExtIDIndex() (int, error) {
+	chainIDs, err := db.FetchAllEBlockChainIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	batch := []interfaces.Record{}
+	for _, chainID := range chainIDs {
+		keys, err := db.ListAllKeys(chainID.Bytes())
+		if err != nil {
+			return count, err
+		}
+		for _, key := range keys {
+			entry, err := db.DB.Get(chainID.Bytes(), key, entryBlock.NewEntry())
+			if err != nil {
+				return count, err
+			}
+			if entry == nil {
+				continue
+			}
+
+			batch = append(batch, extIDIndexRecords(entry.(interfaces.IEBEntry))...)
+			count++
+			if len(batch) >= extIDIndexBatchSize {
+				if err := db.PutInBatch(batch); err != nil {
+					return count, err
+				}
+				batch = []interfaces.Record{}
+			}
+		}
+	}
+	return count, db.PutInBatch(batch)
+}
@@ -0,0 +1,156 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+)
+
+func newExtIDTestEntry(chainID interfaces.IHash, content string, extIDs ...string) *Entry {
+	entry := NewEntry()
+	entry.ChainID = chainID
+	for _, extID := range extIDs {
+		entry.ExtIDs = append(entry.ExtIDs, primitives.ByteSlice{Bytes: []byte(extID)})
+	}
+	entry.Content = primitives.ByteSlice{Bytes: []byte(content)}
+	return entry
+}
+
+func extIDs(values ...string) [][]byte {
+	result := [][]byte{}
+	for _, value := range values {
+		result = append(result, []byte(value))
+	}
+	return result
+}
+
+func insertExtIDTestEntries(t *testing.T, dbo *Overlay) (chain1, chain2 interfaces.IHash, entries []*Entry) {
+	chain1 = primitives.Sha([]byte("chain1"))
+	chain2 = primitives.Sha([]byte("chain2"))
+	entries = []*Entry{
+		newExtIDTestEntry(chain1, "1", "invoice", "2019", "a"),
+		newExtIDTestEntry(chain1, "2", "invoice", "2019", "b"),
+		newExtIDTestEntry(chain1, "3", "invoice", "2020"),
+		newExtIDTestEntry(chain1, "4", "receipt"),
+		newExtIDTestEntry(chain2, "5", "invoice", "2019"),
+	}
+	for _, entry := range entries {
+		if err := dbo.InsertEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return
+}
+
+func TestFetchEntryHashesByExtID(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+	dbo.SetExtIDIndex(true)
+	chain1, chain2, entries := insertExtIDTestEntries(t, dbo)
+
+	testCases := []struct {
+		ChainID  interfaces.IHash
+		ExtIDs   [][]byte
+		Expected []*Entry
+	}{
+		{chain1, extIDs("invoice"), []*Entry{entries[0], entries[1], entries[2]}},
+		{chain1, extIDs("invoice", "2019"), []*Entry{entries[0], entries[1]}},
+		{chain1, extIDs("invoice", "2019", "b"), []*Entry{entries[1]}},
+		{chain1, extIDs("2019"), []*Entry{}},
+		{chain2, extIDs("invoice"), []*Entry{entries[4]}},
+		{nil, extIDs("invoice"), []*Entry{entries[0], entries[1], entries[2], entries[4]}},
+		{nil, extIDs("2019"), []*Entry{entries[0], entries[1], entries[4]}},
+		{nil, extIDs("unknown"), []*Entry{}},
+	}
+	for i, testCase := range testCases {
+		hashes, more, err := dbo.FetchEntryHashesByExtID(testCase.ChainID, testCase.ExtIDs, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if more {
+			t.Errorf("case %d: unexpected more", i)
+		}
+		if len(hashes) != len(testCase.Expected) {
+			t.Errorf("case %d: expected %d entries, got %d", i, len(testCase.Expected), len(hashes))
+			continue
+		}
+		for _, expected := range testCase.Expected {
+			found := false
+			for _, hash := range hashes {
+				found = found || hash.IsSameAs(expected.GetHash())
+			}
+			if !found {
+				t.Errorf("case %d: entry %s not found", i, expected.GetHash())
+			}
+		}
+	}
+}
+
+func TestFetchEntryHashesByExtIDPagination(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+	dbo.SetExtIDIndex(true)
+	insertExtIDTestEntries(t, dbo)
+
+	var all []interfaces.IHash
+	var after interfaces.IHash
+	for page := 0; page < 10; page++ {
+		hashes, more, err := dbo.FetchEntryHashesByExtID(nil, extIDs("invoice"), after, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, hashes...)
+		if !more {
+			break
+		}
+		after = hashes[len(hashes)-1]
+	}
+
+	if len(all) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].String() >= all[i].String() {
+			t.Errorf("entries are not sorted or contain duplicates")
+		}
+	}
+}
+
+func TestRebuildExtIDIndex(t *testing.T) {
+	dbo := NewOverlay(new(mapdb.MapDB))
+	defer dbo.Close()
+	chain1, chain2, _ := insertExtIDTestEntries(t, dbo)
+
+	hashes, _, err := dbo.FetchEntryHashesByExtID(nil, extIDs("invoice"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 0 {
+		t.Errorf("entries are indexed while the index is disabled")
+	}
+
+	for _, chainID := range []interfaces.IHash{chain1, chain2} {
+		if err := dbo.Put(CHAIN_HEAD, chainID.Bytes(), primitives.NewZeroHash()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count, err := dbo.RebuildExtIDIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("expected 5 indexed entries, got %d", count)
+	}
+
+	hashes, _, err = dbo.FetchEntryHashesByExtID(nil, extIDs("invoice"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(hashes))
+	}
+}
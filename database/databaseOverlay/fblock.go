@@ -14,7 +14,11 @@ func (db *Overlay) ProcessFBlockBatch(block interfaces.DatabaseBlockWithEntries)
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(block, false)
+	err = db.SaveIncludedInMultiFromBlock(block, false)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromFBlock(block, false)
 }
 
 func (db *Overlay) ProcessFBlockBatchWithoutHead(block interfaces.DatabaseBlockWithEntries) error {
@@ -22,7 +26,11 @@ func (db *Overlay) ProcessFBlockBatchWithoutHead(block interfaces.DatabaseBlockW
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(block, false)
+	err = db.SaveIncludedInMultiFromBlock(block, false)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromFBlock(block, false)
 }
 
 func (db *Overlay) ProcessFBlockMultiBatch(block interfaces.DatabaseBlockWithEntries) error {
@@ -30,7 +38,11 @@ func (db *Overlay) ProcessFBlockMultiBatch(block interfaces.DatabaseBlockWithEnt
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlockMultiBatch(block, true)
+	err = db.SaveIncludedInMultiFromBlockMultiBatch(block, true)
+	if err != nil {
+		return err
+	}
+	return db.SaveAddressHistoryFromFBlock(block, true)
 }
 
 func (db *Overlay) FetchFBlock(hash interfaces.IHash) (interfaces.IFBlock, error) {
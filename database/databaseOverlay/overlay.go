@@ -71,6 +71,15 @@ var (
 	PAID_FOR = []byte("PaidFor")
 
 	KEY_VALUE_STORE = []byte("KeyValueStore")
+
+	//Entries by ExtID, the prefix of the buckets of the optional ExtID index
+	EXTID_INDEX = []byte("ExtIDIndex")
+
+	//Factoid and EC transactions by address, the prefix of the buckets of the optional address history index
+	ADDRESS_HISTORY = []byte("AddressHistory")
+
+	//Balances of all addresses at every checkpoint height, the prefix of the buckets of the optional checkpoints
+	BALANCE_CHECKPOINT = []byte("BalanceCheckpoint")
 )
 
 var ConstantNamesMap map[string]string
@@ -110,6 +119,9 @@ func init() {
 
 	ConstantNamesMap[string(PAID_FOR)] = "PaidFor"
 	ConstantNamesMap[string(KEY_VALUE_STORE)] = "KeyValueStore"
+	ConstantNamesMap[string(EXTID_INDEX)] = "ExtIDIndex"
+	ConstantNamesMap[string(ADDRESS_HISTORY)] = "AddressHistory"
+	ConstantNamesMap[string(BALANCE_CHECKPOINT)] = "BalanceCheckpoint"
 
 	RegisterPrometheus()
 }
@@ -120,6 +132,18 @@ type Overlay struct {
 	ExportData     bool
 	ExportDataPath string
 
+	// ExtIDIndex indexes the entries by their ExtIDs when they are inserted
+	ExtIDIndex bool
+	// AddressHistoryIndex indexes the factoid and entry credit transactions by address when the blocks are stored
+	AddressHistoryIndex bool
+	// BalanceCheckpoints allows balance queries at past heights
+	BalanceCheckpoints         bool
+	updatingBalanceCheckpoints int32
+	// PruneDepth is the depth after which the entries of the chains that are not kept are deleted, 0 keeps everything
+	PruneDepth      uint32
+	PruneKeepChains map[[32]byte]bool
+	pruning         int32
+
 	BatchSemaphore sync.Mutex
 	MultiBatch     []interfaces.Record
 	BlockExtractor blockExtractor.BlockExtractor
@@ -182,6 +206,22 @@ func (db *Overlay) Get(bucket, key []byte, destination interfaces.BinaryMarshall
 	return db.DB.Get(bucket, key, destination)
 }
 
+func (db *Overlay) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	return db.DB.Iterate(bucket, start, end, reverse)
+}
+
+func (db *Overlay) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	return db.DB.Snapshot()
+}
+
+func (db *Overlay) PutRawInBatch(records []interfaces.Record) error {
+	raw, ok := db.DB.(interfaces.IRawDatabase)
+	if !ok {
+		return fmt.Errorf("database keeps the buckets apart from the keys")
+	}
+	return raw.PutRawInBatch(records)
+}
+
 func (db *Overlay) Clear(bucket []byte) error {
 	return db.DB.Clear(bucket)
 }
@@ -0,0 +1,171 @@
+package databaseOverlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// PruneInterval is the number of saved blocks between two runs of PruneEntries
+var PruneInterval uint32 = 100
+
+var PrunedHeightKey = []byte("PrunedHeight")
+
+// SetPruning deletes the entry blocks and entries of the chains that are not kept once they are depth blocks deep,
+// a depth of 0 keeps everything. Identity chains and the anchor chains are always kept.
+func (db *Overlay) SetPruning(depth uint32, keep []interfaces.IHash) {
+	db.PruneDepth = depth
+	db.PruneKeepChains = make(map[[32]byte]bool, len(keep))
+	for _, chainID := range keep {
+		db.PruneKeepChains[chainID.Fixed()] = true
+	}
+}
+
+func (db *Overlay) GetPruneDepth() uint32 {
+	return db.PruneDepth
+}
+
+// IsChainKept returns true if the entries of the chain are never pruned
+func (db *Overlay) IsChainKept(chainID interfaces.IHash) bool {
+	if db.PruneDepth == 0 {
+		return true
+	}
+	if bytes.HasPrefix(chainID.Bytes(), []byte{0x88, 0x88, 0x88}) {
+		return true
+	}
+	if _, exists := ValidAnchorChains[chainID.String()]; exists {
+		return true
+	}
+	return db.PruneKeepChains[chainID.Fixed()]
+}
+
+func (db *Overlay) SavePrunedHeight(height uint32) error {
+	buf := primitives.NewBuffer(nil)
+	buf.PushUInt32(height)
+	bs := new(primitives.ByteSlice)
+	bs.Bytes = buf.DeepCopyBytes()
+
+	return db.SaveKeyValueStore(bs, PrunedHeightKey)
+}
+
+// FetchPrunedHeight returns the height below which the chains that are not kept have been pruned
+func (db *Overlay) FetchPrunedHeight() (uint32, error) {
+	bs := new(primitives.ByteSlice)
+	data, err := db.FetchKeyValueStore(PrunedHeightKey, bs)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return 0, nil
+	}
+	return primitives.NewBuffer(bs.Bytes).PopUInt32()
+}
+
+// pruneEBlock deletes the entries of the entry block and the entry block itself, unless it is the head of its chain.
+// The IncludedIn records are kept so pruned entries and entry blocks can be told apart from unknown ones.
+func (db *Overlay) pruneEBlock(keyMR interfaces.IHash) error {
+	eblock, err := db.FetchEBlock(keyMR)
+	if err != nil {
+		return err
+	}
+	if eblock == nil {
+		return nil
+	}
+	chainID := eblock.GetChainID()
+
+	for _, hash := range eblock.GetEntryHashes() {
+		if hash.IsMinuteMarker() {
+			continue
+		}
+		if db.ExtIDIndex {
+			entry, err := db.FetchEntry(hash)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				for _, r := range extIDIndexRecords(entry) {
+					if err := db.Delete(r.Bucket, r.Key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err := db.Delete(chainID.Bytes(), hash.Bytes()); err != nil {
+			return err
+		}
+		if err := db.Delete(ENTRY, hash.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	// new entry blocks of the chain link to the head
+	head, err := db.FetchHeadIndexByChainID(chainID)
+	if err != nil {
+		return err
+	}
+	if head != nil && head.IsSameAs(keyMR) {
+		return nil
+	}
+
+	height := make([]byte, 4)
+	binary.BigEndian.PutUint32(height, eblock.GetDatabaseHeight())
+	if err := db.Delete(append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...), height); err != nil {
+		return err
+	}
+	if err := db.Delete(ENTRYBLOCK_SECONDARYINDEX, eblock.DatabaseSecondaryIndex().Bytes()); err != nil {
+		return err
+	}
+	return db.Delete(ENTRYBLOCK, eblock.DatabasePrimaryIndex().Bytes())
+}
+
+// PruneEntries prunes the chains that are not kept up to PruneDepth blocks below the highest saved directory block and
+// returns the pruned height. Only one run happens at a time, concurrent calls return right away.
+func (db *Overlay) PruneEntries() (uint32, error) {
+	if !atomic.CompareAndSwapInt32(&db.pruning, 0, 1) {
+		return db.FetchPrunedHeight()
+	}
+	defer atomic.StoreInt32(&db.pruning, 0)
+
+	pruned, err := db.FetchPrunedHeight()
+	if err != nil {
+		return 0, err
+	}
+	if db.PruneDepth == 0 {
+		return pruned, nil
+	}
+	head, err := db.FetchDBlockHead()
+	if err != nil {
+		return pruned, err
+	}
+	if head == nil || head.GetDatabaseHeight() < db.PruneDepth {
+		return pruned, nil
+	}
+
+	last := head.GetDatabaseHeight() - db.PruneDepth
+	for height := pruned; height < last; height++ {
+		dblock, err := db.FetchDBlockByHeight(height)
+		if err != nil {
+			return pruned, err
+		}
+		if dblock == nil {
+			return pruned, fmt.Errorf("directory block %d not found", height)
+		}
+		for _, ebe := range dblock.GetEBlockDBEntries() {
+			if db.IsChainKept(ebe.GetChainID()) {
+				continue
+			}
+			if err := db.pruneEBlock(ebe.GetKeyMR()); err != nil {
+				return pruned, err
+			}
+		}
+		if err := db.SavePrunedHeight(height + 1); err != nil {
+			return pruned, err
+		}
+		pruned = height + 1
+	}
+	return pruned, nil
+}
@@ -0,0 +1,106 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	. "github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+// checkPruned checks that the entries and entry blocks of the chain are deleted below the height and kept above
+func checkPruned(t *testing.T, dbo *Overlay, blocks []*testHelper.BlockSet, chainID interfaces.IHash, height uint32) {
+	for _, block := range blocks {
+		for _, eblock := range []interfaces.IEntryBlock{block.EBlock, block.AnchorEBlock} {
+			if !eblock.GetChainID().IsSameAs(chainID) {
+				continue
+			}
+			pruned := uint32(block.Height) < height
+			keyMR := eblock.DatabasePrimaryIndex()
+
+			got, err := dbo.FetchEBlock(keyMR)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pruned != (got == nil) {
+				t.Errorf("height %d: entry block %s pruned = %v, want %v", block.Height, keyMR, got == nil, pruned)
+			}
+			for _, hash := range eblock.GetEntryHashes() {
+				if hash.IsMinuteMarker() {
+					continue
+				}
+				entry, err := dbo.FetchEntry(hash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pruned != (entry == nil) {
+					t.Errorf("height %d: entry %s pruned = %v, want %v", block.Height, hash, entry == nil, pruned)
+				}
+				if included, _ := dbo.FetchIncludedIn(hash); included == nil {
+					t.Errorf("height %d: IncludedIn of entry %s was deleted", block.Height, hash)
+				}
+			}
+		}
+	}
+}
+
+func TestPruneEntries(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	chainID := blocks[0].EBlock.GetChainID()
+	anchorChainID := blocks[0].AnchorEBlock.GetChainID()
+
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	// pruning is off by default
+	if pruned, err := dbo.PruneEntries(); err != nil || pruned != 0 {
+		t.Fatalf("pruned below %d without a depth: %v", pruned, err)
+	}
+	checkPruned(t, dbo, blocks, chainID, 0)
+
+	dbo.SetPruning(3, nil)
+	if dbo.IsChainKept(chainID) {
+		t.Errorf("chain %s is kept without being in the allow-list", chainID)
+	}
+	if !dbo.IsChainKept(anchorChainID) {
+		t.Errorf("anchor chain %s is not kept", anchorChainID)
+	}
+
+	expected := uint32(len(blocks) - 1 - 3)
+	pruned, err := dbo.PruneEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != expected {
+		t.Errorf("pruned below %d, want %d", pruned, expected)
+	}
+	if height, _ := dbo.FetchPrunedHeight(); height != expected {
+		t.Errorf("saved pruned height %d, want %d", height, expected)
+	}
+	checkPruned(t, dbo, blocks, chainID, expected)
+	checkPruned(t, dbo, blocks, anchorChainID, 0)
+
+	// the chain head stays
+	if head, err := dbo.FetchEBlockHead(chainID); err != nil || head == nil {
+		t.Errorf("chain head was pruned: %v", err)
+	}
+
+	// nothing left to prune
+	if pruned, err = dbo.PruneEntries(); err != nil || pruned != expected {
+		t.Errorf("second run pruned below %d: %v", pruned, err)
+	}
+}
+
+func TestPruneEntries_keep(t *testing.T) {
+	blocks := testHelper.CreateFullTestBlockSet()
+	chainID := blocks[0].EBlock.GetChainID()
+
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	defer dbo.Close()
+
+	dbo.SetPruning(3, []interfaces.IHash{chainID})
+	if _, err := dbo.PruneEntries(); err != nil {
+		t.Fatal(err)
+	}
+	checkPruned(t, dbo, blocks, chainID, 0)
+}
@@ -183,3 +183,17 @@ func (db *HybridDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	}
 	return exist, nil
 }
+
+func (db *HybridDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.Iterate(bucket, start, end, reverse)
+}
+
+func (db *HybridDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.Snapshot()
+}
@@ -0,0 +1,392 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package integrityCheck checks that the blocks of a database link up, that the block indexes point to them and that
+// no blocks or entries are missing. It is used by the DatabaseIntegrityCheck tool and to verify restored backups.
+package integrityCheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// Report is the result of a check
+type Report struct {
+	Log      io.Writer // progress and problems are written to it as they are found
+	Problems []string
+
+	BlockSets int // consecutive sets of directory, admin, entry credit and factoid blocks
+	EBlocks   int
+	Entries   int
+}
+
+func newReport(log io.Writer) *Report {
+	if log == nil {
+		log = ioutil.Discard
+	}
+	return &Report{Log: log}
+}
+
+func (r *Report) logf(format string, args ...interface{}) {
+	fmt.Fprintf(r.Log, format, args...)
+}
+
+func (r *Report) problemf(format string, args ...interface{}) {
+	p := fmt.Sprintf(format, args...)
+	r.Problems = append(r.Problems, p)
+	fmt.Fprintln(r.Log, p)
+}
+
+// OK is true if no problems were found
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckDatabase walks the directory blocks from the head down to 0 and checks every set of blocks against the
+// previous one, then looks for block indexes, blocks and entries that are missing or don't belong to the chain.
+// An error is returned if the database can't be read, the problems found are in the report.
+func CheckDatabase(dbo interfaces.DBOverlay, log io.Writer) (*Report, error) {
+	r := newReport(log)
+
+	dBlock, err := dbo.FetchDBlockHead()
+	if err != nil {
+		return r, err
+	}
+	if dBlock == nil {
+		return r, fmt.Errorf("directory block head not found")
+	}
+
+	next, err := FetchBlockSet(dbo, dBlock.DatabasePrimaryIndex())
+	if err != nil {
+		return r, err
+	}
+
+	r.logf("\tStarting consecutive block analysis\n")
+
+	hashMap := map[string]string{}
+
+	fcthashes := make(map[[32]byte]int)
+	fcthashes2 := make(map[[32]byte]int)
+	for {
+		prev, err := FetchBlockSet(dbo, next.DBlock.GetHeader().GetPrevKeyMR())
+		if err != nil {
+			return r, err
+		}
+
+		dbheight := next.DBlock.GetHeader().GetDBHeight()
+		if dbheight%1000 == 0 {
+			r.logf("DBHeight %d\n", dbheight)
+		}
+
+		hashMap[next.DBlock.DatabasePrimaryIndex().String()] = "OK"
+		err = directoryBlock.CheckBlockPairIntegrity(next.DBlock, prev.DBlock)
+		if err != nil {
+			r.problemf("Error for DBlock %v %v - %v", next.DBlock.GetHeader().GetDBHeight(), next.DBlock.DatabasePrimaryIndex(), err)
+		}
+
+		if next.ABlock == nil || next.ECBlock == nil || next.FBlock == nil {
+			r.problemf("Missing blocks of DBlock %v %v", dbheight, next.DBlock.DatabasePrimaryIndex())
+			r.BlockSets++
+			if prev.DBlock == nil {
+				break
+			}
+			next = prev
+			continue
+		}
+
+		hashMap[next.ABlock.DatabasePrimaryIndex().String()] = "OK"
+		err = adminBlock.CheckBlockPairIntegrity(next.ABlock, prev.ABlock)
+		if err != nil {
+			r.problemf("Error for ABlock %v %v - %v", next.ABlock.GetDatabaseHeight(), next.ABlock.DatabasePrimaryIndex(), err)
+		}
+
+		hashMap[next.ECBlock.DatabasePrimaryIndex().String()] = "OK"
+		err = entryCreditBlock.CheckBlockPairIntegrity(next.ECBlock, prev.ECBlock)
+		if err != nil {
+			r.problemf("Error for ECBlock %v %v - %v", next.ECBlock.GetDatabaseHeight(), next.ECBlock.DatabasePrimaryIndex(), err)
+		}
+
+		hashMap[next.FBlock.DatabasePrimaryIndex().String()] = "OK"
+
+		err = factoid.CheckBlockPairIntegrity(next.FBlock, prev.FBlock)
+		// Check to make sure no transactions exist that repeat the hash of the entire transaction
+		// This hash can be altered if a malleability attack is discovered and deployed.
+		for _, fct := range next.FBlock.GetEntryHashes() {
+			if fcthashes[fct.Fixed()] > 0 {
+				r.problemf("At %d (previous: %d) Duplicate FCT TxID detected of:\n%x", dbheight, fcthashes[fct.Fixed()], fct.Fixed())
+			}
+			fcthashes[fct.Fixed()] = int(dbheight)
+		}
+		// Check to make sure no transactions exist that repeat the hash of the transaction less the signatures.
+		// This is the hash that we use for the Transaction ID
+		for _, fct := range next.FBlock.GetEntrySigHashes() {
+			if fcthashes2[fct.Fixed()] > 0 {
+				r.problemf("At %d (previous: %d) Duplicate FCT (sig hash) detected:\n%x", dbheight, fcthashes2[fct.Fixed()], fct.Fixed())
+			}
+			fcthashes2[fct.Fixed()] = int(dbheight)
+		}
+
+		if err != nil {
+			r.problemf("Error for FBlock %v %v - %v", next.FBlock.GetDatabaseHeight(), next.FBlock.DatabasePrimaryIndex(), err)
+		}
+
+		r.BlockSets++
+		if prev.DBlock == nil {
+			break
+		}
+		next = prev
+	}
+
+	r.logf("\tFinished analysing %v sets of blocks\n", r.BlockSets)
+
+	r.logf("\tChecking block indexes\n")
+
+	for _, index := range []struct {
+		bucket []byte
+		name   string
+	}{
+		{databaseOverlay.DIRECTORYBLOCK_NUMBER, "DBlock"},
+		{databaseOverlay.FACTOIDBLOCK_NUMBER, "FBlock"},
+		{databaseOverlay.ADMINBLOCK_NUMBER, "ABlock"},
+		{databaseOverlay.ENTRYCREDITBLOCK_NUMBER, "ECBlock"},
+	} {
+		hashes, keys, err := dbo.GetAll(index.bucket, primitives.NewZeroHash())
+		if err != nil {
+			return r, err
+		}
+		for i, v := range hashes {
+			h := v.(*primitives.Hash)
+			if hashMap[h.String()] != "OK" {
+				r.problemf("Invalid %s indexed at height 0x%x - %v", index.name, keys[i], h)
+			}
+		}
+	}
+
+	r.logf("\tFinished checking block indexes\n")
+
+	r.logf("\tLooking for free-floating blocks\n")
+
+	dBlocks, err := dbo.FetchAllDBlockKeys()
+	if err != nil {
+		return r, err
+	}
+	aBlocks, err := dbo.FetchAllABlockKeys()
+	if err != nil {
+		return r, err
+	}
+	fBlocks, err := dbo.FetchAllFBlockKeys()
+	if err != nil {
+		return r, err
+	}
+	ecBlocks, err := dbo.FetchAllECBlockKeys()
+	if err != nil {
+		return r, err
+	}
+	for _, blocks := range []struct {
+		keys []interfaces.IHash
+		name string
+	}{{dBlocks, "DBlock"}, {aBlocks, "ABlock"}, {fBlocks, "FBlock"}, {ecBlocks, "ECBlock"}} {
+		if len(blocks.keys) != r.BlockSets {
+			r.problemf("Found %v %ss, expected %v", len(blocks.keys), blocks.name, r.BlockSets)
+		}
+		for _, block := range blocks.keys {
+			if hashMap[block.String()] == "" {
+				r.problemf("Free-floating %s - %v", blocks.name, block.String())
+			}
+		}
+	}
+
+	ecChains := 0
+	ecEntries := 0
+
+	for _, block := range ecBlocks {
+		ecblk, err := dbo.FetchECBlock(block)
+		if err == nil && ecblk != nil {
+			for _, ebe := range ecblk.GetEntries() {
+				switch ebe.ECID() {
+				case constants.ECIDEntryCommit:
+					ecEntries++
+					eec := ebe.(*entryCreditBlock.CommitEntry)
+					if e, err := dbo.FetchEntry(eec.EntryHash); err != nil || e == nil {
+						r.problemf("\t **** Failed to find entry %x for the commit. dbht %d",
+							eec.EntryHash.Bytes(),
+							ecblk.GetHeader().GetDBHeight())
+					}
+				case constants.ECIDChainCommit:
+					ecChains++
+				default:
+
+				}
+			}
+		}
+	}
+
+	r.logf("\tEntry Credit Block found chains: %v entries: %v total: %v \n",
+		ecChains,
+		ecEntries,
+		ecChains+ecEntries)
+
+	r.logf("\tFinished looking for free-floating blocks\n")
+
+	r.logf("\tLooking for missing EBlocks\n")
+
+	missingBlocks := 0
+	for _, dHash := range dBlocks {
+		dBlock, err := dbo.FetchDBlock(dHash)
+		if err != nil {
+			return r, err
+		}
+		if dBlock == nil {
+			r.problemf("Could not find DBlock %v!", dHash.String())
+			continue
+		}
+		for _, v := range dBlock.GetEBlockDBEntries() {
+			eBlock, err := dbo.FetchEBlock(v.GetKeyMR())
+			if err != nil {
+				return r, err
+			}
+			if eBlock == nil {
+				r.problemf("Could not find eBlock %v!", v.GetKeyMR())
+				missingBlocks++
+			} else {
+				r.EBlocks++
+			}
+		}
+	}
+
+	r.logf("\tFinished looking for missing EBlocks. Missing %d Found %v\n", missingBlocks, r.EBlocks)
+
+	r.logf("\tLooking for missing EBlock Entries\n")
+
+	chains, err := dbo.FetchAllEBlockChainIDs()
+	if err != nil {
+		return r, err
+	}
+	missingCount := 0
+
+	for _, chain := range chains {
+		blocks := 0
+		err := dbo.ForEachEBlockByChain(chain, func(block interfaces.IEntryBlock) error {
+			blocks++
+			entryHashes := block.GetEntryHashes()
+			if len(entryHashes) == 0 {
+				r.problemf("EBlock %v has no entries", block.DatabasePrimaryIndex())
+			}
+			for _, eHash := range entryHashes {
+				if eHash.IsMinuteMarker() == true {
+					continue
+				}
+
+				entry, err := dbo.FetchEntry(eHash)
+				if err != nil {
+					return err
+				}
+				if entry == nil {
+					missingCount++
+					exists, err := dbo.DoesKeyExist(databaseOverlay.ENTRY, eHash.Bytes())
+					if err != nil {
+						return err
+					}
+					if exists == true {
+						r.problemf("Missing entry %v!, but the key exists", eHash.String())
+					} else {
+						r.problemf("Missing entry %v!", eHash.String())
+					}
+				} else {
+					r.Entries++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return r, err
+		}
+		if blocks == 0 {
+			r.problemf("Found no EBlocks of chain %v", chain.String())
+		}
+	}
+	r.logf("\tFound %v entries, missing %v\n", r.Entries, missingCount)
+	r.logf("\tFinished looking for missing EBlock Entries\n")
+	r.logf("\tDifference between entries and commits: **** %d ****\n", ecEntries+ecChains-r.Entries)
+	return r, nil
+}
+
+// CheckMinuteNumbers checks that every entry credit block has the minute numbers 1 to 10 in order
+func CheckMinuteNumbers(dbo interfaces.DBOverlay, log io.Writer) (*Report, error) {
+	r := newReport(log)
+	r.logf("\tChecking Minute Numbers\n")
+
+	ecBlocks, err := dbo.FetchAllECBlocks()
+	if err != nil {
+		return r, err
+	}
+	for _, v := range ecBlocks {
+		entries := v.GetEntries()
+		found := 0
+		lastNumber := 0
+		for _, e := range entries {
+			if e.ECID() == constants.ECIDMinuteNumber {
+				number := int(e.(*entryCreditBlock.MinuteNumber).Number)
+				if number != lastNumber+1 {
+					r.problemf("Block #%v %v, Minute Number %v is not last minute plus 1", v.GetDatabaseHeight(), v.GetHash().String(), number)
+				}
+				lastNumber = number
+				found++
+			}
+		}
+		if found != 10 {
+			r.problemf("Block #%v %v only contains %v minute numbers", v.GetDatabaseHeight(), v.GetHash().String(), found)
+		}
+	}
+	r.logf("\tFinished checking Minute Numbers\n")
+	return r, nil
+}
+
+type BlockSet struct {
+	ABlock  interfaces.IAdminBlock
+	ECBlock interfaces.IEntryCreditBlock
+	FBlock  interfaces.IFBlock
+	DBlock  interfaces.IDirectoryBlock
+}
+
+// FetchBlockSet fetches the directory block and the admin, entry credit and factoid blocks it points to. The
+// blocks that are not found are nil.
+func FetchBlockSet(dbo interfaces.DBOverlay, dBlockHash interfaces.IHash) (*BlockSet, error) {
+	bs := new(BlockSet)
+
+	dBlock, err := dbo.FetchDBlock(dBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	bs.DBlock = dBlock
+
+	if dBlock == nil {
+		return bs, nil
+	}
+	for _, entry := range dBlock.GetDBEntries() {
+		switch entry.GetChainID().String() {
+		case "000000000000000000000000000000000000000000000000000000000000000a":
+			bs.ABlock, err = dbo.FetchABlock(entry.GetKeyMR())
+		case "000000000000000000000000000000000000000000000000000000000000000c":
+			bs.ECBlock, err = dbo.FetchECBlock(entry.GetKeyMR())
+		case "000000000000000000000000000000000000000000000000000000000000000f":
+			bs.FBlock, err = dbo.FetchFBlock(entry.GetKeyMR())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bs, nil
+}
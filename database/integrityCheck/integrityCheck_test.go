@@ -0,0 +1,42 @@
+package integrityCheck_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	. "github.com/FactomProject/factomd/database/integrityCheck"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestCheckDatabase(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	r, err := CheckDatabase(dbo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Errorf("problems in a valid database: %v", r.Problems)
+	}
+	blocks := testHelper.CreateFullTestBlockSet()
+	if r.BlockSets != len(blocks) {
+		t.Errorf("checked %d sets of blocks, want %d", r.BlockSets, len(blocks))
+	}
+
+	entry := blocks[len(blocks)-1].Entries[0]
+	if err := dbo.Delete(databaseOverlay.ENTRY, entry.GetHash().Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	r, err = CheckDatabase(dbo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OK() {
+		t.Errorf("missing entry %s not found", entry.GetHash())
+	}
+}
+
+func TestCheckEmptyDatabase(t *testing.T) {
+	if _, err := CheckDatabase(testHelper.CreateEmptyTestDatabaseOverlay(), nil); err == nil {
+		t.Errorf("checked an empty database")
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/goleveldb/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb/iterator"
 	"github.com/FactomProject/goleveldb/leveldb/opt"
 	"github.com/FactomProject/goleveldb/leveldb/util"
 )
@@ -28,6 +29,7 @@ type LevelDB struct {
 }
 
 var _ interfaces.IDatabase = (*LevelDB)(nil)
+var _ interfaces.IRawDatabase = (*LevelDB)(nil)
 
 func (db *LevelDB) ListAllBuckets() ([][]byte, error) {
 	//TODO: fix Level to solve this issue
@@ -165,6 +167,23 @@ func (db *LevelDB) PutInBatch(records []interfaces.Record) error {
 	return nil
 }
 
+// PutRawInBatch writes the records of a snapshot by their key as it is stored
+func (db *LevelDB) PutRawInBatch(records []interfaces.Record) error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	batch := new(leveldb.Batch)
+	for _, v := range records {
+		hex, err := v.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		batch.Put(v.Key, hex)
+		LevelDBPuts.Inc()
+	}
+	return db.lDB.Write(batch, db.wo)
+}
+
 func (db *LevelDB) Clear(bucket []byte) error {
 	keys, err := db.ListAllKeys(bucket)
 	if err != nil {
@@ -310,3 +329,98 @@ func (db *LevelDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	ldbKey := CombineBucketAndKey(bucket, key)
 	return db.lDB.Has(ldbKey, db.ro)
 }
+
+func (db *LevelDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	// the keys are built in new slices, ExtendBucket appends to the bucket
+	prefix := make([]byte, 0, len(bucket)+1)
+	prefix = append(append(prefix, bucket...), ';')
+	r := &util.Range{Start: prefix, Limit: addOneToByteArray(prefix)}
+	if start != nil {
+		r.Start = append(prefix[:len(prefix):len(prefix)], start...)
+	}
+	if end != nil {
+		r.Limit = append(prefix[:len(prefix):len(prefix)], end...)
+	}
+
+	it := new(Iterator)
+	it.iter = db.lDB.NewIterator(r, db.ro)
+	it.prefix = len(prefix)
+	it.reverse = reverse
+	return it
+}
+
+// Iterator walks over a range of the keys of a bucket. It reads from an implicit snapshot of the database, taken
+// when the iterator is created.
+type Iterator struct {
+	iter    iterator.Iterator
+	prefix  int
+	reverse bool
+	started bool
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	if !it.reverse {
+		return it.iter.Next()
+	}
+	if !it.started {
+		it.started = true
+		return it.iter.Last()
+	}
+	return it.iter.Prev()
+}
+
+func (it *Iterator) Key() []byte {
+	return it.iter.Key()[it.prefix:]
+}
+
+func (it *Iterator) Value() []byte {
+	return it.iter.Value()
+}
+
+func (it *Iterator) Error() error {
+	return it.iter.Error()
+}
+
+func (it *Iterator) Release() {
+	it.iter.Release()
+}
+
+func (db *LevelDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	snap, err := db.lDB.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{snap: snap, ro: db.ro}, nil
+}
+
+// Snapshot is a LevelDB snapshot of the whole database. LevelDB doesn't keep the buckets apart from the keys and both
+// can contain a ';', so the records have no bucket and the key as it is stored, which PutRawInBatch writes back.
+type Snapshot struct {
+	snap *leveldb.Snapshot
+	ro   *opt.ReadOptions
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+func (s *Snapshot) ForEach(f func(bucket, key, value []byte) error) error {
+	iter := s.snap.NewIterator(nil, s.ro)
+	defer iter.Release()
+	for iter.Next() {
+		if err := f(nil, iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (s *Snapshot) Release() {
+	s.snap.Release()
+}
@@ -5,6 +5,7 @@
 package mapdb
 
 import (
+	"bytes"
 	"sort"
 	"sync"
 
@@ -264,3 +265,115 @@ func (db *MapDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	}
 	return true, nil
 }
+
+// Iterate copies the keys of the range when it is called, later writes to the bucket don't change the iteration
+func (db *MapDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	it := new(Iterator)
+	for k, v := range db.Cache[string(bucket)] {
+		key := []byte(k)
+		if start != nil && bytes.Compare(key, start) < 0 {
+			continue
+		}
+		if end != nil && bytes.Compare(key, end) >= 0 {
+			continue
+		}
+		// Get treats records put with nil data as missing
+		if v == nil {
+			continue
+		}
+		it.keys = append(it.keys, key)
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(util.ByByteArray(it.keys)))
+	} else {
+		sort.Sort(util.ByByteArray(it.keys))
+	}
+	it.values = make([][]byte, len(it.keys))
+	for i, k := range it.keys {
+		it.values[i] = db.Cache[string(bucket)][string(k)]
+	}
+	it.pos = -1
+	return it
+}
+
+// Iterator walks over the records that were in the range when it was created
+type Iterator struct {
+	keys   [][]byte
+	values [][]byte
+	pos    int
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+	return it.pos < len(it.keys)
+}
+
+func (it *Iterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos]
+}
+
+func (it *Iterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.values) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *Iterator) Error() error {
+	return nil
+}
+
+func (it *Iterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+// Snapshot copies the records when it is called, the values themselves are shared as writes replace them
+func (db *MapDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	s := new(Snapshot)
+	s.cache = make(map[string]map[string][]byte, len(db.Cache))
+	for bucket, records := range db.Cache {
+		copied := make(map[string][]byte, len(records))
+		for k, v := range records {
+			if v != nil {
+				copied[k] = v
+			}
+		}
+		s.cache[bucket] = copied
+	}
+	return s, nil
+}
+
+// Snapshot holds the records that were in the database when it was taken
+type Snapshot struct {
+	cache map[string]map[string][]byte
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+func (s *Snapshot) ForEach(f func(bucket, key, value []byte) error) error {
+	for bucket, records := range s.cache {
+		for k, v := range records {
+			if err := f([]byte(bucket), []byte(k), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Snapshot) Release() {
+	s.cache = nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/badgerdb"
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/leveldb"
 	"github.com/FactomProject/factomd/database/mapdb"
@@ -281,9 +282,83 @@ func (db *EncryptedDB) Init(filename string, dbtype string) {
 		}
 	case "Bolt":
 		db.db = boltdb.NewBoltDB(nil, filename)
+	case "Badger":
+		db.db, err = badgerdb.NewBadgerDB(filename, true)
+		if err != nil {
+			panic(err)
+		}
 	default:
-		panic(fmt.Sprintf("%s is not a valid option. Expect 'Map', 'LDB', or 'Bolt'", dbtype))
+		panic(fmt.Sprintf("%s is not a valid option. Expect 'Map', 'LDB', 'Bolt', or 'Badger'", dbtype))
+	}
+}
+
+func (db *EncryptedDB) Iterate(bucket, start, end []byte, reverse bool) interfaces.IIterator {
+	it := new(Iterator)
+	if db.isLocked() {
+		it.err = lockedError
+		return it
+	}
+	it.iter = db.db.Iterate(bucket, start, end, reverse)
+	it.encryptionkey = db.encryptionkey
+	return it
+}
+
+// Iterator decrypts the values of the underlying iterator, the keys are not encrypted
+type Iterator struct {
+	iter          interfaces.IIterator
+	encryptionkey []byte
+
+	value []byte
+	err   error
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.iter == nil || !it.iter.Next() {
+		it.value = nil
+		return false
+	}
+
+	plain := new(primitives.ByteSlice)
+	_, err := NewEncryptedMarshaler(it.encryptionkey, plain).UnmarshalBinaryData(it.iter.Value())
+	if err != nil {
+		it.err = err
+		it.value = nil
+		return false
 	}
+	it.value = plain.Bytes
+	return true
+}
+
+func (it *Iterator) Key() []byte {
+	if it.value == nil {
+		return nil
+	}
+	return it.iter.Key()
+}
+
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+func (it *Iterator) Error() error {
+	if it.err != nil || it.iter == nil {
+		return it.err
+	}
+	return it.iter.Error()
+}
+
+func (it *Iterator) Release() {
+	if it.iter != nil {
+		it.iter.Release()
+	}
+}
+
+// Snapshot returns a snapshot of the underlying database. The records stay encrypted and include the metadata, so
+// they only make sense to an encrypted database with the same password.
+func (db *EncryptedDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	return db.db.Snapshot()
 }
 
 func (db *EncryptedDB) DoesKeyExist(bucket, key []byte) (bool, error) {
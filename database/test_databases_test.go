@@ -5,11 +5,13 @@
 package database_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
 
 	"reflect"
+	"sort"
 
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/common/primitives/random"
+	"github.com/FactomProject/factomd/database/badgerdb"
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/leveldb"
@@ -69,7 +72,7 @@ func TestOneDatabase(t *testing.T) {
 }
 
 func TestAllDatabases(t *testing.T) {
-	totalTests := 4
+	totalTests := 6
 
 	// Secure Bolt
 	for i := 0; i < totalTests; i++ {
@@ -80,7 +83,7 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished Secure Bolt DB (1/6)")
+	t.Log("Finished Secure Bolt DB (1/7)")
 
 	// Secure LDB
 	for i := 0; i < totalTests; i++ {
@@ -91,7 +94,7 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished Secure LDB (2/6)")
+	t.Log("Finished Secure LDB (2/7)")
 
 	// Secure Map
 	for i := 0; i < totalTests; i++ {
@@ -102,7 +105,7 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished Secure Map (3/6)")
+	t.Log("Finished Secure Map (3/7)")
 
 	// Bolt
 	for i := 0; i < totalTests; i++ {
@@ -110,7 +113,7 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished Bolt DB (4/6)")
+	t.Log("Finished Bolt DB (4/7)")
 
 	// Level
 	for i := 0; i < totalTests; i++ {
@@ -121,7 +124,18 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished LDB (5/6)")
+	t.Log("Finished LDB (5/7)")
+
+	// Badger
+	for i := 0; i < totalTests; i++ {
+		m, err := badgerdb.NewBadgerDB(dbFilename, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB(t, m, i)
+		CleanupTest(t, m)
+	}
+	t.Log("Finished Badger DB (6/7)")
 
 	// Map
 	for i := 0; i < totalTests; i++ {
@@ -129,7 +143,7 @@ func TestAllDatabases(t *testing.T) {
 		testDB(t, m, i)
 		CleanupTest(t, m)
 	}
-	t.Log("Finished Map (3/6)")
+	t.Log("Finished Map (7/7)")
 }
 
 func testDB(t *testing.T, m interfaces.IDatabase, i int) {
@@ -142,6 +156,10 @@ func testDB(t *testing.T, m interfaces.IDatabase, i int) {
 		testDoesKeyExist(t, m)
 	case 3:
 		testGetAll(t, m)
+	case 4:
+		testIterate(t, m)
+	case 5:
+		testSnapshot(t, m)
 	}
 }
 
@@ -394,3 +412,128 @@ func filledMap(start, length int) map[int]struct{} {
 	}
 	return avail
 }
+
+func testIterate(t *testing.T, m interfaces.IDatabase) {
+	defer CleanupTest(t, m)
+
+	bucket := []byte("bucket")
+	batch := []interfaces.Record{}
+	for i := 0; i < 10; i++ {
+		batch = append(batch, interfaces.Record{Bucket: bucket, Key: []byte{byte(i)}, Data: &TestData{Str: fmt.Sprintf("Data %v", i)}})
+	}
+	// a bucket that LevelDB's keys could mix up with the first one
+	batch = append(batch, interfaces.Record{Bucket: []byte("bucket2"), Key: []byte{5}, Data: &TestData{Str: "other"}})
+	if err := m.PutInBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(start, end []byte, reverse bool, expected ...int) {
+		it := m.Iterate(bucket, start, end, reverse)
+		defer it.Release()
+
+		got := []int{}
+		for it.Next() {
+			i := int(it.Key()[0])
+			if string(it.Value()) != fmt.Sprintf("Data %v", i) {
+				t.Errorf("value of key %d = %q", i, it.Value())
+			}
+			got = append(got, i)
+		}
+		if err := it.Error(); err != nil {
+			t.Error(err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Iterate(%x, %x, %v) = %v, want %v", start, end, reverse, got, expected)
+		}
+	}
+	check(nil, nil, false, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	check(nil, nil, true, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0)
+	check([]byte{3}, []byte{6}, false, 3, 4, 5)
+	check([]byte{3}, []byte{6}, true, 5, 4, 3)
+	check([]byte{3, 0}, nil, false, 4, 5, 6, 7, 8, 9)
+	check(nil, []byte{2, 0}, true, 2, 1, 0)
+	check([]byte{20}, nil, false)
+	check([]byte{6}, []byte{3}, false)
+
+	it := m.Iterate([]byte("missing"), nil, nil, false)
+	if it.Next() || it.Error() != nil {
+		t.Errorf("iterated over a missing bucket: %v", it.Error())
+	}
+	it.Release()
+}
+
+func testSnapshot(t *testing.T, m interfaces.IDatabase) {
+	defer CleanupTest(t, m)
+
+	bucket := []byte("bucket")
+	batch := []interfaces.Record{}
+	for i := 0; i < 5; i++ {
+		batch = append(batch, interfaces.Record{Bucket: bucket, Key: []byte{byte(i)}, Data: &TestData{Str: fmt.Sprintf("Data %v", i)}})
+	}
+	if err := m.PutInBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := m.Put(bucket, []byte{0}, &TestData{Str: "changed"}); err != nil {
+			t.Error(err)
+		}
+		if err := m.Delete(bucket, []byte{1}); err != nil {
+			t.Error(err)
+		}
+		if err := m.Put(bucket, []byte{9}, &TestData{Str: "Data 9"}); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+
+	_, encrypted := m.(*securedb.EncryptedDB)
+	got := []int{}
+	err = snap.ForEach(func(b, key, value []byte) error {
+		if b == nil {
+			// LevelDB keeps the bucket in the key
+			if !bytes.HasPrefix(key, []byte(string(bucket)+";")) {
+				return nil
+			}
+			b, key = bucket, key[len(bucket)+1:]
+		}
+		if string(b) != string(bucket) {
+			return nil
+		}
+		got = append(got, int(key[0]))
+		if !encrypted && string(value) != fmt.Sprintf("Data %v", key[0]) {
+			t.Errorf("value of key %d = %q", key[0], value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	snap.Release()
+	<-done
+
+	sort.Ints(got)
+	if fmt.Sprint(got) != fmt.Sprint([]int{0, 1, 2, 3, 4}) {
+		t.Errorf("snapshot has keys %v", got)
+	}
+
+	stop := fmt.Errorf("stop")
+	snap, err = m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snap.ForEach(func(b, key, value []byte) error { return stop }); err != stop {
+		t.Errorf("ForEach returned %v", err)
+	}
+	snap.Release()
+}
@@ -23,9 +23,11 @@ import (
 	"github.com/FactomProject/factomd/common/messages/msgsupport"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/controlPanel"
+	"github.com/FactomProject/factomd/database/badgerdb"
 	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/leveldb"
 	"github.com/FactomProject/factomd/elections"
+	"github.com/FactomProject/factomd/events/eventservices"
 	"github.com/FactomProject/factomd/p2p"
 	"github.com/FactomProject/factomd/state"
 	"github.com/FactomProject/factomd/util"
@@ -191,6 +193,17 @@ func NetStart(s *state.State, p *FactomParams) {
 	p2pconf.ChannelCapacity = 5000
 	p2pconf.PingInterval = time.Second * 15
 	p2pconf.ProtocolVersion = 10
+	// acks and EOMs keep a node in consensus, they must not wait behind the blocks a peer is syncing
+	p2pconf.MessagePriorities = map[byte]p2p.Priority{
+		constants.EOM_MSG:                       p2p.PriorityHigh,
+		constants.ACK_MSG:                       p2p.PriorityHigh,
+		constants.DIRECTORY_BLOCK_SIGNATURE_MSG: p2p.PriorityHigh,
+		constants.HEARTBEAT_MSG:                 p2p.PriorityHigh,
+		constants.DBSTATE_MSG:                   p2p.PriorityLow,
+		constants.DATA_RESPONSE:                 p2p.PriorityLow,
+		constants.MISSING_MSG_RESPONSE:          p2p.PriorityLow,
+		constants.ENTRY_BLOCK_RESPONSE:          p2p.PriorityLow,
+	}
 
 	fmt.Println(">>>>>>>>>>>>>>>>")
 	fmt.Println(">>>>>>>>>>>>>>>> Net Sim Start!")
@@ -254,6 +267,12 @@ func NetStart(s *state.State, p *FactomParams) {
 	s.AddPrefix(p.Prefix)
 	s.SetOut(false)
 	s.Init()
+	if p.SnapshotExport != "" || p.SnapshotImport != "" {
+		os.Exit(runSnapshot(s, p))
+	}
+	if p.BackupRestore != "" {
+		os.Exit(runBackupRestore(s, p))
+	}
 	s.SetDropRate(p.DropRate)
 
 	if p.Sync2 >= 0 {
@@ -408,6 +427,35 @@ func NetStart(s *state.State, p *FactomParams) {
 	p2pconf.WriteDeadline = time.Minute * 5
 
 	if p.EnableNet {
+		if cfg, ok := s.Cfg.(*util.FactomdConfig); ok {
+			if cfg.App.P2PProtocolVersion != 0 {
+				p2pconf.ProtocolVersion = uint16(cfg.App.P2PProtocolVersion)
+			}
+			p2pconf.SpecialKeys = cfg.App.P2PSpecialKeys
+			p2pconf.BandwidthGlobalIn = uint64(cfg.App.P2PBandwidthIn) * 1024
+			p2pconf.BandwidthGlobalOut = uint64(cfg.App.P2PBandwidthOut) * 1024
+			p2pconf.BandwidthPeerIn = uint64(cfg.App.P2PPeerBandwidthIn) * 1024
+			p2pconf.BandwidthPeerOut = uint64(cfg.App.P2PPeerBandwidthOut) * 1024
+			p2pconf.Gossip = cfg.App.P2PGossip
+			p2pconf.ExternalHost = cfg.App.P2PExternalHost
+			p2pconf.ExternalPort = cfg.App.P2PExternalPort
+			p2pconf.PortMapping = cfg.App.P2PPortMapping
+			p2pconf.SeedKeys = cfg.App.P2PSeedKeys
+			p2pconf.SeedDNSServer = cfg.App.P2PSeedDNSServer
+			p2pconf.Compression = cfg.App.P2PCompression
+			if cfg.App.P2PCompressionThreshold > 0 {
+				p2pconf.CompressionThreshold = cfg.App.P2PCompressionThreshold
+			}
+			if cfg.App.P2PNodeKeyFile != "" {
+				key, err := p2p.LoadNodeKey(cfg.App.P2PNodeKeyFile)
+				if err != nil {
+					fmt.Println(err)
+					panic("Unable to load the p2p node key")
+				}
+				p2pconf.NodeKey = key
+				fmt.Printf("P2P node key: %x\n", key.Public())
+			}
+		}
 
 		if net, err := p2p.NewNetwork(p2pconf); err != nil {
 			fmt.Println(err)
@@ -436,7 +484,8 @@ func NetStart(s *state.State, p *FactomParams) {
 
 	}
 
-	// Start live feed service
+	// Start live feed service, the subscriptions of the api server are always available
+	s.EventService.ConfigSubscriptions(s, eventservices.GetEventHub())
 	config := s.Cfg.(*util.FactomdConfig)
 	if config.LiveFeedAPI.EnableLiveFeedAPI || p.EnableLiveFeedAPI {
 		s.EventService.ConfigService(s, config, p)
@@ -613,6 +662,7 @@ func NetStart(s *state.State, p *FactomParams) {
 	state.RegisterPrometheus()
 	//	p2pold.RegisterPrometheus()
 	leveldb.RegisterPrometheus()
+	badgerdb.RegisterPrometheus()
 	RegisterPrometheus()
 
 	go controlPanel.ServeControlPanel(fnodes[0].State.ControlPanelChannel, fnodes[0].State, connectionMetricsChannel, network, Build, p.NodeName)
@@ -669,6 +719,7 @@ func startServer(i int, fnode *FactomNode, load bool) {
 	if i > 0 {
 		fnode.State.Init()
 	}
+	fnode.State.StartDatabaseMaintenance()
 	NetworkProcessorNet(fnode)
 	if load {
 		go state.LoadDatabase(fnode.State)
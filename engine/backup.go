@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/common/globals"
+	"github.com/FactomProject/factomd/state"
+)
+
+// runBackupRestore restores a backup instead of starting the node. Returns the exit code.
+func runBackupRestore(s *state.State, p *globals.FactomParams) int {
+	defer s.DB.Close()
+
+	m, r, err := state.RestoreBackup(s, p.BackupRestore, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Restore failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Restored %d records up to directory block %d %s from %s\n", m.Records, m.Height, m.KeyMR, p.BackupRestore)
+	if m.State != nil {
+		fmt.Printf("Restored the save state at height %d\n", m.StateHeight)
+		if !s.StateSaverStruct.FastBoot {
+			fmt.Printf("Fast boot is disabled, the node will rebuild the state from the blocks instead of using the save state\n")
+		}
+	}
+	if !r.OK() {
+		fmt.Fprintf(os.Stderr, "The integrity check found %d problems\n", len(r.Problems))
+		return 1
+	}
+	fmt.Printf("The integrity check found no problems\n")
+	return 0
+}
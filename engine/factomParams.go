@@ -45,7 +45,7 @@ func init() {
 	flag.BoolVar(&p.Journaling, "journaling", false, "Write a journal of all messages received. Default is off.")
 	flag.BoolVar(&p.Follower, "follower", false, "If true, force node to be a follower.  Only used when replaying a journal.")
 	flag.BoolVar(&p.Leader, "leader", true, "If true, force node to be a leader.  Only used when replaying a journal.")
-	flag.StringVar(&p.Db, "db", "", "Override the Database in the Config file and use this Database implementation. Options Map, LDB, or Bolt")
+	flag.StringVar(&p.Db, "db", "", "Override the Database in the Config file and use this Database implementation. Options Map, LDB, Bolt, or Badger")
 	flag.StringVar(&p.CloneDB, "clonedb", "", "Override the main node and use this database for the clones in a Network.")
 	flag.StringVar(&p.NetworkName, "network", "", "Network to join: MAIN, TEST or LOCAL")
 	flag.StringVar(&p.Peers, "peers", "", "Array of peer addresses. ")
@@ -91,6 +91,10 @@ func init() {
 	flag.StringVar(&p.ControlPanelSetting, "controlpanelsetting", "", "Can set to 'disabled', 'readonly', or 'readwrite' to overwrite config file")
 	flag.BoolVar(&p.FullHashesLog, "fullhasheslog", false, "true create a log of all unique hashes seen during processing")
 	flag.BoolVar(&p.ReparseAnchorChains, "reparseanchorchains", false, "If true, reparse bitcoin and ethereum anchor chains in the database")
+	flag.StringVar(&p.SnapshotExport, "snapshot-export", "", "Write all blocks, entries and the fast boot state to a snapshot file, then exit")
+	flag.StringVar(&p.SnapshotImport, "snapshot-import", "", "Load a snapshot file into an empty database, then exit. Requires -snapshot-keymr")
+	flag.StringVar(&p.SnapshotKeyMR, "snapshot-keymr", "", "KeyMR of the highest directory block in the snapshot to import, from a source you trust")
+	flag.StringVar(&p.BackupRestore, "backup-restore", "", "Restore a backup directory or tarball into an empty database and check its integrity, then exit")
 
 	// Live feed API params
 	flag.BoolVar(&p.EnableLiveFeedAPI, "enablelivefeedapi", false, "Enable life feed events service; default false")
@@ -103,6 +107,7 @@ func init() {
 	flag.StringVar(&p.EventBroadcastContent, "eventbroadcastcontent", "", "Settings for including content in the event messages always|once|never; default once")
 	flag.BoolVar(&p.EventReplayDuringStartup, "eventreplayduringstartup", false, "Replay events since the last save state during startup; default false")
 	flag.BoolVar(&p.PersistentReconnect, "persistentreconnect", false, "Persistently try to reconnect with LiveFeed listener(s)")
+	flag.BoolVar(&p.EventDurableDelivery, "eventdurabledelivery", false, "Spool events on disk and resend missed events after a reconnect, tcp only; default false")
 
 }
 
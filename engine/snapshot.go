@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/common/globals"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/state"
+)
+
+// runSnapshot exports or imports a snapshot instead of starting the node. Returns the exit code.
+func runSnapshot(s *state.State, p *globals.FactomParams) int {
+	defer s.DB.Close()
+
+	if p.SnapshotExport != "" {
+		m, err := state.ExportSnapshot(s, p.SnapshotExport)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Snapshot export failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Exported blocks 0 to %d and the save state at height %d to %s\n", m.Height, m.StateHeight, p.SnapshotExport)
+		fmt.Printf("KeyMR of directory block %d: %s\n", m.Height, m.KeyMRs[m.Height].String())
+		fmt.Printf("Manifest root: %s\n", m.Root().String())
+		return 0
+	}
+
+	if p.SnapshotKeyMR == "" {
+		fmt.Fprintf(os.Stderr, "Snapshot import requires -snapshot-keymr, the KeyMR of the highest directory block in the snapshot from a source you trust\n")
+		return 1
+	}
+	trusted, err := primitives.HexToHash(p.SnapshotKeyMR)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -snapshot-keymr: %v\n", err)
+		return 1
+	}
+	m, err := state.ImportSnapshot(s, p.SnapshotImport, trusted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Snapshot import failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported blocks 0 to %d and the save state at height %d from %s\n", m.Height, m.StateHeight, p.SnapshotImport)
+	fmt.Printf("Factoid balance hash: %s\n", m.FactoidBalanceHash.String())
+	fmt.Printf("Entry credit balance hash: %s\n", m.ECBalanceHash.String())
+	if !s.StateSaverStruct.FastBoot {
+		fmt.Printf("Fast boot is disabled, the node will rebuild the state from the blocks instead of using the save state\n")
+	}
+	return 0
+}
@@ -7,15 +7,18 @@ import (
 	"github.com/FactomProject/factomd/common/globals"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/events/eventconfig"
 	"github.com/FactomProject/factomd/events/eventinput"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
 	"github.com/FactomProject/factomd/events/eventservices"
+	"github.com/FactomProject/factomd/log"
 	"github.com/FactomProject/factomd/util"
 )
 
 type EventService interface {
 	ConfigService(state StateEventServices, config *util.FactomdConfig, factomParams *globals.FactomParams)
 	ConfigSender(state StateEventServices, sender eventservices.EventSender)
+	ConfigSubscriptions(state StateEventServices, eventHub *eventservices.EventHub)
 	EmitRegistrationEvent(msg interfaces.IMsg)
 	EmitStateChangeEvent(msg interfaces.IMsg, entityState eventmessages.EntityState)
 	EmitDirectoryBlockCommitEvent(dbState interfaces.IDBState)
@@ -29,8 +32,15 @@ type EventService interface {
 }
 
 type eventEmitter struct {
-	parentState StateEventServices
-	eventSender eventservices.EventSender
+	parentState  StateEventServices
+	eventSenders []eventservices.EventSender
+	eventHub     *eventservices.EventHub
+}
+
+// mappingKey identifies the settings of a receiver that influence how an event is mapped
+type mappingKey struct {
+	broadcastContent      eventconfig.BroadcastContent
+	sendStateChangeEvents bool
 }
 
 func NewEventService() EventService {
@@ -39,12 +49,22 @@ func NewEventService() EventService {
 
 func (eventEmitter *eventEmitter) ConfigService(state StateEventServices, config *util.FactomdConfig, factomParams *globals.FactomParams) {
 	eventEmitter.parentState = state
-	eventEmitter.eventSender = eventservices.NewEventSender(config, factomParams)
+	eventEmitter.eventSenders = eventservices.NewEventSenders(config, factomParams)
 }
 
 func (eventEmitter *eventEmitter) ConfigSender(state StateEventServices, eventSender eventservices.EventSender) {
 	eventEmitter.parentState = state
-	eventEmitter.eventSender = eventSender
+	eventEmitter.eventSenders = []eventservices.EventSender{eventSender}
+}
+
+func (eventEmitter *eventEmitter) ConfigSubscriptions(state StateEventServices, eventHub *eventservices.EventHub) {
+	eventEmitter.parentState = state
+	eventEmitter.eventHub = eventHub
+}
+
+// isEnabled returns true when there is someone listening to the events, either a live feed sender or a subscriber
+func (eventEmitter *eventEmitter) isEnabled() bool {
+	return len(eventEmitter.eventSenders) > 0 || eventEmitter.eventHub.HasSubscribers()
 }
 
 func (eventEmitter *eventEmitter) Send(event eventinput.EventInput) error {
@@ -52,106 +72,158 @@ func (eventEmitter *eventEmitter) Send(event eventinput.EventInput) error {
 		return nil
 	}
 
-	// Only send info messages when EventReplayDuringStartup is disabled
-	if !eventEmitter.eventSender.ReplayDuringStartup() && !eventEmitter.parentState.IsRunLeader() {
+	eventEmitter.Publish(event)
+
+	// receivers with the same settings share the mapped event, an event that can't be mapped for some settings is
+	// still sent to the receivers with other settings
+	var mappingErr error
+	mappedEvents := make(map[mappingKey]*eventmessages.FactomEvent)
+	for _, eventSender := range eventEmitter.eventSenders {
+		// Only send info messages when EventReplayDuringStartup is disabled
+		if !eventSender.ReplayDuringStartup() && !eventEmitter.parentState.IsRunLeader() {
+			switch event.(type) {
+			case *eventinput.ProcessListEvent:
+			case *eventinput.NodeMessageEvent:
+			default:
+				continue
+			}
+		}
+
+		key := mappingKey{eventSender.GetBroadcastContent(), eventSender.IsSendStateChangeEvents()}
+		factomEvent, ok := mappedEvents[key]
+		if !ok {
+			var err error
+			factomEvent, err = eventservices.MapToFactomEvent(event, key.broadcastContent, key.sendStateChangeEvents)
+			if err != nil {
+				log.LogPrintf("livefeed", "Failed to map to factom event: %v", err)
+				if mappingErr == nil {
+					mappingErr = fmt.Errorf("failed to map to factom event: %v\n", err)
+				}
+				factomEvent = nil
+			}
+			if factomEvent != nil {
+				factomEvent.IdentityChainID = eventEmitter.parentState.GetIdentityChainID().Bytes()
+			}
+			mappedEvents[key] = factomEvent
+		}
+		if factomEvent == nil || !eventSender.GetEventFilter().Accepts(factomEvent) {
+			continue
+		}
+
+		select {
+		case eventSender.GetEventQueue() <- factomEvent:
+		default:
+			eventSender.IncreaseDroppedFromQueueCounter()
+		}
+	}
+	return mappingErr
+}
+
+// Publish maps the event for the subscribers of the event hub, subscribers only receive live events.
+func (eventEmitter *eventEmitter) Publish(event eventinput.EventInput) {
+	if !eventEmitter.eventHub.HasSubscribers() || eventEmitter.parentState.GetRunState() > runstate.Running {
+		return
+	}
+
+	if !eventEmitter.parentState.IsRunLeader() {
 		switch event.(type) {
 		case *eventinput.ProcessListEvent:
 		case *eventinput.NodeMessageEvent:
 		default:
-			return nil
+			return
 		}
 	}
 
-	broadcastContent := eventEmitter.eventSender.GetBroadcastContent()
-	sendStateChangeEvents := eventEmitter.eventSender.IsSendStateChangeEvents()
-	factomEvent, err := eventservices.MapToFactomEvent(event, broadcastContent, sendStateChangeEvents)
-	if err != nil {
-		return fmt.Errorf("failed to map to factom event: %v\n", err)
+	factomEvent, err := eventservices.MapToFactomEvent(event, eventservices.SubscriberBroadcastContent, false)
+	if err != nil || factomEvent == nil {
+		return
 	}
-	if factomEvent == nil {
-		return nil
-	}
-
 	factomEvent.IdentityChainID = eventEmitter.parentState.GetIdentityChainID().Bytes()
-	select {
-	case eventEmitter.eventSender.GetEventQueue() <- factomEvent:
-	default:
-		eventEmitter.eventSender.IncreaseDroppedFromQueueCounter()
+
+	var payload interfaces.IMsg
+	if event, ok := event.(interface{ GetPayload() interfaces.IMsg }); ok {
+		payload = event.GetPayload()
 	}
-	return nil
+	eventEmitter.eventHub.Publish(factomEvent, payload)
 }
 
 func (eventEmitter *eventEmitter) EmitRegistrationEvent(msg interfaces.IMsg) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		switch msg.(type) { // Do not fill the channel with message we don't need (like EOM's)
 		case *messages.CommitChainMsg, *messages.CommitEntryMsg, *messages.RevealEntryMsg:
 			event := eventinput.NewRegistrationEvent(eventEmitter.GetStreamSource(), msg)
 			eventEmitter.Send(event)
+		case *messages.FactoidTransaction: // factoid transactions are not part of the live feed, only subscribers receive them
+			event := eventinput.NewRegistrationEvent(eventEmitter.GetStreamSource(), msg)
+			eventEmitter.Publish(event)
 		}
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitStateChangeEvent(msg interfaces.IMsg, entityState eventmessages.EntityState) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		switch msg.(type) {
 		case *messages.CommitChainMsg, *messages.CommitEntryMsg, *messages.RevealEntryMsg, *messages.DBStateMsg:
 			event := eventinput.NewStateChangeEvent(eventEmitter.GetStreamSource(), entityState, msg)
 			eventEmitter.Send(event)
+		case *messages.FactoidTransaction:
+			event := eventinput.NewStateChangeEvent(eventEmitter.GetStreamSource(), entityState, msg)
+			eventEmitter.Publish(event)
 		}
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitDirectoryBlockCommitEvent(dbState interfaces.IDBState) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NewDirectoryBlockEvent(eventEmitter.GetStreamSource(), dbState)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitDirectoryBlockAnchorEvent(dirBlockInfo interfaces.IDirBlockInfo) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NewAnchorEvent(eventEmitter.GetStreamSource(), dirBlockInfo)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitReplayDirectoryBlockCommit(msg interfaces.IMsg) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NewReplayDirectoryBlockEvent(eventmessages.EventSource_REPLAY_BOOT, msg)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitProcessListEventNewBlock(newBlockHeight uint32) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.ProcessListEventNewBlock(eventEmitter.GetStreamSource(), newBlockHeight)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitProcessListEventNewMinute(newMinute int, blockHeight uint32) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.ProcessListEventNewMinute(eventEmitter.GetStreamSource(), newMinute, blockHeight)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitNodeInfoMessage(messageCode eventmessages.NodeMessageCode, message string) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NodeInfoMessageF(messageCode, message)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitNodeInfoMessageF(messageCode eventmessages.NodeMessageCode, format string, values ...interface{}) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NodeInfoMessageF(messageCode, format, values...)
 		eventEmitter.Send(event)
 	}
 }
 
 func (eventEmitter *eventEmitter) EmitNodeErrorMessage(messageCode eventmessages.NodeMessageCode, message string, values interface{}) {
-	if eventEmitter.eventSender != nil {
+	if eventEmitter.isEnabled() {
 		event := eventinput.NodeErrorMessage(messageCode, message, values)
 		eventEmitter.Send(event)
 	}
@@ -4,11 +4,14 @@ import (
 	"testing"
 
 	"github.com/FactomProject/factomd/common/constants/runstate"
+	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/events/eventconfig"
 	"github.com/FactomProject/factomd/events/eventinput"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/FactomProject/factomd/events/eventservices"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
@@ -25,10 +28,10 @@ func TestEventEmitter_Send(t *testing.T) {
 				parentState: StateMock{
 					IdentityChainID: primitives.NewZeroHash(),
 				},
-				eventSender: &mockEventSender{
+				eventSenders: []eventservices.EventSender{&mockEventSender{
 					eventsOutQueue:          make(chan *eventmessages.FactomEvent, 0),
 					droppedFromQueueCounter: prometheus.NewCounter(prometheus.CounterOpts{}),
-				},
+				}},
 			},
 			Event: eventinput.NodeInfoMessageF(eventmessages.NodeMessageCode_GENERAL, "test message of node: %s", "node name"),
 			Assertion: func(t *testing.T, eventService *mockEventSender, err error) {
@@ -39,9 +42,9 @@ func TestEventEmitter_Send(t *testing.T) {
 		},
 		"not-running": {
 			Emitter: &eventEmitter{
-				eventSender: &mockEventSender{
+				eventSenders: []eventservices.EventSender{&mockEventSender{
 					eventsOutQueue: make(chan *eventmessages.FactomEvent, 5000),
-				},
+				}},
 				parentState: StateMock{
 					RunState: runstate.Stopping,
 				},
@@ -54,10 +57,10 @@ func TestEventEmitter_Send(t *testing.T) {
 		},
 		"nil-event": {
 			Emitter: &eventEmitter{
-				eventSender: &mockEventSender{
+				eventSenders: []eventservices.EventSender{&mockEventSender{
 					eventsOutQueue:      make(chan *eventmessages.FactomEvent, 5000),
 					replayDuringStartup: true,
-				},
+				}},
 				parentState: StateMock{},
 			},
 			Event: nil,
@@ -68,10 +71,10 @@ func TestEventEmitter_Send(t *testing.T) {
 		},
 		"mute-replay-starting": {
 			Emitter: &eventEmitter{
-				eventSender: &mockEventSender{
+				eventSenders: []eventservices.EventSender{&mockEventSender{
 					eventsOutQueue:      make(chan *eventmessages.FactomEvent, 5000),
 					replayDuringStartup: false,
-				},
+				}},
 				parentState: StateMock{
 					RunLeader: false,
 				},
@@ -87,7 +90,7 @@ func TestEventEmitter_Send(t *testing.T) {
 	for name, testCase := range testCases {
 		t.Run(name, func(t *testing.T) {
 			err := testCase.Emitter.Send(testCase.Event)
-			testCase.Assertion(t, testCase.Emitter.eventSender.(*mockEventSender), err)
+			testCase.Assertion(t, testCase.Emitter.eventSenders[0].(*mockEventSender), err)
 		})
 	}
 }
@@ -103,7 +106,7 @@ func TestEventsService_SendFillupQueue(t *testing.T) {
 		parentState: StateMock{
 			IdentityChainID: primitives.NewZeroHash(),
 		},
-		eventSender: eventSender,
+		eventSenders: []eventservices.EventSender{eventSender},
 	}
 
 	event := eventinput.NodeInfoMessageF(eventmessages.NodeMessageCode_GENERAL, "test message of node: %s", "node name")
@@ -115,6 +118,59 @@ func TestEventsService_SendFillupQueue(t *testing.T) {
 	assert.Equal(t, float64(1), getCounterValue(t, eventSender.droppedFromQueueCounter))
 }
 
+func TestEventEmitter_SendToMultipleReceivers(t *testing.T) {
+	filter, _ := eventservices.ParseEventFilter("node-message", "")
+	onlyNodeMessages := &mockEventSender{
+		eventsOutQueue: make(chan *eventmessages.FactomEvent, 10),
+		filter:         filter,
+	}
+	allEvents := &mockEventSender{
+		eventsOutQueue: make(chan *eventmessages.FactomEvent, 10),
+	}
+	eventEmitter := &eventEmitter{
+		parentState: StateMock{
+			IdentityChainID: primitives.NewZeroHash(),
+			RunLeader:       true,
+		},
+		eventSenders: []eventservices.EventSender{onlyNodeMessages, allEvents},
+	}
+
+	assert.NoError(t, eventEmitter.Send(eventinput.NodeInfoMessageF(eventmessages.NodeMessageCode_GENERAL, "test message")))
+	assert.NoError(t, eventEmitter.Send(eventinput.ProcessListEventNewBlock(eventmessages.EventSource_LIVE, 1)))
+
+	assert.Equal(t, 1, len(onlyNodeMessages.eventsOutQueue))
+	assert.Equal(t, 2, len(allEvents.eventsOutQueue))
+}
+
+func TestEventEmitter_PublishToSubscribers(t *testing.T) {
+	hub := eventservices.NewEventHub()
+	subscriber := hub.Subscribe()
+	defer subscriber.Close()
+
+	eventEmitter := &eventEmitter{}
+	eventEmitter.ConfigSubscriptions(StateMock{
+		IdentityChainID: primitives.NewZeroHash(),
+		RunLeader:       true,
+	}, hub)
+
+	assert.True(t, eventEmitter.isEnabled())
+	eventEmitter.EmitNodeInfoMessage(eventmessages.NodeMessageCode_GENERAL, "test message")
+
+	if assert.Equal(t, 1, len(subscriber.Events())) {
+		event := <-subscriber.Events()
+		assert.Equal(t, primitives.NewZeroHash().Bytes(), event.IdentityChainID)
+		assert.Equal(t, "test message", event.GetNodeMessage().MessageText)
+	}
+
+	eventEmitter.EmitStateChangeEvent(&messages.FactoidTransaction{Transaction: new(factoid.Transaction)}, eventmessages.EntityState_ACCEPTED)
+	if assert.Equal(t, 1, len(subscriber.Events())) {
+		event := <-subscriber.Events()
+		if assert.NotNil(t, event.GetStateChange()) {
+			assert.Equal(t, eventmessages.EntityState_ACCEPTED, event.GetStateChange().EntityState)
+		}
+	}
+}
+
 func getCounterValue(t *testing.T, counter prometheus.Counter) float64 {
 	metric := &dto.Metric{}
 	err := counter.Write(metric)
@@ -129,6 +185,7 @@ type mockEventSender struct {
 	droppedFromQueueCounter prometheus.Counter
 	notSentCounter          prometheus.Counter
 	replayDuringStartup     bool
+	filter                  *eventservices.EventFilter
 }
 
 func (m *mockEventSender) GetBroadcastContent() eventconfig.BroadcastContent {
@@ -147,6 +204,10 @@ func (m *mockEventSender) GetEventQueue() chan *eventmessages.FactomEvent {
 	return m.eventsOutQueue
 }
 
+func (m *mockEventSender) GetEventFilter() *eventservices.EventFilter {
+	return m.filter
+}
+
 func (m *mockEventSender) Shutdown() {}
 
 type StateMock struct {
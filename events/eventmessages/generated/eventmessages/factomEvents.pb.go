@@ -155,6 +155,7 @@ type FactomEvent struct {
 	//	*FactomEvent_NodeMessage
 	//	*FactomEvent_DirectoryBlockAnchor
 	Event                isFactomEvent_Event `protobuf_oneof:"event"`
+	SequenceNumber       uint64              `protobuf:"varint,12,opt,name=sequenceNumber,proto3" json:"sequenceNumber,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
 	XXX_unrecognized     []byte              `json:"-"`
 	XXX_sizecache        int32               `json:"-"`
@@ -317,6 +318,13 @@ func (m *FactomEvent) GetDirectoryBlockAnchor() *DirectoryBlockAnchor {
 	return nil
 }
 
+func (m *FactomEvent) GetSequenceNumber() uint64 {
+	if m != nil {
+		return m.SequenceNumber
+	}
+	return 0
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*FactomEvent) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -1458,95 +1466,96 @@ func init() {
 func init() { proto.RegisterFile("eventmessages/factomEvents.proto", fileDescriptor_d6566f2e3579336b) }
 
 var fileDescriptor_d6566f2e3579336b = []byte{
-	// 1399 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xcf, 0x73, 0xdb, 0xc4,
-	0x17, 0xb7, 0xfc, 0x23, 0x89, 0x9f, 0xec, 0xc4, 0xdd, 0x49, 0xfb, 0xf5, 0x37, 0x14, 0xd7, 0x23,
-	0x0a, 0x13, 0x32, 0x8c, 0x3b, 0x13, 0x98, 0x01, 0x06, 0x28, 0xd8, 0xb2, 0x52, 0xbb, 0x75, 0xec,
-	0xb0, 0x71, 0xe9, 0xa4, 0x97, 0x8c, 0x2c, 0x6d, 0x13, 0x81, 0x2d, 0x65, 0x24, 0x39, 0x6d, 0xff,
-	0x08, 0x0e, 0xbd, 0xc1, 0x81, 0x3f, 0x80, 0x23, 0x07, 0x4e, 0xfc, 0x03, 0x1c, 0x39, 0x70, 0x87,
-	0x29, 0x47, 0xfe, 0x09, 0x66, 0x77, 0x65, 0x6b, 0xb5, 0x52, 0xda, 0xb4, 0x3d, 0xc5, 0xfb, 0xf6,
-	0xf3, 0xd9, 0x7d, 0xef, 0xed, 0x67, 0xdf, 0x3e, 0x05, 0x9a, 0xe4, 0x9c, 0xb8, 0xe1, 0x8c, 0x04,
-	0x81, 0x79, 0x42, 0x82, 0x5b, 0x8f, 0x4c, 0x2b, 0xf4, 0x66, 0x06, 0xb5, 0x05, 0xad, 0x33, 0xdf,
-	0x0b, 0x3d, 0x54, 0x4d, 0x20, 0xb6, 0x6e, 0x9c, 0x78, 0xde, 0xc9, 0x94, 0xdc, 0x62, 0x93, 0x93,
-	0xf9, 0xa3, 0x5b, 0xa1, 0x33, 0x23, 0x41, 0x68, 0xce, 0xce, 0x38, 0x7e, 0xab, 0x91, 0x5c, 0xd1,
-	0xb4, 0x67, 0x8e, 0xdb, 0x99, 0x7a, 0xd6, 0x77, 0xd1, 0xbc, 0x96, 0x9c, 0xb7, 0x1d, 0x9f, 0x58,
-	0xa1, 0xe7, 0x3f, 0x15, 0x31, 0xd2, 0x1a, 0xc4, 0x0d, 0x93, 0xf3, 0x59, 0x5e, 0x3b, 0xb6, 0x80,
-	0xd0, 0xfe, 0x2d, 0x81, 0xba, 0x17, 0x07, 0x83, 0x3e, 0x07, 0x95, 0x71, 0x0e, 0xbd, 0xb9, 0x6f,
-	0x91, 0xba, 0xd2, 0x54, 0xb6, 0xd7, 0x77, 0xb7, 0x5a, 0x89, 0x75, 0x5a, 0x46, 0x8c, 0xc0, 0x22,
-	0x1c, 0xbd, 0x07, 0xeb, 0x3c, 0x33, 0x43, 0xcf, 0x26, 0x43, 0x73, 0x46, 0xea, 0xf9, 0xa6, 0xb2,
-	0x5d, 0xc6, 0x92, 0x15, 0x6d, 0xc3, 0x86, 0x63, 0x13, 0x37, 0x74, 0xc2, 0xa7, 0xfa, 0xa9, 0xe9,
-	0xb8, 0xfd, 0x6e, 0xbd, 0xd0, 0x54, 0xb6, 0x2b, 0x58, 0x36, 0xa3, 0xdb, 0xa0, 0x5a, 0xf4, 0xa7,
-	0xee, 0xcd, 0x66, 0x4e, 0x58, 0x2f, 0x36, 0x95, 0x6d, 0x35, 0xe5, 0x8f, 0x1e, 0x23, 0x7a, 0x39,
-	0x2c, 0x12, 0x28, 0x9f, 0x65, 0x25, 0xe2, 0x97, 0x32, 0xf9, 0x46, 0x8c, 0xa0, 0x7c, 0x81, 0xb0,
-	0xe4, 0x63, 0x72, 0x4e, 0xcc, 0x69, 0x7d, 0xe5, 0x62, 0x3e, 0x47, 0x2c, 0xf9, 0x7c, 0x48, 0xf9,
-	0x41, 0x68, 0x86, 0x44, 0x3f, 0x35, 0xdd, 0x13, 0x52, 0x5f, 0xcd, 0xe4, 0x1f, 0xc6, 0x08, 0xca,
-	0x17, 0x08, 0xe8, 0x08, 0x36, 0x93, 0x27, 0x1f, 0x05, 0xb2, 0xc6, 0x16, 0x7a, 0x47, 0x5a, 0xa8,
-	0x9b, 0x01, 0xed, 0xe5, 0x70, 0xe6, 0x12, 0x68, 0x1f, 0x6a, 0x67, 0xbe, 0x67, 0x91, 0x20, 0x18,
-	0x38, 0x41, 0xc8, 0xce, 0xb4, 0x5e, 0x66, 0xcb, 0xde, 0x90, 0x96, 0x3d, 0x90, 0x60, 0xbd, 0x1c,
-	0x4e, 0x51, 0x69, 0xa4, 0xae, 0x67, 0x93, 0x7d, 0x4e, 0xaa, 0x43, 0x66, 0xa4, 0xc3, 0x18, 0x41,
-	0x23, 0x15, 0x08, 0xe9, 0x48, 0xdb, 0xae, 0x75, 0xea, 0xf9, 0x75, 0xf5, 0x12, 0x91, 0x72, 0x68,
-	0x3a, 0x52, 0x6e, 0xef, 0xac, 0x42, 0x89, 0xb1, 0xb5, 0xbf, 0xf2, 0xa0, 0x0a, 0x62, 0x61, 0x6a,
-	0x67, 0x72, 0x63, 0x27, 0x70, 0x91, 0xda, 0x63, 0x04, 0x16, 0xe1, 0xa8, 0x19, 0x69, 0xb3, 0xdf,
-	0xed, 0x99, 0xc1, 0x29, 0x93, 0x7a, 0x05, 0x8b, 0x26, 0x74, 0x1d, 0xca, 0x4c, 0x0c, 0x6c, 0x9e,
-	0x2b, 0x3c, 0x36, 0x20, 0x04, 0xc5, 0xc7, 0x64, 0x6a, 0x33, 0x51, 0x57, 0x30, 0xfb, 0x8d, 0x3e,
-	0x81, 0xf2, 0xb2, 0x50, 0x2c, 0xd5, 0xca, 0x4b, 0x49, 0x6b, 0x51, 0x4a, 0x5a, 0xe3, 0x05, 0x02,
-	0xc7, 0x60, 0x54, 0x87, 0x55, 0xcb, 0x27, 0xb6, 0x13, 0x06, 0x4c, 0xa5, 0x55, 0xbc, 0x18, 0xa2,
-	0x5d, 0xd8, 0xe4, 0x92, 0x66, 0xe3, 0x83, 0xf9, 0x64, 0xea, 0x58, 0xf7, 0xc8, 0x53, 0x26, 0xc6,
-	0x0a, 0xce, 0x9c, 0xa3, 0x9e, 0x07, 0xce, 0x89, 0x6b, 0x86, 0x73, 0x9f, 0x30, 0xb1, 0x55, 0x70,
-	0x6c, 0xa0, 0x7b, 0x9d, 0x13, 0x3f, 0x70, 0x3c, 0x97, 0x29, 0xa6, 0x8a, 0x17, 0x43, 0xed, 0xe7,
-	0x3c, 0xa8, 0xc2, 0x75, 0x7a, 0xc3, 0x0c, 0x27, 0xf2, 0x97, 0x97, 0xf3, 0x97, 0xc8, 0x55, 0xe1,
-	0x35, 0x73, 0x55, 0xbc, 0x5c, 0xae, 0x4a, 0x97, 0xcd, 0xd5, 0xca, 0x0b, 0x72, 0xb5, 0x9a, 0xcc,
-	0xd5, 0x6f, 0x4a, 0x94, 0xab, 0xa8, 0x56, 0xbc, 0x59, 0xae, 0x3e, 0x82, 0x12, 0xf3, 0x8e, 0xe5,
-	0x49, 0xdd, 0x6d, 0x64, 0xd5, 0x28, 0x76, 0x29, 0xf8, 0x96, 0x1c, 0xfc, 0xfa, 0x39, 0xd4, 0xbe,
-	0x57, 0x40, 0x15, 0x0a, 0x17, 0x6a, 0x00, 0x70, 0x77, 0xd8, 0x61, 0x29, 0x2c, 0x0d, 0x82, 0x45,
-	0x8e, 0x2e, 0xff, 0xca, 0x77, 0x6d, 0x42, 0x9d, 0xef, 0x11, 0xe7, 0xe4, 0x34, 0x64, 0x9e, 0x56,
-	0xb1, 0x68, 0xd2, 0x7e, 0x29, 0xc0, 0x66, 0x56, 0xfd, 0x43, 0x06, 0xac, 0x27, 0xab, 0x02, 0x73,
-	0x4e, 0xdd, 0x7d, 0xfb, 0x85, 0x25, 0x05, 0x4b, 0x24, 0xf4, 0x29, 0x40, 0xfc, 0x46, 0x47, 0x49,
-	0xfe, 0xbf, 0xb4, 0x44, 0x7b, 0x09, 0xc0, 0x02, 0x18, 0x7d, 0x09, 0x15, 0xf1, 0xe9, 0x8d, 0xf2,
-	0xfc, 0x96, 0x44, 0xde, 0x13, 0x20, 0x38, 0x41, 0x40, 0xf7, 0xa0, 0x26, 0x28, 0x8f, 0x2f, 0x52,
-	0xcc, 0x2c, 0xd5, 0x86, 0x04, 0xc3, 0x29, 0x22, 0xfa, 0x2c, 0x7a, 0xd2, 0xd8, 0x28, 0xa8, 0x97,
-	0x9a, 0x85, 0x8c, 0x48, 0x62, 0xb9, 0x60, 0x11, 0x8d, 0x06, 0x70, 0x85, 0x24, 0x94, 0xe4, 0x10,
-	0x5a, 0x6f, 0x0a, 0x97, 0x50, 0x5c, 0x9a, 0xa8, 0x3d, 0x53, 0xa0, 0x26, 0x7b, 0x8c, 0xbe, 0x80,
-	0x95, 0x53, 0x62, 0xda, 0xc4, 0x8f, 0xce, 0xe9, 0xdd, 0x97, 0x84, 0xd8, 0x63, 0x60, 0x1c, 0x91,
-	0xd0, 0x6d, 0x58, 0x25, 0x91, 0x5f, 0x79, 0xe6, 0xd7, 0xcd, 0x97, 0xf0, 0xb9, 0x77, 0x0b, 0x92,
-	0xf6, 0xa7, 0x02, 0xd7, 0xb2, 0xb7, 0x40, 0x5b, 0xb0, 0x36, 0xf1, 0x6c, 0x51, 0xe0, 0xcb, 0x31,
-	0x6a, 0x01, 0x3a, 0xf3, 0xc9, 0xb9, 0xe3, 0xcd, 0x03, 0x8e, 0x16, 0x6a, 0x56, 0xc6, 0x0c, 0xda,
-	0xa1, 0xaf, 0x2f, 0xb7, 0xee, 0xcd, 0xa7, 0x53, 0xe1, 0x85, 0x48, 0xd9, 0x65, 0xf1, 0x17, 0x53,
-	0xe2, 0xa7, 0x08, 0x6f, 0xf2, 0x2d, 0xb1, 0x42, 0xdd, 0x9b, 0xbb, 0xbc, 0xcd, 0x29, 0x62, 0xd1,
-	0xa4, 0x3d, 0x2b, 0xc0, 0xd5, 0xcc, 0xc8, 0xe5, 0x16, 0x4b, 0x79, 0xc3, 0x16, 0x2b, 0xff, 0xaa,
-	0x2d, 0xd6, 0x5d, 0xd8, 0x70, 0x5c, 0xcb, 0x27, 0x66, 0x40, 0x3a, 0xe6, 0xd4, 0x74, 0x2d, 0x12,
-	0x5d, 0x10, 0x59, 0x50, 0xfd, 0x24, 0xaa, 0x97, 0xc3, 0x32, 0x11, 0xb5, 0xa1, 0x32, 0x73, 0xdc,
-	0x79, 0x48, 0x86, 0xf3, 0xd9, 0x84, 0xf8, 0xd1, 0x25, 0x91, 0x6f, 0xda, 0xbe, 0x00, 0xe9, 0xe5,
-	0x70, 0x82, 0x82, 0x0e, 0xe0, 0x4a, 0x40, 0xfc, 0x73, 0xe2, 0xf7, 0x5d, 0x9b, 0x3c, 0x89, 0xd6,
-	0xe1, 0x2f, 0x71, 0x53, 0xee, 0xdb, 0x64, 0x5c, 0x2f, 0x87, 0xd3, 0xe4, 0xce, 0xff, 0xe0, 0x2a,
-	0xc9, 0xca, 0xbc, 0xf6, 0xa3, 0x02, 0x1b, 0x52, 0x50, 0x17, 0x3e, 0x40, 0xca, 0x0b, 0x1e, 0xa0,
-	0x9b, 0x50, 0x0d, 0x7d, 0xd3, 0x0d, 0x4c, 0x2b, 0x74, 0x3c, 0xda, 0x4c, 0x73, 0xd9, 0x25, 0x8d,
-	0x68, 0x13, 0x4a, 0x0e, 0xf5, 0x8a, 0x65, 0xb7, 0x88, 0xf9, 0x00, 0x5d, 0x83, 0x15, 0x73, 0xc6,
-	0x44, 0x53, 0x64, 0xe6, 0x68, 0xa4, 0xed, 0x42, 0x45, 0x4c, 0x13, 0xd2, 0xa4, 0xcc, 0x2a, 0x4c,
-	0x84, 0x09, 0x9b, 0xd6, 0x86, 0x2b, 0xa9, 0x94, 0xa0, 0x0f, 0xb2, 0xf2, 0xc9, 0xd9, 0xe9, 0x09,
-	0xed, 0x27, 0x05, 0x54, 0xa1, 0x49, 0x44, 0x5f, 0x81, 0x1a, 0xa5, 0x5b, 0xf7, 0xec, 0xc5, 0x9b,
-	0xd8, 0xb8, 0xb8, 0xab, 0xa4, 0x28, 0x2c, 0x52, 0xd0, 0x0e, 0x94, 0xa6, 0xe4, 0x9c, 0x4c, 0xa3,
-	0x17, 0x67, 0x53, 0xe2, 0x0e, 0xe8, 0x1c, 0xe6, 0x10, 0x7a, 0x8d, 0xa2, 0x89, 0x31, 0x79, 0xc2,
-	0x5f, 0x99, 0x32, 0x16, 0x4d, 0xda, 0xaf, 0x0a, 0xd4, 0xe4, 0x76, 0x18, 0x75, 0xa1, 0xea, 0x92,
-	0xc7, 0xfc, 0x60, 0x59, 0x1b, 0xcd, 0xef, 0xd0, 0x75, 0xd9, 0x4d, 0x11, 0xd3, 0xcb, 0xe1, 0x24,
-	0x09, 0xdd, 0x81, 0x75, 0x97, 0x3c, 0xe6, 0x49, 0xe7, 0xcb, 0xe4, 0x33, 0xdf, 0xa9, 0x61, 0x02,
-	0xd4, 0xcb, 0x61, 0x89, 0xd6, 0x41, 0xe9, 0xc6, 0x5e, 0xfb, 0x18, 0xaa, 0x89, 0xed, 0xe9, 0xa7,
-	0xda, 0x62, 0xfb, 0xa8, 0xac, 0xf0, 0x33, 0x91, 0xac, 0xda, 0x01, 0xac, 0x27, 0x37, 0xa4, 0xed,
-	0xce, 0x72, 0xc3, 0x88, 0x14, 0x1b, 0xe4, 0x5a, 0x95, 0x4f, 0xd5, 0xaa, 0x9d, 0x6d, 0x50, 0x85,
-	0x0f, 0x48, 0xb4, 0x06, 0xc5, 0x41, 0xff, 0x1b, 0xa3, 0x96, 0x43, 0x1b, 0xa0, 0x62, 0xe3, 0x60,
-	0xd0, 0x3e, 0x3a, 0xee, 0x8c, 0x46, 0xe3, 0x9a, 0xb2, 0xf3, 0x90, 0xf5, 0x47, 0xcb, 0x1e, 0xa0,
-	0x0a, 0x65, 0x6c, 0x7c, 0x7d, 0xdf, 0x38, 0x1c, 0x1b, 0xdd, 0x5a, 0x0e, 0x55, 0x60, 0xad, 0xad,
-	0xeb, 0xc6, 0x01, 0x1d, 0x29, 0x74, 0x84, 0x8d, 0xbb, 0x86, 0x4e, 0x47, 0x79, 0xd4, 0x84, 0xeb,
-	0xfa, 0x68, 0x7f, 0xbf, 0x3f, 0x1e, 0x1b, 0xdd, 0xe3, 0xf1, 0xe8, 0xb8, 0xdb, 0xc7, 0x86, 0x3e,
-	0x1e, 0xe1, 0xa3, 0xe3, 0xce, 0x60, 0xa4, 0xdf, 0xab, 0x15, 0x76, 0xde, 0x87, 0x12, 0x3b, 0x7a,
-	0xba, 0x7f, 0x7f, 0xb8, 0x37, 0xaa, 0xe5, 0x90, 0x0a, 0xab, 0x0f, 0xda, 0x78, 0xd8, 0x1f, 0xde,
-	0xa9, 0x29, 0xa8, 0x0c, 0x25, 0x03, 0xe3, 0x11, 0xae, 0xe5, 0x77, 0x0c, 0xd8, 0x90, 0x14, 0x46,
-	0xa1, 0x77, 0x8c, 0xa1, 0x81, 0xdb, 0x03, 0xce, 0x3b, 0x1c, 0xb7, 0x31, 0xf7, 0x03, 0x60, 0xe5,
-	0xf0, 0x68, 0xa8, 0x33, 0x2f, 0x2a, 0xb0, 0x76, 0xd8, 0xbb, 0x3f, 0xee, 0x8e, 0x1e, 0x0c, 0x6b,
-	0x85, 0x4e, 0xfb, 0xf7, 0xe7, 0x0d, 0xe5, 0x8f, 0xe7, 0x0d, 0xe5, 0xef, 0xe7, 0x0d, 0xe5, 0x87,
-	0x7f, 0x1a, 0x39, 0x68, 0x5a, 0xde, 0xac, 0xc5, 0x3f, 0x8d, 0xa3, 0x3f, 0x76, 0xf2, 0xac, 0x1f,
-	0x26, 0xff, 0xa9, 0x30, 0x59, 0x61, 0x2d, 0xd9, 0x87, 0xff, 0x05, 0x00, 0x00, 0xff, 0xff, 0x60,
-	0x04, 0x7d, 0x07, 0x8e, 0x10, 0x00, 0x00,
+	// 1412 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0x5d, 0x73, 0xdb, 0x44,
+	0x17, 0xb6, 0xfc, 0x95, 0xf8, 0xc8, 0x4e, 0xdc, 0x9d, 0xb4, 0xaf, 0xdf, 0xbc, 0x7d, 0x5d, 0x8f,
+	0x28, 0x8c, 0xc9, 0x30, 0xee, 0x4c, 0x60, 0x06, 0x18, 0xa0, 0xe0, 0x0f, 0xa5, 0x76, 0xeb, 0xd8,
+	0x61, 0xe3, 0xd2, 0x49, 0x6f, 0x32, 0xb2, 0xb4, 0x4d, 0x04, 0xb6, 0x14, 0x24, 0x39, 0x6d, 0x7f,
+	0x04, 0x17, 0x1d, 0x6e, 0xe0, 0x82, 0x1f, 0xc0, 0x25, 0x17, 0x5c, 0xf1, 0x07, 0xb8, 0xe4, 0x82,
+	0x7b, 0x98, 0xf2, 0x47, 0x98, 0xdd, 0x95, 0xad, 0xd5, 0x4a, 0x69, 0xd3, 0xf6, 0x2a, 0xde, 0xb3,
+	0xcf, 0xb3, 0x7b, 0xce, 0xb3, 0x67, 0xcf, 0x1e, 0x05, 0x1a, 0xe4, 0x9c, 0x38, 0xc1, 0x9c, 0xf8,
+	0xbe, 0x71, 0x42, 0xfc, 0x5b, 0x8f, 0x0c, 0x33, 0x70, 0xe7, 0x3a, 0xb5, 0xf9, 0xad, 0x33, 0xcf,
+	0x0d, 0x5c, 0x54, 0x89, 0x21, 0xb6, 0x6f, 0x9c, 0xb8, 0xee, 0xc9, 0x8c, 0xdc, 0x62, 0x93, 0xd3,
+	0xc5, 0xa3, 0x5b, 0x81, 0x3d, 0x27, 0x7e, 0x60, 0xcc, 0xcf, 0x38, 0x7e, 0xbb, 0x1e, 0x5f, 0xd1,
+	0xb0, 0xe6, 0xb6, 0xd3, 0x99, 0xb9, 0xe6, 0x37, 0xe1, 0xbc, 0x16, 0x9f, 0xb7, 0x6c, 0x8f, 0x98,
+	0x81, 0xeb, 0x3d, 0x15, 0x31, 0xd2, 0x1a, 0xc4, 0x09, 0xe2, 0xf3, 0x69, 0x5e, 0xdb, 0x96, 0x80,
+	0xd0, 0xbe, 0x2f, 0x82, 0xba, 0x17, 0x05, 0x83, 0x3e, 0x05, 0x95, 0x71, 0x0e, 0xdd, 0x85, 0x67,
+	0x92, 0x9a, 0xd2, 0x50, 0x9a, 0x1b, 0xbb, 0xdb, 0xad, 0xd8, 0x3a, 0x2d, 0x3d, 0x42, 0x60, 0x11,
+	0x8e, 0xde, 0x81, 0x0d, 0xae, 0xcc, 0xc8, 0xb5, 0xc8, 0xc8, 0x98, 0x93, 0x5a, 0xb6, 0xa1, 0x34,
+	0x4b, 0x58, 0xb2, 0xa2, 0x26, 0x6c, 0xda, 0x16, 0x71, 0x02, 0x3b, 0x78, 0xda, 0x3d, 0x35, 0x6c,
+	0x67, 0xd0, 0xab, 0xe5, 0x1a, 0x4a, 0xb3, 0x8c, 0x65, 0x33, 0xba, 0x0d, 0xaa, 0x49, 0x7f, 0x76,
+	0xdd, 0xf9, 0xdc, 0x0e, 0x6a, 0xf9, 0x86, 0xd2, 0x54, 0x13, 0xfe, 0x74, 0x23, 0x44, 0x3f, 0x83,
+	0x45, 0x02, 0xe5, 0x33, 0x55, 0x42, 0x7e, 0x21, 0x95, 0xaf, 0x47, 0x08, 0xca, 0x17, 0x08, 0x2b,
+	0x3e, 0x26, 0xe7, 0xc4, 0x98, 0xd5, 0x8a, 0x17, 0xf3, 0x39, 0x62, 0xc5, 0xe7, 0x43, 0xca, 0xf7,
+	0x03, 0x23, 0x20, 0xdd, 0x53, 0xc3, 0x39, 0x21, 0xb5, 0xb5, 0x54, 0xfe, 0x61, 0x84, 0xa0, 0x7c,
+	0x81, 0x80, 0x8e, 0x60, 0x2b, 0x7e, 0xf2, 0x61, 0x20, 0xeb, 0x6c, 0xa1, 0xb7, 0xa4, 0x85, 0x7a,
+	0x29, 0xd0, 0x7e, 0x06, 0xa7, 0x2e, 0x81, 0xf6, 0xa1, 0x7a, 0xe6, 0xb9, 0x26, 0xf1, 0xfd, 0xa1,
+	0xed, 0x07, 0xec, 0x4c, 0x6b, 0x25, 0xb6, 0xec, 0x0d, 0x69, 0xd9, 0x03, 0x09, 0xd6, 0xcf, 0xe0,
+	0x04, 0x95, 0x46, 0xea, 0xb8, 0x16, 0xd9, 0xe7, 0xa4, 0x1a, 0xa4, 0x46, 0x3a, 0x8a, 0x10, 0x34,
+	0x52, 0x81, 0x90, 0x8c, 0xb4, 0xed, 0x98, 0xa7, 0xae, 0x57, 0x53, 0x2f, 0x11, 0x29, 0x87, 0x26,
+	0x23, 0xe5, 0x76, 0x9a, 0x96, 0x3e, 0xf9, 0x76, 0x41, 0x1c, 0x93, 0x8c, 0x16, 0xf3, 0x29, 0xf1,
+	0x6a, 0xe5, 0x86, 0xd2, 0xcc, 0x63, 0xc9, 0xda, 0x59, 0x83, 0x02, 0xdb, 0x45, 0xfb, 0x2b, 0x0b,
+	0xaa, 0x90, 0x54, 0xec, 0x56, 0xb0, 0xb4, 0x64, 0x27, 0x75, 0xd1, 0xad, 0x88, 0x10, 0x58, 0x84,
+	0xa3, 0x46, 0x98, 0xc3, 0x83, 0x5e, 0xdf, 0xf0, 0x4f, 0xd9, 0x95, 0x28, 0x63, 0xd1, 0x84, 0xae,
+	0x43, 0x89, 0x25, 0x0d, 0x9b, 0xe7, 0x37, 0x21, 0x32, 0x20, 0x04, 0xf9, 0xc7, 0x64, 0x66, 0xb1,
+	0xe4, 0x2f, 0x63, 0xf6, 0x1b, 0x7d, 0x04, 0xa5, 0x55, 0x41, 0x59, 0x65, 0x35, 0x2f, 0x39, 0xad,
+	0x65, 0xc9, 0x69, 0x4d, 0x96, 0x08, 0x1c, 0x81, 0x51, 0x0d, 0xd6, 0x4c, 0x8f, 0x58, 0x76, 0xe0,
+	0xb3, 0x6c, 0xae, 0xe0, 0xe5, 0x10, 0xed, 0xc2, 0x16, 0x4f, 0x7d, 0x36, 0x3e, 0x58, 0x4c, 0x67,
+	0xb6, 0x79, 0x8f, 0x3c, 0x65, 0x49, 0x5b, 0xc6, 0xa9, 0x73, 0xd4, 0x73, 0xdf, 0x3e, 0x71, 0x8c,
+	0x60, 0xe1, 0x11, 0x96, 0x94, 0x65, 0x1c, 0x19, 0xe8, 0x5e, 0xe7, 0xc4, 0xf3, 0x6d, 0xd7, 0x61,
+	0x99, 0x55, 0xc1, 0xcb, 0xa1, 0xf6, 0x73, 0x16, 0x54, 0xe1, 0xda, 0xbd, 0xa1, 0xc2, 0x31, 0xfd,
+	0xb2, 0xb2, 0x7e, 0x31, 0xad, 0x72, 0xaf, 0xa9, 0x55, 0xfe, 0x72, 0x5a, 0x15, 0x2e, 0xab, 0x55,
+	0xf1, 0x05, 0x5a, 0xad, 0xc5, 0xb5, 0xfa, 0x4d, 0x09, 0xb5, 0x0a, 0x6b, 0xca, 0x9b, 0x69, 0xf5,
+	0x01, 0x14, 0x98, 0x77, 0x4c, 0x27, 0x75, 0xb7, 0x9e, 0x56, 0xcb, 0xd8, 0xe5, 0xe1, 0x5b, 0x72,
+	0xf0, 0xeb, 0x6b, 0xa8, 0x7d, 0xa7, 0x80, 0x2a, 0x14, 0x38, 0x54, 0x07, 0xe0, 0xee, 0xb0, 0xc3,
+	0x52, 0x98, 0x0c, 0x82, 0x45, 0x8e, 0x2e, 0xfb, 0xca, 0x77, 0x6d, 0x4a, 0x9d, 0xef, 0x13, 0xfb,
+	0xe4, 0x34, 0x60, 0x9e, 0x56, 0xb0, 0x68, 0xd2, 0x7e, 0xc9, 0xc1, 0x56, 0x5a, 0x9d, 0x44, 0x3a,
+	0x6c, 0xc4, 0xab, 0x07, 0x73, 0x4e, 0xdd, 0xfd, 0xff, 0x0b, 0x4b, 0x0f, 0x96, 0x48, 0xe8, 0x63,
+	0x80, 0xe8, 0x2d, 0x0f, 0x45, 0xfe, 0xaf, 0xb4, 0x44, 0x7b, 0x05, 0xc0, 0x02, 0x18, 0x7d, 0x0e,
+	0x65, 0xf1, 0x89, 0x0e, 0x75, 0xfe, 0x9f, 0x44, 0xde, 0x13, 0x20, 0x38, 0x46, 0x40, 0xf7, 0xa0,
+	0x2a, 0x64, 0x1e, 0x5f, 0x24, 0x9f, 0x5a, 0xd2, 0x75, 0x09, 0x86, 0x13, 0x44, 0xf4, 0x49, 0xf8,
+	0xf4, 0xb1, 0x91, 0x5f, 0x2b, 0x34, 0x72, 0x29, 0x91, 0x44, 0xe9, 0x82, 0x45, 0x34, 0x1a, 0xc2,
+	0x15, 0x12, 0xcb, 0x24, 0x9b, 0xd0, 0x7a, 0x93, 0xbb, 0x44, 0xc6, 0x25, 0x89, 0xda, 0x33, 0x05,
+	0xaa, 0xb2, 0xc7, 0xe8, 0x33, 0x28, 0x9e, 0x12, 0xc3, 0x22, 0x5e, 0x78, 0x4e, 0x6f, 0xbf, 0x24,
+	0xc4, 0x3e, 0x03, 0xe3, 0x90, 0x84, 0x6e, 0xc3, 0x1a, 0x09, 0xfd, 0xca, 0x32, 0xbf, 0x6e, 0xbe,
+	0x84, 0xcf, 0xbd, 0x5b, 0x92, 0xb4, 0x3f, 0x15, 0xb8, 0x96, 0xbe, 0x05, 0xda, 0x86, 0xf5, 0xa9,
+	0x6b, 0x89, 0x09, 0xbe, 0x1a, 0xa3, 0x16, 0xa0, 0x33, 0x8f, 0x9c, 0xdb, 0xee, 0xc2, 0xe7, 0x68,
+	0xa1, 0x66, 0xa5, 0xcc, 0xa0, 0x1d, 0xa8, 0x2e, 0xad, 0x7b, 0x8b, 0xd9, 0x4c, 0x78, 0x21, 0x12,
+	0x76, 0x39, 0xf9, 0xf3, 0x89, 0xe4, 0xa7, 0x08, 0x77, 0xfa, 0x35, 0x31, 0x83, 0xae, 0xbb, 0x70,
+	0x78, 0x3b, 0x94, 0xc7, 0xa2, 0x49, 0x7b, 0x96, 0x83, 0xab, 0xa9, 0x91, 0xcb, 0xad, 0x98, 0xf2,
+	0x86, 0xad, 0x58, 0xf6, 0x55, 0x5b, 0xb1, 0xbb, 0xb0, 0x69, 0x3b, 0xa6, 0x47, 0x0c, 0x9f, 0x74,
+	0x8c, 0x99, 0xe1, 0x98, 0x24, 0xbc, 0x20, 0x72, 0x42, 0x0d, 0xe2, 0xa8, 0x7e, 0x06, 0xcb, 0x44,
+	0xd4, 0x86, 0xf2, 0xdc, 0x76, 0x16, 0xc1, 0xb2, 0x1f, 0xc8, 0xa7, 0xde, 0xb4, 0x7d, 0x01, 0xd2,
+	0xcf, 0xe0, 0x18, 0x05, 0x1d, 0xc0, 0x15, 0x9f, 0x78, 0xe7, 0xc4, 0x1b, 0x38, 0x16, 0x79, 0x12,
+	0xae, 0xc3, 0x5f, 0xe2, 0x86, 0xdc, 0xdf, 0xc9, 0xb8, 0x7e, 0x06, 0x27, 0xc9, 0x9d, 0xff, 0xc0,
+	0x55, 0x92, 0xa6, 0xbc, 0xf6, 0xa3, 0x02, 0x9b, 0x52, 0x50, 0x17, 0x3e, 0x40, 0xca, 0x0b, 0x1e,
+	0xa0, 0x9b, 0x50, 0x09, 0x3c, 0xc3, 0xf1, 0x0d, 0x33, 0xb0, 0x5d, 0xda, 0x74, 0xf3, 0xb4, 0x8b,
+	0x1b, 0xd1, 0x16, 0x14, 0x6c, 0xea, 0x15, 0x53, 0x37, 0x8f, 0xf9, 0x00, 0x5d, 0x83, 0xa2, 0x31,
+	0x67, 0x49, 0x93, 0x67, 0xe6, 0x70, 0xa4, 0xed, 0x42, 0x59, 0x94, 0x09, 0x69, 0x92, 0xb2, 0x0a,
+	0x4b, 0xc2, 0x98, 0x4d, 0x6b, 0xc3, 0x95, 0x84, 0x24, 0xe8, 0xbd, 0x34, 0x3d, 0x39, 0x3b, 0x39,
+	0xa1, 0xfd, 0xa4, 0x80, 0x2a, 0x34, 0x93, 0xe8, 0x0b, 0x50, 0x43, 0xb9, 0xbb, 0xae, 0xb5, 0x7c,
+	0x13, 0xeb, 0x17, 0x77, 0x9f, 0x14, 0x85, 0x45, 0x0a, 0xda, 0x81, 0xc2, 0x8c, 0x9c, 0x93, 0x59,
+	0xf8, 0xe2, 0x6c, 0x49, 0xdc, 0x21, 0x9d, 0xc3, 0x1c, 0x42, 0xaf, 0x51, 0x38, 0x31, 0x21, 0x4f,
+	0xf8, 0x2b, 0x53, 0xc2, 0xa2, 0x49, 0xfb, 0x55, 0x81, 0xaa, 0xdc, 0x36, 0xa3, 0x1e, 0x54, 0x1c,
+	0xf2, 0x98, 0x1f, 0x2c, 0x35, 0x84, 0x77, 0xe8, 0xba, 0xec, 0xa6, 0x88, 0xe9, 0x67, 0x70, 0x9c,
+	0x84, 0xee, 0xc0, 0x86, 0x43, 0x1e, 0x73, 0xd1, 0xf9, 0x32, 0xd9, 0xd4, 0x77, 0x6a, 0x14, 0x03,
+	0xf5, 0x33, 0x58, 0xa2, 0x75, 0x50, 0xf2, 0x03, 0x40, 0xfb, 0x10, 0x2a, 0xb1, 0xed, 0x69, 0xef,
+	0xbc, 0xdc, 0x3e, 0x2c, 0x2b, 0xfc, 0x4c, 0x24, 0xab, 0x76, 0x00, 0x1b, 0xf1, 0x0d, 0x69, 0xbb,
+	0xb3, 0xda, 0x30, 0x24, 0x45, 0x06, 0xb9, 0x56, 0x65, 0x13, 0xb5, 0x6a, 0xa7, 0x09, 0xaa, 0xf0,
+	0xa1, 0x89, 0xd6, 0x21, 0x3f, 0x1c, 0x7c, 0xa5, 0x57, 0x33, 0x68, 0x13, 0x54, 0xac, 0x1f, 0x0c,
+	0xdb, 0x47, 0xc7, 0x9d, 0xf1, 0x78, 0x52, 0x55, 0x76, 0x1e, 0xb2, 0xfe, 0x68, 0xd5, 0x03, 0x54,
+	0xa0, 0x84, 0xf5, 0x2f, 0xef, 0xeb, 0x87, 0x13, 0xbd, 0x57, 0xcd, 0xa0, 0x32, 0xac, 0xb7, 0xbb,
+	0x5d, 0xfd, 0x80, 0x8e, 0x14, 0x3a, 0xc2, 0xfa, 0x5d, 0xbd, 0x4b, 0x47, 0x59, 0xd4, 0x80, 0xeb,
+	0xdd, 0xf1, 0xfe, 0xfe, 0x60, 0x32, 0xd1, 0x7b, 0xc7, 0x93, 0xf1, 0x71, 0x6f, 0x80, 0xf5, 0xee,
+	0x64, 0x8c, 0x8f, 0x8e, 0x3b, 0xc3, 0x71, 0xf7, 0x5e, 0x35, 0xb7, 0xf3, 0x2e, 0x14, 0xd8, 0xd1,
+	0xd3, 0xfd, 0x07, 0xa3, 0xbd, 0x71, 0x35, 0x83, 0x54, 0x58, 0x7b, 0xd0, 0xc6, 0xa3, 0xc1, 0xe8,
+	0x4e, 0x55, 0x41, 0x25, 0x28, 0xe8, 0x18, 0x8f, 0x71, 0x35, 0xbb, 0xa3, 0xc3, 0xa6, 0x94, 0x61,
+	0x14, 0x7a, 0x47, 0x1f, 0xe9, 0xb8, 0x3d, 0xe4, 0xbc, 0xc3, 0x49, 0x1b, 0x73, 0x3f, 0x00, 0x8a,
+	0x87, 0x47, 0xa3, 0x2e, 0xf3, 0xa2, 0x0c, 0xeb, 0x87, 0xfd, 0xfb, 0x93, 0xde, 0xf8, 0xc1, 0xa8,
+	0x9a, 0xeb, 0xb4, 0x7f, 0x7f, 0x5e, 0x57, 0xfe, 0x78, 0x5e, 0x57, 0xfe, 0x7e, 0x5e, 0x57, 0x7e,
+	0xf8, 0xa7, 0x9e, 0x81, 0x86, 0xe9, 0xce, 0x5b, 0xfc, 0x13, 0x3a, 0xfc, 0x63, 0xc5, 0xcf, 0xfa,
+	0x61, 0xfc, 0x9f, 0x0f, 0xd3, 0x22, 0x6b, 0xc9, 0xde, 0xff, 0x77, 0x00, 0xd6, 0xf6, 0x05, 0x99,
+	0xb6, 0x10, 0x00, 0x00,
 }
 
 func (m *FactomEvent) Marshal() (dAtA []byte, err error) {
@@ -1573,6 +1582,11 @@ func (m *FactomEvent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.SequenceNumber != 0 {
+		i = encodeVarintFactomEvents(dAtA, i, uint64(m.SequenceNumber))
+		i--
+		dAtA[i] = 0x60
+	}
 	if m.Event != nil {
 		{
 			size := m.Event.Size()
@@ -2728,6 +2742,9 @@ func (m *FactomEvent) Size() (n int) {
 	if m.Event != nil {
 		n += m.Event.Size()
 	}
+	if m.SequenceNumber != 0 {
+		n += 1 + sovFactomEvents(uint64(m.SequenceNumber))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -3674,6 +3691,25 @@ func (m *FactomEvent) Unmarshal(dAtA []byte) error {
 			}
 			m.Event = &FactomEvent_DirectoryBlockAnchor{v}
 			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SequenceNumber", wireType)
+			}
+			m.SequenceNumber = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFactomEvents
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SequenceNumber |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFactomEvents(dAtA[iNdEx:])
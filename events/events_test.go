@@ -16,6 +16,7 @@ import (
 	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/events/eventconfig"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/FactomProject/factomd/events/eventservices"
 	"github.com/FactomProject/factomd/testHelper"
 	"github.com/stretchr/testify/assert"
 
@@ -365,6 +366,9 @@ func (m *mockEventSender) ReplayDuringStartup() bool {
 func (m *mockEventSender) GetEventQueue() chan *eventmessages.FactomEvent {
 	return m.eventsOutQueue
 }
+func (m *mockEventSender) GetEventFilter() *eventservices.EventFilter {
+	return nil
+}
 func (m *mockEventSender) Shutdown() {}
 
 func (m *mockEventSender) IncreaseDroppedFromQueueCounter() {}
@@ -0,0 +1,124 @@
+package eventservices
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+)
+
+const (
+	EventTypeChainCommit    = "chain-commit"
+	EventTypeEntryCommit    = "entry-commit"
+	EventTypeEntryReveal    = "entry-reveal"
+	EventTypeStateChange    = "state-change"
+	EventTypeDirectoryBlock = "directory-block"
+	EventTypeAnchor         = "anchor"
+	EventTypeProcessList    = "process-list"
+	EventTypeNodeMessage    = "node-message"
+)
+
+// EventFilter selects the events that are sent to a receiver.
+// The chain filter only applies to events that refer to a chain: chain commits and entry reveals.
+// A nil filter accepts all events.
+type EventFilter struct {
+	eventTypes    map[string]bool
+	chainIDs      [][]byte
+	chainIDHashes [][]byte
+}
+
+// ParseEventFilter parses the comma separated event types and chain ids, returns nil if both are empty.
+func ParseEventFilter(eventTypes string, chainIDs string) (*EventFilter, error) {
+	filter := &EventFilter{}
+	for _, eventType := range splitList(eventTypes) {
+		switch eventType {
+		case EventTypeChainCommit, EventTypeEntryCommit, EventTypeEntryReveal, EventTypeStateChange,
+			EventTypeDirectoryBlock, EventTypeAnchor, EventTypeProcessList, EventTypeNodeMessage:
+		default:
+			return nil, fmt.Errorf("unknown event type %s", eventType)
+		}
+		if filter.eventTypes == nil {
+			filter.eventTypes = make(map[string]bool)
+		}
+		filter.eventTypes[eventType] = true
+	}
+	for _, chainID := range splitList(chainIDs) {
+		hash, err := primitives.HexToHash(chainID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain id %s: %v", chainID, err)
+		}
+		filter.chainIDs = append(filter.chainIDs, hash.Bytes())
+		filter.chainIDHashes = append(filter.chainIDHashes, primitives.Shad(hash.Bytes()).Bytes())
+	}
+
+	if filter.eventTypes == nil && filter.chainIDs == nil {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if len(item) > 0 {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (filter *EventFilter) Accepts(event *eventmessages.FactomEvent) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.eventTypes != nil && !filter.eventTypes[EventTypeOf(event)] {
+		return false
+	}
+	if filter.chainIDs == nil {
+		return true
+	}
+
+	switch {
+	case event.GetChainCommit() != nil:
+		return containsBytes(filter.chainIDHashes, event.GetChainCommit().ChainIDHash)
+	case event.GetEntryReveal() != nil && event.GetEntryReveal().Entry != nil:
+		return containsBytes(filter.chainIDs, event.GetEntryReveal().Entry.ChainID)
+	}
+	return true
+}
+
+func containsBytes(list [][]byte, value []byte) bool {
+	for _, item := range list {
+		if bytes.Equal(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventTypeOf returns the event type name of the factom event as used in the event filter configuration
+func EventTypeOf(event *eventmessages.FactomEvent) string {
+	switch event.Event.(type) {
+	case *eventmessages.FactomEvent_ChainCommit:
+		return EventTypeChainCommit
+	case *eventmessages.FactomEvent_EntryCommit:
+		return EventTypeEntryCommit
+	case *eventmessages.FactomEvent_EntryReveal:
+		return EventTypeEntryReveal
+	case *eventmessages.FactomEvent_StateChange:
+		return EventTypeStateChange
+	case *eventmessages.FactomEvent_DirectoryBlockCommit:
+		return EventTypeDirectoryBlock
+	case *eventmessages.FactomEvent_DirectoryBlockAnchor:
+		return EventTypeAnchor
+	case *eventmessages.FactomEvent_ProcessListEvent:
+		return EventTypeProcessList
+	case *eventmessages.FactomEvent_NodeMessage:
+		return EventTypeNodeMessage
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,62 @@
+package eventservices
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	testCases := map[string]struct {
+		EventTypes string
+		ChainIDs   string
+		IsNil      bool
+		IsError    bool
+	}{
+		"empty":           {"", "", true, false},
+		"whitespace":      {" , ", "", true, false},
+		"types":           {"entry-reveal, Directory-Block", "", false, false},
+		"chains":          {"", "888888b1255ea1cc6b9d6bb4a6b6b58d6b0e1e1d3c2d1e5d84e3e7a5a3b2c1d0", false, false},
+		"unknown-type":    {"entry", "", true, true},
+		"invalid-chain":   {"", "xyz", true, true},
+		"types-and-chain": {"chain-commit", "888888b1255ea1cc6b9d6bb4a6b6b58d6b0e1e1d3c2d1e5d84e3e7a5a3b2c1d0", false, false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			filter, err := ParseEventFilter(testCase.EventTypes, testCase.ChainIDs)
+			assert.Equal(t, testCase.IsError, err != nil, "error: %v", err)
+			assert.Equal(t, testCase.IsNil, filter == nil)
+		})
+	}
+}
+
+func TestEventFilter_Accepts(t *testing.T) {
+	chainID, _ := primitives.HexToHash("888888b1255ea1cc6b9d6bb4a6b6b58d6b0e1e1d3c2d1e5d84e3e7a5a3b2c1d0")
+	otherChainID := primitives.NewZeroHash()
+
+	reveal := func(chainID []byte) *eventmessages.FactomEvent {
+		return &eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_EntryReveal{EntryReveal: &eventmessages.EntryReveal{Entry: &eventmessages.EntryBlockEntry{ChainID: chainID}}}}
+	}
+	commit := func(chainID []byte) *eventmessages.FactomEvent {
+		return &eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_ChainCommit{ChainCommit: &eventmessages.ChainCommit{ChainIDHash: primitives.Shad(chainID).Bytes()}}}
+	}
+	nodeMessage := &eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_NodeMessage{NodeMessage: &eventmessages.NodeMessage{}}}
+
+	var nilFilter *EventFilter
+	assert.True(t, nilFilter.Accepts(nodeMessage))
+
+	typeFilter, _ := ParseEventFilter("entry-reveal", "")
+	assert.True(t, typeFilter.Accepts(reveal(otherChainID.Bytes())))
+	assert.False(t, typeFilter.Accepts(commit(chainID.Bytes())))
+	assert.False(t, typeFilter.Accepts(nodeMessage))
+
+	chainFilter, _ := ParseEventFilter("", chainID.String())
+	assert.True(t, chainFilter.Accepts(reveal(chainID.Bytes())))
+	assert.False(t, chainFilter.Accepts(reveal(otherChainID.Bytes())))
+	assert.True(t, chainFilter.Accepts(commit(chainID.Bytes())))
+	assert.False(t, chainFilter.Accepts(commit(otherChainID.Bytes())))
+	assert.True(t, chainFilter.Accepts(nodeMessage))
+}
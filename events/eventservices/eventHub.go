@@ -0,0 +1,127 @@
+package eventservices
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/events/eventconfig"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultSubscriberQueueSize = 1000
+
+	// subscribers always receive the content of entries, the filtering is done per subscription
+	SubscriberBroadcastContent = eventconfig.BroadcastAlways
+)
+
+var eventHubInstance *EventHub
+var eventHubMutex sync.Mutex
+
+// EventHub distributes factom events to in-process subscribers like the websocket subscriptions of the wsapi server.
+// Every subscriber has its own queue, when a subscriber can't keep up only its own events are dropped.
+type EventHub struct {
+	mutex                   sync.RWMutex
+	subscribers             map[*EventSubscriber]struct{}
+	droppedFromQueueCounter prometheus.Counter
+	sequence                uint64
+}
+
+type EventSubscriber struct {
+	hub       *EventHub
+	queue     chan *SubscriberEvent
+	closeOnce sync.Once
+}
+
+// SubscriberEvent is a factom event with the message it was mapped from, nil for events that are not about a
+// message. The state change of a factom event doesn't tell what kind of entity changed, the message does.
+type SubscriberEvent struct {
+	*eventmessages.FactomEvent
+	Payload interfaces.IMsg
+}
+
+// GetEventHub returns the event hub shared by the event emitter and the subscribers.
+func GetEventHub() *EventHub {
+	eventHubMutex.Lock()
+	defer eventHubMutex.Unlock()
+
+	if eventHubInstance == nil {
+		eventHubInstance = NewEventHub()
+		prometheus.MustRegister(eventHubInstance.droppedFromQueueCounter)
+	}
+	return eventHubInstance
+}
+
+func NewEventHub() *EventHub {
+	hub := &EventHub{
+		subscribers: make(map[*EventSubscriber]struct{}),
+	}
+	hub.droppedFromQueueCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_livefeed_subscriber_dropped_counter",
+		Help: "Number of times we dropped events due of a full subscriber queue",
+	})
+	return hub
+}
+
+func (hub *EventHub) Subscribe() *EventSubscriber {
+	subscriber := &EventSubscriber{
+		hub:   hub,
+		queue: make(chan *SubscriberEvent, defaultSubscriberQueueSize),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	hub.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (hub *EventHub) Unsubscribe(subscriber *EventSubscriber) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if _, ok := hub.subscribers[subscriber]; ok {
+		delete(hub.subscribers, subscriber)
+		close(subscriber.queue)
+	}
+}
+
+func (hub *EventHub) HasSubscribers() bool {
+	if hub == nil {
+		return false
+	}
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+	return len(hub.subscribers) > 0
+}
+
+// Publish offers the event to every subscriber without blocking the caller.
+// Subscribers can detect dropped events by a gap in the sequence numbers. The payload is the message the event
+// was mapped from, if any.
+func (hub *EventHub) Publish(event *eventmessages.FactomEvent, payload interfaces.IMsg) {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	event.SequenceNumber = atomic.AddUint64(&hub.sequence, 1)
+	subscriberEvent := &SubscriberEvent{FactomEvent: event, Payload: payload}
+
+	for subscriber := range hub.subscribers {
+		select {
+		case subscriber.queue <- subscriberEvent:
+		default:
+			hub.droppedFromQueueCounter.Inc()
+		}
+	}
+}
+
+// Events returns the queue of the subscriber, it is closed when the subscriber is unsubscribed.
+func (subscriber *EventSubscriber) Events() <-chan *SubscriberEvent {
+	return subscriber.queue
+}
+
+func (subscriber *EventSubscriber) Close() {
+	subscriber.closeOnce.Do(func() {
+		subscriber.hub.Unsubscribe(subscriber)
+	})
+}
@@ -0,0 +1,49 @@
+package eventservices
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHub_Publish(t *testing.T) {
+	hub := NewEventHub()
+	assert.False(t, hub.HasSubscribers())
+
+	first := hub.Subscribe()
+	second := hub.Subscribe()
+	assert.True(t, hub.HasSubscribers())
+
+	event := &eventmessages.FactomEvent{EventSource: eventmessages.EventSource_LIVE}
+	hub.Publish(event, nil)
+
+	assert.Equal(t, event, (<-first.Events()).FactomEvent)
+	assert.Equal(t, event, (<-second.Events()).FactomEvent)
+
+	first.Close()
+	first.Close() // closing twice is allowed
+	_, ok := <-first.Events()
+	assert.False(t, ok)
+
+	second.Close()
+	assert.False(t, hub.HasSubscribers())
+}
+
+func TestEventHub_PublishFullQueue(t *testing.T) {
+	hub := NewEventHub()
+	subscriber := hub.Subscribe()
+	defer subscriber.Close()
+
+	for i := 0; i < defaultSubscriberQueueSize+10; i++ {
+		hub.Publish(&eventmessages.FactomEvent{}, nil)
+	}
+
+	assert.Equal(t, defaultSubscriberQueueSize, len(subscriber.Events()))
+	assert.Equal(t, float64(10), getCounterValue(t, hub.droppedFromQueueCounter))
+}
+
+func TestEventHub_NilHasNoSubscribers(t *testing.T) {
+	var hub *EventHub
+	assert.False(t, hub.HasSubscribers())
+}
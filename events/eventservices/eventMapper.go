@@ -64,6 +64,9 @@ func mapRegistrationEvent(registrationEvent *eventinput.RegistrationEvent, broad
 			} else {
 				return nil, nil
 			}
+		case *messages.FactoidTransaction:
+			factoidTransaction := msg.(*messages.FactoidTransaction)
+			event.Event = mapFactoidTransactionState(eventmessages.EntityState_REQUESTED, factoidTransaction)
 		default:
 			return nil, errors.New("unknown message type")
 		}
@@ -100,6 +103,9 @@ func mapStateChangeEvent(stateChangeEvent *eventinput.StateChangeEvent, broadcas
 			} else if shouldIncludeContent {
 				event.Event = mapRevealEntryEvent(stateChangeEvent.GetEntityState(), revealEntryMsg)
 			}
+		case *messages.FactoidTransaction:
+			factoidTransaction := msg.(*messages.FactoidTransaction)
+			event.Event = mapFactoidTransactionState(stateChangeEvent.GetEntityState(), factoidTransaction)
 		default:
 			return nil, errors.New("unknown message type")
 		}
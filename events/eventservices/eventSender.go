@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
 	"time"
@@ -27,17 +28,21 @@ const (
 	defaultConnectionPort = 8040
 	defaultOutputFormat   = eventconfig.Protobuf
 	protocolVersion       = byte(1)
+	// the durable protocol starts with a handshake in which the receiver sends the last sequence number it received
+	durableProtocolVersion = byte(2)
 )
 
 var (
 	dialRetryPostponeDuration = 5 * time.Minute
 	redialSleepDuration       = 10 * time.Second
 	sendRetries               = 3
+	handshakeTimeout          = 10 * time.Second
 )
 
 type EventSender interface {
 	// Send(event eventinput.EventInput) error
 	GetBroadcastContent() eventconfig.BroadcastContent
+	GetEventFilter() *EventFilter
 	Shutdown()
 	IsSendStateChangeEvents() bool
 	ReplayDuringStartup() bool
@@ -52,46 +57,167 @@ type eventSender struct {
 	connection              net.Conn
 	droppedFromQueueCounter prometheus.Counter
 	notSentCounter          prometheus.Counter
+	evictedCounter          prometheus.Counter
+
+	// sequence is the sequence number of the last event, it is only used when events are not spooled
+	sequence uint64
+	// spool keeps the events on disk for durable delivery, the cursor is the sequence number of the last event sent
+	// to the receiver. After every reconnect the cursor is set to the sequence number the receiver sends in the handshake.
+	spool       *eventSpool
+	cursor      uint64
+	connections int
 }
 
+// NewEventSender creates the sender of the default receiver, it returns nil if the receiver is skipped
 func NewEventSender(config *util.FactomdConfig, factomParams *globals.FactomParams) EventSender {
-	return NewEventSenderTo(selectParameters(factomParams, config))
+	params := selectParameters(factomParams, config)
+	if params == nil {
+		return nil
+	}
+	switch params.Protocol {
+	case protocolHTTP, protocolHTTPS, protocolFile:
+		return newEventSenderFor(params)
+	}
+	return NewEventSenderTo(params)
+}
+
+// NewEventSenders creates the sender of the default receiver and a sender for every additional receiver in the config.
+func NewEventSenders(config *util.FactomdConfig, factomParams *globals.FactomParams) []EventSender {
+	var eventSenders []EventSender
+	if eventSender := NewEventSender(config, factomParams); eventSender != nil {
+		eventSenders = append(eventSenders, eventSender)
+	}
+	for _, params := range selectReceiverParameters(config) {
+		eventSenders = append(eventSenders, newEventSenderFor(params))
+	}
+	return eventSenders
 }
 
 func NewEventSenderTo(params *EventServiceParams) EventSender {
 	if eventSenderInstance == nil {
-		eventSenderInstance = &eventSender{
-			eventsOutQueue: make(chan *eventmessages.FactomEvent, 5000),
-			params:         params,
-		}
+		eventSenderInstance = newEventSender(params)
+	}
+	return eventSenderInstance
+}
 
-		eventSenderInstance.droppedFromQueueCounter = prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "factomd_livefeed_dropped_from_queue_counter",
-			Help: "Number of times we dropped events due of a full the event queue",
-		})
-		eventSenderInstance.notSentCounter = prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "factomd_livefeed_not_send_counter",
-			Help: "Number of times we couldn't send out an event",
-		})
+func newEventSender(params *EventServiceParams) *eventSender {
+	eventSender := &eventSender{
+		eventsOutQueue: make(chan *eventmessages.FactomEvent, 5000),
+		params:         params,
+	}
 
-		go eventSenderInstance.processEventsChannel()
+	eventSender.droppedFromQueueCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "factomd_livefeed_dropped_from_queue_counter",
+		Help:        "Number of times we dropped events due of a full the event queue",
+		ConstLabels: prometheus.Labels{"receiver": params.Name},
+	})
+	eventSender.notSentCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "factomd_livefeed_not_send_counter",
+		Help:        "Number of times we couldn't send out an event",
+		ConstLabels: prometheus.Labels{"receiver": params.Name},
+	})
+	eventSender.evictedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "factomd_livefeed_spool_evicted_counter",
+		Help:        "Number of events that were removed from the spool before the receiver received them",
+		ConstLabels: prometheus.Labels{"receiver": params.Name},
+	})
+
+	if params.DurableDelivery {
+		spool, err := openEventSpool(params.SpoolPath, params.SpoolMaxSize)
+		if err != nil {
+			log.Errorf("Failed to open the event spool of receiver %s, falling back to best effort delivery: %v", params.Address, err)
+		} else {
+			eventSender.spool = spool
+			eventSender.cursor = spool.LastSequence()
+		}
 	}
-	return eventSenderInstance
+
+	go eventSender.processEventsChannel()
+	return eventSender
 }
 
-// TODO describe choice of dropping events.
+// Without durable delivery events are dropped while the receiver is unreachable, so a broken receiver doesn't
+// hold up the node. With durable delivery every event is spooled to disk first and sent from the spool, events
+// are only lost when the spool reaches its maximum size.
 func (eventSender *eventSender) processEventsChannel() {
 	eventSender.connect()
 
 	for event := range eventSender.eventsOutQueue {
-		if eventSender.postponeSendingUntil.IsZero() || eventSender.postponeSendingUntil.Before(time.Now()) {
-			eventSender.sendEvent(event)
+		// the event may be shared with other receivers, the sequence number is assigned to a copy
+		sequencedEvent := *event
+		if eventSender.spool != nil {
+			if _, err := eventSender.spool.Append(&sequencedEvent); err != nil {
+				log.Errorf("An error occurred while spooling an event for receiver %s: %v", eventSender.params.Address, err)
+				eventSender.notSentCounter.Inc()
+			}
+			if !eventSender.isPostponed() {
+				eventSender.sendSpooledEvents()
+			}
+			continue
+		}
+
+		eventSender.sequence++
+		sequencedEvent.SequenceNumber = eventSender.sequence
+		if !eventSender.isPostponed() {
+			eventSender.sendEvent(&sequencedEvent)
 		} else {
 			eventSender.notSentCounter.Inc()
 		}
 	}
 }
 
+func (eventSender *eventSender) isPostponed() bool {
+	return !eventSender.postponeSendingUntil.IsZero() && eventSender.postponeSendingUntil.After(time.Now())
+}
+
+var errReconnected = errors.New("reconnected to the receiver")
+var errSendFailed = errors.New("failed to send event")
+
+// sendSpooledEvents sends the spooled events after the cursor. When the sender reconnects while sending, the cursor
+// is moved to the sequence number from the handshake and sending restarts from there.
+func (eventSender *eventSender) sendSpooledEvents() {
+	for restarts := 0; ; restarts++ {
+		if restarts > sendRetries {
+			// the receiver keeps accepting connections without receiving events
+			eventSender.postponeSendingUntil = time.Now().Add(dialRetryPostponeDuration)
+			return
+		}
+		if first := eventSender.spool.FirstSequence(); eventSender.cursor+1 < first {
+			log.Warnf("Receiver %s missed events %d to %d, they are no longer in the spool", eventSender.params.Address, eventSender.cursor+1, first-1)
+			eventSender.evictedCounter.Add(float64(first - eventSender.cursor - 1))
+			eventSender.cursor = first - 1
+		}
+
+		connections := eventSender.connections
+		err := eventSender.spool.ReadFrom(eventSender.cursor, func(event *eventmessages.FactomEvent) error {
+			data, err := eventSender.marshallMessage(event)
+			if err != nil {
+				log.Errorf("An error occurred while serializing factom event %d: %v", event.SequenceNumber, err)
+				eventSender.notSentCounter.Inc()
+				eventSender.cursor = event.SequenceNumber
+				return nil
+			}
+			if !eventSender.writeWithRetries(data) {
+				eventSender.postponeSendingUntil = time.Now().Add(dialRetryPostponeDuration)
+				return errSendFailed
+			}
+			if eventSender.connections != connections {
+				return errReconnected
+			}
+			eventSender.cursor = event.SequenceNumber
+			restarts = 0
+			return nil
+		})
+		if err == errReconnected {
+			continue
+		}
+		if err != nil && err != errSendFailed {
+			log.Errorf("An error occurred while reading the event spool of receiver %s: %v", eventSender.params.Address, err)
+		}
+		return
+	}
+}
+
 func (eventSender *eventSender) sendEvent(event *eventmessages.FactomEvent) {
 	data, err := eventSender.marshallMessage(event)
 	if err != nil {
@@ -100,6 +226,16 @@ func (eventSender *eventSender) sendEvent(event *eventmessages.FactomEvent) {
 		return
 	}
 
+	if !eventSender.writeWithRetries(data) {
+		eventSender.notSentCounter.Inc()
+		eventSender.postponeSendingUntil = time.Now().Add(dialRetryPostponeDuration)
+	}
+}
+
+// writeWithRetries writes the data to the receiver, it reconnects when writing fails
+func (eventSender *eventSender) writeWithRetries(data []byte) bool {
+	var err error
+	connections := eventSender.connections
 	// retry sending event ... times
 	sendSuccessful := false
 	for retry := 0; (eventSender.params.PersistentReconnect || retry < sendRetries) && !sendSuccessful; retry++ {
@@ -108,6 +244,10 @@ func (eventSender *eventSender) sendEvent(event *eventmessages.FactomEvent) {
 			time.Sleep(redialSleepDuration)
 			continue
 		}
+		if eventSender.spool != nil && eventSender.connections != connections {
+			// the receiver told where to resume in the handshake, the events are sent again from the spool
+			return true
+		}
 
 		// send the factom event to the live api
 		if err = eventSender.writeEvent(data); err == nil {
@@ -121,11 +261,7 @@ func (eventSender *eventSender) sendEvent(event *eventmessages.FactomEvent) {
 			time.Sleep(redialSleepDuration)
 		}
 	}
-
-	if !sendSuccessful {
-		eventSender.notSentCounter.Inc()
-		eventSender.postponeSendingUntil = time.Now().Add(dialRetryPostponeDuration)
-	}
+	return sendSuccessful
 }
 
 func (eventSender *eventSender) marshallMessage(event *eventmessages.FactomEvent) ([]byte, error) {
@@ -160,12 +296,41 @@ func (eventSender *eventSender) connect() error {
 		if err != nil {
 			return fmt.Errorf("failed to connect: %v", err)
 		}
+		if eventSender.spool != nil {
+			lastSequence, err := readHandshake(conn)
+			if err != nil {
+				conn.Close()
+				return fmt.Errorf("failed handshake: %v", err)
+			}
+			eventSender.cursor = lastSequence
+			if last := eventSender.spool.LastSequence(); eventSender.cursor > last {
+				// the receiver knows more events than the spool, it has been reset: start over
+				eventSender.cursor = 0
+			}
+		}
 		eventSender.connection = conn
+		eventSender.connections++
 		eventSender.postponeSendingUntil = time.Time{}
 	}
 	return nil
 }
 
+// readHandshake reads the handshake of the receiver: the durable protocol version followed by the
+// sequence number of the last event it received as uint64 little endian, zero if it has none.
+func readHandshake(conn net.Conn) (uint64, error) {
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	handshake := make([]byte, 9)
+	if _, err := io.ReadFull(conn, handshake); err != nil {
+		return 0, fmt.Errorf("failed to read handshake: %v", err)
+	}
+	if handshake[0] != durableProtocolVersion {
+		return 0, fmt.Errorf("unsupported protocol version %d", handshake[0])
+	}
+	return binary.LittleEndian.Uint64(handshake[1:]), nil
+}
+
 func catchConnectPanics() error {
 	if r := recover(); r != nil {
 		return errors.New(fmt.Sprintf("failed to connect to receiver: %v", r))
@@ -195,7 +360,11 @@ func (eventSender *eventSender) writeEvent(data []byte) (err error) {
 	defer catchSendPanics()
 
 	writer := bufio.NewWriter(eventSender.connection)
-	writer.WriteByte(protocolVersion)
+	if eventSender.spool != nil {
+		writer.WriteByte(durableProtocolVersion)
+	} else {
+		writer.WriteByte(protocolVersion)
+	}
 	writer.Flush() // Flush this already to expedite a possible broken pipe which will only be detected in the second flush (unless there hasn't been any traffic for a few minutes)
 
 	dataSize := int32(len(data))
@@ -230,6 +399,10 @@ func (eventSender *eventSender) GetBroadcastContent() eventconfig.BroadcastConte
 	return eventSender.params.BroadcastContent
 }
 
+func (eventSender *eventSender) GetEventFilter() *EventFilter {
+	return eventSender.params.Filter
+}
+
 func (eventSender *eventSender) IsSendStateChangeEvents() bool {
 	return eventSender.params.SendStateChangeEvents
 }
@@ -249,5 +422,10 @@ func (eventSender *eventSender) Shutdown() {
 	}
 	close(eventSender.eventsOutQueue)
 	eventSender.disconnect()
-	eventSenderInstance = nil
+	if eventSender.spool != nil {
+		eventSender.spool.Close()
+	}
+	if eventSenderInstance == eventSender {
+		eventSenderInstance = nil
+	}
 }
@@ -3,8 +3,10 @@ package eventservices
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
 	"strings"
@@ -15,6 +17,7 @@ import (
 	"github.com/FactomProject/factomd/events/eventconfig"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
 	"github.com/FactomProject/factomd/util/atomic"
+	"github.com/gogo/protobuf/proto"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/sirupsen/logrus"
@@ -34,7 +37,7 @@ func TestEventsService_Send(t *testing.T) {
 	var finished atomic.AtomicBool
 	finished.Store(false)
 
-	expectedMessage := `{"identityChainID":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=","Event":{"nodeMessage":{"messageText":"test message of node: node name"}}}`
+	expectedMessage := `{"identityChainID":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=","Event":{"nodeMessage":{"messageText":"test message of node: node name"}},"sequenceNumber":1}`
 
 	// mock server by reading everything until stop byte is found
 	// use the stop byte to stop as soon as possible, note: don't use stop byte in test message before the end
@@ -63,6 +66,7 @@ func TestEventsService_Send(t *testing.T) {
 	params := &EventServiceParams{
 		OutputFormat: eventconfig.Json,
 	}
+	eventSenderInstance = nil
 	NewEventSenderTo(params)
 
 	// set connection
@@ -598,3 +602,89 @@ func getCounterValue(t *testing.T, counter prometheus.Counter) float64 {
 	}
 	return *metric.Counter.Value
 }
+
+func TestEventsService_DurableDeliveryReplay(t *testing.T) {
+	redialSleepDuration = 1 * time.Millisecond
+	sendRetries = 3
+
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatalf("setup test failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("setup test failed: %v", err)
+	}
+	defer listener.Close()
+
+	// the receiver reads three events on the first connection, and acknowledges only two of them on the second
+	received := make(chan uint64, 10)
+	go func() {
+		for _, lastSequence := range []uint64{0, 2} {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			handshake := make([]byte, 9)
+			handshake[0] = durableProtocolVersion
+			binary.LittleEndian.PutUint64(handshake[1:], lastSequence)
+			conn.Write(handshake)
+
+			reader := bufio.NewReader(conn)
+			for i := 0; lastSequence > 0 || i < 3; i++ {
+				event, err := readDurableFrame(reader)
+				if err != nil {
+					break
+				}
+				received <- event.SequenceNumber
+			}
+			conn.Close()
+		}
+	}()
+
+	eventService := newEventSender(&EventServiceParams{
+		Name:            "durable",
+		Protocol:        "tcp",
+		Address:         listener.Addr().String(),
+		OutputFormat:    eventconfig.Protobuf,
+		DurableDelivery: true,
+		SpoolPath:       dir,
+		SpoolMaxSize:    1024 * 1024,
+	})
+	defer eventService.Shutdown()
+
+	for i := 0; i < 5; i++ {
+		eventService.eventsOutQueue <- newSpoolTestEvent("durable")
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	var sequences []uint64
+	timeout := time.After(10 * time.Second)
+	for len(sequences) < 6 {
+		select {
+		case sequence := <-received:
+			sequences = append(sequences, sequence)
+		case <-timeout:
+			t.Fatalf("received only events %v", sequences)
+		}
+	}
+	// after the reconnect the events following the acknowledged event are sent again
+	assert.Equal(t, []uint64{1, 2, 3, 3, 4, 5}, sequences)
+}
+
+func readDurableFrame(reader *bufio.Reader) (*eventmessages.FactomEvent, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	if header[0] != durableProtocolVersion {
+		return nil, fmt.Errorf("unexpected protocol version %d", header[0])
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	event := &eventmessages.FactomEvent{}
+	return event, proto.Unmarshal(data, event)
+}
@@ -2,6 +2,10 @@ package eventservices
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/FactomProject/factomd/common/globals"
 	"github.com/FactomProject/factomd/events/eventconfig"
@@ -9,7 +13,14 @@ import (
 	"github.com/FactomProject/factomd/util"
 )
 
+const (
+	defaultReceiverName = "default"
+	defaultSpoolDir     = "livefeed"
+	defaultSpoolMaxSize = 512 // MB
+)
+
 type EventServiceParams struct {
+	Name                  string
 	EnableLiveFeedAPI     bool
 	Protocol              string
 	Address               string
@@ -19,10 +30,24 @@ type EventServiceParams struct {
 	SendStateChangeEvents bool
 	BroadcastContent      eventconfig.BroadcastContent
 	PersistentReconnect   bool
+	Filter                *EventFilter
+	DurableDelivery       bool
+	SpoolPath             string
+	SpoolMaxSize          int64
+	URLPath               string
+	WebhookSecret         string
+	BatchSize             int
+	BatchInterval         time.Duration
+	FilePath              string
+	FileMaxSize           int64
+	FileMaxBackups        int
 }
 
+// selectParameters returns the parameters of the default receiver, or nil if its event filter is invalid, like
+// selectReceiverParameters skips additional receivers with an invalid filter.
 func selectParameters(factomParams *globals.FactomParams, config *util.FactomdConfig) *EventServiceParams {
 	params := new(EventServiceParams)
+	params.Name = defaultReceiverName
 	if factomParams != nil && len(factomParams.EventReceiverProtocol) > 0 {
 		params.Protocol = factomParams.EventReceiverProtocol
 	} else if config != nil && len(config.LiveFeedAPI.EventReceiverProtocol) > 0 {
@@ -72,5 +97,140 @@ func selectParameters(factomParams *globals.FactomParams, config *util.FactomdCo
 		params.BroadcastContent = eventconfig.BroadcastOnce
 	}
 
+	if config != nil {
+		params.Filter, err = ParseEventFilter(config.LiveFeedAPI.EventFilterTypes, config.LiveFeedAPI.EventFilterChainIDs)
+		if err != nil {
+			log.LogPrintf("livefeed", "Live feed receiver %s is skipped, the event filter could not be parsed: %v", params.Name, err)
+			return nil
+		}
+	}
+
+	durableDelivery := (factomParams != nil && factomParams.EventDurableDelivery) || (config != nil && config.LiveFeedAPI.EventDurableDelivery)
+	if durableDelivery {
+		spoolMaxSize := 0
+		if config != nil {
+			spoolMaxSize = config.LiveFeedAPI.EventSpoolMaxSize
+		}
+		selectDurableDelivery(params, config, spoolMaxSize)
+	}
+
+	if config != nil {
+		selectSinkParameters(params, config, &util.LiveFeedReceiverConfig{
+			EventReceiverPath:   config.LiveFeedAPI.EventReceiverPath,
+			EventWebhookSecret:  config.LiveFeedAPI.EventWebhookSecret,
+			EventBatchSize:      config.LiveFeedAPI.EventBatchSize,
+			EventBatchInSeconds: config.LiveFeedAPI.EventBatchInSeconds,
+			EventFilePath:       config.LiveFeedAPI.EventFilePath,
+			EventFileMaxSize:    config.LiveFeedAPI.EventFileMaxSize,
+			EventFileMaxBackups: config.LiveFeedAPI.EventFileMaxBackups,
+		})
+	}
+
 	return params
 }
+
+// selectReceiverParameters returns the parameters of the additional receivers, defined as [LiveFeedReceiver "name"] sections in the config.
+func selectReceiverParameters(config *util.FactomdConfig) []*EventServiceParams {
+	if config == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(config.LiveFeedReceiver))
+	for name := range config.LiveFeedReceiver {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	receivers := make([]*EventServiceParams, 0, len(names))
+	for _, name := range names {
+		receiverConfig := config.LiveFeedReceiver[name]
+		if receiverConfig == nil {
+			continue
+		}
+		if receiverConfig.EventReceiverProtocol != protocolFile && (len(receiverConfig.EventReceiverHost) == 0 || receiverConfig.EventReceiverPort <= 0) {
+			log.LogPrintf("livefeed", "Live feed receiver %s is skipped, it has no EventReceiverHost or EventReceiverPort", name)
+			continue
+		}
+
+		params := new(EventServiceParams)
+		params.Name = name
+		params.EnableLiveFeedAPI = config.LiveFeedAPI.EnableLiveFeedAPI
+		params.ReplayDuringStartup = config.LiveFeedAPI.EventReplayDuringStartup
+		params.Address = fmt.Sprintf("%s:%d", receiverConfig.EventReceiverHost, receiverConfig.EventReceiverPort)
+		params.Protocol = defaultProtocol
+		if len(receiverConfig.EventReceiverProtocol) > 0 {
+			params.Protocol = receiverConfig.EventReceiverProtocol
+		}
+		if receiverConfig.EventSenderPort > 0 {
+			params.ClientPort = fmt.Sprintf(":%d", receiverConfig.EventSenderPort)
+		}
+		params.OutputFormat = eventconfig.EventFormatFrom(receiverConfig.EventFormat, defaultOutputFormat)
+		params.SendStateChangeEvents = receiverConfig.EventSendStateChange
+		params.PersistentReconnect = receiverConfig.PersistentReconnect
+
+		var err error
+		params.BroadcastContent = eventconfig.BroadcastOnce
+		if len(receiverConfig.EventBroadcastContent) > 0 {
+			params.BroadcastContent, err = eventconfig.ParseBroadcastContent(receiverConfig.EventBroadcastContent)
+			if err != nil {
+				log.LogPrintf("livefeed", "Configuration property LiveFeedReceiver %s EventBroadcastContent could not be parsed: %v", name, err)
+				params.BroadcastContent = eventconfig.BroadcastOnce
+			}
+		}
+		params.Filter, err = ParseEventFilter(receiverConfig.EventFilterTypes, receiverConfig.EventFilterChainIDs)
+		if err != nil {
+			log.LogPrintf("livefeed", "Live feed receiver %s is skipped, the event filter could not be parsed: %v", name, err)
+			continue
+		}
+		if receiverConfig.EventDurableDelivery {
+			selectDurableDelivery(params, config, receiverConfig.EventSpoolMaxSize)
+		}
+		selectSinkParameters(params, config, receiverConfig)
+		receivers = append(receivers, params)
+	}
+	return receivers
+}
+
+// selectDurableDelivery enables durable delivery for tcp receivers, every receiver spools into its own directory.
+func selectDurableDelivery(params *EventServiceParams, config *util.FactomdConfig, spoolMaxSize int) {
+	if params.Protocol != "tcp" {
+		log.LogPrintf("livefeed", "Durable delivery of live feed receiver %s is disabled, it is only supported for tcp", params.Name)
+		return
+	}
+
+	spoolPath := ""
+	if config != nil {
+		spoolPath = config.LiveFeedAPI.EventSpoolPath
+		if len(spoolPath) == 0 {
+			spoolPath = filepath.Join(config.App.HomeDir, defaultSpoolDir)
+		}
+	}
+	if len(spoolPath) == 0 {
+		spoolPath = filepath.Join(util.GetHomeDir(), ".factom", "m2", defaultSpoolDir)
+	}
+	if spoolMaxSize <= 0 {
+		spoolMaxSize = defaultSpoolMaxSize
+	}
+
+	params.DurableDelivery = true
+	params.SpoolPath = filepath.Join(spoolPath, params.Name)
+	params.SpoolMaxSize = int64(spoolMaxSize) * 1024 * 1024
+}
+
+// selectSinkParameters selects the settings of the webhook and file senders
+func selectSinkParameters(params *EventServiceParams, config *util.FactomdConfig, receiverConfig *util.LiveFeedReceiverConfig) {
+	params.URLPath = receiverConfig.EventReceiverPath
+	if !strings.HasPrefix(params.URLPath, "/") {
+		params.URLPath = "/" + params.URLPath
+	}
+	params.WebhookSecret = receiverConfig.EventWebhookSecret
+	params.BatchSize = receiverConfig.EventBatchSize
+	params.BatchInterval = time.Duration(receiverConfig.EventBatchInSeconds) * time.Second
+
+	params.FilePath = receiverConfig.EventFilePath
+	if len(params.FilePath) == 0 {
+		params.FilePath = filepath.Join(config.App.HomeDir, defaultSpoolDir, params.Name+".ndjson")
+	}
+	params.FileMaxSize = int64(receiverConfig.EventFileMaxSize) * 1024 * 1024
+	params.FileMaxBackups = receiverConfig.EventFileMaxBackups
+}
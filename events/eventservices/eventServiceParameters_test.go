@@ -2,7 +2,9 @@ package eventservices
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/FactomProject/factomd/common/globals"
 	"github.com/FactomProject/factomd/events/eventconfig"
@@ -123,30 +125,117 @@ func TestEventServiceParameters_ParseBroadcastErrorOverride(t *testing.T) {
 	assert.Equal(t, eventconfig.BroadcastOnce, params.BroadcastContent)
 }
 
-func buildBaseConfig(enable bool, protocol string, address string, port int, format string, replay bool, stateChange bool, broadcast string, persistentReconnect bool) *util.FactomdConfig {
-	return &util.FactomdConfig{
-		LiveFeedAPI: struct {
-			EnableLiveFeedAPI        bool
-			EventReceiverProtocol    string
-			EventReceiverHost        string
-			EventReceiverPort        int
-			EventSenderPort          int
-			EventFormat              string
-			EventReplayDuringStartup bool
-			EventSendStateChange     bool
-			EventBroadcastContent    string
-			PersistentReconnect      bool
-		}{
-			EnableLiveFeedAPI:        enable,
-			EventReceiverProtocol:    protocol,
-			EventReceiverHost:        address,
-			EventReceiverPort:        port,
-			EventSenderPort:          port,
-			EventFormat:              format,
-			EventReplayDuringStartup: replay,
-			EventSendStateChange:     stateChange,
-			EventBroadcastContent:    broadcast,
-			PersistentReconnect:      persistentReconnect,
+func TestEventServiceParameters_InvalidFilter(t *testing.T) {
+	config := buildBaseConfig(true, "tcp", "127.0.0.1", 8444, "protobuf", true, false, "once", false)
+	config.LiveFeedAPI.EventFilterTypes = "unknown"
+	assert.Nil(t, selectParameters(globals.Params, config))
+	assert.Empty(t, NewEventSenders(config, globals.Params))
+}
+
+func TestEventServiceParameters_ReceiverParameters(t *testing.T) {
+	config := buildBaseConfig(true, "tcp", "127.0.0.1", 8444, "protobuf", true, false, "once", false)
+	config.LiveFeedAPI.EventFilterTypes = "directory-block"
+	config.LiveFeedReceiver = map[string]*util.LiveFeedReceiverConfig{
+		"archive": {
+			EventReceiverHost:     "archive.local",
+			EventReceiverPort:     9000,
+			EventFormat:           "json",
+			EventBroadcastContent: "always",
+			EventFilterTypes:      "entry-reveal, chain-commit",
+			EventFilterChainIDs:   "888888b1255ea1cc6b9d6bb4a6b6b58d6b0e1e1d3c2d1e5d84e3e7a5a3b2c1d0",
+			EventDurableDelivery:  true,
+			EventSpoolMaxSize:     64,
+		},
+		"alerting": {
+			EventReceiverProtocol: "udp",
+			EventReceiverHost:     "alerting.local",
+			EventReceiverPort:     9001,
+			EventSenderPort:       9002,
+			EventSendStateChange:  true,
+			PersistentReconnect:   true,
+			EventDurableDelivery:  true,
+		},
+		"hook": {
+			EventReceiverProtocol: "https",
+			EventReceiverHost:     "hook.local",
+			EventReceiverPort:     443,
+			EventReceiverPath:     "events",
+			EventWebhookSecret:    "secret",
+			EventBatchSize:        10,
+			EventBatchInSeconds:   5,
 		},
+		"log": {
+			EventReceiverProtocol: "file",
+			EventFileMaxSize:      10,
+			EventFileMaxBackups:   3,
+		},
+		"no-host": {
+			EventReceiverPort: 9003,
+		},
+		"invalid-filter": {
+			EventReceiverHost: "invalid.local",
+			EventReceiverPort: 9004,
+			EventFilterTypes:  "unknown",
+		},
+	}
+
+	params := selectParameters(globals.Params, config)
+	assert.Equal(t, defaultReceiverName, params.Name)
+	assert.NotNil(t, params.Filter)
+
+	receivers := selectReceiverParameters(config)
+	if assert.Equal(t, 4, len(receivers)) {
+		alerting := receivers[0]
+		assert.Equal(t, "alerting", alerting.Name)
+		assert.Equal(t, "udp", alerting.Protocol)
+		assert.Equal(t, "alerting.local:9001", alerting.Address)
+		assert.Equal(t, ":9002", alerting.ClientPort)
+		assert.Equal(t, eventconfig.Protobuf, alerting.OutputFormat)
+		assert.Equal(t, eventconfig.BroadcastOnce, alerting.BroadcastContent)
+		assert.True(t, alerting.SendStateChangeEvents)
+		assert.True(t, alerting.PersistentReconnect)
+		assert.True(t, alerting.ReplayDuringStartup)
+		assert.Nil(t, alerting.Filter)
+		assert.False(t, alerting.DurableDelivery, "durable delivery is only supported for tcp")
+
+		archive := receivers[1]
+		assert.Equal(t, "archive", archive.Name)
+		assert.Equal(t, defaultProtocol, archive.Protocol)
+		assert.Equal(t, "archive.local:9000", archive.Address)
+		assert.Equal(t, eventconfig.Json, archive.OutputFormat)
+		assert.Equal(t, eventconfig.BroadcastAlways, archive.BroadcastContent)
+		assert.NotNil(t, archive.Filter)
+		assert.True(t, archive.DurableDelivery)
+		assert.Equal(t, filepath.Join(config.App.HomeDir, defaultSpoolDir, "archive"), archive.SpoolPath)
+		assert.Equal(t, int64(64*1024*1024), archive.SpoolMaxSize)
+
+		hook := receivers[2]
+		assert.Equal(t, "https", hook.Protocol)
+		assert.Equal(t, "hook.local:443", hook.Address)
+		assert.Equal(t, "/events", hook.URLPath)
+		assert.Equal(t, "secret", hook.WebhookSecret)
+		assert.Equal(t, 10, hook.BatchSize)
+		assert.Equal(t, 5*time.Second, hook.BatchInterval)
+
+		fileLog := receivers[3]
+		assert.Equal(t, "file", fileLog.Protocol)
+		assert.Equal(t, filepath.Join(config.App.HomeDir, defaultSpoolDir, "log.ndjson"), fileLog.FilePath)
+		assert.Equal(t, int64(10*1024*1024), fileLog.FileMaxSize)
+		assert.Equal(t, 3, fileLog.FileMaxBackups)
 	}
 }
+
+func buildBaseConfig(enable bool, protocol string, address string, port int, format string, replay bool, stateChange bool, broadcast string, persistentReconnect bool) *util.FactomdConfig {
+	config := &util.FactomdConfig{}
+	config.LiveFeedAPI.EnableLiveFeedAPI = enable
+	config.LiveFeedAPI.EventReceiverProtocol = protocol
+	config.LiveFeedAPI.EventReceiverHost = address
+	config.LiveFeedAPI.EventReceiverPort = port
+	config.LiveFeedAPI.EventSenderPort = port
+	config.LiveFeedAPI.EventFormat = format
+	config.LiveFeedAPI.EventReplayDuringStartup = replay
+	config.LiveFeedAPI.EventSendStateChange = stateChange
+	config.LiveFeedAPI.EventBroadcastContent = broadcast
+	config.LiveFeedAPI.PersistentReconnect = persistentReconnect
+	return config
+}
@@ -0,0 +1,111 @@
+package eventservices
+
+import (
+	"time"
+
+	"github.com/FactomProject/factomd/events/eventconfig"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	protocolHTTP  = "http"
+	protocolHTTPS = "https"
+	protocolFile  = "file"
+)
+
+// sinkShutdownTimeout limits how long a shutdown waits for the queued events to be dispatched, and again for the
+// sink to stop, a receiver that is down must not keep the node from stopping
+var sinkShutdownTimeout = 10 * time.Second
+
+// newEventSenderFor creates the sender that matches the protocol of the receiver
+func newEventSenderFor(params *EventServiceParams) EventSender {
+	switch params.Protocol {
+	case protocolHTTP, protocolHTTPS:
+		return newWebhookSender(params)
+	case protocolFile:
+		return newFileSender(params)
+	default:
+		return newEventSender(params)
+	}
+}
+
+// eventSink has the queue, counters and settings that the webhook and file senders share. The events in the queue
+// are handled by the sink in a single go routine, which closes done when the queue is closed and drained. Stop is
+// closed on shutdown to end retries.
+type eventSink struct {
+	params                  *EventServiceParams
+	eventsOutQueue          chan *eventmessages.FactomEvent
+	droppedFromQueueCounter prometheus.Counter
+	notSentCounter          prometheus.Counter
+	sequence                uint64
+	stop                    chan struct{}
+	done                    chan struct{}
+}
+
+func newEventSink(params *EventServiceParams) eventSink {
+	return eventSink{
+		params:         params,
+		eventsOutQueue: make(chan *eventmessages.FactomEvent, 5000),
+		droppedFromQueueCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "factomd_livefeed_dropped_from_queue_counter",
+			Help:        "Number of times we dropped events due of a full the event queue",
+			ConstLabels: prometheus.Labels{"receiver": params.Name},
+		}),
+		notSentCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "factomd_livefeed_not_send_counter",
+			Help:        "Number of times we couldn't send out an event",
+			ConstLabels: prometheus.Labels{"receiver": params.Name},
+		}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// sequenced returns a copy of the event with the next sequence number, the event itself may be shared with other receivers
+func (sink *eventSink) sequenced(event *eventmessages.FactomEvent) *eventmessages.FactomEvent {
+	sink.sequence++
+	sequencedEvent := *event
+	sequencedEvent.SequenceNumber = sink.sequence
+	return &sequencedEvent
+}
+
+func (sink *eventSink) GetEventQueue() chan *eventmessages.FactomEvent {
+	return sink.eventsOutQueue
+}
+
+func (sink *eventSink) GetBroadcastContent() eventconfig.BroadcastContent {
+	return sink.params.BroadcastContent
+}
+
+func (sink *eventSink) GetEventFilter() *EventFilter {
+	return sink.params.Filter
+}
+
+func (sink *eventSink) IsSendStateChangeEvents() bool {
+	return sink.params.SendStateChangeEvents
+}
+
+func (sink *eventSink) ReplayDuringStartup() bool {
+	return sink.params.ReplayDuringStartup
+}
+
+func (sink *eventSink) IncreaseDroppedFromQueueCounter() {
+	sink.droppedFromQueueCounter.Inc()
+}
+
+func (sink *eventSink) Shutdown() {
+	log.Infof("Waiting until queued event messages for receiver %s have been dispatched.", sink.params.Name)
+	deadline := time.Now().Add(sinkShutdownTimeout)
+	for len(sink.eventsOutQueue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(25 * time.Millisecond)
+	}
+	close(sink.stop)
+	close(sink.eventsOutQueue)
+	select {
+	case <-sink.done:
+	case <-time.After(sinkShutdownTimeout):
+		log.Errorf("Receiver %s did not stop in time, %d queued events are not dispatched", sink.params.Name, len(sink.eventsOutQueue))
+	}
+}
@@ -0,0 +1,286 @@
+package eventservices
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	spoolSegmentExtension  = ".spool"
+	spoolRecordHeaderSize  = 12 // sequence number (8 bytes) + data size (4 bytes)
+	defaultSpoolSegmentMax = 16 * 1024 * 1024
+)
+
+// eventSpool is a bounded on-disk queue of the events of a receiver. Every event is appended to the current
+// segment file together with its sequence number. When the spool grows beyond its maximum size, the oldest
+// segments are removed, the events in there can no longer be replayed.
+type eventSpool struct {
+	mutex          sync.Mutex
+	dir            string
+	maxSize        int64
+	segmentMaxSize int64
+	segments       []*spoolSegment
+	current        *os.File
+	lastSequence   uint64
+}
+
+type spoolSegment struct {
+	path          string
+	firstSequence uint64
+	size          int64
+}
+
+func openEventSpool(dir string, maxSize int64) (*eventSpool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory %s: %v", dir, err)
+	}
+
+	spool := &eventSpool{
+		dir:            dir,
+		maxSize:        maxSize,
+		segmentMaxSize: defaultSpoolSegmentMax,
+	}
+	if spool.segmentMaxSize > maxSize/2 {
+		spool.segmentMaxSize = maxSize / 2
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory %s: %v", dir, err)
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), spoolSegmentExtension) {
+			continue
+		}
+		firstSequence, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), spoolSegmentExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		spool.segments = append(spool.segments, &spoolSegment{
+			path:          filepath.Join(dir, file.Name()),
+			firstSequence: firstSequence,
+			size:          file.Size(),
+		})
+	}
+	sort.Slice(spool.segments, func(i, j int) bool {
+		return spool.segments[i].firstSequence < spool.segments[j].firstSequence
+	})
+
+	if err := spool.recoverLastSegment(); err != nil {
+		return nil, err
+	}
+	return spool, nil
+}
+
+// recoverLastSegment finds the last sequence number and removes a partially written record at the end of the last segment
+func (spool *eventSpool) recoverLastSegment() error {
+	if len(spool.segments) == 0 {
+		return nil
+	}
+
+	last := spool.segments[len(spool.segments)-1]
+	spool.lastSequence = last.firstSequence - 1
+	validSize, err := readSegment(last.path, func(sequence uint64, _ []byte) error {
+		spool.lastSequence = sequence
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read spool segment %s: %v", last.path, err)
+	}
+	if validSize < last.size {
+		if err := os.Truncate(last.path, validSize); err != nil {
+			return fmt.Errorf("failed to truncate spool segment %s: %v", last.path, err)
+		}
+		last.size = validSize
+	}
+	return nil
+}
+
+// Append assigns the next sequence number to the event and writes it to the spool
+func (spool *eventSpool) Append(event *eventmessages.FactomEvent) (uint64, error) {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+
+	sequence := spool.lastSequence + 1
+	event.SequenceNumber = sequence
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	segment, err := spool.currentSegment(sequence)
+	if err != nil {
+		return 0, err
+	}
+
+	record := make([]byte, spoolRecordHeaderSize+len(data))
+	binary.LittleEndian.PutUint64(record, sequence)
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(data)))
+	copy(record[spoolRecordHeaderSize:], data)
+	if _, err := spool.current.Write(record); err != nil {
+		return 0, fmt.Errorf("failed to write to spool segment %s: %v", segment.path, err)
+	}
+	segment.size += int64(len(record))
+	spool.lastSequence = sequence
+
+	spool.removeOldSegments()
+	return sequence, nil
+}
+
+// currentSegment returns the segment to append to, a new segment is started when the current one is full
+func (spool *eventSpool) currentSegment(sequence uint64) (*spoolSegment, error) {
+	var segment *spoolSegment
+	if len(spool.segments) > 0 {
+		segment = spool.segments[len(spool.segments)-1]
+	}
+
+	if segment != nil && segment.size < spool.segmentMaxSize {
+		if spool.current == nil {
+			file, err := os.OpenFile(segment.path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open spool segment %s: %v", segment.path, err)
+			}
+			spool.current = file
+		}
+		return segment, nil
+	}
+
+	if spool.current != nil {
+		spool.current.Close()
+		spool.current = nil
+	}
+	segment = &spoolSegment{
+		path:          filepath.Join(spool.dir, fmt.Sprintf("%020d%s", sequence, spoolSegmentExtension)),
+		firstSequence: sequence,
+	}
+	file, err := os.OpenFile(segment.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool segment %s: %v", segment.path, err)
+	}
+	spool.current = file
+	spool.segments = append(spool.segments, segment)
+	return segment, nil
+}
+
+func (spool *eventSpool) removeOldSegments() {
+	for len(spool.segments) > 1 && spool.size() > spool.maxSize {
+		oldest := spool.segments[0]
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			log.Errorf("failed to remove spool segment %s: %v", oldest.path, err)
+			return
+		}
+		spool.segments = spool.segments[1:]
+	}
+}
+
+func (spool *eventSpool) size() int64 {
+	var size int64
+	for _, segment := range spool.segments {
+		size += segment.size
+	}
+	return size
+}
+
+// LastSequence returns the sequence number of the last event in the spool, zero if nothing was spooled yet
+func (spool *eventSpool) LastSequence() uint64 {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	return spool.lastSequence
+}
+
+// FirstSequence returns the sequence number of the oldest event that can still be replayed
+func (spool *eventSpool) FirstSequence() uint64 {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if len(spool.segments) == 0 {
+		return spool.lastSequence + 1
+	}
+	return spool.segments[0].firstSequence
+}
+
+// ReadFrom calls handle for every spooled event with a sequence number higher than the given sequence.
+// Reading stops at the first error returned by handle, this error is returned.
+func (spool *eventSpool) ReadFrom(sequence uint64, handle func(event *eventmessages.FactomEvent) error) error {
+	spool.mutex.Lock()
+	var segments []spoolSegment
+	for i, segment := range spool.segments {
+		// skip the segments which only contain events up to the given sequence
+		if i+1 < len(spool.segments) && spool.segments[i+1].firstSequence <= sequence+1 {
+			continue
+		}
+		segments = append(segments, *segment)
+	}
+	spool.mutex.Unlock()
+
+	for _, segment := range segments {
+		_, err := readSegment(segment.path, func(eventSequence uint64, data []byte) error {
+			if eventSequence <= sequence {
+				return nil
+			}
+			event := &eventmessages.FactomEvent{}
+			if err := proto.Unmarshal(data, event); err != nil {
+				return fmt.Errorf("failed to unmarshal spooled event %d: %v", eventSequence, err)
+			}
+			return handle(event)
+		})
+		if os.IsNotExist(err) {
+			// the segment was removed while reading, the events in there are lost
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSegment reads all complete records of a segment and returns the size of these records
+func readSegment(path string, handle func(sequence uint64, data []byte) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, spoolRecordHeaderSize)
+	var validSize int64
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			// EOF or a partially written header
+			return validSize, nil
+		}
+		sequence := binary.LittleEndian.Uint64(header)
+		data := make([]byte, binary.LittleEndian.Uint32(header[8:]))
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return validSize, nil
+		}
+		validSize += int64(spoolRecordHeaderSize + len(data))
+
+		if err := handle(sequence, data); err != nil {
+			return validSize, err
+		}
+	}
+}
+
+func (spool *eventSpool) Close() {
+	spool.mutex.Lock()
+	defer spool.mutex.Unlock()
+	if spool.current != nil {
+		spool.current.Close()
+		spool.current = nil
+	}
+}
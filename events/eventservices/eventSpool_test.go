@@ -0,0 +1,137 @@
+package eventservices
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSpoolTestEvent(text string) *eventmessages.FactomEvent {
+	return &eventmessages.FactomEvent{
+		EventSource: eventmessages.EventSource_LIVE,
+		Event: &eventmessages.FactomEvent_NodeMessage{
+			NodeMessage: &eventmessages.NodeMessage{MessageText: text},
+		},
+	}
+}
+
+func readSpool(t *testing.T, spool *eventSpool, from uint64) []uint64 {
+	var sequences []uint64
+	err := spool.ReadFrom(from, func(event *eventmessages.FactomEvent) error {
+		sequences = append(sequences, event.SequenceNumber)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sequences
+}
+
+func TestEventSpool_AppendAndReadFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := openEventSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+
+	for i := 0; i < 5; i++ {
+		event := newSpoolTestEvent("test")
+		sequence, err := spool.Append(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint64(i+1), sequence)
+		assert.Equal(t, uint64(i+1), event.SequenceNumber)
+	}
+
+	assert.Equal(t, uint64(1), spool.FirstSequence())
+	assert.Equal(t, uint64(5), spool.LastSequence())
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, readSpool(t, spool, 0))
+	assert.Equal(t, []uint64{4, 5}, readSpool(t, spool, 3))
+	assert.Empty(t, readSpool(t, spool, 5))
+}
+
+func TestEventSpool_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := openEventSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := spool.Append(newSpoolTestEvent("test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	spool.Close()
+
+	// simulate a crash while writing a record
+	segment := filepath.Join(dir, "00000000000000000001.spool")
+	file, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.Write([]byte{4, 0, 0, 0, 0, 0, 0, 0, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	spool, err = openEventSpool(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	assert.Equal(t, uint64(3), spool.LastSequence())
+
+	sequence, err := spool.Append(newSpoolTestEvent("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(4), sequence)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, readSpool(t, spool, 0))
+}
+
+func TestEventSpool_MaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := openEventSpool(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+
+	for i := 0; i < 100; i++ {
+		_, err := spool.Append(newSpoolTestEvent("a test message of some length"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.True(t, spool.size() <= 1000, "spool size %d exceeds the maximum", spool.size())
+	assert.True(t, spool.FirstSequence() > 1)
+	assert.Equal(t, uint64(100), spool.LastSequence())
+
+	sequences := readSpool(t, spool, 0)
+	assert.Equal(t, spool.FirstSequence(), sequences[0])
+	assert.Equal(t, uint64(100), sequences[len(sequences)-1])
+}
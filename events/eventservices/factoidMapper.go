@@ -2,6 +2,7 @@ package eventservices
 
 import (
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
 )
 
@@ -117,3 +118,13 @@ func mapFactoidSignatureBlockSignatures(signatures []interfaces.ISignature) [][]
 	}
 	return result
 }
+
+func mapFactoidTransactionState(state eventmessages.EntityState, factoidTransaction *messages.FactoidTransaction) *eventmessages.FactomEvent_StateChange {
+	result := &eventmessages.FactomEvent_StateChange{
+		StateChange: &eventmessages.StateChange{
+			EntityHash:  factoidTransaction.Transaction.GetSigHash().Bytes(),
+			EntityState: state,
+		},
+	}
+	return result
+}
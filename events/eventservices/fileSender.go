@@ -0,0 +1,140 @@
+package eventservices
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultFileMaxSize    = 100 * 1024 * 1024
+	defaultFileMaxBackups = 10
+	fileFlushInterval     = 1 * time.Second
+)
+
+// fileSender appends the events as newline delimited json to a file. When the file grows beyond its maximum size
+// it is rotated: events.ndjson is renamed to events.ndjson.1, events.ndjson.1 to events.ndjson.2 and so on,
+// the oldest file is removed when there are more backups than configured.
+type fileSender struct {
+	eventSink
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+}
+
+func newFileSender(params *EventServiceParams) *fileSender {
+	fileSender := &fileSender{
+		eventSink: newEventSink(params),
+		path:      params.FilePath,
+	}
+
+	go fileSender.processEventsChannel()
+	return fileSender
+}
+
+func (fileSender *fileSender) processEventsChannel() {
+	defer close(fileSender.done)
+	defer fileSender.close()
+
+	ticker := time.NewTicker(fileFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-fileSender.eventsOutQueue:
+			if !ok {
+				return
+			}
+			if err := fileSender.writeEvent(fileSender.sequenced(event)); err != nil {
+				log.Errorf("An error occurred while writing an event to %s: %v", fileSender.path, err)
+				fileSender.notSentCounter.Inc()
+			}
+		case <-ticker.C:
+			if fileSender.writer != nil {
+				fileSender.writer.Flush()
+			}
+		}
+	}
+}
+
+func (fileSender *fileSender) writeEvent(event *eventmessages.FactomEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+	data = append(data, '\n')
+
+	if fileSender.file != nil && fileSender.size+int64(len(data)) > fileSender.maxSize() && fileSender.size > 0 {
+		if err := fileSender.rotate(); err != nil {
+			return err
+		}
+	}
+	if fileSender.file == nil {
+		if err := fileSender.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := fileSender.writer.Write(data)
+	fileSender.size += int64(n)
+	return err
+}
+
+func (fileSender *fileSender) open() error {
+	if err := os.MkdirAll(filepath.Dir(fileSender.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	file, err := os.OpenFile(fileSender.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	fileSender.file = file
+	fileSender.writer = bufio.NewWriter(file)
+	fileSender.size = info.Size()
+	return nil
+}
+
+func (fileSender *fileSender) close() {
+	if fileSender.file == nil {
+		return
+	}
+	fileSender.writer.Flush()
+	fileSender.file.Close()
+	fileSender.file = nil
+	fileSender.writer = nil
+}
+
+func (fileSender *fileSender) rotate() error {
+	fileSender.close()
+
+	maxBackups := fileSender.params.FileMaxBackups
+	if maxBackups <= 0 {
+		maxBackups = defaultFileMaxBackups
+	}
+	os.Remove(fmt.Sprintf("%s.%d", fileSender.path, maxBackups))
+	for i := maxBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", fileSender.path, i), fmt.Sprintf("%s.%d", fileSender.path, i+1))
+	}
+	if err := os.Rename(fileSender.path, fileSender.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate file: %v", err)
+	}
+	return fileSender.open()
+}
+
+func (fileSender *fileSender) maxSize() int64 {
+	if fileSender.params.FileMaxSize > 0 {
+		return fileSender.params.FileMaxSize
+	}
+	return defaultFileMaxSize
+}
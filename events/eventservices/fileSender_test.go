@@ -0,0 +1,72 @@
+package eventservices
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readNDJSON(t *testing.T, path string) []uint64 {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var sequences []uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event struct {
+			SequenceNumber uint64 `json:"sequenceNumber"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		sequences = append(sequences, event.SequenceNumber)
+	}
+	return sequences
+}
+
+func TestFileSender_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.ndjson")
+	fileSender := newFileSender(&EventServiceParams{
+		Name:           "file",
+		Protocol:       protocolFile,
+		FilePath:       path,
+		FileMaxSize:    300,
+		FileMaxBackups: 2,
+	})
+	for i := 0; i < 10; i++ {
+		fileSender.GetEventQueue() <- newSpoolTestEvent("a test message of some length")
+	}
+	fileSender.Shutdown()
+
+	// the files hold consecutive events, the oldest files are removed
+	var sequences []uint64
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, info.Size() <= 300, "file %s exceeds the maximum size", name)
+		sequences = append(sequences, readNDJSON(t, name)...)
+	}
+	assert.True(t, sequences[0] > 1)
+	for i, sequence := range sequences {
+		assert.Equal(t, sequences[0]+uint64(i), sequence)
+	}
+	assert.Equal(t, uint64(10), sequences[len(sequences)-1])
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,193 @@
+package eventservices
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/FactomProject/factomd/events/eventconfig"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	WebhookSignatureHeader = "X-Factomd-Signature"
+	WebhookSequenceHeader  = "X-Factomd-Sequence"
+
+	defaultBatchSize     = 100
+	defaultBatchInterval = 1 * time.Second
+)
+
+var (
+	webhookTimeout       = 30 * time.Second
+	webhookRetryDelay    = 1 * time.Second
+	webhookMaxRetryDelay = 1 * time.Minute
+)
+
+// errPermanent marks a batch the receiver refused, sending it again won't help
+var errPermanent = errors.New("the receiver refused the events")
+
+// webhookSender posts the events in batches to an http endpoint. A batch is posted when it is full or when the
+// batch interval has passed since the first event of the batch was queued.
+//
+// Json batches are posted as a json array of events. Protobuf batches are the concatenation of the events, each
+// preceded by its size as uint32 little endian. When a secret is configured, the body is signed with HMAC-SHA256
+// and the hex encoded signature is sent in the X-Factomd-Signature header as sha256=<signature>.
+type webhookSender struct {
+	eventSink
+	url    string
+	client *http.Client
+}
+
+func newWebhookSender(params *EventServiceParams) *webhookSender {
+	webhookSender := &webhookSender{
+		eventSink: newEventSink(params),
+		url:       fmt.Sprintf("%s://%s%s", params.Protocol, params.Address, params.URLPath),
+		client:    &http.Client{Timeout: webhookTimeout},
+	}
+
+	go webhookSender.processEventsChannel()
+	return webhookSender
+}
+
+func (webhookSender *webhookSender) processEventsChannel() {
+	defer close(webhookSender.done)
+
+	batchSize := webhookSender.params.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	batchInterval := webhookSender.params.BatchInterval
+	if batchInterval <= 0 {
+		batchInterval = defaultBatchInterval
+	}
+
+	var batch []*eventmessages.FactomEvent
+	var timeout <-chan time.Time
+	for {
+		select {
+		case event, ok := <-webhookSender.eventsOutQueue:
+			if !ok {
+				webhookSender.sendBatch(batch)
+				return
+			}
+			if len(batch) == 0 {
+				timeout = time.After(batchInterval)
+			}
+			batch = append(batch, webhookSender.sequenced(event))
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-timeout:
+		}
+
+		webhookSender.sendBatch(batch)
+		batch = nil
+		timeout = nil
+	}
+}
+
+// sendBatch posts the batch, failed posts are retried with an exponential backoff until the sender is stopped
+func (webhookSender *webhookSender) sendBatch(batch []*eventmessages.FactomEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	body, contentType, err := webhookSender.marshallBatch(batch)
+	if err != nil {
+		log.Errorf("An error occurred while serializing %d factom events for receiver %s: %v", len(batch), webhookSender.url, err)
+		webhookSender.notSentCounter.Add(float64(len(batch)))
+		return
+	}
+
+	delay := webhookRetryDelay
+retries:
+	for retry := 0; webhookSender.params.PersistentReconnect || retry < sendRetries; retry++ {
+		err = webhookSender.post(body, contentType, batch[0].SequenceNumber)
+		if err == nil {
+			return
+		}
+		log.Errorf("An error occurred while posting events to receiver %s: %v, retry %d", webhookSender.url, err, retry)
+		if err == errPermanent {
+			break
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-webhookSender.stop:
+			break retries
+		}
+		delay *= 2
+		if delay > webhookMaxRetryDelay {
+			delay = webhookMaxRetryDelay
+		}
+	}
+	webhookSender.notSentCounter.Add(float64(len(batch)))
+}
+
+func (webhookSender *webhookSender) marshallBatch(batch []*eventmessages.FactomEvent) ([]byte, string, error) {
+	switch webhookSender.params.OutputFormat {
+	case eventconfig.Json:
+		data, err := json.Marshal(batch)
+		return data, "application/json", err
+	case eventconfig.Protobuf:
+		var buffer bytes.Buffer
+		for _, event := range batch {
+			data, err := proto.Marshal(event)
+			if err != nil {
+				return nil, "", err
+			}
+			binary.Write(&buffer, binary.LittleEndian, uint32(len(data)))
+			buffer.Write(data)
+		}
+		return buffer.Bytes(), "application/x-protobuf", nil
+	default:
+		return nil, "", errors.New("unsupported event format: " + webhookSender.params.OutputFormat.String())
+	}
+}
+
+func (webhookSender *webhookSender) post(body []byte, contentType string, firstSequence uint64) error {
+	request, err := http.NewRequest(http.MethodPost, webhookSender.url, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set(WebhookSequenceHeader, fmt.Sprintf("%d", firstSequence))
+	if len(webhookSender.params.WebhookSecret) > 0 {
+		request.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookBody(webhookSender.params.WebhookSecret, body))
+	}
+
+	response, err := webhookSender.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return nil
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		return fmt.Errorf("receiver responded with %s", response.Status)
+	default:
+		log.Errorf("Receiver %s refused the events with %s", webhookSender.url, response.Status)
+		return errPermanent
+	}
+}
+
+// SignWebhookBody returns the hex encoded HMAC-SHA256 of the body, receivers use it to verify the signature header
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
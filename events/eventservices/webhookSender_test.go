@@ -0,0 +1,186 @@
+package eventservices
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/events/eventconfig"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookTestServer(statusCodes ...int) (*httptest.Server, chan *webhookRequest) {
+	requests := make(chan *webhookRequest, 10)
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		requests <- &webhookRequest{header: request.Header, body: body}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(statusCodes) > 0 {
+			writer.WriteHeader(statusCodes[0])
+			statusCodes = statusCodes[1:]
+		}
+	}))
+	return server, requests
+}
+
+func newWebhookTestParams(server *httptest.Server, format eventconfig.EventFormat) *EventServiceParams {
+	return &EventServiceParams{
+		Name:          "webhook",
+		Protocol:      protocolHTTP,
+		Address:       strings.TrimPrefix(server.URL, "http://"),
+		URLPath:       "/events",
+		OutputFormat:  format,
+		BatchSize:     3,
+		BatchInterval: 50 * time.Millisecond,
+	}
+}
+
+func receiveWebhookRequest(t *testing.T, requests chan *webhookRequest) *webhookRequest {
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return nil
+	}
+}
+
+func TestWebhookSender_JsonBatches(t *testing.T) {
+	server, requests := newWebhookTestServer()
+	defer server.Close()
+
+	params := newWebhookTestParams(server, eventconfig.Json)
+	params.WebhookSecret = "secret"
+	webhookSender := newWebhookSender(params)
+	for i := 0; i < 4; i++ {
+		webhookSender.GetEventQueue() <- newSpoolTestEvent("webhook")
+	}
+
+	// the first batch is full, the second is sent after the batch interval
+	for _, expected := range [][]uint64{{1, 2, 3}, {4}} {
+		request := receiveWebhookRequest(t, requests)
+		assert.Equal(t, "application/json", request.header.Get("Content-Type"))
+		assert.Equal(t, "sha256="+SignWebhookBody("secret", request.body), request.header.Get(WebhookSignatureHeader))
+
+		var events []struct {
+			SequenceNumber uint64 `json:"sequenceNumber"`
+		}
+		if err := json.Unmarshal(request.body, &events); err != nil {
+			t.Fatal(err)
+		}
+		var sequences []uint64
+		for _, event := range events {
+			sequences = append(sequences, event.SequenceNumber)
+		}
+		assert.Equal(t, expected, sequences)
+	}
+	webhookSender.Shutdown()
+}
+
+func TestWebhookSender_ProtobufBatch(t *testing.T) {
+	server, requests := newWebhookTestServer()
+	defer server.Close()
+
+	webhookSender := newWebhookSender(newWebhookTestParams(server, eventconfig.Protobuf))
+	webhookSender.GetEventQueue() <- newSpoolTestEvent("first")
+	webhookSender.GetEventQueue() <- newSpoolTestEvent("second")
+
+	request := receiveWebhookRequest(t, requests)
+	assert.Equal(t, "application/x-protobuf", request.header.Get("Content-Type"))
+	assert.Empty(t, request.header.Get(WebhookSignatureHeader))
+
+	reader := bytes.NewReader(request.body)
+	var messages []string
+	for reader.Len() > 0 {
+		var size uint32
+		binary.Read(reader, binary.LittleEndian, &size)
+		data := make([]byte, size)
+		reader.Read(data)
+		event := &eventmessages.FactomEvent{}
+		if err := proto.Unmarshal(data, event); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, event.GetNodeMessage().MessageText)
+	}
+	assert.Equal(t, []string{"first", "second"}, messages)
+	webhookSender.Shutdown()
+}
+
+func TestWebhookSender_Retry(t *testing.T) {
+	webhookRetryDelay = 1 * time.Millisecond
+	sendRetries = 3
+
+	testCases := map[string]struct {
+		StatusCodes      []int
+		ExpectedRequests int
+		ExpectedNotSent  float64
+	}{
+		"server error is retried":    {[]int{http.StatusInternalServerError, http.StatusServiceUnavailable}, 3, 0},
+		"retries are exhausted":      {[]int{500, 500, 500}, 3, 1},
+		"bad request is not retried": {[]int{http.StatusBadRequest}, 1, 1},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server, requests := newWebhookTestServer(testCase.StatusCodes...)
+			defer server.Close()
+
+			webhookSender := &webhookSender{
+				eventSink: eventSink{
+					params:         newWebhookTestParams(server, eventconfig.Json),
+					notSentCounter: prometheus.NewCounter(prometheus.CounterOpts{}),
+				},
+				client: server.Client(),
+				url:    server.URL,
+			}
+			webhookSender.sendBatch([]*eventmessages.FactomEvent{webhookSender.sequenced(newSpoolTestEvent("retry"))})
+
+			assert.Equal(t, testCase.ExpectedRequests, len(requests))
+			assert.Equal(t, testCase.ExpectedNotSent, getCounterValue(t, webhookSender.notSentCounter))
+		})
+	}
+}
+
+func TestWebhookSender_ShutdownStopsRetries(t *testing.T) {
+	defer func(delay time.Duration) { webhookRetryDelay = delay }(webhookRetryDelay)
+	webhookRetryDelay = time.Hour
+
+	server, requests := newWebhookTestServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	params := newWebhookTestParams(server, eventconfig.Json)
+	params.PersistentReconnect = true
+	webhookSender := newWebhookSender(params)
+	webhookSender.GetEventQueue() <- newSpoolTestEvent("unreachable")
+	receiveWebhookRequest(t, requests)
+
+	stopped := make(chan struct{})
+	go func() {
+		webhookSender.Shutdown()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown waits for the retries")
+	}
+	assert.Equal(t, float64(1), getCounterValue(t, webhookSender.notSentCounter))
+}
@@ -15,6 +15,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "peers" {
+		os.Exit(runPeers(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		os.Exit(runBackup(os.Args[2:]))
+	}
+
 	fmt.Println("Command Line Arguments:")
 
 	for _, v := range os.Args[1:] {
@@ -16,12 +16,13 @@ require (
 	github.com/FactomProject/logrustash v0.0.0-20171005151533-9c7278ede46e
 	github.com/FactomProject/netki-go-partner-client v0.0.0-20160324224126-426acb535e66 // indirect
 	github.com/FactomProject/serveridentity v0.0.0-20180611231115-cf42d2aa8deb
-	github.com/FactomProject/snappy-go v0.0.0-20170202213131-f2f83b22c29e // indirect
+	github.com/FactomProject/snappy-go v0.0.0-20170202213131-f2f83b22c29e
 	github.com/FactomProject/web v0.1.1-0.20200312214504-cff1e06a4e47 // indirect
 	github.com/Netflix/go-expect v0.0.0-20200312175327-da48e75238e2 // indirect
 	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
 	github.com/btcsuitereleases/btcutil v0.0.0-20150612230727-f2b1058a8255
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/dgraph-io/badger v1.6.2
 	github.com/dustin/go-humanize v1.0.0
 	github.com/gogo/protobuf v1.3.1-0.20190908201246-8a5ed79f6888
 	github.com/golang/protobuf v1.3.4
@@ -30,6 +31,7 @@ require (
 	github.com/hinshun/vt10x v0.0.0-20180809195222-d55458df857c // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
+	github.com/klauspost/compress v1.11.13
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/kr/pty v1.1.8 // indirect
@@ -42,11 +44,11 @@ require (
 	github.com/prometheus/client_model v0.2.0
 	github.com/rs/cors v1.7.0
 	github.com/sirupsen/logrus v1.5.0
-	github.com/spf13/cobra v0.0.4-0.20180915222204-8d114be902bc
+	github.com/spf13/cobra v0.0.5
 	github.com/spf13/pflag v1.0.3 // indirect
 	github.com/stretchr/testify v1.5.1
 	golang.org/x/crypto v0.0.0-20200311171314-f7b00557c8c4
-	golang.org/x/net v0.0.0-20200301022130-244492dfa37a // indirect
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
 	golang.org/x/sys v0.0.0-20200327173247-9dae0f8f5775 // indirect
 	golang.org/x/text v0.3.1-0.20181010134911-4d1c5fb19474 // indirect
 	gopkg.in/AlecAivazis/survey.v1 v1.6.2
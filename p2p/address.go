@@ -0,0 +1,148 @@
+package p2p
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// maxObservedHosts limits the number of different hosts peers can claim to observe this node at
+const maxObservedHosts = 32
+
+// advertisedEndpoint returns the host and port other nodes should use to connect to this node.
+// The host is taken from the configuration, the port mapping, or peer observations in that
+// order and is empty if unknown. The port is the configured, mapped, or listen port.
+func (c *controller) advertisedEndpoint() (string, string) {
+	conf := c.net.conf
+	c.addressMtx.RLock()
+	defer c.addressMtx.RUnlock()
+
+	host := normalizeHost(conf.ExternalHost)
+	if host == "" {
+		host = c.mappedHost
+	}
+	if host == "" {
+		host = c.learnedHost
+	}
+
+	port := conf.ExternalPort
+	if port == "" {
+		port = c.mappedPort
+	}
+	if port == "" {
+		port = conf.ListenPort
+	}
+	return host, port
+}
+
+// newHandshake creates a handshake that advertises this node's address and tells the
+// remote end which address it was observed at
+func (c *controller) newHandshake(observed string, loopback uint64) *Handshake {
+	hs := newHandshake(c.net.conf, loopback)
+	hs.ExternalHost, hs.ListenPort = c.advertisedEndpoint()
+	hs.ObservedHost = observed
+	return hs
+}
+
+// setMapped sets the address of the gateway's port mapping, empty if there is none
+func (c *controller) setMapped(host, port string) {
+	c.addressMtx.Lock()
+	c.mappedHost = host
+	c.mappedPort = port
+	c.addressMtx.Unlock()
+}
+
+// observeAddress processes the address a peer reports to see this node at. Once enough
+// peers from different addresses agree on a host, it is advertised to other nodes.
+func (c *controller) observeAddress(reporter string, observed string) {
+	if c.net.conf.ObservedAddressThreshold == 0 || reporter == "" {
+		return
+	}
+	ip := net.ParseIP(observed)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return
+	}
+	observed = ip.String()
+
+	c.addressMtx.Lock()
+	defer c.addressMtx.Unlock()
+
+	if c.observed == nil {
+		c.observed = make(map[string]map[string]bool)
+	}
+	if c.observed[observed] == nil {
+		if len(c.observed) >= maxObservedHosts {
+			c.evictObservedHost()
+		}
+		c.observed[observed] = make(map[string]bool)
+		c.observedOrder = append(c.observedOrder, observed)
+	}
+	c.observed[observed][ipGroup(reporter)] = true // an IPv6 subnet only counts once
+
+	// the host with the most reporters wins
+	best, count := "", 0
+	for host, reporters := range c.observed {
+		if len(reporters) > count || (len(reporters) == count && host == c.learnedHost) {
+			best, count = host, len(reporters)
+		}
+	}
+	if uint(count) >= c.net.conf.ObservedAddressThreshold && best != c.learnedHost {
+		c.logger.Infof("Learned external address %s from %d peers", best, count)
+		c.learnedHost = best
+	}
+}
+
+// evictObservedHost removes the host with the fewest reporters, the oldest one if several
+// have the same amount. The learned host is kept. Requires addressMtx to be held.
+func (c *controller) evictObservedHost() {
+	evict := -1
+	for i, host := range c.observedOrder {
+		if host == c.learnedHost {
+			continue
+		}
+		if evict < 0 || len(c.observed[host]) < len(c.observed[c.observedOrder[evict]]) {
+			evict = i
+		}
+	}
+	if evict < 0 {
+		return
+	}
+	delete(c.observed, c.observedOrder[evict])
+	c.observedOrder = append(c.observedOrder[:evict], c.observedOrder[evict+1:]...)
+}
+
+// advertised returns the endpoint to share with other nodes for a peer connected from ep.
+// The host the peer advertises is only shared if it resolves to the address of the connection,
+// otherwise a peer could make other nodes connect to arbitrary hosts.
+func (hs *Handshake) advertised(ep Endpoint, timeout time.Duration) Endpoint {
+	if hs.ExternalHost == "" {
+		return ep
+	}
+	host := normalizeHost(hs.ExternalHost)
+	if !resolvesTo(host, ep.IP, timeout) {
+		return ep
+	}
+	return Endpoint{IP: host, Port: hs.ListenPort}
+}
+
+// resolvesTo returns true if the host is the ip address or a hostname with the ip address
+// among its addresses
+func resolvesTo(host string, ip string, timeout time.Duration) bool {
+	ip = normalizeHost(ip)
+	if net.ParseIP(host) != nil {
+		return host == ip
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if normalizeHost(addr) == ip {
+			return true
+		}
+	}
+	return false
+}
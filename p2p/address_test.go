@@ -0,0 +1,165 @@
+package p2p
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_controller_advertisedEndpoint(t *testing.T) {
+	n := testNetworkHarness(t)
+	c := n.controller
+	n.conf.ListenPort = "8108"
+
+	if host, port := c.advertisedEndpoint(); host != "" || port != "8108" {
+		t.Errorf("wrong default. got = %s:%s, want = :8108", host, port)
+	}
+
+	c.learnedHost = "198.51.100.1"
+	if host, port := c.advertisedEndpoint(); host != "198.51.100.1" || port != "8108" {
+		t.Errorf("learned host not used. got = %s:%s", host, port)
+	}
+
+	c.setMapped("198.51.100.2", "9000")
+	if host, port := c.advertisedEndpoint(); host != "198.51.100.2" || port != "9000" {
+		t.Errorf("mapping not used. got = %s:%s", host, port)
+	}
+
+	n.conf.ExternalHost = "node.example.com"
+	n.conf.ExternalPort = "9108"
+	if host, port := c.advertisedEndpoint(); host != "node.example.com" || port != "9108" {
+		t.Errorf("configuration not used. got = %s:%s", host, port)
+	}
+
+	hs := c.newHandshake("192.0.2.1", 1)
+	if hs.ExternalHost != "node.example.com" || hs.ListenPort != "9108" || hs.ObservedHost != "192.0.2.1" {
+		t.Errorf("handshake doesn't advertise. got = %s:%s observed %s", hs.ExternalHost, hs.ListenPort, hs.ObservedHost)
+	}
+	if err := hs.Valid(n.conf); err != nil {
+		t.Errorf("advertising handshake is invalid: %v", err)
+	}
+}
+
+func Test_controller_observeAddress(t *testing.T) {
+	n := testNetworkHarness(t)
+	c := n.controller
+	n.conf.ObservedAddressThreshold = 3
+
+	// the same reporter counts once, invalid and loopback addresses are ignored
+	c.observeAddress("192.0.2.1", "198.51.100.1")
+	c.observeAddress("192.0.2.1", "198.51.100.1")
+	c.observeAddress("192.0.2.2", "198.51.100.1")
+	c.observeAddress("192.0.2.3", "127.0.0.1")
+	c.observeAddress("192.0.2.4", "garbage")
+	c.observeAddress("192.0.2.5", "")
+	if c.learnedHost != "" {
+		t.Fatalf("learned host before reaching the threshold: %s", c.learnedHost)
+	}
+
+	c.observeAddress("192.0.2.3", "198.51.100.1")
+	if c.learnedHost != "198.51.100.1" {
+		t.Fatalf("host not learned. got = %q", c.learnedHost)
+	}
+
+	// a tie keeps the current host, a majority replaces it
+	for _, r := range []string{"192.0.2.6", "192.0.2.7", "192.0.2.8"} {
+		c.observeAddress(r, "198.51.100.2")
+	}
+	if c.learnedHost != "198.51.100.1" {
+		t.Errorf("host replaced on a tie. got = %s", c.learnedHost)
+	}
+	c.observeAddress("192.0.2.9", "198.51.100.2")
+	if c.learnedHost != "198.51.100.2" {
+		t.Errorf("host not replaced by majority. got = %s", c.learnedHost)
+	}
+
+	// a full list of hosts evicts the least reported host instead of starting over
+	c.observeAddress("192.0.2.10", "198.51.100.3")
+	c.observeAddress("192.0.2.11", "198.51.100.3")
+	for i := 0; len(c.observed) < maxObservedHosts; i++ {
+		c.observeAddress("192.0.2.11", fmt.Sprintf("203.0.113.%d", i))
+	}
+	c.observeAddress("192.0.2.12", "198.51.100.4")
+	if len(c.observed) != maxObservedHosts || len(c.observedOrder) != maxObservedHosts {
+		t.Errorf("wrong number of observed hosts. got = %d", len(c.observed))
+	}
+	if c.observed["203.0.113.0"] != nil || c.observed["198.51.100.4"] == nil {
+		t.Errorf("oldest least reported host was not evicted")
+	}
+	for _, host := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		if c.observed[host] == nil {
+			t.Errorf("host %s was evicted", host)
+		}
+	}
+	if c.learnedHost != "198.51.100.2" {
+		t.Errorf("learned host changed by eviction. got = %s", c.learnedHost)
+	}
+
+	n.conf.ObservedAddressThreshold = 0
+	c.learnedHost = ""
+	for _, r := range []string{"192.0.2.10", "192.0.2.11", "192.0.2.12"} {
+		c.observeAddress(r, "198.51.100.3")
+	}
+	if c.learnedHost != "" {
+		t.Errorf("host learned with learning disabled")
+	}
+}
+
+func Test_controller_makePeerShareAdvertised(t *testing.T) {
+	n := testNetworkHarness(t)
+	c := n.controller
+	n.conf.PeerShareAmount = 10
+
+	p := testRandomPeer(n)
+	p.Endpoint.IP = "203.0.113.9"
+	hs := &Handshake{ListenPort: "9108", ExternalHost: "203.0.113.9"}
+	p.Advertised = hs.advertised(p.Endpoint, time.Second)
+	c.peers.Add(p)
+
+	plain := testRandomPeer(n)
+	plain.Advertised = (&Handshake{ListenPort: plain.Endpoint.Port}).advertised(plain.Endpoint, time.Second)
+	c.peers.Add(plain)
+
+	spoofed := testRandomPeer(n)
+	spoofed.Advertised = (&Handshake{ListenPort: "9108", ExternalHost: "203.0.113.10"}).advertised(spoofed.Endpoint, time.Second)
+	c.peers.Add(spoofed)
+
+	share := c.makePeerShare(Endpoint{})
+	found := map[Endpoint]bool{}
+	for _, ep := range share {
+		found[ep] = true
+	}
+	if !found[Endpoint{IP: "203.0.113.9", Port: "9108"}] {
+		t.Errorf("advertised endpoint not shared. got = %v", share)
+	}
+	if !found[plain.Endpoint] {
+		t.Errorf("connection endpoint not shared. got = %v", share)
+	}
+	if found[Endpoint{IP: "203.0.113.10", Port: "9108"}] || !found[spoofed.Endpoint] {
+		t.Errorf("host that isn't the peer's address was shared. got = %v", share)
+	}
+}
+
+func TestHandshake_advertised(t *testing.T) {
+	ep := Endpoint{IP: "127.0.0.1", Port: "9108"}
+	tests := []struct {
+		name string
+		host string
+		want Endpoint
+	}{
+		{"none", "", ep},
+		{"same ip", "127.0.0.1", ep},
+		{"mapped ip", "[::ffff:127.0.0.1]", ep},
+		{"other ip", "198.51.100.1", ep},
+		{"hostname", "localhost", Endpoint{IP: "localhost", Port: "9108"}},
+		{"unresolvable", "invalid.invalid", ep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := &Handshake{ListenPort: "9108", ExternalHost: tt.host}
+			if got := hs.advertised(ep, time.Second); got != tt.want {
+				t.Errorf("advertised() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+)
+
+// Priority is the lane a parcel is sent in. Parcels in a higher lane are always
+// sent before parcels in a lower lane
+type Priority uint8
+
+const (
+	// PriorityNormal is the lane of all parcels without a configured priority
+	PriorityNormal Priority = iota
+	// PriorityHigh is for small messages that are time critical, like acks and EOMs
+	PriorityHigh
+	// PriorityLow is for bulk data that can wait, like DBState responses
+	PriorityLow
+)
+
+var priorityStrings = map[Priority]string{
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityLow:    "low",
+}
+
+func (p Priority) String() string {
+	return priorityStrings[p]
+}
+
+// parcelPriority determines the lane of a parcel. Application messages are sorted by
+// the first byte of their payload, the application's message type, including those sent via gossip
+func parcelPriority(conf *Configuration, parcel *Parcel) Priority {
+	if parcel == nil {
+		return PriorityNormal
+	}
+	if (parcel.IsApplicationMessage() || parcel.ptype == TypeGossip) && len(parcel.Payload) > 0 {
+		if prio, ok := conf.MessagePriorities[parcel.Payload[0]]; ok {
+			return prio
+		}
+		return PriorityNormal
+	}
+	return conf.ParcelPriorities[parcel.ptype]
+}
+
+// tokenBucket limits the throughput of bytes. The bucket fills up at rate bytes per
+// second up to burst bytes. Reservations are allowed to take more tokens than are
+// available, the next reservation has to wait until the debt is paid off.
+// A nil tokenBucket is unlimited.
+type tokenBucket struct {
+	mtx    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate uint64, burst time.Duration) *tokenBucket {
+	if rate == 0 {
+		return nil
+	}
+	b := new(tokenBucket)
+	b.rate = float64(rate)
+	b.burst = b.rate * burst.Seconds()
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	b.tokens = b.burst
+	b.last = time.Now()
+	return b
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// reserve takes n tokens and returns how long to wait before using them
+func (b *tokenBucket) reserve(n int, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.refill(now)
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.tokens -= float64(n)
+	return wait
+}
+
+// Rate returns the limit in bytes per second, 0 if unlimited
+func (b *tokenBucket) Rate() uint64 {
+	if b == nil {
+		return 0
+	}
+	return uint64(b.rate)
+}
+
+// IsThrottling returns true if the next reservation would have to wait
+func (b *tokenBucket) IsThrottling() bool {
+	if b == nil {
+		return false
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.refill(time.Now())
+	return b.tokens < 0
+}
+
+// reserveBandwidth reserves n bytes in all of the buckets and returns how long to wait
+// until the slowest bucket allows them to pass
+func reserveBandwidth(n int, buckets ...*tokenBucket) time.Duration {
+	now := time.Now()
+	var wait time.Duration
+	for _, b := range buckets {
+		if w := b.reserve(n, now); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// waitBandwidth reserves n bytes in all of the buckets and waits until they may pass.
+// Returns the time spent waiting and false if stopped before that.
+func waitBandwidth(n int, stop <-chan bool, buckets ...*tokenBucket) (time.Duration, bool) {
+	wait := reserveBandwidth(n, buckets...)
+	if wait <= 0 {
+		return 0, true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return wait, true
+	case <-stop:
+		return wait, false
+	}
+}
@@ -0,0 +1,126 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+// testAbout compares durations with a tolerance for floating point rounding
+func testAbout(got, want time.Duration) bool {
+	d := got - want
+	return d > -time.Microsecond && d < time.Microsecond
+}
+
+func Test_tokenBucket_reserve(t *testing.T) {
+	var unlimited *tokenBucket
+	if w := unlimited.reserve(1<<30, time.Now()); w != 0 {
+		t.Errorf("unlimited bucket has to wait %s", w)
+	}
+	if newTokenBucket(0, time.Second) != nil {
+		t.Errorf("rate 0 did not create an unlimited bucket")
+	}
+
+	b := newTokenBucket(1000, time.Second) // 1000 bytes/sec, burst of 1000
+	now := b.last
+
+	if w := b.reserve(1500, now); w != 0 {
+		t.Errorf("full bucket has to wait %s", w)
+	}
+	// 500 bytes in debt = half a second
+	if w := b.reserve(100, now); !testAbout(w, time.Millisecond*500) {
+		t.Errorf("wrong wait. got = %s, want = %s", w, time.Millisecond*500)
+	}
+	if !b.IsThrottling() {
+		t.Errorf("bucket in debt is not throttling")
+	}
+
+	// refills at 1000 bytes/sec, debt is 600
+	if w := b.reserve(100, now.Add(time.Millisecond*500)); !testAbout(w, time.Millisecond*100) {
+		t.Errorf("wrong wait after refill. got = %s, want = %s", w, time.Millisecond*100)
+	}
+
+	// never refills above the burst
+	if b.reserve(0, now.Add(time.Hour)); b.tokens != 1000 {
+		t.Errorf("bucket overfilled. got = %f, want = 1000", b.tokens)
+	}
+}
+
+func Test_waitBandwidth(t *testing.T) {
+	peer := newTokenBucket(10000, time.Millisecond*10)
+	global := newTokenBucket(100000, time.Millisecond*10)
+	stop := make(chan bool)
+
+	start := time.Now()
+	var waited time.Duration
+	for i := 0; i < 11; i++ { // 1100 bytes with 100 bytes of burst at 10000 bytes/sec
+		w, ok := waitBandwidth(100, stop, peer, global)
+		if !ok {
+			t.Fatal("stopped without stop signal")
+		}
+		waited += w
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*80 {
+		t.Errorf("limit not enforced, took %s", elapsed)
+	}
+	if waited < time.Millisecond*80 {
+		t.Errorf("reported wait too short: %s", waited)
+	}
+
+	close(stop)
+	waitBandwidth(10000, stop, peer)
+	if _, ok := waitBandwidth(100, stop, peer); ok {
+		t.Errorf("wait did not abort after stop")
+	}
+}
+
+func Test_parcelPriority(t *testing.T) {
+	conf := DefaultP2PConfiguration()
+	conf.MessagePriorities[1] = PriorityHigh
+	conf.MessagePriorities[20] = PriorityLow
+
+	tests := []struct {
+		parcel *Parcel
+		want   Priority
+	}{
+		{nil, PriorityNormal},
+		{newParcel(TypePing, []byte("Ping")), PriorityHigh},
+		{newParcel(TypePong, []byte("Pong")), PriorityHigh},
+		{newParcel(TypePeerRequest, []byte("Peer Request")), PriorityNormal},
+		{newParcel(TypeMessage, []byte{1, 2, 3}), PriorityHigh},
+		{newParcel(TypeMessagePart, []byte{20, 2, 3}), PriorityLow},
+		{newParcel(TypeMessage, []byte{5}), PriorityNormal},
+		{newParcel(TypeMessage, nil), PriorityNormal},
+	}
+	for i, tt := range tests {
+		if got := parcelPriority(&conf, tt.parcel); got != tt.want {
+			t.Errorf("test %d: got = %s, want = %s", i, got, tt.want)
+		}
+	}
+}
+
+func TestPeer_lanes(t *testing.T) {
+	n := testNetworkHarness(t)
+	n.conf.MessagePriorities[1] = PriorityHigh
+	n.conf.MessagePriorities[20] = PriorityLow
+	p := testRandomPeer(n)
+
+	low := newParcel(TypeMessage, []byte{20})
+	normal := newParcel(TypeMessage, []byte{5})
+	high := newParcel(TypeMessage, []byte{1})
+	ping := newParcel(TypePing, []byte("Ping"))
+
+	for _, parcel := range []*Parcel{low, normal, high, ping} {
+		p.Send(parcel)
+	}
+
+	m := p.GetMetrics()
+	if m.QueuedHigh != 2 || m.QueuedNormal != 1 || m.QueuedLow != 1 {
+		t.Errorf("wrong lane metrics. got = %d/%d/%d, want = 2/1/1", m.QueuedHigh, m.QueuedNormal, m.QueuedLow)
+	}
+
+	for i, want := range []*Parcel{high, ping, normal, low} {
+		if got, ok := p.nextParcel(); !ok || got != want {
+			t.Errorf("parcel %d out of order. got = %v, want = %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package p2p
+
+import "time"
+
+// Clock is the source of time of a network. By default, networks use the time package.
+// A Simulation provides a virtual clock that only advances while the simulation runs
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// Since returns the time elapsed since t
+	Since(t time.Time) time.Duration
+	// Sleep pauses the current goroutine for the duration
+	Sleep(d time.Duration)
+	// After delivers the time on the returned channel once the duration elapsed
+	After(d time.Duration) <-chan time.Time
+	// NewTimer creates a timer that delivers the time on its channel once the duration elapsed
+	NewTimer(d time.Duration) Timer
+	// NewTicker creates a ticker that delivers the time on its channel every period
+	NewTicker(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine once the duration elapsed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer or ticker of a Clock
+type Timer interface {
+	// C returns the channel the time is delivered on, nil for timers of AfterFunc
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. Returns false if it already fired or was stopped
+	Stop() bool
+}
+
+// systemClock is the Clock of the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Timer {
+	return systemTicker{time.NewTicker(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.Ticker.C }
+
+func (t systemTicker) Stop() bool {
+	t.Ticker.Stop()
+	return true
+}
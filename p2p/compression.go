@@ -0,0 +1,194 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	snappy "github.com/FactomProject/snappy-go"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used to compress the payload of a TypeCompressed parcel.
+// Each algorithm has a feature that nodes announce in the handshake if they can decompress it.
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionSnappy
+	CompressionZstd
+)
+
+// compressedHeader is the size of the header of a compressed payload:
+// the algorithm (1 byte) and the type of the original parcel (2 bytes)
+const compressedHeader = 3
+
+var compressionNames = map[Compression]string{
+	CompressionNone:   "none",
+	CompressionSnappy: "snappy",
+	CompressionZstd:   "zstd",
+}
+
+var compressionFeatures = map[Compression]Feature{
+	CompressionSnappy: FeatureSnappy,
+	CompressionZstd:   FeatureZstd,
+}
+
+func (c Compression) String() string {
+	if s, ok := compressionNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown(%d)", byte(c))
+}
+
+// parseCompression parses a comma separated list of compression algorithms, in
+// order of preference
+func parseCompression(raw string) ([]Compression, error) {
+	var list []Compression
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for c, n := range compressionNames {
+			if n == name && c != CompressionNone {
+				list = append(list, c)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown compression %s", name)
+		}
+	}
+	return list, nil
+}
+
+// supportedCompression returns the features of all algorithms in the configuration
+func supportedCompression(conf *Configuration) Feature {
+	var f Feature
+	list, _ := parseCompression(conf.Compression)
+	for _, c := range list {
+		f |= compressionFeatures[c]
+	}
+	return f
+}
+
+// negotiateCompression picks the first algorithm of the configuration that the peer supports
+func negotiateCompression(conf *Configuration, features Feature) Compression {
+	list, _ := parseCompression(conf.Compression)
+	for _, c := range list {
+		if features&compressionFeatures[c] > 0 {
+			return c
+		}
+	}
+	return CompressionNone
+}
+
+var zstdOnce sync.Once
+var zstdEncoder *zstd.Encoder
+var zstdDecoder *zstd.Decoder
+
+// initZstd creates the shared zstd encoder and decoder, which are safe for concurrent use
+// in EncodeAll and DecodeAll
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(V11MaxParcelSize))
+	})
+}
+
+// compressParcel returns a TypeCompressed parcel wrapping the parcel. If the compressed payload
+// is not smaller than the original, the original parcel is returned
+func compressParcel(c Compression, parcel *Parcel) *Parcel {
+	var data []byte
+	switch c {
+	case CompressionSnappy:
+		data = snappy.Encode(nil, parcel.Payload)
+	case CompressionZstd:
+		initZstd()
+		data = zstdEncoder.EncodeAll(parcel.Payload, nil)
+	default:
+		return parcel
+	}
+
+	if len(data)+compressedHeader >= len(parcel.Payload) {
+		return parcel
+	}
+
+	payload := make([]byte, compressedHeader+len(data))
+	payload[0] = byte(c)
+	binary.BigEndian.PutUint16(payload[1:], uint16(parcel.ptype))
+	copy(payload[compressedHeader:], data)
+
+	compressed := newParcel(TypeCompressed, payload)
+	compressed.Address = parcel.Address
+	return compressed
+}
+
+// decompressParcel unwraps a TypeCompressed parcel. Only the algorithms in features are accepted
+func decompressParcel(features Feature, parcel *Parcel) (*Parcel, error) {
+	if len(parcel.Payload) <= compressedHeader {
+		return nil, fmt.Errorf("compressed payload too short")
+	}
+	c := Compression(parcel.Payload[0])
+	f, ok := compressionFeatures[c]
+	if !ok || features&f == 0 {
+		return nil, fmt.Errorf("compression %s was not negotiated", c)
+	}
+	ptype := ParcelType(binary.BigEndian.Uint16(parcel.Payload[1:]))
+	if ptype == TypeCompressed {
+		return nil, fmt.Errorf("nested compression")
+	}
+	data := parcel.Payload[compressedHeader:]
+
+	var payload []byte
+	var err error
+	switch c {
+	case CompressionSnappy:
+		var size int
+		if size, err = snappy.DecodedLen(data); err != nil {
+			return nil, err
+		}
+		if size > V11MaxParcelSize {
+			return nil, fmt.Errorf("decompressed payload too large %d bytes (max %d)", size, V11MaxParcelSize)
+		}
+		payload, err = snappy.Decode(nil, data)
+	case CompressionZstd:
+		initZstd()
+		payload, err = zstdDecoder.DecodeAll(data, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decompressed := newParcel(ptype, payload)
+	decompressed.Address = parcel.Address
+	return decompressed, nil
+}
+
+// compressionStats counts the payload bytes before and after compression and the time spent,
+// for one direction of a peer
+type compressionStats struct {
+	parcels      uint64
+	uncompressed uint64
+	compressed   uint64
+	time         time.Duration
+}
+
+func (cs *compressionStats) add(uncompressed, compressed int, took time.Duration) {
+	cs.parcels++
+	cs.uncompressed += uint64(uncompressed)
+	cs.compressed += uint64(compressed)
+	cs.time += took
+}
+
+// ratio is the uncompressed size divided by the compressed size, 0 if nothing was compressed
+func (cs *compressionStats) ratio() float64 {
+	if cs.compressed == 0 {
+		return 0
+	}
+	return float64(cs.uncompressed) / float64(cs.compressed)
+}
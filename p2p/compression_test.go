@@ -0,0 +1,179 @@
+package p2p
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+func testCompressiblePayload(size int) []byte {
+	words := [][]byte{[]byte("factom "), []byte("entry "), []byte("block "), []byte("directory ")}
+	var buf bytes.Buffer
+	for buf.Len() < size {
+		buf.Write(words[rand.Intn(len(words))])
+	}
+	return buf.Bytes()[:size]
+}
+
+func Test_parseCompression(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []Compression
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"zstd", []Compression{CompressionZstd}, false},
+		{" Snappy , zstd ", []Compression{CompressionSnappy, CompressionZstd}, false},
+		{"zstd,lz4", nil, true},
+		{"none", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseCompression(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCompression(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseCompression(%q) = %v, want %v", tt.raw, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseCompression(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		}
+	}
+}
+
+func Test_negotiateCompression(t *testing.T) {
+	conf := DefaultP2PConfiguration()
+	conf.Compression = "zstd,snappy"
+	if f := supportedFeatures(&conf); f&FeatureZstd == 0 || f&FeatureSnappy == 0 {
+		t.Errorf("supportedFeatures() = %d, missing compression", f)
+	}
+
+	if c := negotiateCompression(&conf, FeatureSnappy|FeatureZstd); c != CompressionZstd {
+		t.Errorf("negotiateCompression() = %s, want zstd", c)
+	}
+	if c := negotiateCompression(&conf, FeatureSnappy|FeatureGossip); c != CompressionSnappy {
+		t.Errorf("negotiateCompression() = %s, want snappy", c)
+	}
+	if c := negotiateCompression(&conf, FeatureGossip); c != CompressionNone {
+		t.Errorf("negotiateCompression() = %s, want none", c)
+	}
+
+	conf.Compression = ""
+	if c := negotiateCompression(&conf, FeatureSnappy|FeatureZstd); c != CompressionNone {
+		t.Errorf("negotiateCompression() = %s with compression disabled", c)
+	}
+}
+
+func Test_compressParcel(t *testing.T) {
+	for _, c := range []Compression{CompressionSnappy, CompressionZstd} {
+		t.Run(c.String(), func(t *testing.T) {
+			parcel := NewParcel("target", testCompressiblePayload(64*1024))
+			compressed := compressParcel(c, parcel)
+			if compressed.ptype != TypeCompressed {
+				t.Fatalf("parcel was not compressed")
+			}
+			if len(compressed.Payload) >= len(parcel.Payload)/2 {
+				t.Errorf("compressed payload is %d bytes, original %d", len(compressed.Payload), len(parcel.Payload))
+			}
+
+			got, err := decompressParcel(compressionFeatures[c], compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ptype != parcel.ptype || got.Address != parcel.Address || !bytes.Equal(got.Payload, parcel.Payload) {
+				t.Errorf("decompressed parcel differs. got = %s, want = %s", got, parcel)
+			}
+
+			if _, err := decompressParcel(FeatureGossip, compressed); err == nil {
+				t.Errorf("decompressed a parcel without the feature")
+			}
+
+			// incompressible data is sent as is
+			random := make([]byte, 4096)
+			rand.Read(random)
+			parcel = NewParcel("", random)
+			if got := compressParcel(c, parcel); got != parcel {
+				t.Errorf("incompressible parcel was compressed")
+			}
+		})
+	}
+}
+
+func Test_decompressParcel_invalid(t *testing.T) {
+	all := FeatureSnappy | FeatureZstd
+	valid := compressParcel(CompressionSnappy, NewParcel("", testCompressiblePayload(4096)))
+
+	nested := newParcel(TypeCompressed, append([]byte(nil), valid.Payload...))
+	nested.Payload[2] = byte(TypeCompressed)
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"short", []byte{byte(CompressionSnappy), 0, byte(TypeMessage)}},
+		{"unknown algorithm", []byte{9, 0, byte(TypeMessage), 1, 2, 3}},
+		{"corrupt snappy", []byte{byte(CompressionSnappy), 0, byte(TypeMessage), 0xff, 0xff, 0xff, 0xff, 0x0f, 1}},
+		{"corrupt zstd", []byte{byte(CompressionZstd), 0, byte(TypeMessage), 1, 2, 3, 4, 5}},
+		{"nested", nested.Payload},
+	}
+	for _, tt := range tests {
+		if _, err := decompressParcel(all, newParcel(TypeCompressed, tt.payload)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestPeer_compression(t *testing.T) {
+	n := testNetworkHarness(t)
+	n.conf.CompressionThreshold = 1024
+	A, B := net.Pipe()
+
+	sender := testRandomPeer(n)
+	sender.conn = A
+	sender._setProtocol(11, A)
+	sender.Features = FeatureZstd
+	sender.compression = CompressionZstd
+
+	receiver := testRandomPeer(n)
+	receiver.conn = B
+	receiver._setProtocol(11, B)
+	receiver.Features = FeatureZstd
+
+	go sender.sendLoop()
+	go receiver.readLoop()
+	defer sender.Stop()
+	defer receiver.Stop()
+
+	small := NewParcel("", testCompressiblePayload(100))
+	large := NewParcel("", testCompressiblePayload(32*1024))
+	sender.Send(small)
+	sender.Send(large)
+
+	for _, want := range []*Parcel{small, large} {
+		select {
+		case pp := <-n.controller.peerData:
+			if pp.parcel.ptype != want.ptype || !bytes.Equal(pp.parcel.Payload, want.Payload) {
+				t.Errorf("received parcel differs. got = %s, want = %s", pp.parcel, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("parcel did not arrive")
+		}
+	}
+
+	out, in := sender.GetMetrics(), receiver.GetMetrics()
+	if out.Compression != "zstd" || out.CompressionOut <= 2 {
+		t.Errorf("sender metrics: compression = %s, ratio = %f", out.Compression, out.CompressionOut)
+	}
+	if in.CompressionIn != out.CompressionOut {
+		t.Errorf("receiver ratio = %f, sender ratio = %f", in.CompressionIn, out.CompressionOut)
+	}
+	if out.CompressTime <= 0 || in.DecompressTime <= 0 {
+		t.Errorf("time spent was not counted. compress = %s, decompress = %s", out.CompressTime, in.DecompressTime)
+	}
+}
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"strconv"
 	"time"
@@ -33,7 +34,7 @@ type Configuration struct {
 	// to check for changes
 	PeerReseedInterval time.Duration
 	// PeerIPLimit specifies the maximum amount of peers to accept from a single
-	// ip address
+	// ip address. IPv6 addresses are limited per /64 subnet
 	// 0 for unlimited
 	PeerIPLimitIncoming uint
 	PeerIPLimitOutgoing uint
@@ -41,6 +42,13 @@ type Configuration struct {
 	// Special is a list of special peers, separated by comma. If no port is specified, the entire
 	// ip is considered special
 	Special string
+	// SpecialKeys is a list of hex encoded ed25519 public keys of special peers, separated by comma.
+	// Peers are only recognized by their key if they connect via protocol V12
+	SpecialKeys string
+
+	// NodeKey is the ed25519 key that identifies this node in protocol V12. A random key is
+	// created at startup if none is set
+	NodeKey ed25519.PrivateKey
 
 	// PeerCacheFile is the filepath to the file to save peers. It is persisted in every CAT round
 	PeerCacheFile string
@@ -72,17 +80,62 @@ type Configuration struct {
 	// Higher values increase fault tolerance but also increase network congestion
 	Fanout uint
 
-	// SeedURL is the URL of the remote seed file
+	// Gossip enables the structured gossip of Broadcast parcels with peers that support it.
+	// Messages are pushed along an eager set of at most Fanout peers and announced to the
+	// rest, which request them if they don't arrive in time. Peers that send duplicates are
+	// moved to the lazy set, forming a spanning tree. Peers without support use random selection
+	Gossip bool
+	// GossipLazyDelay is how long to wait for an announced message to arrive before
+	// requesting it from the peer that announced it
+	GossipLazyDelay time.Duration
+	// GossipAnnounceSize is the payload size in bytes from which messages are only announced,
+	// even to eager peers, who then request them. 0 to always push
+	GossipAnnounceSize int
+	// GossipCacheTime is how long messages are kept to answer requests and filter duplicates
+	GossipCacheTime time.Duration
+	// GossipMaxPending is the maximum number of announced messages waiting to arrive.
+	// Announcements of new messages beyond that are ignored
+	GossipMaxPending int
+	// GossipMaxPendingPerPeer is the maximum number of messages a single peer announced that
+	// haven't arrived yet. Further announcements of the peer are ignored
+	GossipMaxPendingPerPeer int
+
+	// SeedURL is a comma separated list of seed sources: http(s) urls of seed files,
+	// "dns:<name>[:port]" for DNS seeds, or paths of local seed files
 	SeedURL string // URL to a source of peer info
+	// SeedKeys is a list of hex encoded ed25519 public keys, separated by comma. If set,
+	// seed lists are only used if they are signed by one of the keys
+	SeedKeys string
+	// SeedDNSServer is the address (host:port) of the DNS server to query for DNS seeds.
+	// If empty, the system's resolver is used
+	SeedDNSServer string
+	// SeedMaxPerSource is the maximum number of endpoints used from a single seed source.
+	// 0 for unlimited
+	SeedMaxPerSource uint
 
 	// === Connection Settings ===
 
-	// BindIP is the ip address to bind to for listening and connecting
+	// BindIP is the ip address to bind to for listening and connecting. To bind to both
+	// an IPv4 and an IPv6 address, separate them by comma
 	//
 	// leave blank to bind to all
 	BindIP string
 	// ListenPort is the port to listen to incoming tcp connections on
 	ListenPort string
+	// ExternalHost and ExternalPort are the address other nodes should use to connect to this
+	// node, if it differs from the address they see, eg behind NAT or port forwarding.
+	// If empty, the host is learned from the port mapping or from peers and the port is the
+	// mapped port or ListenPort
+	ExternalHost string
+	ExternalPort string
+	// PortMapping sets up a port mapping on the gateway: "upnp", "natpmp", "auto" to try both,
+	// or empty to disable
+	PortMapping string
+	// PortMappingGateway is the address of the NAT-PMP gateway. If empty, the default gateway is used
+	PortMappingGateway string
+	// ObservedAddressThreshold is the number of peers with different addresses that have to
+	// observe this node at the same host before it is advertised. 0 to disable
+	ObservedAddressThreshold uint
 	// ListenLimit is the lockout period of accepting connections from a single
 	// ip after having a successful connection from that ip
 	ListenLimit time.Duration
@@ -98,6 +151,16 @@ type Configuration struct {
 	// ManualBan is the duration to ban an address for when banned manually
 	ManualBan time.Duration
 
+	// MisbehaviorThreshold is the score at which an address is banned automatically.
+	// Misbehavior adds its severity to the score of the address. 0 to disable
+	MisbehaviorThreshold uint
+	// MisbehaviorHalfLife is the time it takes for a misbehavior score to decay to half
+	MisbehaviorHalfLife time.Duration
+	// MisbehaviorBan is the duration of the first automatic ban, it doubles with every
+	// further ban of the same address up to MisbehaviorBanMax
+	MisbehaviorBan    time.Duration
+	MisbehaviorBanMax time.Duration
+
 	// HandshakeDeadline is the maximum acceptable time for an incoming conneciton
 	// to send the first parcel after connecting
 	HandshakeTimeout time.Duration
@@ -121,6 +184,14 @@ type Configuration struct {
 
 	EnablePrometheus bool // Enable prometheus logging. Disable if you run multiple instances
 
+	// Transport creates the connections of the network, nil for TCP. See Simulation
+	Transport Transport
+	// Clock is the source of time of the network, nil for the system clock. See Simulation
+	Clock Clock
+	// RandomSeed seeds the network's random number generator if it's not 0, to make
+	// simulations repeatable. Otherwise the current time is used
+	RandomSeed int64
+
 	// PeerResend turns on tracking of application parcels to prevent sending the same
 	// application parcel to peers who already sent it to us
 	PeerResendFilter bool
@@ -129,6 +200,29 @@ type Configuration struct {
 	PeerResendBuckets int
 	// PeerResendInterval controls how wide each bucket is
 	PeerResendInterval time.Duration
+
+	// Bandwidth limits in bytes per second, for all peers combined and for every single peer.
+	// 0 for unlimited
+	BandwidthGlobalIn  uint64
+	BandwidthGlobalOut uint64
+	BandwidthPeerIn    uint64
+	BandwidthPeerOut   uint64
+	// BandwidthBurst is how long a connection can be idle before it stops accumulating
+	// bandwidth for a burst
+	BandwidthBurst time.Duration
+
+	// Compression is a comma separated list of the algorithms ("zstd", "snappy") used to compress
+	// parcels, in order of preference. Parcels to a peer are compressed with the first algorithm
+	// it supports, empty to disable
+	Compression string
+	// CompressionThreshold is the payload size in bytes from which parcels are compressed
+	CompressionThreshold int
+
+	// ParcelPriorities determines the send lane of p2p parcels, not listed types use PriorityNormal
+	ParcelPriorities map[ParcelType]Priority
+	// MessagePriorities determines the send lane of application messages by the first byte of their
+	// payload, not listed messages use PriorityNormal
+	MessagePriorities map[byte]Priority
 }
 
 // DefaultP2PConfiguration returns a network configuration with base values
@@ -144,12 +238,22 @@ func DefaultP2PConfiguration() (c Configuration) {
 	c.PeerIPLimitIncoming = 0
 	c.PeerIPLimitOutgoing = 0
 	c.ManualBan = time.Hour * 24 * 7 // a week
+	c.MisbehaviorThreshold = 100
+	c.MisbehaviorHalfLife = time.Minute * 5
+	c.MisbehaviorBan = time.Minute * 10
+	c.MisbehaviorBanMax = time.Hour * 24
 
 	c.PeerCacheFile = ""
 	c.PeerCacheAge = time.Hour //
 
 	c.MaxIncoming = 36
 	c.Fanout = 8
+	c.Gossip = false
+	c.GossipLazyDelay = time.Millisecond * 500
+	c.GossipAnnounceSize = 1 << 15
+	c.GossipCacheTime = time.Minute * 2
+	c.GossipMaxPending = 8192
+	c.GossipMaxPendingPerPeer = 1024
 	c.PeerShareAmount = 3 // CAT share
 	c.PeerShareTimeout = time.Second * 5
 	c.RoundTime = time.Minute * 15
@@ -157,10 +261,12 @@ func DefaultP2PConfiguration() (c Configuration) {
 	c.MaxPeers = 36
 	c.DropTo = 30
 	c.MinReseed = 10
+	c.SeedMaxPerSource = 64
 
 	c.BindIP = "" // bind to all
 	c.ListenPort = "8108"
 	c.ListenLimit = time.Second
+	c.ObservedAddressThreshold = 3
 	c.PingInterval = time.Second * 15
 	c.RedialInterval = time.Minute * 2
 
@@ -178,6 +284,15 @@ func DefaultP2PConfiguration() (c Configuration) {
 	c.PeerResendFilter = true
 	c.PeerResendBuckets = 3
 	c.PeerResendInterval = time.Second * 20
+
+	c.BandwidthBurst = time.Second
+	c.Compression = "zstd,snappy"
+	c.CompressionThreshold = 1024
+	c.ParcelPriorities = map[ParcelType]Priority{
+		TypePing: PriorityHigh,
+		TypePong: PriorityHigh,
+	}
+	c.MessagePriorities = make(map[byte]Priority)
 	return
 }
 
@@ -201,6 +316,22 @@ func (c Configuration) Check() error {
 		return fmt.Errorf("config.ListenPort cannot be converted to a number: %v", err)
 	}
 
+	if c.ExternalPort != "" {
+		if port, err := strconv.Atoi(c.ExternalPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("config.ExternalPort is not a valid port")
+		}
+	}
+
+	if c.ExternalHost != "" && !(Endpoint{IP: normalizeHost(c.ExternalHost), Port: c.ListenPort}).Valid() {
+		return fmt.Errorf("config.ExternalHost is not a valid host")
+	}
+
+	switch c.PortMapping {
+	case "", PortMappingUPnP, PortMappingNATPMP, PortMappingAuto:
+	default:
+		return fmt.Errorf("config.PortMapping has unknown value %s", c.PortMapping)
+	}
+
 	if c.PeerShareAmount == 0 {
 		return fmt.Errorf("config.PeerShareAmount is zero")
 	}
@@ -217,6 +348,14 @@ func (c Configuration) Check() error {
 		return fmt.Errorf("config.Fanout is not set")
 	}
 
+	if c.Gossip && (c.GossipLazyDelay <= 0 || c.GossipCacheTime < c.GossipLazyDelay) {
+		return fmt.Errorf("config.GossipLazyDelay is not set or longer than config.GossipCacheTime")
+	}
+
+	if c.Gossip && (c.GossipMaxPending <= 0 || c.GossipMaxPendingPerPeer <= 0) {
+		return fmt.Errorf("config.GossipMaxPending or config.GossipMaxPendingPerPeer is not set")
+	}
+
 	if c.HandshakeTimeout == 0 {
 		return fmt.Errorf("config.HandshakeTimeout is not set")
 	}
@@ -233,14 +372,26 @@ func (c Configuration) Check() error {
 		return fmt.Errorf("config.WriteDeadline is not set")
 	}
 
-	if c.ProtocolVersion < 9 || c.ProtocolVersion > 11 {
-		return fmt.Errorf("config.ProtocolVersion outside of range of support protocols (9,10,11)")
+	if c.ProtocolVersion < 9 || c.ProtocolVersion > 12 {
+		return fmt.Errorf("config.ProtocolVersion outside of range of support protocols (9,10,11,12)")
 	}
 
-	if c.ProtocolVersionMinimum > 11 {
+	if c.ProtocolVersionMinimum > 12 {
 		return fmt.Errorf("config.ProtocolVersionMinimum is higher than the maximum supported protocol")
 	}
 
+	if c.MisbehaviorThreshold > 0 && (c.MisbehaviorBan == 0 || c.MisbehaviorBanMax < c.MisbehaviorBan) {
+		return fmt.Errorf("config.MisbehaviorBan is not set or larger than config.MisbehaviorBanMax")
+	}
+
+	if (c.BandwidthGlobalIn > 0 || c.BandwidthGlobalOut > 0 || c.BandwidthPeerIn > 0 || c.BandwidthPeerOut > 0) && c.BandwidthBurst <= 0 {
+		return fmt.Errorf("config.BandwidthBurst is not set")
+	}
+
+	if _, err := parseCompression(c.Compression); err != nil {
+		return fmt.Errorf("config.Compression: %v", err)
+	}
+
 	if c.ChannelCapacity == 0 {
 		return fmt.Errorf("config.ChannelCapacity is not set")
 	}
@@ -253,5 +404,17 @@ func (c Configuration) Check() error {
 		return fmt.Errorf("config.Special contains unparseable endpoints")
 	}
 
+	if _, err := parsePublicKeys(c.SpecialKeys); err != nil {
+		return fmt.Errorf("config.SpecialKeys contains unparseable keys: %v", err)
+	}
+
+	if _, err := parsePublicKeys(c.SeedKeys); err != nil {
+		return fmt.Errorf("config.SeedKeys contains unparseable keys: %v", err)
+	}
+
+	if c.NodeKey != nil && len(c.NodeKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("config.NodeKey has the wrong length")
+	}
+
 	return nil
 }
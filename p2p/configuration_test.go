@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"reflect"
 	"testing"
@@ -31,6 +32,10 @@ func TestConfiguration_Check(t *testing.T) {
 		{"ListenPort", "abc"},
 		{"ListenPort", ":123"},
 		{"ListenPort", "123abc"},
+		{"ExternalPort", "abc"},
+		{"ExternalPort", "70000"},
+		{"ExternalHost", "bad host!"},
+		{"PortMapping", "foo"},
 		{"PeerShareAmount", uint(0)},
 		{"RoundTime", time.Duration(0)},
 		{"TargetPeers", uint(0)},
@@ -41,10 +46,15 @@ func TestConfiguration_Check(t *testing.T) {
 		{"WriteDeadline", time.Duration(0)},
 		{"ProtocolVersion", uint16(0)},
 		{"ProtocolVersion", uint16(8)},
-		{"ProtocolVersion", uint16(12)},
-		{"ProtocolVersionMinimum", uint16(12)},
+		{"ProtocolVersion", uint16(13)},
+		{"ProtocolVersionMinimum", uint16(13)},
 		{"ChannelCapacity", uint(0)},
 		{"Special", "abc"}, // parseSpecial has its own unit tests, only check that it's checked
+		{"SpecialKeys", "abc"},
+		{"SpecialKeys", "abcd"},
+		{"SeedKeys", "abc"},
+		{"Compression", "zstd,lz4"},
+		{"NodeKey", ed25519.PrivateKey{1, 2, 3}},
 	}
 	for i, tt := range tests {
 		t.Run(fmt.Sprintf("%d-%s", i, tt.name), func(t *testing.T) {
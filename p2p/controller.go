@@ -27,8 +27,25 @@ type controller struct {
 	bans             map[string]time.Time // (ip|ip:port) => time the ban ends
 	special          map[string]bool      // (ip|ip:port) => bool
 	specialEndpoints []Endpoint
+	specialKeys      map[string]bool // ed25519 public key => bool
 	bootstrap        []Endpoint
 
+	reputationMtx sync.Mutex
+	scores        map[string]*peerScore // ip => misbehavior score
+	offenses      map[string]*Offense   // ip => automatic bans so far
+
+	addressMtx    sync.RWMutex
+	mappedHost    string                     // external host of the port mapping
+	mappedPort    string                     // external port of the port mapping
+	observed      map[string]map[string]bool // host => ips of peers that observed this node at host
+	observedOrder []string                   // observed hosts, oldest first
+	learnedHost   string                     // host observed by enough peers
+
+	gossipMtx      sync.Mutex
+	gossipMessages map[gossipID]*gossipMessage
+	gossipPending  int            // announced messages that haven't arrived yet
+	gossipAnnounce map[string]int // peer hash => announced messages of the peer that haven't arrived yet
+
 	shareListener map[string]chan *Parcel
 	shareMtx      sync.RWMutex
 
@@ -65,7 +82,9 @@ func newController(network *Network) (*controller, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize dialer: %v", err)
 	}
-	c.lastPersist = time.Now()
+	c.dialer.transport = conf.Transport
+	c.dialer.clock = network.clock
+	c.lastPersist = c.net.clock.Now()
 
 	c.peerStatus = make(chan peerStatus, 10) // TODO reconsider this value
 	c.peerData = make(chan peerParcel, conf.ChannelCapacity)
@@ -74,11 +93,15 @@ func newController(network *Network) (*controller, error) {
 	c.shareListener = make(map[string]chan *Parcel)
 
 	// CAT
-	c.lastRound = time.Now()
-	c.seed = newSeed(conf.SeedURL, conf.PeerReseedInterval)
+	c.lastRound = c.net.clock.Now()
+	c.seed, err = newSeed(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize seed: %v", err)
+	}
 
 	c.peers = NewPeerStore()
 	c.setSpecial(conf.Special)
+	c.setSpecialKeys(conf.SpecialKeys)
 
 	if cache, err := c.loadPeerCache(); err != nil || cache == nil {
 		c.logger.Infof("no valid bootstrap file found")
@@ -87,6 +110,13 @@ func newController(network *Network) (*controller, error) {
 	} else if cache != nil {
 		c.bans = cache.Bans
 		c.bootstrap = cache.Peers
+		c.offenses = cache.Offenses
+	}
+	c.scores = make(map[string]*peerScore)
+	c.gossipMessages = make(map[gossipID]*gossipMessage)
+	c.gossipAnnounce = make(map[string]int)
+	if c.offenses == nil {
+		c.offenses = make(map[string]*Offense)
 	}
 
 	return c, nil
@@ -99,7 +129,7 @@ func (c *controller) ban(hash string, duration time.Duration) {
 	if peer != nil {
 		c.banMtx.Lock()
 
-		end := time.Now().Add(duration)
+		end := c.net.clock.Now().Add(duration)
 
 		// there's a stronger ban in place already
 		if existing, ok := c.bans[peer.Endpoint.IP]; ok && end.Before(existing) {
@@ -112,7 +142,7 @@ func (c *controller) ban(hash string, duration time.Duration) {
 
 		for _, p := range c.peers.Slice() {
 			if p.Endpoint.IP == peer.Endpoint.IP {
-				peer.Stop()
+				p.Stop()
 			}
 		}
 		c.banMtx.Unlock()
@@ -123,7 +153,7 @@ func (c *controller) ban(hash string, duration time.Duration) {
 // to nullify a ban, use a duration of zero.
 func (c *controller) banEndpoint(ep Endpoint, duration time.Duration) {
 	c.banMtx.Lock()
-	c.bans[ep.String()] = time.Now().Add(duration)
+	c.bans[ep.String()] = c.net.clock.Now().Add(duration)
 	c.banMtx.Unlock()
 
 	if duration > 0 {
@@ -135,16 +165,51 @@ func (c *controller) banEndpoint(ep Endpoint, duration time.Duration) {
 	}
 }
 
+// unban lifts the ban of an endpoint or an ip address, including the bans of all endpoints
+// with that ip. Returns false if nothing was banned
+func (c *controller) unban(addr string) bool {
+	c.banMtx.Lock()
+	defer c.banMtx.Unlock()
+
+	found := false
+	now := c.net.clock.Now()
+	for key, end := range c.bans {
+		match := key == addr
+		if ep, err := ParseEndpoint(key); err == nil && ep.IP == addr {
+			match = true
+		}
+		if match {
+			found = found || now.Before(end)
+			delete(c.bans, key)
+		}
+	}
+	return found
+}
+
+// copyBans returns the active bans
+func (c *controller) copyBans() map[string]time.Time {
+	c.banMtx.RLock()
+	defer c.banMtx.RUnlock()
+	bans := make(map[string]time.Time)
+	now := c.net.clock.Now()
+	for addr, end := range c.bans {
+		if now.Before(end) {
+			bans[addr] = end
+		}
+	}
+	return bans
+}
+
 func (c *controller) isBannedEndpoint(ep Endpoint) bool {
 	c.banMtx.RLock()
 	defer c.banMtx.RUnlock()
-	return time.Now().Before(c.bans[ep.IP]) || time.Now().Before(c.bans[ep.String()])
+	return c.net.clock.Now().Before(c.bans[ep.IP]) || c.net.clock.Now().Before(c.bans[ep.String()])
 }
 
 func (c *controller) isBannedIP(ip string) bool {
 	c.banMtx.RLock()
 	defer c.banMtx.RUnlock()
-	return time.Now().Before(c.bans[ip])
+	return c.net.clock.Now().Before(c.bans[ip])
 }
 
 func (c *controller) isSpecial(ep Endpoint) bool {
@@ -159,6 +224,19 @@ func (c *controller) isSpecialIP(ip string) bool {
 	return c.special[ip]
 }
 
+// isSpecialPeer checks if the peer is special by either its endpoint or its verified public key
+func (c *controller) isSpecialPeer(p *Peer) bool {
+	if c.isSpecial(p.Endpoint) {
+		return true
+	}
+	if len(p.PublicKey) == 0 {
+		return false
+	}
+	c.specialMtx.RLock()
+	defer c.specialMtx.RUnlock()
+	return c.specialKeys[string(p.PublicKey)]
+}
+
 func (c *controller) disconnect(hash string) {
 	peer := c.peers.Get(hash)
 	if peer != nil {
@@ -191,15 +269,28 @@ func (c *controller) setSpecial(raw string) {
 	}
 }
 
+func (c *controller) setSpecialKeys(raw string) {
+	keys, err := parsePublicKeys(raw)
+	if err != nil {
+		c.logger.WithError(err).Warnf("unable to parse special keys")
+		keys = make(map[string]bool)
+	}
+
+	c.specialMtx.Lock()
+	c.specialKeys = keys
+	c.specialMtx.Unlock()
+}
+
 // Start starts the controller
 // reads from the seed and connect to peers
 func (c *controller) Start() {
 	c.logger.Info("Starting the Controller")
 
-	go c.run()          // cycle every 1s
-	go c.manageData()   // blocking on data
-	go c.manageOnline() // blocking on peer status changes
-	go c.listen()       // blocking on tcp connections
-	go c.catReplenish() // cycle every 1s
-	go c.route()        // route data
+	go c.run()            // cycle every 1s
+	go c.manageData()     // blocking on data
+	go c.manageOnline()   // blocking on peer status changes
+	go c.listen()         // blocking on tcp connections
+	go c.catReplenish()   // cycle every 1s
+	go c.route()          // route data
+	go c.runPortMapping() // renews the port mapping, if enabled
 }
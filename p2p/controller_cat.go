@@ -8,10 +8,10 @@ import (
 // runs a single CAT round that persists peers and drops random connections.
 // this function is triggered once a second by the controller.run function
 func (c *controller) runCatRound() {
-	if time.Since(c.lastRound) < c.net.conf.RoundTime {
+	if c.net.clock.Since(c.lastRound) < c.net.conf.RoundTime {
 		return
 	}
-	c.lastRound = time.Now()
+	c.lastRound = c.net.clock.Now()
 	c.logger.Debug("Cat Round")
 	c.rounds++
 	if c.net.prom != nil {
@@ -31,7 +31,7 @@ func (c *controller) runCatRound() {
 
 		dropped := 0
 		for _, i := range perm {
-			if c.isSpecial(peers[i].Endpoint) {
+			if c.isSpecialPeer(peers[i]) {
 				continue
 			}
 			peers[i].Stop()
@@ -49,6 +49,7 @@ func (c *controller) processPeerShare(peer *Peer, parcel *Parcel) []Endpoint {
 
 	if err != nil {
 		c.logger.WithError(err).Warnf("Failed to unmarshal peer share from peer %s", peer)
+		c.peerMisbehavior(peer, SeverityModerate, "undecodable peer share")
 		return nil
 	}
 
@@ -58,6 +59,7 @@ func (c *controller) processPeerShare(peer *Peer, parcel *Parcel) []Endpoint {
 	for _, ep := range list {
 		if !ep.Valid() {
 			c.logger.Infof("Peer %s tried to send us peer share with bad data: %s", peer, ep)
+			c.peerMisbehavior(peer, SeverityModerate, "invalid peer share")
 			return nil
 		}
 
@@ -89,7 +91,11 @@ func (c *controller) makePeerShare(exclude Endpoint) []Endpoint {
 		if exclude.Equal(peers[i].Endpoint) {
 			continue
 		}
-		list = append(list, peers[i].Endpoint)
+		ep := peers[i].Advertised
+		if !ep.Valid() {
+			ep = peers[i].Endpoint
+		}
+		list = append(list, ep)
 		if uint(len(list)) >= c.net.conf.PeerShareAmount {
 			break
 		}
@@ -135,14 +141,14 @@ func (c *controller) asyncPeerRequest(peer *Peer) ([]Endpoint, error) {
 	}()
 
 	req := newParcel(TypePeerRequest, []byte("Peer Request"))
-	peer.lastPeerSend = time.Now()
+	peer.lastPeerSend = c.net.clock.Now()
 	peer.Send(req)
 
 	select {
 	case parcel := <-async:
 		share := c.shuffleTrimShare(c.processPeerShare(peer, parcel))
 		return share, nil
-	case <-time.After(c.net.conf.PeerShareTimeout):
+	case <-c.net.clock.After(c.net.conf.PeerShareTimeout):
 		return nil, fmt.Errorf("timeout")
 	}
 }
@@ -174,7 +180,7 @@ func (c *controller) catReplenish() {
 		c.bootstrap = nil
 	}
 
-	lastReseed := time.Now()
+	lastReseed := c.net.clock.Now()
 
 	for {
 		select {
@@ -185,7 +191,7 @@ func (c *controller) catReplenish() {
 
 		var connect []Endpoint
 		if uint(c.peers.Total()) >= c.net.conf.TargetPeers {
-			time.Sleep(time.Second)
+			c.net.clock.Sleep(time.Second)
 			continue
 		}
 
@@ -202,7 +208,7 @@ func (c *controller) catReplenish() {
 			minReseed = uint(c.seed.size()) - 1
 		}
 
-		if uint(c.peers.Total()) <= minReseed || time.Since(lastReseed) > c.net.conf.PeerReseedInterval {
+		if uint(c.peers.Total()) <= minReseed || c.net.clock.Since(lastReseed) > c.net.conf.PeerReseedInterval {
 			seeds := c.seed.retrieve()
 			// shuffle to hit different seeds
 			c.net.rng.Shuffle(len(seeds), func(i, j int) {
@@ -213,12 +219,12 @@ func (c *controller) catReplenish() {
 					connect = append(connect, s)
 				}
 			}
-			lastReseed = time.Now()
+			lastReseed = c.net.clock.Now()
 		}
 
 		if c.peers.Total() > 0 {
 			rand := c.randomPeerConditional(func(p *Peer) bool {
-				return time.Since(p.lastPeerSend) >= c.net.conf.PeerRequestInterval
+				return c.net.clock.Since(p.lastPeerSend) >= c.net.conf.PeerRequestInterval
 			})
 			if rand != nil {
 				// error just means timeout of async request
@@ -261,7 +267,7 @@ func (c *controller) catReplenish() {
 		}
 
 		if attempts == 0 { // no peers and we exhausted special and seeds
-			time.Sleep(time.Second)
+			c.net.clock.Sleep(time.Second)
 		}
 	}
 }
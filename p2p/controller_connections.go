@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -78,7 +79,7 @@ func (c *controller) handshakeIncoming(con net.Conn, ep Endpoint) error {
 		defer c.net.prom.Connecting.Dec()
 	}
 
-	con.SetDeadline(time.Now().Add(c.net.conf.HandshakeTimeout))
+	con.SetDeadline(c.net.clock.Now().Add(c.net.conf.HandshakeTimeout))
 
 	// reject incoming connections based on host
 	// the ep's port is our local port so we can't check that yet
@@ -90,13 +91,16 @@ func (c *controller) handshakeIncoming(con net.Conn, ep Endpoint) error {
 
 	// upgrade connection to a metrics connection
 	metrics := NewMetricsReadWriter(con)
-	prot, handshake, err := c.detectProtocolFromFirstMessage(metrics)
+	prot, handshake, err := c.detectProtocolFromFirstMessage(metrics, nil)
 	if err != nil {
+		if isProtocolError(err) {
+			c.misbehavior(ep.IP, SeverityModerate, "failed handshake")
+		}
 		con.Close()
 		return fmt.Errorf("error detecting protocol: %v", err)
 	}
 
-	reply := newHandshake(c.net.conf, c.net.instanceID)
+	reply := c.newHandshake(ep.IP, c.net.instanceID)
 	reply.Version = handshake.Version
 	if err := prot.SendHandshake(reply); err != nil {
 		con.Close()
@@ -106,7 +110,9 @@ func (c *controller) handshakeIncoming(con net.Conn, ep Endpoint) error {
 	// listenport has been validated in handshake.Valid
 	ep.Port = handshake.ListenPort
 
-	peer := newPeer(c.net, handshake.NodeID, ep, con, prot, metrics, true)
+	peer := newPeer(c.net, handshake.NodeID, ep, con, prot, metrics, true, handshake.Features)
+	peer.Advertised = handshake.advertised(ep, c.net.conf.HandshakeTimeout)
+	c.observeAddress(ep.IP, handshake.ObservedHost)
 	c.peerStatus <- peerStatus{peer: peer, online: true}
 
 	// a p2p1 node sends a peer request, so it needs to be processed
@@ -122,7 +128,8 @@ func (c *controller) handshakeIncoming(con net.Conn, ep Endpoint) error {
 
 // detectProtocolFromFirstMessage will listen for data to arrive on the ReadWriter and then attempt to interpret it.
 // the existing protocol is only needed for nodes running v9 in order to bring
-func (c *controller) detectProtocolFromFirstMessage(rw io.ReadWriter) (Protocol, *Handshake, error) {
+// and for nodes running v12, which continue the key exchange they started
+func (c *controller) detectProtocolFromFirstMessage(rw io.ReadWriter, desired Protocol) (Protocol, *Handshake, error) {
 	var prot Protocol
 	var handshake *Handshake
 
@@ -149,6 +156,32 @@ func (c *controller) detectProtocolFromFirstMessage(rw io.ReadWriter) (Protocol,
 			return nil, nil, err
 		}
 		handshake = hs
+	} else if bytes.Equal(sig, V12Signature) {
+		rw = struct {
+			io.Reader
+			io.Writer
+		}{buffy, rw}
+
+		var v12 *ProtocolV12
+		if outgoing, ok := desired.(*ProtocolV12); ok {
+			v12 = outgoing
+			v12.rw = rw
+		} else if desired == nil {
+			v12 = newProtocolV12(rw, c.net.conf.NodeKey, c.net.conf.Network, false)
+		} else {
+			return nil, nil, fmt.Errorf("remote replied with protocol 12 to a handshake of protocol %s", desired)
+		}
+
+		hs, err := v12.ReadHandshake()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := hs.Valid(c.net.conf); err != nil {
+			return nil, nil, err
+		}
+		prot = v12
+		handshake = hs
 	} else { // default = gob
 		decoder := gob.NewDecoder(buffy)
 		encoder := gob.NewEncoder(rw)
@@ -190,6 +223,8 @@ func (c *controller) detectProtocolFromFirstMessage(rw io.ReadWriter) (Protocol,
 // used to send the initial handshake when no other information is present.
 func (c *controller) selectProtocol(rw io.ReadWriter) Protocol {
 	switch c.net.conf.ProtocolVersion {
+	case 12:
+		return newProtocolV12(rw, c.net.conf.NodeKey, c.net.conf.Network, true)
 	case 11:
 		return newProtocolV11(rw)
 	case 10:
@@ -215,10 +250,10 @@ func (c *controller) selectProtocol(rw io.ReadWriter) Protocol {
 // For more information, see the README
 func (c *controller) handshakeOutgoing(con net.Conn, ep Endpoint) (*Peer, []Endpoint, error) {
 	tmplogger := c.logger.WithField("endpoint", ep)
-	timeout := time.Now().Add(c.net.conf.HandshakeTimeout)
+	timeout := c.net.clock.Now().Add(c.net.conf.HandshakeTimeout)
 	con.SetDeadline(timeout)
 
-	handshake := newHandshake(c.net.conf, c.net.instanceID)
+	handshake := c.newHandshake(ep.IP, c.net.instanceID)
 	metrics := NewMetricsReadWriter(con)
 	desiredProt := c.selectProtocol(metrics)
 
@@ -231,7 +266,7 @@ func (c *controller) handshakeOutgoing(con net.Conn, ep Endpoint) (*Peer, []Endp
 		return failfunc(err)
 	}
 
-	prot, reply, err := c.detectProtocolFromFirstMessage(metrics)
+	prot, reply, err := c.detectProtocolFromFirstMessage(metrics, desiredProt)
 	if err != nil {
 		return failfunc(err)
 	}
@@ -260,7 +295,9 @@ func (c *controller) handshakeOutgoing(con net.Conn, ep Endpoint) (*Peer, []Endp
 		return nil, reply.Alternatives, fmt.Errorf("connection rejected")
 	}
 
-	peer := newPeer(c.net, reply.NodeID, ep, con, prot, metrics, false)
+	peer := newPeer(c.net, reply.NodeID, ep, con, prot, metrics, false, reply.Features)
+	peer.Advertised = ep // reachable at the dialed address
+	c.observeAddress(ep.IP, reply.ObservedHost)
 	c.peerStatus <- peerStatus{peer: peer, online: true}
 
 	// a p2p1 node sends a peer request, so it needs to be processed
@@ -280,9 +317,15 @@ func (c *controller) handshakeOutgoing(con net.Conn, ep Endpoint) (*Peer, []Endp
 func (c *controller) RejectWithShare(con net.Conn, share []Endpoint) error {
 	defer con.Close() // we're rejecting, so always close
 
-	prot := c.selectProtocol(con)
+	// v12 can only send a handshake after the key exchange, so the rejection uses v11
+	var prot Protocol
+	if c.net.conf.ProtocolVersion == 12 {
+		prot = newProtocolV11(con)
+	} else {
+		prot = c.selectProtocol(con)
+	}
 
-	handshake := newHandshake(c.net.conf, 0)
+	handshake := c.newHandshake("", 0)
 	handshake.Type = TypeRejectAlternative
 	handshake.Alternatives = share
 
@@ -330,13 +373,31 @@ func (c *controller) listen() {
 	tmpLogger := c.logger.WithFields(log.Fields{"host": c.net.conf.BindIP, "port": c.net.conf.ListenPort})
 	tmpLogger.Debug("controller.listen() starting up")
 
-	addr := fmt.Sprintf("%s:%s", c.net.conf.BindIP, c.net.conf.ListenPort)
-
-	l, err := NewLimitedListener(addr, c.net.conf.ListenLimit)
+	// an empty bind ip listens on all addresses of both ip versions
+	addrs := []string{net.JoinHostPort("", c.net.conf.ListenPort)}
+	if ips := splitAddresses(c.net.conf.BindIP); len(ips) > 0 {
+		addrs = addrs[:0]
+		for _, ip := range ips {
+			addrs = append(addrs, net.JoinHostPort(ip, c.net.conf.ListenPort))
+		}
+	}
+	addr := strings.Join(addrs, ",")
+
+	var l *LimitedListener
+	var err error
+	if c.net.conf.Transport != nil {
+		var tl net.Listener
+		if tl, err = c.net.conf.Transport.Listen(addr); err == nil {
+			l = newLimitedListener(tl, c.net.conf.ListenLimit)
+		}
+	} else {
+		l, err = NewLimitedListener(addr, c.net.conf.ListenLimit)
+	}
 	if err != nil {
 		tmpLogger.WithError(err).Error("controller.Start() unable to start limited listener")
 		return
 	}
+	l.clock = c.net.clock
 	defer tmpLogger.Debug("controller.listen() stopping")
 	c.listener = l
 
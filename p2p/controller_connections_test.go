@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"math/rand"
 	"net"
 	"reflect"
@@ -18,6 +19,7 @@ func _detectProtocol(t *testing.T, version uint16) {
 	conf.ProtocolVersion = version
 	conf.NodeID = 1
 	conf.ListenPort = "8999"
+	conf.Compression = "" // features don't survive v9 and v10 handshakes
 
 	A, B := net.Pipe()
 	A.SetDeadline(time.Now().Add(time.Millisecond * 50))
@@ -26,6 +28,7 @@ func _detectProtocol(t *testing.T, version uint16) {
 	c := new(controller)
 	c.net = new(Network)
 	c.net.conf = &conf
+	c.net.clock = systemClock{}
 	c.net.instanceID = 666
 
 	sendprot := c.selectProtocol(B)
@@ -42,7 +45,7 @@ func _detectProtocol(t *testing.T, version uint16) {
 		}
 	}()
 
-	prot, hs, err := c.detectProtocolFromFirstMessage(A)
+	prot, hs, err := c.detectProtocolFromFirstMessage(A, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -79,12 +82,14 @@ func handshakeTestInstance(version uint16) *controller {
 	conf.ListenPort = "123"
 	conf.ReadDeadline = time.Second
 	conf.WriteDeadline = time.Second
+	_, conf.NodeKey, _ = ed25519.GenerateKey(nil)
 	c := new(controller)
 	c.peers = new(PeerStore)
 	c.special = make(map[string]bool)
 	c.net = new(Network)
 	c.net.controller = c
 	c.net.conf = &conf
+	c.net.clock = systemClock{}
 
 	c.peerStatus = make(chan peerStatus, 2)
 	c.peerData = make(chan peerParcel, 2)
@@ -182,6 +187,9 @@ func Test_controller_handshakes(t *testing.T) {
 	testControllerHandshakes(t, "agree on upper 10->11", 10, 11, 11)
 	testControllerHandshakes(t, "agree on lower 10->9", 10, 9, 9)
 	testControllerHandshakes(t, "agree on lower 11->9", 11, 9, 9)
+	testControllerHandshakes(t, "same version 12", 12, 12, 12)
+	testControllerHandshakes(t, "agree on upper 9->12", 9, 12, 12)
+	testControllerHandshakes(t, "agree on upper 11->12", 11, 12, 12)
 
 }
 
@@ -264,6 +272,67 @@ func Test_controller_listen(t *testing.T) {
 	<-done
 }
 
+func Test_controller_listenDualStack(t *testing.T) {
+	n1 := testNetworkHarness(t)
+	n1.conf.BindIP = "127.0.0.1,::1"
+	n1.conf.ListenPort = "14238"
+
+	done := make(chan bool, 1)
+	go func() {
+		n1.controller.listen()
+		done <- true
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		con, err := net.Dial("tcp", net.JoinHostPort(host, n1.conf.ListenPort))
+		if err != nil {
+			t.Errorf("unable to connect to %s: %v", host, err)
+			continue
+		}
+		con.Close()
+	}
+	n1.Stop()
+	<-done
+}
+
+// connects two nodes over the ipv6 loopback
+func Test_controller_connectIPv6(t *testing.T) {
+	n1 := testNetworkHarness(t)
+	n1.conf.BindIP = "::1"
+	n1.conf.ListenPort = "14239"
+	n2 := testNetworkHarness(t)
+
+	done := make(chan bool, 1)
+	go func() {
+		n1.controller.listen()
+		done <- true
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	peer, _ := n2.controller.Dial(Endpoint{IP: "::1", Port: "14239"})
+	if peer == nil {
+		t.Fatal("unable to connect via ipv6")
+	}
+	defer peer.Stop()
+	if peer.Endpoint.String() != "[::1]:14239" {
+		t.Errorf("wrong peer endpoint. got = %s", peer.Endpoint)
+	}
+
+	select {
+	case pc := <-n1.controller.peerStatus:
+		if pc.peer.Endpoint.IP != "::1" {
+			t.Errorf("wrong incoming endpoint. got = %s", pc.peer.Endpoint)
+		}
+		pc.peer.Stop()
+	case <-time.After(time.Second):
+		t.Errorf("incoming ipv6 peer not registered")
+	}
+
+	n1.Stop()
+	<-done
+}
+
 // only checks that controller.Dial will open a tcp connection
 func Test_controller_Dial(t *testing.T) {
 	n1 := testNetworkHarness(t)
@@ -335,6 +404,22 @@ func Test_controller_allowIncoming(t *testing.T) {
 	}
 }
 
+func Test_controller_allowIncomingIPv6(t *testing.T) {
+	net := testNetworkHarness(t)
+	net.conf.PeerIPLimitIncoming = 1
+
+	p := testRandomPeer(net)
+	p.Endpoint = Endpoint{IP: "2001:db8::1", Port: "8108"}
+	net.controller.peers.Add(p)
+
+	if err := net.controller.allowIncoming("2001:db8::2"); err == nil {
+		t.Errorf("accepted a second peer from the same /64 subnet")
+	}
+	if err := net.controller.allowIncoming("2001:db8:0:1::1"); err != nil {
+		t.Errorf("rejected a peer from a different subnet: %v", err)
+	}
+}
+
 func Test_controller_RejectWithShare(t *testing.T) {
 	n := testNetworkHarness(t)
 	for _, i := range []uint16{9, 11} {
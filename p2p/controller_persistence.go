@@ -10,11 +10,14 @@ import (
 type PeerCache struct {
 	Bans  map[string]time.Time `json:"bans"` // can be ip or ip:port
 	Peers []Endpoint           `json:"peers"`
+	// Offenses are the automatic bans per ip, they determine the length of the next one
+	Offenses map[string]*Offense `json:"offenses,omitempty"`
 }
 
 func newPeerCache() *PeerCache {
 	pc := new(PeerCache)
 	pc.Bans = make(map[string]time.Time)
+	pc.Offenses = make(map[string]*Offense)
 	return pc
 }
 
@@ -62,6 +65,8 @@ func (c *controller) createPeerCache() *PeerCache {
 	}
 	c.banMtx.Unlock()
 
+	pc.Offenses = c.copyOffenses()
+
 	peers := c.peers.Slice()
 	pc.Peers = make([]Endpoint, len(peers))
 	for i, p := range peers {
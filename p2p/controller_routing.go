@@ -2,7 +2,6 @@ package p2p
 
 import (
 	"crypto/sha1"
-	"time"
 )
 
 // route takes messages from ToNetwork and routes it to the appropriate peers
@@ -34,6 +33,9 @@ func (c *controller) route() {
 				} else {
 					selection = c.peers.Slice()
 				}
+				if c.net.conf.Gossip {
+					selection = c.gossipBroadcast(parcel, selection)
+				}
 				selection = c.selectBroadcastPeers(selection, c.net.conf.Fanout)
 				for _, p := range selection {
 					p.Send(parcel)
@@ -86,13 +88,37 @@ func (c *controller) manageData() {
 				if dropped > 0 && c.net.prom != nil {
 					c.net.prom.DroppedFromNetwork.Add(float64(dropped))
 				}
+			case TypeGossip:
+				if c.gossipReceive(peer, parcel) {
+					parcel.ptype = TypeMessage
+					_, dropped := c.net.fromNetwork.Send(parcel)
+					if dropped > 0 && c.net.prom != nil {
+						c.net.prom.DroppedFromNetwork.Add(float64(dropped))
+					}
+				}
+			case TypeGossipHave:
+				if err := c.gossipHave(peer, parcel); err != nil {
+					c.logger.WithError(err).Warnf("peer %s sent a bad gossip announcement", peer)
+					c.peerMisbehavior(peer, SeverityModerate, "bad gossip announcement")
+				}
+			case TypeGossipGraft:
+				if err := c.gossipGraft(peer, parcel); err != nil {
+					c.logger.WithError(err).Warnf("peer %s sent a bad gossip request", peer)
+					c.peerMisbehavior(peer, SeverityModerate, "bad gossip request")
+				}
+			case TypeGossipPrune:
+				if err := c.gossipPrune(peer, parcel); err != nil {
+					c.logger.WithError(err).Warnf("peer %s sent a bad gossip prune", peer)
+					c.peerMisbehavior(peer, SeverityModerate, "bad gossip prune")
+				}
 			case TypePeerRequest:
-				if time.Since(peer.lastPeerRequest) >= c.net.conf.PeerRequestInterval {
-					peer.lastPeerRequest = time.Now()
+				if c.net.clock.Since(peer.lastPeerRequest) >= c.net.conf.PeerRequestInterval {
+					peer.lastPeerRequest = c.net.clock.Now()
 					share := c.makePeerShare(peer.Endpoint)
 					go c.sharePeers(peer, share)
 				} else {
 					c.logger.Warnf("peer %s sent a peer request too early", peer)
+					c.peerMisbehavior(peer, SeverityModerate, "early peer request")
 				}
 			case TypePeerResponse:
 				c.shareMtx.RLock()
@@ -159,7 +185,7 @@ func (c *controller) selectBroadcastPeers(peers []*Peer, fanout uint) []*Peer {
 	var regular []*Peer
 
 	for _, p := range peers {
-		if c.isSpecial(p.Endpoint) {
+		if c.isSpecialPeer(p) {
 			special = append(special, p)
 		} else {
 			regular = append(regular, p)
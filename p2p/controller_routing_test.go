@@ -24,7 +24,7 @@ func Test_controller_route(t *testing.T) {
 
 	rp := net.controller.randomPeer()
 
-	single := testRandomParcel()
+	single := testParcel(TypeMessage)
 	single.Address = rp.Hash
 
 	net.toNetwork <- single
@@ -38,7 +38,7 @@ func Test_controller_route(t *testing.T) {
 		t.Errorf("single target parcel did not arrive")
 	}
 
-	rand := testRandomParcel()
+	rand := testParcel(TypeMessage)
 	rand.Address = RandomPeer
 	net.toNetwork <- rand
 
@@ -59,7 +59,7 @@ func Test_controller_route(t *testing.T) {
 		t.Errorf("received incorrect amount of parcels. got = %d, want = %d", parcels, 1)
 	}
 
-	broadcast := testRandomParcel()
+	broadcast := testParcel(TypeMessage)
 	broadcast.Address = Broadcast
 	net.toNetwork <- broadcast
 
@@ -85,7 +85,7 @@ func Test_controller_route(t *testing.T) {
 		t.Errorf("received incorrect amount of broadcast parcels. got = %d, want = %d", parcels, net.conf.Fanout)
 	}
 
-	fullBroadcast := testRandomParcel()
+	fullBroadcast := testParcel(TypeMessage)
 	fullBroadcast.Address = FullBroadcast
 	net.toNetwork <- fullBroadcast
 
@@ -164,9 +164,9 @@ func Test_controller_manageData(t *testing.T) {
 	// give asynchronous requests some time to finish before checking
 	time.Sleep(time.Millisecond * 500)
 
-	if len(ping.send) != 1 {
+	if len(ping.sendHigh) != 1 {
 		t.Errorf("ping peer did not receive pong")
-	} else if p := <-ping.send; p.ptype != TypePong {
+	} else if p := <-ping.sendHigh; p.ptype != TypePong {
 		t.Errorf("ping peer did not send right response. got = %s, want = %s", p.ptype, TypePong)
 	}
 
@@ -14,11 +14,12 @@ func (c *controller) run() {
 		c.runCatRound()
 		c.runMetrics()
 		c.runPing()
+		c.runGossip()
 
 		select {
 		case <-c.net.stopper:
 			return
-		case <-time.After(time.Second):
+		case <-c.net.clock.After(time.Second):
 		}
 	}
 }
@@ -49,11 +50,18 @@ func (c *controller) runMetrics() {
 	}
 	if c.net.prom != nil {
 		var MPSDown, MPSUp, BPSDown, BPSUp float64
+		var throttling, queuedHigh, queuedNormal, queuedLow int
 		for _, m := range metrics {
 			MPSDown += float64(m.MPSDown)
 			MPSUp += float64(m.MPSUp)
 			BPSDown += float64(m.BPSDown)
 			BPSUp += float64(m.BPSUp)
+			if m.Throttling {
+				throttling++
+			}
+			queuedHigh += m.QueuedHigh
+			queuedNormal += m.QueuedNormal
+			queuedLow += m.QueuedLow
 		}
 
 		c.net.prom.ByteRateDown.Set(BPSDown)
@@ -61,6 +69,13 @@ func (c *controller) runMetrics() {
 		c.net.prom.MessageRateUp.Set(MPSUp)
 		c.net.prom.MessageRateDown.Set(MPSDown)
 
+		c.net.prom.BandwidthLimitIn.Set(float64(c.net.bandwidthIn.Rate()))
+		c.net.prom.BandwidthLimitOut.Set(float64(c.net.bandwidthOut.Rate()))
+		c.net.prom.Throttling.Set(float64(throttling))
+		c.net.prom.LaneQueued.WithLabelValues(PriorityHigh.String()).Set(float64(queuedHigh))
+		c.net.prom.LaneQueued.WithLabelValues(PriorityNormal.String()).Set(float64(queuedNormal))
+		c.net.prom.LaneQueued.WithLabelValues(PriorityLow.String()).Set(float64(queuedLow))
+
 		c.net.prom.ToNetwork.Set(float64(len(c.net.toNetwork)))
 		c.net.prom.ToNetworkRatio.Set(c.net.toNetwork.FillRatio())
 		c.net.prom.FromNetwork.Set(float64(len(c.net.fromNetwork)))
@@ -101,16 +101,16 @@ func Test_controller_runPing(t *testing.T) {
 
 	net.controller.runPing()
 
-	if len(peers[0].send) > 0 {
-		p := <-peers[0].send
+	if len(peers[0].sendHigh) > 0 {
+		p := <-peers[0].sendHigh
 		t.Errorf("peer 0 was sent a parcel of type %s. expected nil", p.ptype)
 	}
 
 	for i := 1; i < 2; i++ {
-		if len(peers[i].send) == 0 {
+		if len(peers[i].sendHigh) == 0 {
 			t.Error("peer i did not receive a parcel")
 		} else {
-			p := <-peers[i].send
+			p := <-peers[i].sendHigh
 			if p.ptype != TypePing {
 				t.Errorf("peer %d did not receive a ping. got = %s", i, p.ptype)
 			}
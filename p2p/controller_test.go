@@ -1,6 +1,8 @@
 package p2p
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
 	"strings"
 	"testing"
 )
@@ -56,3 +58,34 @@ func Test_controller_setSpecial(t *testing.T) {
 	cmp(add3, add2)
 	cmp(nil, add3) // will call setSpecial("")
 }
+
+func Test_controller_isSpecialPeer(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+
+	c := new(controller)
+	c.logger = packageLogger
+	c.setSpecial("a:1")
+	c.setSpecialKeys(hex.EncodeToString(pub))
+
+	tests := []struct {
+		name string
+		peer *Peer
+		want bool
+	}{
+		{"endpoint", &Peer{Endpoint: Endpoint{"a", "1"}}, true},
+		{"key", &Peer{Endpoint: Endpoint{"b", "1"}, PublicKey: pub}, true},
+		{"other key", &Peer{Endpoint: Endpoint{"b", "1"}, PublicKey: other}, false},
+		{"no key", &Peer{Endpoint: Endpoint{"b", "1"}}, false},
+	}
+	for _, tt := range tests {
+		if got := c.isSpecialPeer(tt.peer); got != tt.want {
+			t.Errorf("isSpecialPeer(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	c.setSpecialKeys("")
+	if c.isSpecialPeer(tests[1].peer) {
+		t.Errorf("key was not removed")
+	}
+}
@@ -9,12 +9,15 @@ import (
 
 // Dialer is a construct to throttle dialing and limit by attempts
 type Dialer struct {
-	dialer      net.Dialer
 	bindTo      string
+	local4      *net.TCPAddr  // local address for IPv4 connections, nil for any
+	local6      *net.TCPAddr  // local address for IPv6 connections, nil for any
 	interval    time.Duration // Minimum duration enforced between two dial attempts
 	timeout     time.Duration
 	attempts    map[Endpoint]time.Time
 	attemptsMtx sync.RWMutex
+	transport   Transport // nil for TCP
+	clock       Clock
 }
 
 // NewDialer creates a new Dialer
@@ -23,6 +26,7 @@ func NewDialer(bindTo string, interval, timeout time.Duration) (*Dialer, error)
 	d.interval = interval
 	d.timeout = timeout
 	d.attempts = make(map[Endpoint]time.Time)
+	d.clock = systemClock{}
 
 	err := d.Bind(bindTo)
 	if err != nil {
@@ -32,24 +36,54 @@ func NewDialer(bindTo string, interval, timeout time.Duration) (*Dialer, error)
 	return d, nil
 }
 
+// Bind sets the local address to dial from. Accepts a comma separated list of at most one
+// IPv4 and one IPv6 address. Connections of an address family without a bound address
+// use any local address
 func (d *Dialer) Bind(to string) error {
-	local, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:0", to))
-	if err != nil {
-		return err
+	var local4, local6 *net.TCPAddr
+	for _, ip := range splitAddresses(to) {
+		local, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(ip, "0"))
+		if err != nil {
+			return err
+		}
+		if local.IP.To4() != nil {
+			if local4 != nil {
+				return fmt.Errorf("more than one IPv4 address to bind to")
+			}
+			local4 = local
+		} else {
+			if local6 != nil {
+				return fmt.Errorf("more than one IPv6 address to bind to")
+			}
+			local6 = local
+		}
 	}
 	d.bindTo = to
-	d.dialer = net.Dialer{
-		LocalAddr: local,
-		Timeout:   d.timeout,
-	}
+	d.local4 = local4
+	d.local6 = local6
 	return nil
 }
 
+// localAddr picks the local address to dial the endpoint from
+func (d *Dialer) localAddr(ep Endpoint) *net.TCPAddr {
+	if ip := net.ParseIP(ep.IP); ip != nil {
+		if ip.To4() != nil {
+			return d.local4
+		}
+		return d.local6
+	}
+	// hostnames resolve to the family of the local address
+	if d.local4 != nil {
+		return d.local4
+	}
+	return d.local6
+}
+
 // CanDial checks if the given ip can be dialed yet
 func (d *Dialer) CanDial(ep Endpoint) bool {
 	d.attemptsMtx.RLock()
 	defer d.attemptsMtx.RUnlock()
-	if a, ok := d.attempts[ep]; !ok || time.Since(a) >= d.interval {
+	if a, ok := d.attempts[ep]; !ok || d.clock.Since(a) >= d.interval {
 		return true
 	}
 
@@ -59,14 +93,22 @@ func (d *Dialer) CanDial(ep Endpoint) bool {
 // Dial an ip. Returns the active TCP connection or error if it failed to connect
 func (d *Dialer) Dial(ep Endpoint) (net.Conn, error) {
 	d.attemptsMtx.Lock() // don't unlock with defer so we can dial concurrently
-	if t, ok := d.attempts[ep]; ok && time.Since(t) < d.interval {
+	if t, ok := d.attempts[ep]; ok && d.clock.Since(t) < d.interval {
 		d.attemptsMtx.Unlock()
 		return nil, fmt.Errorf("dialing too soon")
 	}
-	d.attempts[ep] = time.Now()
+	d.attempts[ep] = d.clock.Now()
 	d.attemptsMtx.Unlock()
 
-	con, err := d.dialer.Dial("tcp", ep.String())
+	if d.transport != nil {
+		return d.transport.Dial(ep, d.timeout)
+	}
+
+	dialer := net.Dialer{Timeout: d.timeout}
+	if local := d.localAddr(ep); local != nil {
+		dialer.LocalAddr = local
+	}
+	con, err := dialer.Dial("tcp", ep.String())
 	if err != nil {
 		return nil, err
 	}
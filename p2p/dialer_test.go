@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"net"
 	"testing"
 	"time"
 )
@@ -33,3 +34,36 @@ func TestDialer(t *testing.T) {
 		t.Error("can dial during second blocking interval")
 	}
 }
+
+func TestDialer_DualStack(t *testing.T) {
+	if _, err := NewDialer("127.0.0.1,127.0.0.2", time.Second, time.Second); err == nil {
+		t.Error("no error binding to two ipv4 addresses")
+	}
+
+	d, err := NewDialer("127.0.0.1, [::1]", 0, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		ep, err := ParseEndpoint(l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		con, err := d.Dial(ep)
+		if err != nil {
+			t.Errorf("unable to dial %s: %v", ep, err)
+			continue
+		}
+		if local := con.LocalAddr().(*net.TCPAddr).IP.String(); local != host {
+			t.Errorf("dialed %s from the wrong address. got = %s, want = %s", ep, local, host)
+		}
+		con.Close()
+	}
+}
@@ -20,16 +20,17 @@ type Endpoint struct {
 	Port string `json:"port"`
 }
 
-// NewEndpoint creates an Endpoint struct from a given ip and port, throws error if ip could not be resolved
+// NewEndpoint creates an Endpoint struct from a given ip and port, throws error if ip could not be resolved.
+// IPv6 addresses may be bracketed and are stored in their canonical form without brackets
 func NewEndpoint(ip, port string) (Endpoint, error) {
-	ep := Endpoint{ip, port}
+	ep := Endpoint{normalizeHost(ip), port}
 	if !ep.Valid() {
 		return Endpoint{}, fmt.Errorf("(%s:%s) is not a valid endpoint", ip, port)
 	}
 	return ep, nil
 }
 
-// ParseEndpoint takes input in the form of "ip:port" and returns its IP
+// ParseEndpoint takes input in the form of "ip:port" or "[ipv6]:port" and returns its IP
 func ParseEndpoint(s string) (Endpoint, error) {
 	ip, port, err := net.SplitHostPort(s)
 	if err != nil {
@@ -49,7 +50,13 @@ func ParseEndpoint(s string) (Endpoint, error) {
 }
 
 func (ep Endpoint) String() string {
-	return fmt.Sprintf("%s:%s", ep.IP, ep.Port)
+	return net.JoinHostPort(ep.IP, ep.Port)
+}
+
+// IsIPv6 returns true if the endpoint's host is an IPv6 address
+func (ep Endpoint) IsIPv6() bool {
+	ip := net.ParseIP(ep.IP)
+	return ip != nil && ip.To4() == nil
 }
 
 // Verify checks if the data is usable. Does not check if the remote address works
@@ -74,3 +81,27 @@ func (ep Endpoint) Valid() bool {
 func (ep Endpoint) Equal(o Endpoint) bool {
 	return ep.IP == o.IP && ep.Port == o.Port
 }
+
+// normalizeHost strips the brackets of IPv6 literals and returns ip addresses in their
+// canonical form, so that "[::FFFF:127.0.0.1]" and "127.0.0.1" are the same host.
+// Hostnames are returned unchanged
+func normalizeHost(host string) string {
+	if len(host) > 1 && host[0] == '[' && host[len(host)-1] == ']' {
+		host = host[1 : len(host)-1]
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// ipGroup returns the key that per-ip connection limits apply to. IPv6 hosts usually
+// control an entire /64 subnet, so IPv6 addresses are grouped by their /64 prefix.
+// IPv4 addresses and hostnames are their own group
+func ipGroup(host string) string {
+	ip := net.ParseIP(host)
+	if ip == nil || ip.To4() != nil {
+		return host
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
@@ -44,6 +44,11 @@ func TestNewEndpoint(t *testing.T) {
 		//{"invalid ip", args{"127.0.0.256", "8088"}, Endpoint{}, true}, // technically a valid hostname
 		{"hostname", args{"localhost", "8088"}, Endpoint{"localhost", "8088"}, false}, // likely uses ::1 ipv6 address
 		{"punycode", args{"xn--qei9019maa.xn--z38hpa", "8088"}, Endpoint{"xn--qei9019maa.xn--z38hpa", "8088"}, false},
+		{"ipv6 loopback", args{"::1", "8088"}, Endpoint{"::1", "8088"}, false},
+		{"ipv6 bracketed", args{"[::1]", "8088"}, Endpoint{"::1", "8088"}, false},
+		{"ipv6 canonical", args{"2001:DB8:0:0::1", "8088"}, Endpoint{"2001:db8::1", "8088"}, false},
+		{"ipv4 mapped", args{"::ffff:127.0.0.1", "8088"}, Endpoint{"127.0.0.1", "8088"}, false},
+		{"ipv6 bad brackets", args{"[::1", "8088"}, Endpoint{}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -80,6 +85,10 @@ func TestParseEndpoint(t *testing.T) {
 		{"wrong format 1", args{"127.0.0.1,80"}, Endpoint{}, true},
 		{"wrong format 2", args{"127.0.0.1:80 test"}, Endpoint{}, true},
 		{"wrong format 3", args{"ip:127.0.0.1 port:80"}, Endpoint{}, true},
+		{"ipv6", args{"[::1]:80"}, Endpoint{"::1", "80"}, false},
+		{"ipv6 full", args{"[2001:db8::8:800:200c:417a]:8108"}, Endpoint{"2001:db8::8:800:200c:417a", "8108"}, false},
+		{"ipv6 no brackets", args{"::1:80"}, Endpoint{}, true},
+		{"ipv6 no port", args{"[::1]"}, Endpoint{}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -104,6 +113,7 @@ func TestEndpoint_String(t *testing.T) {
 		{"normal", Endpoint{IP: "127.0.0.1", Port: "8088"}, "127.0.0.1:8088"},
 		{"no addr", Endpoint{IP: "", Port: "8088"}, ":8088"},
 		{"no port", Endpoint{IP: "127.0.0.1", Port: ""}, "127.0.0.1:"},
+		{"ipv6", Endpoint{IP: "::1", Port: "8088"}, "[::1]:8088"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -176,3 +186,22 @@ func TestEndpoint_Equal(t *testing.T) {
 		})
 	}
 }
+
+func Test_ipGroup(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"127.0.0.1", "127.0.0.1"},
+		{"example.com", "example.com"},
+		{"::1", "::/64"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2::ffff", "2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "2001:db8:1:3::/64"},
+	}
+	for _, tt := range tests {
+		if got := ipGroup(tt.host); got != tt.want {
+			t.Errorf("ipGroup(%s) = %s, want %s", tt.host, got, tt.want)
+		}
+	}
+}
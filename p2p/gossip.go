@@ -0,0 +1,402 @@
+package p2p
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"time"
+)
+
+// gossipState is the position of a peer in the gossip tree
+type gossipState uint8
+
+const (
+	// gossipUnassigned peers are assigned a set on the next broadcast
+	gossipUnassigned gossipState = iota
+	// gossipEager peers receive the full message
+	gossipEager
+	// gossipLazy peers receive an announcement of the message id
+	gossipLazy
+)
+
+// gossipID identifies a message by the hash of its payload, the same hash used by the PeerHashCache
+type gossipID [sha1.Size]byte
+
+const (
+	// gossipHaveLazy announcements are requested if the message doesn't arrive via the eager set in time
+	gossipHaveLazy byte = iota
+	// gossipHavePull announcements are requested right away, used for large payloads
+	gossipHavePull
+)
+
+// gossipMessage is the state of a single message in the gossip tree.
+// Messages that were announced but haven't arrived yet have no payload.
+type gossipMessage struct {
+	payload    []byte
+	created    time.Time
+	have       map[string]bool // peer hash => peer is known to have the message
+	announcers []string        // peers that announced the message, in order
+	asked      int             // announcers asked so far
+	timer      Timer
+}
+
+func newGossipMessage(created time.Time) *gossipMessage {
+	msg := new(gossipMessage)
+	msg.created = created
+	msg.have = make(map[string]bool)
+	return msg
+}
+
+// Coverage is the number of peers known to have the message
+func (msg *gossipMessage) Coverage() int {
+	return len(msg.have)
+}
+
+// usesGossip returns true if the peer takes part in the gossip tree
+func (p *Peer) usesGossip() bool {
+	return p.Features&FeatureGossip > 0
+}
+
+// gossipMessage returns the state of the message, creating it if it doesn't exist.
+// Requires gossipMtx to be held.
+func (c *controller) gossipMessage(id gossipID) *gossipMessage {
+	if c.gossipMessages == nil {
+		c.gossipMessages = make(map[gossipID]*gossipMessage)
+	}
+	msg, ok := c.gossipMessages[id]
+	if !ok {
+		msg = newGossipMessage(c.net.clock.Now())
+		c.gossipMessages[id] = msg
+	}
+	return msg
+}
+
+// setGossipState moves a peer to the eager or lazy set. Requires gossipMtx to be held.
+func (c *controller) setGossipState(p *Peer, state gossipState) {
+	if p.gossip == state {
+		return
+	}
+	p.gossip = state
+	if state == gossipLazy && c.net.prom != nil {
+		c.net.prom.GossipPrunes.Inc()
+	}
+}
+
+// assignGossipState puts new peers in the eager set until it has Fanout regular peers.
+// Special peers always start out eager. Requires gossipMtx to be held.
+func (c *controller) assignGossipState(peers []*Peer) {
+	eager := uint(0)
+	for _, p := range peers {
+		if p.gossip == gossipEager && !c.isSpecialPeer(p) {
+			eager++
+		}
+	}
+	for _, p := range peers {
+		if p.gossip != gossipUnassigned {
+			continue
+		}
+		if c.isSpecialPeer(p) {
+			p.gossip = gossipEager
+		} else if eager < c.net.conf.Fanout {
+			p.gossip = gossipEager
+			eager++
+		} else {
+			p.gossip = gossipLazy
+		}
+	}
+}
+
+// gossipBroadcast sends a broadcast parcel to the peers of the selection that take part in
+// the gossip tree and returns the remaining peers. A message the application broadcasts
+// again after receiving it is forwarded to everyone except the peers known to have it.
+func (c *controller) gossipBroadcast(parcel *Parcel, selection []*Peer) []*Peer {
+	var gossip, legacy []*Peer
+	for _, p := range selection {
+		if p.usesGossip() {
+			gossip = append(gossip, p)
+		} else {
+			legacy = append(legacy, p)
+		}
+	}
+	if len(gossip) == 0 {
+		return legacy
+	}
+
+	id := gossipID(sha1.Sum(parcel.Payload))
+	announceOnly := c.net.conf.GossipAnnounceSize > 0 && len(parcel.Payload) >= c.net.conf.GossipAnnounceSize
+
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+
+	msg := c.gossipMessage(id)
+	if msg.payload == nil {
+		msg.payload = parcel.Payload
+		c.stopGossipTimer(msg)
+		c.releaseGossipPending(msg)
+	}
+
+	c.assignGossipState(gossip)
+	for _, p := range gossip {
+		if msg.have[p.Hash] {
+			continue
+		}
+		switch {
+		case p.gossip == gossipEager && !announceOnly:
+			p.Send(newParcel(TypeGossip, parcel.Payload))
+			msg.have[p.Hash] = true
+			if c.net.prom != nil {
+				c.net.prom.GossipPushed.Inc()
+			}
+		case p.gossip == gossipEager:
+			p.Send(newParcel(TypeGossipHave, encodeGossipHave(gossipHavePull, id)))
+			if c.net.prom != nil {
+				c.net.prom.GossipAnnounced.Inc()
+			}
+		default:
+			p.Send(newParcel(TypeGossipHave, encodeGossipHave(gossipHaveLazy, id)))
+			if c.net.prom != nil {
+				c.net.prom.GossipAnnounced.Inc()
+			}
+		}
+	}
+	return legacy
+}
+
+// gossipReceive processes a pushed message. Returns true if the message is new and
+// should be delivered to the application. A peer that sends a duplicate is moved to
+// the lazy set and told to do the same.
+func (c *controller) gossipReceive(peer *Peer, parcel *Parcel) bool {
+	id := gossipID(sha1.Sum(parcel.Payload))
+
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+
+	msg := c.gossipMessage(id)
+	msg.have[peer.Hash] = true
+	if msg.payload != nil {
+		if c.net.prom != nil {
+			c.net.prom.GossipDuplicates.Inc()
+		}
+		if peer.gossip != gossipLazy {
+			c.setGossipState(peer, gossipLazy)
+			// parcels need a payload, the prune carries the id of the duplicate
+			peer.Send(newParcel(TypeGossipPrune, id[:]))
+		}
+		return false
+	}
+
+	msg.payload = parcel.Payload
+	c.stopGossipTimer(msg)
+	c.releaseGossipPending(msg)
+	return true
+}
+
+// gossipHave processes an announcement of message ids. Announcements of messages that
+// would exceed the limits of pending messages overall or of the peer are ignored.
+func (c *controller) gossipHave(peer *Peer, parcel *Parcel) error {
+	if len(parcel.Payload) < 1 {
+		return fmt.Errorf("empty announcement")
+	}
+	mode := parcel.Payload[0]
+	ids, err := decodeGossipIDs(parcel.Payload[1:])
+	if err != nil {
+		return err
+	}
+
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+
+	for _, id := range ids {
+		msg, ok := c.gossipMessages[id]
+		if !ok && c.gossipPending >= c.net.conf.GossipMaxPending {
+			continue
+		}
+		if ok && msg.payload != nil {
+			msg.have[peer.Hash] = true
+			continue
+		}
+		if ok && msg.have[peer.Hash] {
+			continue
+		}
+		if c.gossipAnnounce[peer.Hash] >= c.net.conf.GossipMaxPendingPerPeer {
+			continue
+		}
+
+		if !ok {
+			msg = c.gossipMessage(id)
+			c.gossipPending++
+		}
+		msg.have[peer.Hash] = true
+		msg.announcers = append(msg.announcers, peer.Hash)
+		c.gossipAnnounce[peer.Hash]++
+		if msg.timer != nil {
+			continue
+		}
+		if mode == gossipHavePull {
+			c.gossipAsk(id, msg)
+		} else {
+			c.startGossipTimer(id, msg)
+		}
+	}
+	return nil
+}
+
+// gossipGraft processes a request for messages. The peer is moved to the eager set.
+func (c *controller) gossipGraft(peer *Peer, parcel *Parcel) error {
+	ids, err := decodeGossipIDs(parcel.Payload)
+	if err != nil {
+		return err
+	}
+
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+
+	c.setGossipState(peer, gossipEager)
+	for _, id := range ids {
+		if msg, ok := c.gossipMessages[id]; ok && msg.payload != nil {
+			peer.Send(newParcel(TypeGossip, msg.payload))
+			msg.have[peer.Hash] = true
+		}
+	}
+	return nil
+}
+
+// gossipPrune processes the prune of a duplicate message, the peer is moved to the lazy set
+func (c *controller) gossipPrune(peer *Peer, parcel *Parcel) error {
+	if _, err := decodeGossipIDs(parcel.Payload); err != nil {
+		return err
+	}
+
+	c.gossipMtx.Lock()
+	c.setGossipState(peer, gossipLazy)
+	c.gossipMtx.Unlock()
+	return nil
+}
+
+// releaseGossipPending removes a message that arrived or expired from the pending messages
+// of the announcers. Requires gossipMtx to be held.
+func (c *controller) releaseGossipPending(msg *gossipMessage) {
+	if len(msg.announcers) == 0 {
+		return
+	}
+	c.gossipPending--
+	for _, hash := range msg.announcers {
+		if c.gossipAnnounce[hash] <= 1 {
+			delete(c.gossipAnnounce, hash)
+		} else {
+			c.gossipAnnounce[hash]--
+		}
+	}
+}
+
+// gossipAsk requests a missing message from the next peer that announced it and moves
+// that peer to the eager set. If the message doesn't arrive in time, the next announcer
+// is asked. Requires gossipMtx to be held.
+func (c *controller) gossipAsk(id gossipID, msg *gossipMessage) {
+	msg.timer = nil
+	for msg.asked < len(msg.announcers) {
+		peer := c.peers.Get(msg.announcers[msg.asked])
+		msg.asked++
+		if peer == nil {
+			continue
+		}
+
+		c.setGossipState(peer, gossipEager)
+		peer.Send(newParcel(TypeGossipGraft, id[:]))
+		if c.net.prom != nil {
+			c.net.prom.GossipGrafts.Inc()
+		}
+		c.startGossipTimer(id, msg)
+		return
+	}
+}
+
+// startGossipTimer waits for a message to arrive. Requires gossipMtx to be held.
+func (c *controller) startGossipTimer(id gossipID, msg *gossipMessage) {
+	msg.timer = c.net.clock.AfterFunc(c.net.conf.GossipLazyDelay, func() {
+		c.gossipMtx.Lock()
+		defer c.gossipMtx.Unlock()
+		// the message may have arrived or expired in the meantime
+		if c.gossipMessages[id] == msg && msg.payload == nil {
+			c.gossipAsk(id, msg)
+		}
+	})
+}
+
+// stopGossipTimer stops waiting for a message. Requires gossipMtx to be held.
+func (c *controller) stopGossipTimer(msg *gossipMessage) {
+	if msg.timer != nil {
+		msg.timer.Stop()
+		msg.timer = nil
+	}
+}
+
+// gossipCoverage returns the number of peers known to have the message with the given payload
+func (c *controller) gossipCoverage(payload []byte) (int, bool) {
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+	msg, ok := c.gossipMessages[gossipID(sha1.Sum(payload))]
+	if !ok {
+		return 0, false
+	}
+	return msg.Coverage(), true
+}
+
+// runGossip expires old messages and updates the metrics of the gossip tree
+func (c *controller) runGossip() {
+	if !c.net.conf.Gossip {
+		return
+	}
+
+	c.gossipMtx.Lock()
+	defer c.gossipMtx.Unlock()
+
+	for id, msg := range c.gossipMessages {
+		if c.net.clock.Since(msg.created) < c.net.conf.GossipCacheTime {
+			continue
+		}
+		c.stopGossipTimer(msg)
+		delete(c.gossipMessages, id)
+		if msg.payload == nil {
+			c.releaseGossipPending(msg)
+		}
+		if c.net.prom != nil && msg.payload != nil {
+			c.net.prom.GossipCoverage.Observe(float64(msg.Coverage()))
+		}
+	}
+
+	if c.net.prom != nil {
+		var eager, lazy int
+		for _, p := range c.peers.Slice() {
+			switch p.gossip {
+			case gossipEager:
+				eager++
+			case gossipLazy:
+				lazy++
+			}
+		}
+		c.net.prom.GossipEager.Set(float64(eager))
+		c.net.prom.GossipLazy.Set(float64(lazy))
+	}
+}
+
+// encodeGossipHave creates the payload of an announcement
+func encodeGossipHave(mode byte, ids ...gossipID) []byte {
+	payload := make([]byte, 1, 1+len(ids)*sha1.Size)
+	payload[0] = mode
+	for _, id := range ids {
+		payload = append(payload, id[:]...)
+	}
+	return payload
+}
+
+// decodeGossipIDs splits a payload into message ids
+func decodeGossipIDs(payload []byte) ([]gossipID, error) {
+	if len(payload) == 0 || len(payload)%sha1.Size != 0 {
+		return nil, fmt.Errorf("invalid length of message ids: %d", len(payload))
+	}
+	ids := make([]gossipID, len(payload)/sha1.Size)
+	for i := range ids {
+		copy(ids[i][:], payload[i*sha1.Size:])
+	}
+	return ids, nil
+}
@@ -0,0 +1,389 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"net"
+	"testing"
+	"time"
+)
+
+func testGossipNetwork(t *testing.T) *Network {
+	n := testNetworkHarness(t)
+	n.conf.Gossip = true
+	n.conf.GossipLazyDelay = time.Millisecond * 20
+	return n
+}
+
+func testGossipPeer(n *Network) *Peer {
+	p := testRandomPeer(n)
+	p.Features = FeatureGossip
+	n.controller.peers.Add(p)
+	return p
+}
+
+// testSent returns all parcels waiting in the lanes of a peer
+func testSent(p *Peer) []*Parcel {
+	var parcels []*Parcel
+	for _, lane := range []ParcelChannel{p.sendHigh, p.send, p.sendLow} {
+		for len(lane) > 0 {
+			parcels = append(parcels, <-lane)
+		}
+	}
+	return parcels
+}
+
+func Test_gossipIDs(t *testing.T) {
+	a := gossipID(sha1.Sum([]byte("a")))
+	b := gossipID(sha1.Sum([]byte("b")))
+
+	payload := encodeGossipHave(gossipHavePull, a, b)
+	if payload[0] != gossipHavePull {
+		t.Errorf("wrong mode. got = %d, want = %d", payload[0], gossipHavePull)
+	}
+	ids, err := decodeGossipIDs(payload[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != a || ids[1] != b {
+		t.Errorf("ids differ. got = %x", ids)
+	}
+
+	for _, bad := range [][]byte{nil, make([]byte, 19), make([]byte, 21)} {
+		if _, err := decodeGossipIDs(bad); err == nil {
+			t.Errorf("no error for payload of length %d", len(bad))
+		}
+	}
+}
+
+func Test_controller_gossipBroadcast(t *testing.T) {
+	n := testGossipNetwork(t)
+	n.conf.Fanout = 2
+	c := n.controller
+
+	var gossip []*Peer
+	for i := 0; i < 4; i++ {
+		gossip = append(gossip, testGossipPeer(n))
+	}
+	legacy := testRandomPeer(n)
+
+	parcel := newParcel(TypeMessage, []byte("gossip test"))
+	remaining := c.gossipBroadcast(parcel, append([]*Peer{legacy}, gossip...))
+	if len(remaining) != 1 || remaining[0] != legacy {
+		t.Errorf("legacy peer was not returned. got = %v", remaining)
+	}
+
+	var pushed, announced int
+	for _, p := range gossip {
+		for _, sent := range testSent(p) {
+			switch sent.ptype {
+			case TypeGossip:
+				pushed++
+				if p.gossip != gossipEager {
+					t.Errorf("message pushed to peer in state %d", p.gossip)
+				}
+				if !bytes.Equal(sent.Payload, parcel.Payload) {
+					t.Errorf("pushed payload differs. got = %x, want = %x", sent.Payload, parcel.Payload)
+				}
+			case TypeGossipHave:
+				announced++
+				if p.gossip != gossipLazy || sent.Payload[0] != gossipHaveLazy {
+					t.Errorf("eager peer received a lazy announcement")
+				}
+			default:
+				t.Errorf("unexpected parcel %s", sent)
+			}
+		}
+	}
+	if pushed != 2 || announced != 2 {
+		t.Errorf("wrong fanout. pushed = %d, announced = %d, want 2 and 2", pushed, announced)
+	}
+
+	if cov, ok := c.gossipCoverage(parcel.Payload); !ok || cov != 2 {
+		t.Errorf("wrong coverage. got = %d, want = 2", cov)
+	}
+
+	// broadcasting again doesn't push to peers that have it
+	c.gossipBroadcast(parcel, gossip)
+	for _, p := range gossip {
+		for _, sent := range testSent(p) {
+			if sent.ptype == TypeGossip {
+				t.Errorf("message pushed twice to %s", p)
+			}
+		}
+	}
+
+	// large payloads are only announced
+	n.conf.GossipAnnounceSize = 100
+	c.gossipBroadcast(newParcel(TypeMessage, make([]byte, 100)), gossip)
+	for _, p := range gossip {
+		for _, sent := range testSent(p) {
+			if sent.ptype != TypeGossipHave {
+				t.Errorf("large message was sent as %s", sent.ptype)
+			} else if p.gossip == gossipEager && sent.Payload[0] != gossipHavePull {
+				t.Errorf("eager peer was not asked to pull the large message")
+			}
+		}
+	}
+}
+
+func Test_controller_gossipReceive(t *testing.T) {
+	n := testGossipNetwork(t)
+	c := n.controller
+	a := testGossipPeer(n)
+	b := testGossipPeer(n)
+	a.gossip = gossipEager
+	b.gossip = gossipEager
+
+	parcel := newParcel(TypeGossip, []byte("gossip test"))
+	if !c.gossipReceive(a, parcel) {
+		t.Fatalf("new message was not delivered")
+	}
+	if c.gossipReceive(b, parcel) {
+		t.Errorf("duplicate message was delivered")
+	}
+	if b.gossip != gossipLazy {
+		t.Errorf("peer sending a duplicate was not moved to the lazy set")
+	}
+	if sent := testSent(b); len(sent) != 1 || sent[0].ptype != TypeGossipPrune {
+		t.Errorf("peer sending a duplicate was not pruned. got = %v", sent)
+	}
+	if a.gossip != gossipEager || len(testSent(a)) != 0 {
+		t.Errorf("first sender was affected")
+	}
+
+	// the application forwards the message, neither sender gets it back
+	c.gossipBroadcast(newParcel(TypeMessage, parcel.Payload), []*Peer{a, b})
+	if len(testSent(a)) != 0 || len(testSent(b)) != 0 {
+		t.Errorf("message was forwarded to a peer that sent it")
+	}
+
+	id := gossipID(sha1.Sum(parcel.Payload))
+	if err := c.gossipPrune(a, newParcel(TypeGossipPrune, id[:])); err != nil {
+		t.Fatal(err)
+	}
+	if a.gossip != gossipLazy {
+		t.Errorf("prune did not move the peer to the lazy set")
+	}
+	if err := c.gossipPrune(a, newParcel(TypeGossipPrune, []byte{1, 2, 3})); err == nil {
+		t.Errorf("no error for malformed prune")
+	}
+}
+
+func Test_gossipPruneConnection(t *testing.T) {
+	n := testGossipNetwork(t)
+	c := n.controller
+	A, B := net.Pipe()
+
+	sender := testGossipPeer(n)
+	sender.conn = A
+	sender._setProtocol(11, A)
+	sender.gossip = gossipEager
+
+	receiver := testGossipPeer(n)
+	receiver.conn = B
+	receiver._setProtocol(11, B)
+
+	go sender.sendLoop()
+	go receiver.readLoop()
+	defer sender.Stop()
+	defer receiver.Stop()
+
+	// the duplicates prune the sender twice, which the receiving side accepts as valid parcels
+	for _, payload := range []string{"first duplicate", "second duplicate"} {
+		parcel := newParcel(TypeGossip, []byte(payload))
+		c.gossipReceive(testGossipPeer(n), parcel)
+		sender.gossip = gossipEager
+		c.gossipReceive(sender, parcel)
+
+		select {
+		case pp := <-c.peerData:
+			if pp.parcel.ptype != TypeGossipPrune {
+				t.Fatalf("received %s instead of a prune", pp.parcel)
+			}
+			if err := c.gossipPrune(pp.peer, pp.parcel); err != nil {
+				t.Errorf("prune was not accepted: %v", err)
+			}
+		case <-receiver.stop:
+			t.Fatalf("receiver disconnected after a prune")
+		case <-time.After(time.Second):
+			t.Fatalf("prune did not arrive")
+		}
+	}
+	if c.isBannedIP(receiver.Endpoint.IP) {
+		t.Errorf("peer was banned for sending prunes")
+	}
+}
+
+func Test_controller_gossipHave(t *testing.T) {
+	n := testGossipNetwork(t)
+	c := n.controller
+	a := testGossipPeer(n)
+	b := testGossipPeer(n)
+	a.gossip = gossipLazy
+	b.gossip = gossipLazy
+
+	payload := []byte("announced message")
+	id := gossipID(sha1.Sum(payload))
+	have := newParcel(TypeGossipHave, encodeGossipHave(gossipHaveLazy, id))
+
+	if err := c.gossipHave(a, have); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.gossipHave(b, have); err != nil {
+		t.Fatal(err)
+	}
+	if len(testSent(a)) != 0 {
+		t.Errorf("message was requested before the lazy delay")
+	}
+
+	time.Sleep(n.conf.GossipLazyDelay * 2)
+	sent := testSent(a)
+	if len(sent) != 1 || sent[0].ptype != TypeGossipGraft || !bytes.Equal(sent[0].Payload, id[:]) {
+		t.Fatalf("message was not requested from the first announcer. got = %v", sent)
+	}
+	if a.gossip != gossipEager {
+		t.Errorf("announcer was not moved to the eager set")
+	}
+
+	// the first announcer didn't deliver, ask the next one
+	time.Sleep(n.conf.GossipLazyDelay * 2)
+	if sent := testSent(b); len(sent) != 1 || sent[0].ptype != TypeGossipGraft {
+		t.Fatalf("message was not requested from the second announcer. got = %v", sent)
+	}
+
+	if !c.gossipReceive(b, newParcel(TypeGossip, payload)) {
+		t.Errorf("requested message was not delivered")
+	}
+	time.Sleep(n.conf.GossipLazyDelay * 2)
+	if len(testSent(a))+len(testSent(b)) != 0 {
+		t.Errorf("message was requested after it arrived")
+	}
+
+	// announcements of large messages are requested right away
+	pull := gossipID(sha1.Sum([]byte("large message")))
+	if err := c.gossipHave(a, newParcel(TypeGossipHave, encodeGossipHave(gossipHavePull, pull))); err != nil {
+		t.Fatal(err)
+	}
+	if sent := testSent(a); len(sent) != 1 || sent[0].ptype != TypeGossipGraft {
+		t.Errorf("large message was not pulled. got = %v", sent)
+	}
+
+	if err := c.gossipHave(a, newParcel(TypeGossipHave, []byte{gossipHaveLazy, 1, 2, 3})); err == nil {
+		t.Errorf("no error for malformed announcement")
+	}
+}
+
+func Test_controller_gossipHaveLimits(t *testing.T) {
+	n := testGossipNetwork(t)
+	n.conf.GossipLazyDelay = time.Hour
+	n.conf.GossipMaxPending = 4
+	n.conf.GossipMaxPendingPerPeer = 2
+	c := n.controller
+	a := testGossipPeer(n)
+	b := testGossipPeer(n)
+	d := testGossipPeer(n)
+
+	var ids []gossipID
+	for i := 0; i < 6; i++ {
+		ids = append(ids, gossipID(sha1.Sum([]byte{byte(i)})))
+	}
+
+	if err := c.gossipHave(a, newParcel(TypeGossipHave, encodeGossipHave(gossipHaveLazy, ids[:3]...))); err != nil {
+		t.Fatal(err)
+	}
+	if c.gossipPending != 2 || c.gossipAnnounce[a.Hash] != 2 {
+		t.Fatalf("peer limit not applied. pending = %d, peer = %d", c.gossipPending, c.gossipAnnounce[a.Hash])
+	}
+	if _, ok := c.gossipMessages[ids[2]]; ok {
+		t.Errorf("announcement beyond the peer limit was kept")
+	}
+
+	// announcing a pending message counts towards the peer but not the total
+	if err := c.gossipHave(b, newParcel(TypeGossipHave, encodeGossipHave(gossipHaveLazy, ids[0], ids[2]))); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.gossipHave(d, newParcel(TypeGossipHave, encodeGossipHave(gossipHaveLazy, ids[3:]...))); err != nil {
+		t.Fatal(err)
+	}
+	if c.gossipPending != 4 || c.gossipAnnounce[b.Hash] != 2 || c.gossipAnnounce[d.Hash] != 1 {
+		t.Fatalf("total limit not applied. pending = %d, b = %d, d = %d", c.gossipPending, c.gossipAnnounce[b.Hash], c.gossipAnnounce[d.Hash])
+	}
+	if len(c.gossipMessages) != 4 {
+		t.Errorf("announcements beyond the total limit were kept. got = %d messages", len(c.gossipMessages))
+	}
+
+	// arrived messages free up the announcers
+	c.gossipReceive(b, newParcel(TypeGossip, []byte{0}))
+	if c.gossipPending != 3 || c.gossipAnnounce[a.Hash] != 1 || c.gossipAnnounce[b.Hash] != 1 {
+		t.Errorf("arrived message still pending. pending = %d, a = %d, b = %d", c.gossipPending, c.gossipAnnounce[a.Hash], c.gossipAnnounce[b.Hash])
+	}
+
+	// and so do expired ones
+	n.conf.GossipCacheTime = 0
+	c.runGossip()
+	if c.gossipPending != 0 || len(c.gossipAnnounce) != 0 || len(c.gossipMessages) != 0 {
+		t.Errorf("expired messages still pending. pending = %d, peers = %v", c.gossipPending, c.gossipAnnounce)
+	}
+}
+
+func Test_controller_gossipGraft(t *testing.T) {
+	n := testGossipNetwork(t)
+	c := n.controller
+	p := testGossipPeer(n)
+	p.gossip = gossipLazy
+
+	payload := []byte("grafted message")
+	c.gossipReceive(testGossipPeer(n), newParcel(TypeGossip, payload))
+
+	id := gossipID(sha1.Sum(payload))
+	if err := c.gossipGraft(p, newParcel(TypeGossipGraft, id[:])); err != nil {
+		t.Fatal(err)
+	}
+	if p.gossip != gossipEager {
+		t.Errorf("grafting peer was not moved to the eager set")
+	}
+	sent := testSent(p)
+	if len(sent) != 1 || sent[0].ptype != TypeGossip || !bytes.Equal(sent[0].Payload, payload) {
+		t.Errorf("requested message was not sent. got = %v", sent)
+	}
+	if cov, _ := c.gossipCoverage(payload); cov != 2 {
+		t.Errorf("wrong coverage. got = %d, want = 2", cov)
+	}
+}
+
+func Test_controller_runGossip(t *testing.T) {
+	n := testGossipNetwork(t)
+	c := n.controller
+	n.conf.GossipCacheTime = time.Minute
+
+	c.gossipReceive(testGossipPeer(n), newParcel(TypeGossip, []byte("old")))
+	c.gossipReceive(testGossipPeer(n), newParcel(TypeGossip, []byte("new")))
+	c.gossipMessages[gossipID(sha1.Sum([]byte("old")))].created = time.Now().Add(-time.Hour)
+
+	c.runGossip()
+	if _, ok := c.gossipCoverage([]byte("old")); ok {
+		t.Errorf("old message did not expire")
+	}
+	if _, ok := c.gossipCoverage([]byte("new")); !ok {
+		t.Errorf("new message expired")
+	}
+}
+
+func Test_gossipFeatureHandshake(t *testing.T) {
+	conf := DefaultP2PConfiguration()
+	conf.Gossip = true
+	hs := newHandshake(&conf, 1)
+	if hs.Features&FeatureGossip == 0 {
+		t.Fatalf("handshake does not announce gossip")
+	}
+	if got := parseV11Handshake(makeV11Handshake(hs)); got.Features != hs.Features {
+		t.Errorf("features did not survive encoding. got = %d, want = %d", got.Features, hs.Features)
+	}
+
+	conf.Gossip = false
+	conf.Compression = ""
+	if f := newHandshake(&conf, 1).Features; f != 0 {
+		t.Errorf("handshake announces features %d with gossip disabled", f)
+	}
+}
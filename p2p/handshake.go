@@ -1,10 +1,34 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"strconv"
 )
 
+// Feature is a bit field of optional behavior a node supports. Features are only
+// used with a peer if both sides announce them in the handshake
+type Feature uint32
+
+const (
+	// FeatureGossip is the structured gossip of broadcasts, see gossip.go
+	FeatureGossip Feature = 1 << iota
+	// FeatureSnappy is the ability to decompress snappy parcels, see compression.go
+	FeatureSnappy
+	// FeatureZstd is the ability to decompress zstd parcels
+	FeatureZstd
+)
+
+// supportedFeatures returns the features enabled in the configuration
+func supportedFeatures(conf *Configuration) Feature {
+	var f Feature
+	if conf.Gossip {
+		f |= FeatureGossip
+	}
+	f |= supportedCompression(conf)
+	return f
+}
+
 // Handshake is the protocol independent data that is required to authenticate a peer.
 type Handshake struct {
 	Network      NetworkID
@@ -14,6 +38,14 @@ type Handshake struct {
 	ListenPort   string
 	Loopback     uint64
 	Alternatives []Endpoint
+	Features     Feature
+	// ExternalHost is the host the node advertises to be reachable at together with the ListenPort,
+	// empty if the node doesn't know
+	ExternalHost string
+	// ObservedHost is the address the sender sees the receiver at
+	ObservedHost string
+	// PublicKey is the verified node key of the peer, only set for protocols that authenticate peers
+	PublicKey ed25519.PublicKey
 }
 
 // Valid checks the Handshake's data against a configuration.
@@ -36,6 +68,10 @@ func (h *Handshake) Valid(conf *Configuration) error {
 		return fmt.Errorf("given port out of range: %d", port)
 	}
 
+	if h.ExternalHost != "" && !(Endpoint{IP: h.ExternalHost, Port: h.ListenPort}).Valid() {
+		return fmt.Errorf("invalid external host %s", h.ExternalHost)
+	}
+
 	for _, ep := range h.Alternatives {
 		if !ep.Valid() {
 			return fmt.Errorf("invalid list of alternatives provided")
@@ -54,5 +90,6 @@ func newHandshake(conf *Configuration, loopback uint64) *Handshake {
 	hs.NodeID = conf.NodeID
 	hs.ListenPort = conf.ListenPort
 	hs.Loopback = loopback
+	hs.Features = supportedFeatures(conf)
 	return hs
 }
@@ -26,7 +26,7 @@ func TestHandshake_Valid(t *testing.T) {
 	conf := DefaultP2PConfiguration()
 
 	var handshakes []*Handshake
-	for i := 0; i < 7; i++ {
+	for i := 0; i < 9; i++ {
 		hs := newHandshake(&conf, 0)
 		hs.NodeID++
 		handshakes = append(handshakes, hs)
@@ -38,6 +38,8 @@ func TestHandshake_Valid(t *testing.T) {
 	handshakes[4].ListenPort = ""
 	handshakes[5].ListenPort = "0"
 	handshakes[6].ListenPort = "900000"
+	handshakes[7].ExternalHost = "203.0.113.5"
+	handshakes[8].ExternalHost = "not a host!"
 
 	type args struct {
 		conf *Configuration
@@ -55,6 +57,8 @@ func TestHandshake_Valid(t *testing.T) {
 		{"empty port", handshakes[4], args{&conf}, true},
 		{"zero port", handshakes[5], args{&conf}, true},
 		{"too high port", handshakes[6], args{&conf}, true},
+		{"external host", handshakes[7], args{&conf}, false},
+		{"invalid external host", handshakes[8], args{&conf}, true},
 	}
 
 	for _, tt := range tests {
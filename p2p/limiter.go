@@ -1,8 +1,11 @@
 package p2p
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,6 +16,7 @@ type LimitedListener struct {
 	limit          time.Duration
 	lastConnection time.Time
 	history        []attempt
+	clock          Clock
 }
 
 type attempt struct {
@@ -21,26 +25,39 @@ type attempt struct {
 }
 
 // NewLimitedListener initializes a new listener for the specified address (host:port)
-// throttling incoming connections
+// throttling incoming connections. To listen on several addresses, eg one IPv4 and one IPv6
+// address, separate them by comma. IPv6 hosts are throttled per /64 subnet
 func NewLimitedListener(address string, limit time.Duration) (*LimitedListener, error) {
 	if limit < 0 {
 		return nil, fmt.Errorf("Invalid time limit (negative)")
 	}
-	l, err := net.Listen("tcp", address)
+	var l net.Listener
+	var err error
+	if addresses := strings.Split(address, ","); len(addresses) > 1 {
+		l, err = newMultiListener(addresses)
+	} else {
+		l, err = net.Listen("tcp", address)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return newLimitedListener(l, limit), nil
+}
+
+// newLimitedListener throttles the incoming connections of an existing listener
+func newLimitedListener(l net.Listener, limit time.Duration) *LimitedListener {
 	return &LimitedListener{
 		listener:       l,
 		limit:          limit,
 		lastConnection: time.Time{},
 		history:        nil,
-	}, nil
+		clock:          systemClock{},
+	}
 }
 
 // clearHistory truncates the history to only relevant entries
 func (ll *LimitedListener) clearHistory() {
-	tl := time.Now().Add(-ll.limit) // get timelimit of range to check
+	tl := ll.clock.Now().Add(-ll.limit) // get timelimit of range to check
 
 	// no connection made in the last X seconds
 	// the vast majority of connections will proc this
@@ -79,8 +96,8 @@ func (ll *LimitedListener) isInHistory(host string) bool {
 
 // addToHistory adds an address to the system at the current time
 func (ll *LimitedListener) addToHistory(host string) {
-	ll.history = append(ll.history, attempt{host: host, time: time.Now()})
-	ll.lastConnection = time.Now()
+	ll.history = append(ll.history, attempt{host: host, time: ll.clock.Now()})
+	ll.lastConnection = ll.clock.Now()
 }
 
 // Accept accepts a connection if no other connection attempt from that ip has been made
@@ -98,12 +115,13 @@ func (ll *LimitedListener) Accept() (net.Conn, error) {
 		return nil, err
 	}
 
-	if ll.isInHistory(host) {
+	group := ipGroup(host)
+	if ll.isInHistory(group) {
 		con.Close()
 		return nil, fmt.Errorf("connection rate limit exceeded for %s", host)
 	}
 
-	ll.addToHistory(host)
+	ll.addToHistory(group)
 	return con, nil
 }
 
@@ -116,3 +134,82 @@ func (ll *LimitedListener) Addr() net.Addr {
 func (ll *LimitedListener) Close() {
 	ll.listener.Close()
 }
+
+var errListenerClosed = errors.New("use of closed listener")
+
+// multiListener accepts connections from several listeners, eg to listen to an IPv4 and
+// an IPv6 address at the same time
+type multiListener struct {
+	listeners []net.Listener
+	accepted  chan acceptResult
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// newMultiListener listens to all of the addresses (host:port)
+func newMultiListener(addresses []string) (*multiListener, error) {
+	ml := new(multiListener)
+	ml.accepted = make(chan acceptResult)
+	ml.closed = make(chan struct{})
+	for _, addr := range addresses {
+		l, err := net.Listen("tcp", strings.TrimSpace(addr))
+		if err != nil {
+			ml.Close()
+			return nil, err
+		}
+		ml.listeners = append(ml.listeners, l)
+	}
+	for _, l := range ml.listeners {
+		go ml.accept(l)
+	}
+	return ml, nil
+}
+
+// accept passes the connections of a single listener on until it fails or is closed
+func (ml *multiListener) accept(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		select {
+		case ml.accepted <- acceptResult{conn, err}:
+		case <-ml.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if ne, ok := err.(net.Error); err != nil && (!ok || !ne.Temporary()) {
+			return
+		}
+	}
+}
+
+// Accept waits for the next connection on any of the listeners
+func (ml *multiListener) Accept() (net.Conn, error) {
+	select {
+	case res := <-ml.accepted:
+		return res.conn, res.err
+	case <-ml.closed:
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Addr: ml.Addr(), Err: errListenerClosed}
+	}
+}
+
+// Close closes all listeners
+func (ml *multiListener) Close() error {
+	ml.closeOnce.Do(func() {
+		close(ml.closed)
+		for _, l := range ml.listeners {
+			l.Close()
+		}
+	})
+	return nil
+}
+
+// Addr returns the address of the first listener
+func (ml *multiListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
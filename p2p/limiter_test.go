@@ -57,6 +57,7 @@ func createLLHistory() *LimitedListener {
 		limit:          time.Hour,
 		lastConnection: time.Now(),
 		history:        []attempt{past, presence, future}, // order matters, old < new
+		clock:          systemClock{},
 	}
 }
 
@@ -230,3 +231,72 @@ func Test_NewLimitedListener(t *testing.T) {
 		}
 	}
 }
+
+func Test_NewLimitedListener_DualStack(t *testing.T) {
+	ll, err := NewLimitedListener("127.0.0.1:0,[::1]:0", time.Hour)
+	if err != nil {
+		t.Fatalf("Error starting listener: %v", err)
+	}
+	defer ll.Close()
+
+	ml, ok := ll.listener.(*multiListener)
+	if !ok || len(ml.listeners) != 2 {
+		t.Fatalf("listener does not listen to both addresses")
+	}
+
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			con, err := ll.Accept()
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Temporary() {
+					continue
+				}
+				if _, ok := err.(*net.OpError); ok {
+					close(accepted)
+					return
+				}
+				continue // rate limited
+			}
+			accepted <- con
+		}
+	}()
+
+	for _, l := range ml.listeners {
+		con, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("unable to connect to %s: %v", l.Addr(), err)
+		}
+		defer con.Close()
+		select {
+		case in := <-accepted:
+			in.Close()
+		case <-time.After(time.Second):
+			t.Fatalf("connection to %s was not accepted", l.Addr())
+		}
+	}
+
+	bad, err := net.Dial("tcp", ml.listeners[1].Addr().String()) // rate limited ::1
+	if err != nil {
+		t.Fatalf("Bad connection was unable to connect: %v", err)
+	}
+	bad.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := bad.Read(make([]byte, 8)); err != io.EOF {
+		t.Errorf("Bad connection was not closed right away, got: %d bytes read, %v", n, err)
+	}
+	bad.Close()
+
+	ll.Close()
+	select {
+	case _, open := <-accepted:
+		if open {
+			t.Errorf("accepted a connection after closing")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Accept did not return after closing")
+	}
+
+	if !ll.isInHistory("127.0.0.1") || !ll.isInHistory("::/64") {
+		t.Errorf("history is missing addresses: %v", ll.history)
+	}
+}
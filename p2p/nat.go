@@ -0,0 +1,182 @@
+package p2p
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PortMappingUPnP maps the listen port via UPnP IGD
+	PortMappingUPnP = "upnp"
+	// PortMappingNATPMP maps the listen port via NAT-PMP
+	PortMappingNATPMP = "natpmp"
+	// PortMappingAuto tries UPnP first and NAT-PMP second
+	PortMappingAuto = "auto"
+)
+
+const (
+	portMappingLifetime = time.Hour
+	portMappingRetry    = time.Minute * 5
+	portMappingTimeout  = time.Second * 3
+)
+
+// portMapper is a gateway that can forward a port from its external address to this node
+type portMapper interface {
+	// ExternalIP returns the gateway's public address
+	ExternalIP() (net.IP, error)
+	// AddPortMapping forwards the external TCP port to the internal port. Returns the external
+	// port the gateway assigned and how long the mapping lasts
+	AddPortMapping(internal, external int, lifetime time.Duration) (int, time.Duration, error)
+	// DeletePortMapping removes a mapping
+	DeletePortMapping(internal, external int) error
+	String() string
+}
+
+// discoverPortMapper finds a gateway for the configured type of port mapping
+func (c *controller) discoverPortMapper() (portMapper, error) {
+	conf := c.net.conf
+	var errs []string
+
+	if conf.PortMapping == PortMappingUPnP || conf.PortMapping == PortMappingAuto {
+		mapper, err := discoverUPnP(ssdpAddress, portMappingTimeout)
+		if err == nil {
+			return mapper, nil
+		}
+		errs = append(errs, fmt.Sprintf("upnp: %v", err))
+	}
+
+	if conf.PortMapping == PortMappingNATPMP || conf.PortMapping == PortMappingAuto {
+		gateway := conf.PortMappingGateway
+		if gateway == "" {
+			gw, err := defaultGateway()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("nat-pmp: %v", err))
+				return nil, fmt.Errorf("no gateway found (%s)", strings.Join(errs, ", "))
+			}
+			gateway = gw.String()
+		}
+		if _, _, err := net.SplitHostPort(gateway); err != nil {
+			gateway = net.JoinHostPort(gateway, strconv.Itoa(natpmpPort))
+		}
+		mapper := newNATPMP(gateway)
+		if _, err := mapper.ExternalIP(); err != nil {
+			errs = append(errs, fmt.Sprintf("nat-pmp: %v", err))
+		} else {
+			return mapper, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no gateway found (%s)", strings.Join(errs, ", "))
+}
+
+// runPortMapping keeps a port mapping of the listen port alive until the network stops
+// and removes it afterward
+func (c *controller) runPortMapping() {
+	conf := c.net.conf
+	if conf.PortMapping == "" {
+		return
+	}
+	c.logger.Debug("Start runPortMapping()")
+	defer c.logger.Debug("Stop runPortMapping()")
+
+	internal, _ := strconv.Atoi(conf.ListenPort)
+	external := internal
+	if conf.ExternalPort != "" {
+		external, _ = strconv.Atoi(conf.ExternalPort)
+	}
+
+	var mapper portMapper
+	mapped := 0
+	for {
+		wait := portMappingRetry
+		if mapper == nil {
+			var err error
+			if mapper, err = c.discoverPortMapper(); err != nil {
+				c.logger.WithError(err).Warn("Unable to set up a port mapping")
+			}
+		}
+
+		if mapper != nil {
+			if port, lifetime, err := c.mapPort(mapper, internal, external); err != nil {
+				c.logger.WithError(err).Warnf("Unable to map port %d via %s", internal, mapper)
+				c.setMapped("", "")
+				mapper = nil
+			} else {
+				mapped = port
+				if lifetime > 0 {
+					wait = lifetime / 2
+				} else {
+					wait = portMappingLifetime / 2
+				}
+			}
+		}
+
+		select {
+		case <-c.net.stopper:
+			if mapper != nil && mapped > 0 {
+				if err := mapper.DeletePortMapping(internal, mapped); err != nil {
+					c.logger.WithError(err).Warnf("Unable to remove the port mapping via %s", mapper)
+				}
+			}
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// mapPort creates or renews the port mapping and updates the advertised address
+func (c *controller) mapPort(mapper portMapper, internal, external int) (int, time.Duration, error) {
+	port, lifetime, err := mapper.AddPortMapping(internal, external, portMappingLifetime)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	host := ""
+	if ip, err := mapper.ExternalIP(); err != nil {
+		c.logger.WithError(err).Warnf("Unable to get the external address from %s", mapper)
+	} else if !ip.IsUnspecified() {
+		host = ip.String()
+	}
+
+	c.logger.Debugf("Mapped port %d to %s:%d via %s for %s", internal, host, port, mapper, lifetime)
+	c.setMapped(host, strconv.Itoa(port))
+	return port, lifetime, nil
+}
+
+// defaultGateway reads the default IPv4 gateway from the routing table. Only supported on linux
+func defaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the routing table: %v", err)
+	}
+	defer f.Close()
+	return parseRouteTable(f)
+}
+
+// parseRouteTable finds the gateway of the default route in the format of /proc/net/route
+func parseRouteTable(r io.Reader) (net.IP, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip, nil
+	}
+	return nil, fmt.Errorf("no default route")
+}
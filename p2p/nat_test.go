@@ -0,0 +1,294 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testNATPMPGateway is a fake NAT-PMP gateway that maps every port to the port + 1
+type testNATPMPGateway struct {
+	conn     net.PacketConn
+	mtx      sync.Mutex
+	mappings map[uint16]uint16 // internal => external
+}
+
+func newTestNATPMPGateway(t *testing.T) *testNATPMPGateway {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &testNATPMPGateway{conn: conn, mappings: make(map[uint16]uint16)}
+	go gw.serve()
+	return gw
+}
+
+func (gw *testNATPMPGateway) serve() {
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := gw.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 2 || buf[0] != 0 {
+			continue
+		}
+		switch buf[1] {
+		case natpmpOpExternal:
+			resp := make([]byte, 12)
+			resp[1] = natpmpOpResponse + natpmpOpExternal
+			copy(resp[8:], net.IPv4(203, 0, 113, 7).To4())
+			gw.conn.WriteTo(resp, addr)
+		case natpmpOpMapTCP:
+			internal := binary.BigEndian.Uint16(buf[4:6])
+			lifetime := binary.BigEndian.Uint32(buf[8:12])
+			var external uint16
+			gw.mtx.Lock()
+			if lifetime == 0 {
+				delete(gw.mappings, internal)
+			} else {
+				external = internal + 1
+				gw.mappings[internal] = external
+			}
+			gw.mtx.Unlock()
+
+			resp := make([]byte, 16)
+			resp[1] = natpmpOpResponse + natpmpOpMapTCP
+			binary.BigEndian.PutUint16(resp[8:], internal)
+			binary.BigEndian.PutUint16(resp[10:], external)
+			binary.BigEndian.PutUint32(resp[12:], lifetime)
+			gw.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (gw *testNATPMPGateway) mapping(internal uint16) (uint16, bool) {
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+	ext, ok := gw.mappings[internal]
+	return ext, ok
+}
+
+func Test_natPMP(t *testing.T) {
+	gw := newTestNATPMPGateway(t)
+	defer gw.conn.Close()
+
+	n := newNATPMP(gw.conn.LocalAddr().String())
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(203, 0, 113, 7)) {
+		t.Errorf("wrong external ip. got = %s, want = 203.0.113.7", ip)
+	}
+
+	port, lifetime, err := n.AddPortMapping(8108, 8108, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 8109 || lifetime != time.Hour {
+		t.Errorf("wrong mapping. got = %d for %s, want = 8109 for 1h", port, lifetime)
+	}
+
+	if err := n.DeletePortMapping(8108, port); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gw.mapping(8108); ok {
+		t.Errorf("mapping was not deleted")
+	}
+
+	// nobody is listening
+	silent := newNATPMP("127.0.0.1:1")
+	silent.delay = time.Millisecond
+	if _, err := silent.ExternalIP(); err == nil {
+		t.Errorf("no error without a gateway")
+	}
+}
+
+// testUPnPGateway is a fake internet gateway device with an SSDP responder
+type testUPnPGateway struct {
+	ssdp     net.PacketConn
+	http     *httptest.Server
+	mtx      sync.Mutex
+	mappings map[string]string // external port => internal client:port
+	calls    []string
+}
+
+func newTestUPnPGateway(t *testing.T) *testUPnPGateway {
+	gw := &testUPnPGateway{mappings: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/desc.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<device><deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+<deviceList><device><deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+<deviceList><device><deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+<serviceList><service>
+<serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+<controlURL>/ctl/IPConn</controlURL>
+</service></serviceList>
+</device></deviceList>
+</device></deviceList>
+</device></root>`)
+	})
+	mux.HandleFunc("/ctl/IPConn", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		action := r.Header.Get("SOAPAction")
+		gw.mtx.Lock()
+		defer gw.mtx.Unlock()
+		gw.calls = append(gw.calls, action)
+
+		switch {
+		case strings.HasSuffix(action, `#GetExternalIPAddress"`):
+			fmt.Fprint(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1"><NewExternalIPAddress>198.51.100.3</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>`)
+		case strings.HasSuffix(action, `#AddPortMapping"`):
+			if soapValue(body, "NewLeaseDuration") != "0" {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail><UPnPError><errorCode>725</errorCode></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+				return
+			}
+			gw.mappings[soapValue(body, "NewExternalPort")] = soapValue(body, "NewInternalClient") + ":" + soapValue(body, "NewInternalPort")
+		case strings.HasSuffix(action, `#DeletePortMapping"`):
+			delete(gw.mappings, soapValue(body, "NewExternalPort"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	gw.http = httptest.NewServer(mux)
+
+	var err error
+	gw.ssdp, err = net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := gw.ssdp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(string(buf[:n]), "M-SEARCH") {
+				continue
+			}
+			resp := "HTTP/1.1 200 OK\r\nST: " + upnpGateway + "\r\nLOCATION: " + gw.http.URL + "/desc.xml\r\n\r\n"
+			gw.ssdp.WriteTo([]byte(resp), addr)
+		}
+	}()
+	return gw
+}
+
+func (gw *testUPnPGateway) Close() {
+	gw.ssdp.Close()
+	gw.http.Close()
+}
+
+func Test_upnp(t *testing.T) {
+	gw := newTestUPnPGateway(t)
+	defer gw.Close()
+
+	u, err := discoverUPnP(gw.ssdp.LocalAddr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.control != gw.http.URL+"/ctl/IPConn" {
+		t.Errorf("wrong control url. got = %s", u.control)
+	}
+
+	ip, err := u.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("198.51.100.3")) {
+		t.Errorf("wrong external ip. got = %s, want = 198.51.100.3", ip)
+	}
+
+	// the gateway only supports permanent leases
+	port, lifetime, err := u.AddPortMapping(8108, 9108, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 9108 || lifetime != 0 {
+		t.Errorf("wrong mapping. got = %d for %s, want = 9108 for 0s", port, lifetime)
+	}
+	gw.mtx.Lock()
+	if m := gw.mappings["9108"]; m != "127.0.0.1:8108" {
+		t.Errorf("gateway has wrong mapping. got = %s, want = 127.0.0.1:8108", m)
+	}
+	gw.mtx.Unlock()
+
+	if err := u.DeletePortMapping(8108, 9108); err != nil {
+		t.Fatal(err)
+	}
+	gw.mtx.Lock()
+	if len(gw.mappings) != 0 {
+		t.Errorf("mapping was not deleted")
+	}
+	gw.mtx.Unlock()
+
+	// nobody is listening
+	silent, _ := net.ListenPacket("udp4", "127.0.0.1:0")
+	defer silent.Close()
+	if _, err := discoverUPnP(silent.LocalAddr().String(), time.Millisecond*50); err == nil {
+		t.Errorf("no error without a gateway")
+	}
+}
+
+func Test_controller_runPortMapping(t *testing.T) {
+	gw := newTestNATPMPGateway(t)
+	defer gw.conn.Close()
+
+	n := testNetworkHarness(t)
+	n.conf.PortMapping = PortMappingNATPMP
+	n.conf.PortMappingGateway = gw.conn.LocalAddr().String()
+	n.conf.ListenPort = "8108"
+
+	done := make(chan bool)
+	go func() {
+		n.controller.runPortMapping()
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if host, _ := n.controller.advertisedEndpoint(); host != "" {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	if ep := n.AdvertisedEndpoint(); ep.IP != "203.0.113.7" || ep.Port != "8109" {
+		t.Errorf("mapped address not advertised. got = %s, want = 203.0.113.7:8109", ep)
+	}
+
+	close(n.stopper)
+	<-done
+	if _, ok := gw.mapping(8108); ok {
+		t.Errorf("mapping was not removed after stopping")
+	}
+}
+
+func Test_parseRouteTable(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	0000A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth0	00000000	0100A8C0	0003	0	0	0	00000000	0	0	0
+`
+	ip, err := parseRouteTable(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(192, 168, 0, 1)) {
+		t.Errorf("wrong gateway. got = %s, want = 192.168.0.1", ip)
+	}
+
+	if _, err := parseRouteTable(strings.NewReader(strings.SplitN(table, "\n", 3)[0] + "\n")); err == nil {
+		t.Errorf("no error without a default route")
+	}
+}
@@ -0,0 +1,107 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// NAT-PMP as specified in RFC 6886
+const (
+	natpmpPort         = 5351
+	natpmpOpExternal   = 0
+	natpmpOpMapTCP     = 2
+	natpmpOpResponse   = 128
+	natpmpTries        = 4
+	natpmpInitialDelay = time.Millisecond * 250
+)
+
+// natPMP is a NAT-PMP gateway
+type natPMP struct {
+	gateway string // host:port
+	delay   time.Duration
+}
+
+func newNATPMP(gateway string) *natPMP {
+	n := new(natPMP)
+	n.gateway = gateway
+	n.delay = natpmpInitialDelay
+	return n
+}
+
+func (n *natPMP) String() string {
+	return fmt.Sprintf("nat-pmp gateway %s", n.gateway)
+}
+
+// request sends the request to the gateway and waits for the response to the opcode,
+// retrying with a doubling delay
+func (n *natPMP) request(req []byte, size int) ([]byte, error) {
+	conn, err := net.Dial("udp", n.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	op := req[1] + natpmpOpResponse
+	buf := make([]byte, 16)
+	delay := n.delay
+	for i := 0; i < natpmpTries; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(delay)
+		delay *= 2
+
+		for {
+			conn.SetReadDeadline(deadline)
+			read, err := conn.Read(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break // try again
+				}
+				return nil, err
+			}
+			if read < size || buf[0] != 0 || buf[1] != op {
+				continue // not the response we're looking for
+			}
+			if code := binary.BigEndian.Uint16(buf[2:4]); code != 0 {
+				return nil, fmt.Errorf("gateway responded with result code %d", code)
+			}
+			return buf[:read], nil
+		}
+	}
+	return nil, fmt.Errorf("no response from %s", n.gateway)
+}
+
+// ExternalIP asks the gateway for its public address
+func (n *natPMP) ExternalIP() (net.IP, error) {
+	resp, err := n.request([]byte{0, natpmpOpExternal}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// AddPortMapping asks the gateway to forward the external TCP port
+func (n *natPMP) AddPortMapping(internal, external int, lifetime time.Duration) (int, time.Duration, error) {
+	req := make([]byte, 12)
+	req[1] = natpmpOpMapTCP
+	binary.BigEndian.PutUint16(req[4:], uint16(internal))
+	binary.BigEndian.PutUint16(req[6:], uint16(external))
+	binary.BigEndian.PutUint32(req[8:], uint32(lifetime/time.Second))
+
+	resp, err := n.request(req, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	mapped := int(binary.BigEndian.Uint16(resp[10:12]))
+	granted := time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second
+	return mapped, granted, nil
+}
+
+// DeletePortMapping removes the mapping by requesting a lifetime of zero
+func (n *natPMP) DeletePortMapping(internal, external int) error {
+	_, _, err := n.AddPortMapping(internal, 0, 0)
+	return err
+}
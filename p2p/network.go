@@ -1,8 +1,11 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"math/rand"
+	"net"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -19,9 +22,13 @@ type Network struct {
 
 	conf       *Configuration
 	controller *controller
+	clock      Clock
 
 	prom *Prometheus
 
+	// bandwidth limits of all peers combined, nil if unlimited
+	bandwidthIn, bandwidthOut *tokenBucket
+
 	metricsHook func(pm map[string]PeerMetrics)
 
 	rng        *rand.Rand // note: not thread safe for Read()
@@ -47,6 +54,10 @@ func NewNetwork(conf Configuration) (*Network, error) {
 	n.conf = &conf
 	n.conf.Sanitize()
 	n.stopper = make(chan interface{})
+	n.clock = n.conf.Clock
+	if n.clock == nil {
+		n.clock = systemClock{}
+	}
 
 	n.logger = packageLogger.WithField("subpackage", "Network").WithField("node", n.conf.NodeName)
 
@@ -55,7 +66,11 @@ func NewNetwork(conf Configuration) (*Network, error) {
 		n.prom.Setup()
 	}
 
-	if src, err := newLockSource(time.Now().UnixNano()); err != nil {
+	seed := n.conf.RandomSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if src, err := newLockSource(seed); err != nil {
 		return nil, err
 	} else {
 		n.rng = rand.New(src)
@@ -68,6 +83,16 @@ func NewNetwork(conf Configuration) (*Network, error) {
 		n.conf.NodeID = StringToUint32(n.conf.NodeName)
 	}
 
+	// nodes without a persistent identity get a new one every start
+	if n.conf.NodeKey == nil {
+		if _, n.conf.NodeKey, err = ed25519.GenerateKey(nil); err != nil {
+			return nil, fmt.Errorf("unable to generate node key: %v", err)
+		}
+	}
+
+	n.bandwidthIn = newTokenBucket(n.conf.BandwidthGlobalIn, n.conf.BandwidthBurst)
+	n.bandwidthOut = newTokenBucket(n.conf.BandwidthGlobalOut, n.conf.BandwidthBurst)
+
 	n.controller, err = newController(n)
 	if err != nil {
 		return nil, err
@@ -142,6 +167,14 @@ func (n *Network) Ban(hash string) {
 	go n.controller.ban(hash, n.conf.ManualBan)
 }
 
+// ReportMisbehavior adds the severity to the misbehavior score of a peer's address.
+// Addresses that reach the threshold set in the configuration are banned temporarily,
+// each further ban of the same address lasts twice as long
+func (n *Network) ReportMisbehavior(hash string, severity Severity) {
+	n.logger.Debugf("Received misbehavior of %s from application, severity %d", hash, severity)
+	go n.controller.reportMisbehavior(hash, severity)
+}
+
 // Disconnect severs connection for a specific peer. They are free to
 // connect again afterward
 func (n *Network) Disconnect(hash string) {
@@ -158,11 +191,114 @@ func (n *Network) SetSpecial(raw string) {
 	go n.controller.setSpecial(raw)
 }
 
+// ReplaceSpecial replaces the special peers with the comma separated list of endpoints and
+// returns the parsed endpoints. Unlike SetSpecial, it fails if the list can't be parsed
+func (n *Network) ReplaceSpecial(raw string) ([]Endpoint, error) {
+	if strings.TrimSpace(raw) == "" {
+		n.controller.setSpecial("")
+		return nil, nil
+	}
+	eps, err := parseSpecial(raw)
+	if err != nil {
+		return nil, err
+	}
+	n.logger.Debugf("Received replacement of special peers from application: %s", raw)
+	n.controller.setSpecial(raw)
+	return eps, nil
+}
+
+// Connect dials the endpoint and returns the hash of the peer once the handshake succeeded.
+// Endpoints that are banned or already connected are refused
+func (n *Network) Connect(ep Endpoint) (string, error) {
+	if !ep.Valid() {
+		return "", fmt.Errorf("%s is not a valid endpoint", ep)
+	}
+	if n.controller.peers.Connected(ep) {
+		return "", fmt.Errorf("already connected to %s", ep)
+	}
+	if n.controller.isBannedEndpoint(ep) {
+		return "", fmt.Errorf("%s is banned", ep)
+	}
+	n.logger.Debugf("Received connect to %s from application", ep)
+	peer, _ := n.controller.Dial(ep)
+	if peer == nil {
+		return "", fmt.Errorf("unable to connect to %s", ep)
+	}
+	return peer.Hash, nil
+}
+
+// BanPeer bans the address of a connected peer for the duration and disconnects all peers
+// from that address. A duration of zero uses the configured ManualBan
+func (n *Network) BanPeer(hash string, duration time.Duration) error {
+	if n.controller.peers.Get(hash) == nil {
+		return fmt.Errorf("peer %s not found", hash)
+	}
+	if duration <= 0 {
+		duration = n.conf.ManualBan
+	}
+	n.logger.Debugf("Received ban for %s from application for %s", hash, duration)
+	n.controller.ban(hash, duration)
+	return nil
+}
+
+// BanAddress bans an ip address or an endpoint ("ip:port") for the duration and disconnects
+// the peers using it. A duration of zero uses the configured ManualBan
+func (n *Network) BanAddress(addr string, duration time.Duration) error {
+	if duration <= 0 {
+		duration = n.conf.ManualBan
+	}
+	n.logger.Debugf("Received ban for %s from application for %s", addr, duration)
+	if ep, err := ParseEndpoint(addr); err == nil {
+		n.controller.banEndpoint(ep, duration)
+		return nil
+	}
+	if ip := net.ParseIP(normalizeHost(addr)); ip != nil {
+		n.controller.banIP(ip.String(), duration)
+		return nil
+	}
+	return fmt.Errorf("%s is neither an ip address nor an endpoint", addr)
+}
+
+// Unban lifts the ban of an ip address, including all of its endpoints, or of a single
+// endpoint ("ip:port"). Returns false if the address wasn't banned
+func (n *Network) Unban(addr string) bool {
+	n.logger.Debugf("Received unban for %s from application", addr)
+	if ep, err := ParseEndpoint(addr); err == nil {
+		return n.controller.unban(ep.String())
+	}
+	return n.controller.unban(normalizeHost(addr))
+}
+
+// Bans returns the active bans of ip addresses and endpoints and when they end
+func (n *Network) Bans() map[string]time.Time {
+	return n.controller.copyBans()
+}
+
+// SpecialEndpoints returns the endpoints of the current special peers
+func (n *Network) SpecialEndpoints() []Endpoint {
+	n.controller.specialMtx.RLock()
+	defer n.controller.specialMtx.RUnlock()
+	return append([]Endpoint(nil), n.controller.specialEndpoints...)
+}
+
 // Total returns the number of active connections
 func (n *Network) Total() int {
 	return n.controller.peers.Total()
 }
 
+// AdvertisedEndpoint returns the address this node advertises to other nodes. The host is
+// empty if it is neither configured nor learned from the port mapping or peers
+func (n *Network) AdvertisedEndpoint() Endpoint {
+	host, port := n.controller.advertisedEndpoint()
+	return Endpoint{IP: host, Port: port}
+}
+
+// GossipCoverage returns the number of peers known to have the message with the given payload.
+// Only messages that are broadcast via gossip are tracked, returns false for unknown messages
+func (n *Network) GossipCoverage(payload []byte) (int, bool) {
+	return n.controller.gossipCoverage(payload)
+}
+
 // Rounds returns the total number of CAT rounds that have occurred
 func (n *Network) Rounds() int {
 	return n.controller.rounds
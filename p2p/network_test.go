@@ -2,7 +2,9 @@ package p2p
 
 import (
 	"fmt"
+	"net"
 	"testing"
+	"time"
 )
 
 var unitTestNetworks = 1
@@ -22,3 +24,106 @@ func testNetworkHarness(t *testing.T) *Network {
 	}
 	return n
 }
+
+func TestNetwork_BanUnban(t *testing.T) {
+	n := testNetworkHarness(t)
+
+	p := testRandomPeer(n)
+	other := testRandomPeer(n)
+	other.Endpoint.IP = p.Endpoint.IP
+	p.conn, _ = net.Pipe()
+	other.conn, _ = net.Pipe()
+	n.controller.peers.Add(p)
+	n.controller.peers.Add(other)
+
+	if err := n.BanPeer("unknown", 0); err == nil {
+		t.Errorf("banned an unknown peer")
+	}
+	if err := n.BanPeer(p.Hash, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if !n.controller.isBannedIP(p.Endpoint.IP) {
+		t.Errorf("peer's address not banned")
+	}
+	select {
+	case <-other.stop:
+	default:
+		t.Errorf("peer with the same address not disconnected")
+	}
+
+	if err := n.BanAddress("[2001:db8::1]:8108", 0); err != nil {
+		t.Error(err)
+	}
+	if err := n.BanAddress("2001:DB8::2", time.Minute); err != nil {
+		t.Error(err)
+	}
+	if err := n.BanAddress("not an address", 0); err == nil {
+		t.Errorf("banned an invalid address")
+	}
+
+	bans := n.Bans()
+	if len(bans) != 4 {
+		t.Errorf("wrong number of bans. got = %v", bans)
+	}
+	if end := bans["[2001:db8::1]:8108"]; time.Until(end) < n.conf.ManualBan-time.Minute {
+		t.Errorf("manual ban duration not used. got = %s", end)
+	}
+	if _, ok := bans["2001:db8::2"]; !ok {
+		t.Errorf("ip ban missing. got = %v", bans)
+	}
+
+	// unbanning the ip lifts the ban of its endpoint
+	if !n.Unban(p.Endpoint.IP) {
+		t.Errorf("unban of %s returned false", p.Endpoint.IP)
+	}
+	if n.controller.isBannedEndpoint(p.Endpoint) {
+		t.Errorf("endpoint is still banned")
+	}
+	if !n.Unban("[2001:db8::1]:8108") || !n.Unban("2001:db8::2") {
+		t.Errorf("unban of ipv6 addresses returned false")
+	}
+	if n.Unban("192.0.2.1") {
+		t.Errorf("unban of an address that isn't banned returned true")
+	}
+	if bans := n.Bans(); len(bans) != 0 {
+		t.Errorf("bans remain after unbanning. got = %v", bans)
+	}
+}
+
+func TestNetwork_Connect(t *testing.T) {
+	n1 := testNetworkHarness(t)
+	n1.conf.BindIP = "127.0.0.1"
+	n1.conf.ListenPort = "14240"
+	n2 := testNetworkHarness(t)
+
+	done := make(chan bool, 1)
+	go func() {
+		n1.controller.listen()
+		done <- true
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	ep := Endpoint{IP: "127.0.0.1", Port: "14240"}
+	if _, err := n2.Connect(Endpoint{IP: "127.0.0.1"}); err == nil {
+		t.Errorf("connected to an invalid endpoint")
+	}
+
+	n2.controller.banEndpoint(ep, time.Hour)
+	if _, err := n2.Connect(ep); err == nil {
+		t.Errorf("connected to a banned endpoint")
+	}
+	n2.Unban(ep.String())
+
+	hash, err := n2.Connect(ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := <-n2.controller.peerStatus
+	if pc.peer.Hash != hash {
+		t.Errorf("wrong peer hash. got = %s, want = %s", hash, pc.peer.Hash)
+	}
+	pc.peer.Stop()
+
+	n1.Stop()
+	<-done
+}
@@ -0,0 +1,37 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// LoadNodeKey reads the hex encoded ed25519 seed of the node key from a file.
+// If the file does not exist, a new key is generated and saved to it.
+func LoadNodeKey(path string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode node key: %v", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("node key has the wrong length %d (want %d)", len(seed), ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,38 @@
+package p2p
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadNodeKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodekey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nodekey")
+	key, err := LoadNodeKey(path)
+	if err != nil {
+		t.Fatalf("unable to create key: %v", err)
+	}
+
+	loaded, err := LoadNodeKey(path)
+	if err != nil {
+		t.Fatalf("unable to load key: %v", err)
+	}
+	if !bytes.Equal(key, loaded) {
+		t.Errorf("loaded key differs. want = %x, got = %x", key, loaded)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("abcd"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNodeKey(path); err == nil {
+		t.Errorf("short key didn't give us an error")
+	}
+}
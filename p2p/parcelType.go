@@ -24,6 +24,16 @@ const ( // iota is reset to 0
 	TypeHandshake
 	// TypeRejectAlternative is sent instead of a handshake if the server refuses connection
 	TypeRejectAlternative
+	// TypeGossip carries an application message that is pushed along the gossip tree
+	TypeGossip
+	// TypeGossipHave announces the ids of messages without sending the payload
+	TypeGossipHave
+	// TypeGossipGraft requests messages by id and adds the sender to the eager set
+	TypeGossipGraft
+	// TypeGossipPrune moves the sender to the lazy set
+	TypeGossipPrune
+	// TypeCompressed carries another parcel with a compressed payload
+	TypeCompressed
 )
 
 var typeStrings = map[ParcelType]string{
@@ -37,6 +47,11 @@ var typeStrings = map[ParcelType]string{
 	TypeMessagePart:       "MessagePart",
 	TypeHandshake:         "Handshake",
 	TypeRejectAlternative: "Rejection-Alternative",
+	TypeGossip:            "Gossip",
+	TypeGossipHave:        "Gossip-Have",
+	TypeGossipGraft:       "Gossip-Graft",
+	TypeGossipPrune:       "Gossip-Prune",
+	TypeCompressed:        "Compressed",
 }
 
 func (t ParcelType) String() string {
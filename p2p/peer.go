@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"crypto/ed25519"
 	"crypto/sha1"
 	"fmt"
 	"net"
@@ -26,6 +27,17 @@ type Peer struct {
 	IsIncoming bool
 	Endpoint   Endpoint
 	Hash       string // This is more of a connection ID than hash right now.
+	// Advertised is the endpoint other nodes can reach the peer at, shared in peer shares
+	Advertised Endpoint
+	// PublicKey is the verified node key, only set for protocols that authenticate peers
+	PublicKey ed25519.PublicKey
+	// Features are the optional features both sides support
+	Features Feature
+	// compression is the algorithm used to compress parcels to the peer
+	compression Compression
+
+	// position in the gossip tree, guarded by the controller's gossip mutex
+	gossip gossipState
 
 	stopper sync.Once
 	stop    chan bool
@@ -34,7 +46,13 @@ type Peer struct {
 	lastPeerSend    time.Time
 
 	// communication channels
-	send ParcelChannel // parcels from Send() are added here
+	// parcels from Send() are added to the lane of their priority
+	send     ParcelChannel // PriorityNormal
+	sendHigh ParcelChannel
+	sendLow  ParcelChannel
+
+	// bandwidth limits of this peer, nil if unlimited
+	bandwidthIn, bandwidthOut *tokenBucket
 
 	// Metrics
 	metricsMtx           sync.RWMutex
@@ -48,21 +66,30 @@ type Peer struct {
 	bpsDown, bpsUp       float64
 	mpsDown, mpsUp       float64
 	dropped              uint64
+	throttledIn          time.Duration
+	throttledOut         time.Duration
+	compressIn           compressionStats
+	compressOut          compressionStats
 
 	// logging
 	logger *log.Entry
 }
 
-func newPeer(net *Network, id uint32, ep Endpoint, conn net.Conn, protocol Protocol, metrics ReadWriteCollector, incoming bool) *Peer {
+func newPeer(net *Network, id uint32, ep Endpoint, conn net.Conn, protocol Protocol, metrics ReadWriteCollector, incoming bool, features Feature) *Peer {
 	p := new(Peer)
 	p.net = net
 	p.prot = protocol
 	p.Endpoint = ep
 	p.metrics = metrics
 	p.conn = conn
+	p.Features = features & supportedFeatures(net.conf)
+	p.compression = negotiateCompression(net.conf, p.Features)
+	if v12, ok := protocol.(*ProtocolV12); ok {
+		p.PublicKey = v12.PeerKey()
+	}
 
 	p.stop = make(chan bool, 1)
-	p.Hash = fmt.Sprintf("%s:%s %08x", ep.IP, ep.Port, id)
+	p.Hash = fmt.Sprintf("%s %08x", ep, id)
 
 	p.logger = peerLogger.WithFields(log.Fields{
 		"hash":    p.Hash,
@@ -74,8 +101,12 @@ func newPeer(net *Network, id uint32, ep Endpoint, conn net.Conn, protocol Proto
 
 	// initialize channels
 	p.send = newParcelChannel(p.net.conf.ChannelCapacity)
+	p.sendHigh = newParcelChannel(p.net.conf.ChannelCapacity)
+	p.sendLow = newParcelChannel(p.net.conf.ChannelCapacity)
+	p.bandwidthIn = newTokenBucket(p.net.conf.BandwidthPeerIn, p.net.conf.BandwidthBurst)
+	p.bandwidthOut = newTokenBucket(p.net.conf.BandwidthPeerOut, p.net.conf.BandwidthBurst)
 	p.IsIncoming = incoming
-	p.connected = time.Now()
+	p.connected = p.net.clock.Now()
 
 	if net.conf.PeerResendFilter {
 		p.resend = NewPeerHashCache(net.conf.PeerResendBuckets, net.conf.PeerResendInterval)
@@ -97,7 +128,6 @@ func (p *Peer) Stop() {
 		}
 		close(p.stop) // stops sendLoop and readLoop and statLoop
 		p.conn.Close()
-		// sendLoop closes p.send in defer
 		select {
 		case p.net.controller.peerStatus <- peerStatus{peer: p, online: false}:
 		case <-p.net.stopper:
@@ -114,19 +144,31 @@ func (p *Peer) Send(parcel *Parcel) {
 	case <-p.stop:
 		// don't send when stopped
 	default:
-		_, dropped := p.send.Send(parcel)
+		_, dropped := p.lane(parcelPriority(p.net.conf, parcel)).Send(parcel)
 		p.metricsMtx.Lock()
 		p.dropped += uint64(dropped)
 		p.metricsMtx.Unlock()
 	}
 }
 
+// lane returns the send channel for parcels of the given priority
+func (p *Peer) lane(prio Priority) ParcelChannel {
+	switch prio {
+	case PriorityHigh:
+		return p.sendHigh
+	case PriorityLow:
+		return p.sendLow
+	default:
+		return p.send
+	}
+}
+
 func (p *Peer) statLoop() {
-	ticker := time.NewTicker(time.Second)
+	ticker := p.net.clock.NewTicker(time.Second)
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			p.metricsMtx.Lock()
 			mw, mr, bw, br := p.metrics.Collect()
 			p.bpsDown = float64(br)
@@ -153,10 +195,13 @@ func (p *Peer) readLoop() {
 	}
 	defer p.Stop() // close connection on fatal error
 	for {
-		p.conn.SetReadDeadline(time.Now().Add(p.net.conf.ReadDeadline))
+		p.conn.SetReadDeadline(p.net.clock.Now().Add(p.net.conf.ReadDeadline))
 		msg, err := p.prot.Receive()
 		if err != nil {
 			p.logger.WithError(err).Debug("connection error (readLoop)")
+			if isProtocolError(err) {
+				p.net.controller.peerMisbehavior(p, SeveritySevere, "undecodable parcel")
+			}
 			return
 		}
 
@@ -165,12 +210,43 @@ func (p *Peer) readLoop() {
 			if p.net.prom != nil {
 				p.net.prom.Invalid.Inc()
 			}
+			p.net.controller.peerMisbehavior(p, SeveritySevere, "invalid parcel")
 			return
 		}
 
+		// bandwidth is counted before decompression since it limits the wire
+		wireSize := len(msg.Payload)
+
+		if msg.ptype == TypeCompressed {
+			if msg, err = p.decompress(msg); err != nil {
+				p.logger.WithError(err).Warnf("received undecodable compressed parcel, disconnecting peer")
+				p.net.controller.peerMisbehavior(p, SeveritySevere, "undecodable compressed parcel")
+				return
+			}
+		}
+
+		// high priority parcels are passed right away, their bandwidth is
+		// taken from the parcels that come after
+		if parcelPriority(p.net.conf, msg) == PriorityHigh {
+			reserveBandwidth(wireSize, p.bandwidthIn, p.net.bandwidthIn)
+		} else {
+			waited, ok := waitBandwidth(wireSize, p.stop, p.bandwidthIn, p.net.bandwidthIn)
+			if waited > 0 {
+				p.metricsMtx.Lock()
+				p.throttledIn += waited
+				p.metricsMtx.Unlock()
+				if p.net.prom != nil {
+					p.net.prom.ThrottledIn.Add(waited.Seconds())
+				}
+			}
+			if !ok {
+				return
+			}
+		}
+
 		// metrics
 		p.metricsMtx.Lock()
-		p.lastReceive = time.Now()
+		p.lastReceive = p.net.clock.Now()
 		p.metricsMtx.Unlock()
 
 		// stats
@@ -183,7 +259,11 @@ func (p *Peer) readLoop() {
 		}
 
 		if p.resend != nil && msg.IsApplicationMessage() {
-			p.resend.Add(sha1.Sum(msg.Payload))
+			hash := sha1.Sum(msg.Payload)
+			if p.resend.Has(hash) {
+				p.net.controller.peerMisbehavior(p, SeverityMinor, "duplicate message")
+			}
+			p.resend.Add(hash)
 		}
 
 		msg.Address = p.Hash // always set sender = peer
@@ -211,48 +291,118 @@ func (p *Peer) sendLoop() {
 		defer p.net.prom.SendRoutines.Dec()
 	}
 
-	defer close(p.send)
+	// the send lanes are never closed since Send may be called concurrently
 	defer p.Stop() // close connection on fatal error
 	for {
-		select {
-		case <-p.net.stopper:
+		parcel, ok := p.nextParcel()
+		if !ok {
 			return
-		case <-p.stop:
-			return
-		case parcel := <-p.send:
-			if parcel == nil {
-				p.logger.Error("Received <nil> pointer from application")
-				continue
-			}
+		}
+		if parcel == nil {
+			p.logger.Error("Received <nil> pointer from application")
+			continue
+		}
 
-			p.conn.SetWriteDeadline(time.Now().Add(p.net.conf.WriteDeadline))
-			err := p.prot.Send(parcel)
-			if err != nil { // no error is recoverable
-				p.logger.WithError(err).Debug("connection error (sendLoop)")
-				return // stops in defer
-			}
+		parcel = p.compress(parcel)
 
-			// metrics
+		waited, ok := waitBandwidth(len(parcel.Payload), p.stop, p.bandwidthOut, p.net.bandwidthOut)
+		if waited > 0 {
 			p.metricsMtx.Lock()
-			p.lastSend = time.Now()
+			p.throttledOut += waited
 			p.metricsMtx.Unlock()
-
-			// stats
 			if p.net.prom != nil {
-				p.net.prom.ParcelsSent.Inc()
-				p.net.prom.ParcelSize.Observe(float64(len(parcel.Payload)+32) / 1024)
-				if parcel.IsApplicationMessage() {
-					p.net.prom.AppSent.Inc()
-				}
+				p.net.prom.ThrottledOut.Add(waited.Seconds())
 			}
 		}
+		if !ok {
+			return
+		}
+
+		p.conn.SetWriteDeadline(p.net.clock.Now().Add(p.net.conf.WriteDeadline))
+		err := p.prot.Send(parcel)
+		if err != nil { // no error is recoverable
+			p.logger.WithError(err).Debug("connection error (sendLoop)")
+			return // stops in defer
+		}
+
+		// metrics
+		p.metricsMtx.Lock()
+		p.lastSend = p.net.clock.Now()
+		p.metricsMtx.Unlock()
+
+		// stats
+		if p.net.prom != nil {
+			p.net.prom.ParcelsSent.Inc()
+			p.net.prom.ParcelSize.Observe(float64(len(parcel.Payload)+32) / 1024)
+			if parcel.IsApplicationMessage() {
+				p.net.prom.AppSent.Inc()
+			}
+		}
+	}
+}
+
+// compress compresses the parcel with the negotiated algorithm if it's large enough
+func (p *Peer) compress(parcel *Parcel) *Parcel {
+	if p.compression == CompressionNone || len(parcel.Payload) < p.net.conf.CompressionThreshold {
+		return parcel
+	}
+	start := time.Now()
+	compressed := compressParcel(p.compression, parcel)
+	took := time.Since(start)
+
+	p.metricsMtx.Lock()
+	p.compressOut.add(len(parcel.Payload), len(compressed.Payload), took)
+	p.metricsMtx.Unlock()
+	return compressed
+}
+
+// decompress unwraps a compressed parcel and checks the result
+func (p *Peer) decompress(parcel *Parcel) (*Parcel, error) {
+	start := time.Now()
+	decompressed, err := decompressParcel(p.Features, parcel)
+	if err != nil {
+		return nil, err
+	}
+	if err := decompressed.Valid(); err != nil {
+		return nil, err
+	}
+	took := time.Since(start)
+
+	p.metricsMtx.Lock()
+	p.compressIn.add(len(decompressed.Payload), len(parcel.Payload), took)
+	p.metricsMtx.Unlock()
+	return decompressed, nil
+}
+
+// nextParcel blocks until there is a parcel to send, taking it from the highest
+// lane that has one. Returns false if the peer or network stopped.
+func (p *Peer) nextParcel() (*Parcel, bool) {
+	for _, lane := range []ParcelChannel{p.sendHigh, p.send, p.sendLow} {
+		select {
+		case parcel := <-lane:
+			return parcel, true
+		default:
+		}
+	}
+
+	select {
+	case <-p.net.stopper:
+		return nil, false
+	case <-p.stop:
+		return nil, false
+	case parcel := <-p.sendHigh:
+		return parcel, true
+	case parcel := <-p.send:
+		return parcel, true
+	case parcel := <-p.sendLow:
+		return parcel, true
 	}
 }
 
 func (p *Peer) LastSendAge() time.Duration {
 	p.metricsMtx.RLock()
 	defer p.metricsMtx.RUnlock()
-	return time.Since(p.lastSend)
+	return p.net.clock.Since(p.lastSend)
 }
 
 // GetMetrics returns live metrics for this connection
@@ -260,7 +410,7 @@ func (p *Peer) GetMetrics() PeerMetrics {
 	p.metricsMtx.RLock()
 	defer p.metricsMtx.RUnlock()
 	pt := "regular"
-	if p.net.controller.isSpecial(p.Endpoint) {
+	if p.net.controller.isSpecialPeer(p) {
 		pt = "special_config"
 	}
 	return PeerMetrics{
@@ -282,10 +432,23 @@ func (p *Peer) GetMetrics() PeerMetrics {
 		ConnectionState:  fmt.Sprintf("v%s", p.prot),
 		SendFillRatio:    p.SendFillRatio(),
 		Dropped:          p.dropped,
+		LimitIn:          p.bandwidthIn.Rate(),
+		LimitOut:         p.bandwidthOut.Rate(),
+		ThrottledIn:      p.throttledIn,
+		ThrottledOut:     p.throttledOut,
+		Throttling:       p.bandwidthOut.IsThrottling() || p.net.bandwidthOut.IsThrottling(),
+		QueuedHigh:       len(p.sendHigh),
+		QueuedNormal:     len(p.send),
+		QueuedLow:        len(p.sendLow),
+		Compression:      p.compression.String(),
+		CompressionIn:    p.compressIn.ratio(),
+		CompressionOut:   p.compressOut.ratio(),
+		CompressTime:     p.compressOut.time,
+		DecompressTime:   p.compressIn.time,
 	}
 }
 
-// SendFillRatio is a wrapper for the send channel's FillRatio
+// SendFillRatio is the combined fill ratio of the send channels
 func (p *Peer) SendFillRatio() float64 {
-	return p.send.FillRatio()
+	return float64(len(p.sendHigh)+len(p.send)+len(p.sendLow)) / float64(cap(p.sendHigh)+cap(p.send)+cap(p.sendLow))
 }
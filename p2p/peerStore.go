@@ -11,6 +11,7 @@ type PeerStore struct {
 	mtx       sync.RWMutex
 	peers     map[string]*Peer // hash -> peer
 	connected map[string]int   // (ip|ip:port) -> count
+	groups    map[string]int   // ipGroup(ip) -> count
 	curSlice  []*Peer          // temporary slice that gets reset when changes are made
 	incoming  int
 	outgoing  int
@@ -21,6 +22,7 @@ func NewPeerStore() *PeerStore {
 	ps := new(PeerStore)
 	ps.peers = make(map[string]*Peer)
 	ps.connected = make(map[string]int)
+	ps.groups = make(map[string]int)
 	return ps
 }
 
@@ -40,6 +42,7 @@ func (ps *PeerStore) Add(p *Peer) error {
 	ps.peers[p.Hash] = p
 	ps.connected[p.Endpoint.IP]++
 	ps.connected[p.Endpoint.String()]++
+	ps.groups[ipGroup(p.Endpoint.IP)]++
 
 	if p.IsIncoming {
 		ps.incoming++
@@ -67,6 +70,11 @@ func (ps *PeerStore) Remove(p *Peer) {
 		if ps.connected[p.Endpoint.String()] == 0 {
 			delete(ps.connected, p.Endpoint.String())
 		}
+		group := ipGroup(p.Endpoint.IP)
+		ps.groups[group]--
+		if ps.groups[group] == 0 {
+			delete(ps.groups, group)
+		}
 		if old.IsIncoming {
 			ps.incoming--
 		} else {
@@ -119,11 +127,12 @@ func (ps *PeerStore) Connected(ep Endpoint) bool {
 	return ps.connected[ep.String()] > 0
 }
 
-// Count returns the amount of peers connected from a specified ip address
+// Count returns the amount of peers connected from a specified ip address.
+// For IPv6 addresses, all peers from the same /64 subnet are counted
 func (ps *PeerStore) Count(addr string) int {
 	ps.mtx.RLock()
 	defer ps.mtx.RUnlock()
-	return ps.connected[addr]
+	return ps.groups[ipGroup(addr)]
 }
 
 // Slice returns a slice of the current peers that is considered concurrency
@@ -217,6 +217,39 @@ func TestPeerStore_Count(t *testing.T) {
 	}
 }
 
+func TestPeerStore_CountIPv6(t *testing.T) {
+	ps := testStore()
+	a := testPeer("2001:db8::1", "8088", 1, true)
+	b := testPeer("2001:db8::ffff:1", "8088", 1, true)
+	c := testPeer("2001:db8:0:1::1", "8088", 1, true)
+	for _, p := range []*Peer{a, b, c} {
+		ps.Add(p)
+	}
+
+	tests := []struct {
+		addr string
+		want int
+	}{
+		{"2001:db8::1", 2},
+		{"2001:db8::2", 2}, // same /64
+		{"2001:db8:0:1::2", 1},
+		{"2001:db8:0:2::1", 0},
+	}
+	for _, tt := range tests {
+		if got := ps.Count(tt.addr); got != tt.want {
+			t.Errorf("PeerStore.Count(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	ps.Remove(a)
+	if got := ps.Count("2001:db8::2"); got != 1 {
+		t.Errorf("PeerStore.Count() after removal = %v, want 1", got)
+	}
+	if !ps.Connected(Endpoint{"2001:db8::ffff:1", "8088"}) {
+		t.Errorf("PeerStore.Connected() ipv6 endpoint not connected")
+	}
+}
+
 func find(p *Peer, peers []*Peer) bool {
 	if p == nil {
 		return false
@@ -25,6 +25,19 @@ type PeerMetrics struct {
 	BPSUp            float64
 	SendFillRatio    float64
 	Dropped          uint64
+	LimitIn          uint64        // bandwidth limit of the peer in bytes per second, 0 if unlimited
+	LimitOut         uint64        // bandwidth limit of the peer in bytes per second, 0 if unlimited
+	ThrottledIn      time.Duration // total time spent waiting for bandwidth to read
+	ThrottledOut     time.Duration // total time spent waiting for bandwidth to send
+	Throttling       bool          // sending is currently limited
+	QueuedHigh       int           // parcels waiting to be sent per lane
+	QueuedNormal     int
+	QueuedLow        int
+	Compression      string        // algorithm used to compress parcels sent to the peer
+	CompressionIn    float64       // ratio of uncompressed to compressed bytes of received parcels, 0 if none
+	CompressionOut   float64       // ratio of uncompressed to compressed bytes of sent parcels, 0 if none
+	CompressTime     time.Duration // total time spent compressing
+	DecompressTime   time.Duration // total time spent decompressing
 }
 
 // peerStatus is an indicator for peer manager whether the associated peer is going online or offline
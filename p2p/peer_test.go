@@ -27,6 +27,8 @@ func testRandomPeer(net *Network) *Peer {
 	})
 
 	p.send = newParcelChannel(p.net.conf.ChannelCapacity)
+	p.sendHigh = newParcelChannel(p.net.conf.ChannelCapacity)
+	p.sendLow = newParcelChannel(p.net.conf.ChannelCapacity)
 	p.IsIncoming = net.rng.Intn(1) == 0
 	p.connected = time.Now()
 	if net.conf.PeerResendFilter {
@@ -75,6 +77,8 @@ func TestPeer_sendLoop(t *testing.T) {
 	p.stop = make(chan bool, 1)
 
 	p.send = newParcelChannel(128)
+	p.sendHigh = newParcelChannel(128)
+	p.sendLow = newParcelChannel(128)
 	go func() {
 		p.sendLoop()
 		done <- true
@@ -35,6 +35,24 @@ type Prometheus struct {
 	DroppedPeerSend    prometheus.Counter
 	DroppedFromNetwork prometheus.Counter
 	DroppedToNetwork   prometheus.Counter
+	Misbehavior        prometheus.Counter
+	MisbehaviorBans    prometheus.Counter
+
+	BandwidthLimitIn  prometheus.Gauge
+	BandwidthLimitOut prometheus.Gauge
+	ThrottledIn       prometheus.Gauge
+	ThrottledOut      prometheus.Gauge
+	Throttling        prometheus.Gauge
+	LaneQueued        *prometheus.GaugeVec
+
+	GossipPushed     prometheus.Gauge
+	GossipAnnounced  prometheus.Gauge
+	GossipDuplicates prometheus.Gauge
+	GossipGrafts     prometheus.Gauge
+	GossipPrunes     prometheus.Gauge
+	GossipEager      prometheus.Gauge
+	GossipLazy       prometheus.Gauge
+	GossipCoverage   prometheus.Histogram
 
 	ParcelSize prometheus.Histogram
 }
@@ -75,6 +93,33 @@ func (p *Prometheus) _setup(register prometheus.Registerer) {
 	p.DroppedPeerSend = ng("factomd_p2p_dropped_peer_send", "Number of parcels that were unable to be sent because of peer's lack of bandwidth")
 	p.DroppedFromNetwork = ng("factomd_p2p_dropped_from_network", "Number of parcels that were dropped because application is not reading parcels fast enough")
 	p.DroppedToNetwork = ng("factomd_p2p_dropped_to_network", "Number of parcels that were dropped because application is sending parcels too fast")
+	p.Misbehavior = ng("factomd_p2p_misbehavior", "Number of misbehaviors reported against peers")
+	p.MisbehaviorBans = ng("factomd_p2p_misbehavior_bans", "Number of addresses banned automatically for misbehavior")
+
+	p.BandwidthLimitIn = ng("factomd_p2p_bandwidth_limit_in", "Inbound bandwidth limit of all peers combined (in bytes/sec), 0 if unlimited")
+	p.BandwidthLimitOut = ng("factomd_p2p_bandwidth_limit_out", "Outbound bandwidth limit of all peers combined (in bytes/sec), 0 if unlimited")
+	p.ThrottledIn = ng("factomd_p2p_bandwidth_throttled_in", "Total time spent waiting for inbound bandwidth (in seconds)")
+	p.ThrottledOut = ng("factomd_p2p_bandwidth_throttled_out", "Total time spent waiting for outbound bandwidth (in seconds)")
+	p.Throttling = ng("factomd_p2p_bandwidth_throttling", "Number of peers whose sending is currently limited")
+	p.LaneQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "factomd_p2p_lane_queued",
+		Help: "Number of parcels waiting to be sent per priority lane",
+	}, []string{"lane"})
+	register.MustRegister(p.LaneQueued)
+
+	p.GossipPushed = ng("factomd_p2p_gossip_pushed", "Number of gossip messages pushed to eager peers")
+	p.GossipAnnounced = ng("factomd_p2p_gossip_announced", "Number of gossip messages announced to peers")
+	p.GossipDuplicates = ng("factomd_p2p_gossip_duplicates", "Number of gossip messages received more than once")
+	p.GossipGrafts = ng("factomd_p2p_gossip_grafts", "Number of gossip messages requested after an announcement")
+	p.GossipPrunes = ng("factomd_p2p_gossip_prunes", "Number of peers moved to the lazy set after sending a duplicate")
+	p.GossipEager = ng("factomd_p2p_gossip_eager", "Number of peers in the eager set")
+	p.GossipLazy = ng("factomd_p2p_gossip_lazy", "Number of peers in the lazy set")
+	p.GossipCoverage = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "factomd_p2p_gossip_coverage",
+		Help:    "Number of peers known to have a gossip message when it expires",
+		Buckets: prometheus.LinearBuckets(0, 4, 10),
+	})
+	register.MustRegister(p.GossipCoverage)
 
 	p.ParcelSize = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name:    "factomd_p2p_parcels_size",
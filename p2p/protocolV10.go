@@ -89,5 +89,8 @@ func (v10 *ProtocolV10) MakePeerShare(share []Endpoint) ([]byte, error) {
 func (v10 *ProtocolV10) ParsePeerShare(payload []byte) ([]Endpoint, error) {
 	var share []Endpoint
 	err := json.Unmarshal(payload, &share)
+	for i := range share {
+		share[i].IP = normalizeHost(share[i].IP)
+	}
 	return share, err
 }
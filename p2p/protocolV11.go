@@ -32,6 +32,11 @@ func (v11 *ProtocolV11) SendHandshake(hs *Handshake) error {
 		return err
 	}
 
+	return v11.writeMessage(makeV11Handshake(hs))
+}
+
+// makeV11Handshake converts a handshake to its protobuf representation
+func makeV11Handshake(hs *Handshake) *V11Handshake {
 	v11hs := new(V11Handshake)
 	v11hs.Type = uint32(hs.Type)
 	v11hs.ListenPort = hs.ListenPort
@@ -39,6 +44,9 @@ func (v11 *ProtocolV11) SendHandshake(hs *Handshake) error {
 	v11hs.Network = uint32(hs.Network)
 	v11hs.NodeID = hs.NodeID
 	v11hs.Version = uint32(hs.Version)
+	v11hs.Features = uint32(hs.Features)
+	v11hs.ExternalHost = hs.ExternalHost
+	v11hs.ObservedHost = hs.ObservedHost
 
 	if len(hs.Alternatives) > 0 {
 		v11hs.Alternatives = make([]*V11Endpoint, 0, len(hs.Alternatives))
@@ -46,8 +54,7 @@ func (v11 *ProtocolV11) SendHandshake(hs *Handshake) error {
 			v11hs.Alternatives = append(v11hs.Alternatives, &V11Endpoint{Host: alt.IP, Port: alt.Port})
 		}
 	}
-
-	return v11.writeMessage(v11hs)
+	return v11hs
 }
 
 func (v11 *ProtocolV11) ReadHandshake() (*Handshake, error) {
@@ -64,6 +71,11 @@ func (v11 *ProtocolV11) ReadHandshake() (*Handshake, error) {
 		return nil, err
 	}
 
+	return parseV11Handshake(v11hs), nil
+}
+
+// parseV11Handshake converts the protobuf representation back to a handshake
+func parseV11Handshake(v11hs *V11Handshake) *Handshake {
 	hs := new(Handshake)
 	hs.Type = ParcelType(v11hs.Type)
 	hs.ListenPort = v11hs.ListenPort
@@ -71,6 +83,9 @@ func (v11 *ProtocolV11) ReadHandshake() (*Handshake, error) {
 	hs.Network = NetworkID(v11hs.Network)
 	hs.NodeID = v11hs.NodeID
 	hs.Version = uint16(v11hs.Version)
+	hs.Features = Feature(v11hs.Features)
+	hs.ExternalHost = v11hs.ExternalHost
+	hs.ObservedHost = v11hs.ObservedHost
 
 	if len(v11hs.Alternatives) > 0 {
 		hs.Alternatives = make([]Endpoint, 0, len(v11hs.Alternatives))
@@ -78,8 +93,7 @@ func (v11 *ProtocolV11) ReadHandshake() (*Handshake, error) {
 			hs.Alternatives = append(hs.Alternatives, Endpoint{IP: alt.Host, Port: alt.Port})
 		}
 	}
-
-	return hs, nil
+	return hs
 }
 
 func (v11 *ProtocolV11) readCheck(data []byte) error {
@@ -189,7 +203,7 @@ func (v11 *ProtocolV11) ParsePeerShare(payload []byte) ([]Endpoint, error) {
 
 	eps := make([]Endpoint, 0, len(v11share.Share))
 	for _, v11ep := range v11share.Share {
-		eps = append(eps, Endpoint{IP: v11ep.Host, Port: v11ep.Port})
+		eps = append(eps, Endpoint{IP: normalizeHost(v11ep.Host), Port: v11ep.Port})
 	}
 
 	return eps, nil
@@ -30,6 +30,9 @@ type V11Handshake struct {
 	ListenPort           string         `protobuf:"bytes,5,opt,name=ListenPort,proto3" json:"ListenPort,omitempty"`
 	Loopback             uint64         `protobuf:"varint,6,opt,name=Loopback,proto3" json:"Loopback,omitempty"`
 	Alternatives         []*V11Endpoint `protobuf:"bytes,7,rep,name=Alternatives,proto3" json:"Alternatives,omitempty"`
+	Features             uint32         `protobuf:"varint,8,opt,name=Features,proto3" json:"Features,omitempty"`
+	ExternalHost         string         `protobuf:"bytes,9,opt,name=ExternalHost,proto3" json:"ExternalHost,omitempty"`
+	ObservedHost         string         `protobuf:"bytes,10,opt,name=ObservedHost,proto3" json:"ObservedHost,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
 	XXX_sizecache        int32          `json:"-"`
@@ -117,6 +120,27 @@ func (m *V11Handshake) GetAlternatives() []*V11Endpoint {
 	return nil
 }
 
+func (m *V11Handshake) GetFeatures() uint32 {
+	if m != nil {
+		return m.Features
+	}
+	return 0
+}
+
+func (m *V11Handshake) GetExternalHost() string {
+	if m != nil {
+		return m.ExternalHost
+	}
+	return ""
+}
+
+func (m *V11Handshake) GetObservedHost() string {
+	if m != nil {
+		return m.ObservedHost
+	}
+	return ""
+}
+
 type V11Endpoint struct {
 	Host                 string   `protobuf:"bytes,1,opt,name=Host,proto3" json:"Host,omitempty"`
 	Port                 string   `protobuf:"bytes,2,opt,name=Port,proto3" json:"Port,omitempty"`
@@ -284,26 +308,29 @@ func init() {
 func init() { proto.RegisterFile("protocolV11.proto", fileDescriptor_88431f7e68323b26) }
 
 var fileDescriptor_88431f7e68323b26 = []byte{
-	// 303 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0xcb, 0x4a, 0x03, 0x31,
-	0x14, 0x86, 0x4d, 0x2f, 0xd3, 0xf6, 0xb4, 0x42, 0xcd, 0x42, 0x82, 0x8b, 0x61, 0x98, 0x85, 0xcc,
-	0xaa, 0x90, 0xf1, 0xb2, 0x57, 0x14, 0x2a, 0xd4, 0x52, 0x46, 0x99, 0x7d, 0xda, 0x09, 0x76, 0x68,
-	0xc9, 0x09, 0x49, 0x50, 0xfa, 0x26, 0x3e, 0x92, 0x4b, 0x1f, 0xa1, 0xd4, 0x17, 0x91, 0x49, 0x5b,
-	0xa9, 0x8b, 0xee, 0xfe, 0xef, 0xff, 0x12, 0x38, 0x27, 0x81, 0x33, 0x6d, 0xd0, 0xe1, 0x0c, 0x97,
-	0x39, 0xe7, 0x03, 0x9f, 0x69, 0x5d, 0xa7, 0x3a, 0x5e, 0x13, 0xe8, 0xe5, 0x9c, 0x0f, 0x85, 0x2a,
-	0xec, 0x5c, 0x2c, 0x24, 0xa5, 0xd0, 0x78, 0x5d, 0x69, 0xc9, 0x48, 0x44, 0x92, 0xd3, 0xcc, 0x67,
-	0xca, 0xa0, 0x35, 0x96, 0xee, 0x03, 0xcd, 0x82, 0xd5, 0x7c, 0xbd, 0xc7, 0xca, 0xe4, 0xd2, 0xd8,
-	0x12, 0x15, 0xab, 0x6f, 0xcd, 0x0e, 0xe9, 0x39, 0x04, 0x63, 0x2c, 0xe4, 0xd3, 0x03, 0x6b, 0x78,
-	0xb1, 0x23, 0x1a, 0x02, 0x8c, 0x4a, 0xeb, 0xa4, 0x9a, 0xa0, 0x71, 0xac, 0x19, 0x91, 0xa4, 0x93,
-	0x1d, 0x34, 0xf4, 0x02, 0xda, 0x23, 0x44, 0x3d, 0x15, 0xb3, 0x05, 0x0b, 0x22, 0x92, 0x34, 0xb2,
-	0x3f, 0xa6, 0xd7, 0xd0, 0xbb, 0x5b, 0x3a, 0x69, 0x94, 0x70, 0xe5, 0xbb, 0xb4, 0xac, 0x15, 0xd5,
-	0x93, 0x6e, 0xda, 0x1f, 0xe8, 0x54, 0x0f, 0x72, 0xce, 0x1f, 0x55, 0xa1, 0xb1, 0x54, 0x2e, 0xfb,
-	0x77, 0x2a, 0xbe, 0x81, 0xee, 0x81, 0xac, 0x16, 0x1c, 0xa2, 0x75, 0x7e, 0xc1, 0x4e, 0xe6, 0x73,
-	0xd5, 0xf9, 0x71, 0x6a, 0xdb, 0xae, 0xca, 0xf1, 0x2d, 0x04, 0x39, 0xe7, 0xcf, 0xf6, 0xed, 0xd8,
-	0x93, 0x4c, 0xc4, 0x6a, 0x89, 0xa2, 0xf0, 0x97, 0x7a, 0xd9, 0x1e, 0xe3, 0x14, 0xda, 0x39, 0xe7,
-	0x2f, 0x73, 0x61, 0x24, 0xbd, 0x84, 0xa6, 0x0f, 0x8c, 0x1c, 0x99, 0x74, 0xab, 0xef, 0xfb, 0x5f,
-	0x9b, 0x90, 0x7c, 0x6f, 0x42, 0xb2, 0xde, 0x84, 0xe4, 0xf3, 0x27, 0x3c, 0x99, 0x06, 0xfe, 0x8f,
-	0xae, 0x7e, 0x03, 0x00, 0x00, 0xff, 0xff, 0x02, 0x9b, 0xc9, 0x29, 0xb8, 0x01, 0x00, 0x00,
+	// 343 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xbb, 0x6e, 0xea, 0x30,
+	0x18, 0xc7, 0x8f, 0xb9, 0x04, 0x30, 0x39, 0x12, 0xc7, 0xc3, 0x91, 0xd5, 0x21, 0x8a, 0x32, 0x54,
+	0x99, 0x90, 0x9c, 0x5e, 0xf6, 0x56, 0xa5, 0xa2, 0x12, 0xa5, 0x28, 0xad, 0xb2, 0x1b, 0x62, 0x95,
+	0x88, 0x28, 0xb6, 0x6c, 0x97, 0x96, 0x37, 0xe9, 0x03, 0x75, 0xe8, 0xd8, 0x47, 0xa8, 0xe8, 0x8b,
+	0x54, 0xf9, 0x02, 0x08, 0x06, 0xb6, 0xff, 0xe5, 0x67, 0xeb, 0xf3, 0x27, 0xe3, 0x7f, 0x4a, 0x4b,
+	0x2b, 0x67, 0x32, 0x4f, 0x18, 0xeb, 0x83, 0x26, 0x75, 0x15, 0xa9, 0xe0, 0xa3, 0x86, 0xdd, 0x84,
+	0xb1, 0x21, 0x2f, 0x52, 0x33, 0xe7, 0x0b, 0x41, 0x08, 0x6e, 0x3c, 0xad, 0x94, 0xa0, 0xc8, 0x47,
+	0xe1, 0xdf, 0x18, 0x34, 0xa1, 0xb8, 0x35, 0x16, 0xf6, 0x55, 0xea, 0x05, 0xad, 0x41, 0xbc, 0xb5,
+	0x65, 0x93, 0x08, 0x6d, 0x32, 0x59, 0xd0, 0x7a, 0xd5, 0x6c, 0x2c, 0xf9, 0x8f, 0x9d, 0xb1, 0x4c,
+	0xc5, 0xdd, 0x0d, 0x6d, 0x40, 0xb1, 0x71, 0xc4, 0xc3, 0x78, 0x94, 0x19, 0x2b, 0x8a, 0x89, 0xd4,
+	0x96, 0x36, 0x7d, 0x14, 0x76, 0xe2, 0xbd, 0x84, 0x9c, 0xe0, 0xf6, 0x48, 0x4a, 0x35, 0xe5, 0xb3,
+	0x05, 0x75, 0x7c, 0x14, 0x36, 0xe2, 0x9d, 0x27, 0xe7, 0xd8, 0xbd, 0xca, 0xad, 0xd0, 0x05, 0xb7,
+	0xd9, 0x52, 0x18, 0xda, 0xf2, 0xeb, 0x61, 0x37, 0xea, 0xf5, 0x55, 0xa4, 0xfa, 0x09, 0x63, 0x83,
+	0x22, 0x55, 0x32, 0x2b, 0x6c, 0x7c, 0x40, 0x95, 0x37, 0xde, 0x0a, 0x6e, 0x5f, 0xb4, 0x30, 0xb4,
+	0x0d, 0xb3, 0xec, 0x3c, 0x09, 0xb0, 0x3b, 0x78, 0x03, 0x36, 0x1f, 0x4a, 0x63, 0x69, 0x07, 0xe6,
+	0x39, 0xc8, 0x4a, 0xe6, 0x61, 0x6a, 0x84, 0x5e, 0x8a, 0x14, 0x18, 0x5c, 0x31, 0xfb, 0x59, 0x70,
+	0x81, 0xbb, 0x7b, 0x03, 0x94, 0x4b, 0x04, 0x14, 0x01, 0x0a, 0xba, 0xcc, 0xe0, 0xc9, 0xb5, 0x2a,
+	0x2b, 0x75, 0x70, 0x89, 0x9d, 0x84, 0xb1, 0x7b, 0xf3, 0x7c, 0x6c, 0xed, 0x13, 0xbe, 0xca, 0x25,
+	0x4f, 0xe1, 0x90, 0x1b, 0x6f, 0x6d, 0x10, 0xe1, 0x76, 0xc2, 0xd8, 0xe3, 0x9c, 0x6b, 0x41, 0x4e,
+	0x71, 0x13, 0x04, 0x45, 0x47, 0xb6, 0x51, 0xd5, 0xd7, 0xbd, 0xcf, 0xb5, 0x87, 0xbe, 0xd6, 0x1e,
+	0xfa, 0x5e, 0x7b, 0xe8, 0xfd, 0xc7, 0xfb, 0x33, 0x75, 0xe0, 0x1f, 0x9c, 0xfd, 0x0e, 0x00, 0xba,
+	0x75, 0x05, 0x95, 0x1c, 0x02, 0x00, 0x00,
 }
 
 func (m *V11Handshake) Marshal() (dAtA []byte, err error) {
@@ -330,6 +357,25 @@ func (m *V11Handshake) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.ObservedHost) > 0 {
+		i -= len(m.ObservedHost)
+		copy(dAtA[i:], m.ObservedHost)
+		i = encodeVarintProtocolV11(dAtA, i, uint64(len(m.ObservedHost)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if len(m.ExternalHost) > 0 {
+		i -= len(m.ExternalHost)
+		copy(dAtA[i:], m.ExternalHost)
+		i = encodeVarintProtocolV11(dAtA, i, uint64(len(m.ExternalHost)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.Features != 0 {
+		i = encodeVarintProtocolV11(dAtA, i, uint64(m.Features))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.Alternatives) > 0 {
 		for iNdEx := len(m.Alternatives) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -542,6 +588,17 @@ func (m *V11Handshake) Size() (n int) {
 			n += 1 + l + sovProtocolV11(uint64(l))
 		}
 	}
+	if m.Features != 0 {
+		n += 1 + sovProtocolV11(uint64(m.Features))
+	}
+	l = len(m.ExternalHost)
+	if l > 0 {
+		n += 1 + l + sovProtocolV11(uint64(l))
+	}
+	l = len(m.ObservedHost)
+	if l > 0 {
+		n += 1 + l + sovProtocolV11(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -801,6 +858,89 @@ func (m *V11Handshake) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
+			m.Features = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProtocolV11
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Features |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExternalHost", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProtocolV11
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthProtocolV11
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProtocolV11
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ExternalHost = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ObservedHost", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowProtocolV11
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthProtocolV11
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthProtocolV11
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ObservedHost = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipProtocolV11(dAtA[iNdEx:])
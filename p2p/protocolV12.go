@@ -0,0 +1,343 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// V12Signature is the 4-byte sequence that indicates the remote connection wants to use V12
+var V12Signature = []byte{0x70, 0x32, 0x70, 0x65} // ascii for "p2pe"
+
+// v12Label is mixed into the transcript and the key derivation so keys of other protocols can't be replayed
+var v12Label = []byte("factomd p2p v12")
+
+const (
+	v12RoleInitiator byte = 1
+	v12RoleResponder byte = 2
+)
+
+var _ Protocol = (*ProtocolV12)(nil)
+
+// ProtocolV12 is V11 over an encrypted and authenticated connection.
+//
+// The dialing node (initiator) and the listening node (responder) exchange ephemeral X25519 keys, derive
+// a key for each direction and then exchange their handshakes encrypted, each signed with the node's
+// ed25519 key over the hash of the key exchange:
+//
+//	initiator -> responder: V12Signature, ephemeral key
+//	responder -> initiator: V12Signature, ephemeral key
+//	initiator -> responder: frame(public key, signature, V11Handshake)
+//	responder -> initiator: frame(public key, signature, V11Handshake)
+//
+// Every frame after that is a V11Msg sealed with ChaCha20-Poly1305 with the frame's length as additional data.
+type ProtocolV12 struct {
+	rw        io.ReadWriter
+	key       ed25519.PrivateKey
+	network   NetworkID
+	initiator bool
+
+	ephemeral  [32]byte
+	transcript []byte
+	pending    *Handshake
+
+	sendCipher, recvCipher cipher.AEAD
+	sendNonce, recvNonce   uint64
+
+	peerKey ed25519.PublicKey
+}
+
+func newProtocolV12(rw io.ReadWriter, key ed25519.PrivateKey, network NetworkID, initiator bool) *ProtocolV12 {
+	v12 := new(ProtocolV12)
+	v12.rw = rw
+	v12.key = key
+	v12.network = network
+	v12.initiator = initiator
+	return v12
+}
+
+// PeerKey is the public key the remote node proved to own during the handshake
+func (v12 *ProtocolV12) PeerKey() ed25519.PublicKey {
+	return v12.peerKey
+}
+
+// sendEphemeral creates the ephemeral key and sends the public half
+func (v12 *ProtocolV12) sendEphemeral() ([]byte, error) {
+	if _, err := io.ReadFull(rand.Reader, v12.ephemeral[:]); err != nil {
+		return nil, err
+	}
+	public, err := curve25519.X25519(v12.ephemeral[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v12.writeCheck(append(append([]byte{}, V12Signature...), public...)); err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+// readEphemeral reads the signature and the public ephemeral key of the remote node
+func (v12 *ProtocolV12) readEphemeral() ([]byte, error) {
+	buf := make([]byte, len(V12Signature)+32)
+	if _, err := io.ReadFull(v12.rw, buf); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buf[:len(V12Signature)], V12Signature) {
+		return nil, fmt.Errorf("invalid connection signature")
+	}
+	return buf[len(V12Signature):], nil
+}
+
+// deriveKeys computes the shared secret and sets up the ciphers of both directions
+func (v12 *ProtocolV12) deriveKeys(initiatorPublic, responderPublic, remotePublic []byte) error {
+	shared, err := curve25519.X25519(v12.ephemeral[:], remotePublic)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	h.Write(v12Label)
+	binary.Write(h, binary.BigEndian, uint32(v12.network))
+	h.Write(initiatorPublic)
+	h.Write(responderPublic)
+	v12.transcript = h.Sum(nil)
+
+	keys := make([]byte, 2*chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, v12.transcript, v12Label), keys); err != nil {
+		return err
+	}
+
+	toResponder, err := chacha20poly1305.New(keys[:chacha20poly1305.KeySize])
+	if err != nil {
+		return err
+	}
+	toInitiator, err := chacha20poly1305.New(keys[chacha20poly1305.KeySize:])
+	if err != nil {
+		return err
+	}
+
+	if v12.initiator {
+		v12.sendCipher, v12.recvCipher = toResponder, toInitiator
+	} else {
+		v12.sendCipher, v12.recvCipher = toInitiator, toResponder
+	}
+	return nil
+}
+
+// signedTranscript is the data a node signs to prove it owns its key in this session
+func (v12 *ProtocolV12) signedTranscript(role byte) []byte {
+	return append(append([]byte{}, v12.transcript...), role)
+}
+
+func (v12 *ProtocolV12) sendSignedHandshake(hs *Handshake) error {
+	role := v12RoleResponder
+	if v12.initiator {
+		role = v12RoleInitiator
+	}
+
+	data, err := makeV11Handshake(hs).Marshal()
+	if err != nil {
+		return err
+	}
+
+	payload := make([]byte, 0, ed25519.PublicKeySize+ed25519.SignatureSize+len(data))
+	payload = append(payload, v12.key.Public().(ed25519.PublicKey)...)
+	payload = append(payload, ed25519.Sign(v12.key, v12.signedTranscript(role))...)
+	payload = append(payload, data...)
+	return v12.writeFrame(payload)
+}
+
+func (v12 *ProtocolV12) readSignedHandshake() (*Handshake, error) {
+	role := v12RoleInitiator
+	if v12.initiator {
+		role = v12RoleResponder
+	}
+
+	payload, err := v12.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < ed25519.PublicKeySize+ed25519.SignatureSize {
+		return nil, fmt.Errorf("handshake too short")
+	}
+
+	public := ed25519.PublicKey(payload[:ed25519.PublicKeySize])
+	signature := payload[ed25519.PublicKeySize : ed25519.PublicKeySize+ed25519.SignatureSize]
+	if !ed25519.Verify(public, v12.signedTranscript(role), signature) {
+		return nil, fmt.Errorf("invalid handshake signature")
+	}
+
+	v11hs := new(V11Handshake)
+	if err := v11hs.Unmarshal(payload[ed25519.PublicKeySize+ed25519.SignatureSize:]); err != nil {
+		return nil, err
+	}
+
+	v12.peerKey = append(ed25519.PublicKey{}, public...)
+	hs := parseV11Handshake(v11hs)
+	hs.PublicKey = v12.peerKey
+	return hs, nil
+}
+
+// SendHandshake starts the key exchange for the initiator, its handshake is sent once the responder
+// replied with its ephemeral key. The responder sends its handshake right away.
+func (v12 *ProtocolV12) SendHandshake(hs *Handshake) error {
+	if v12.sendCipher != nil {
+		return v12.sendSignedHandshake(hs)
+	}
+	if !v12.initiator {
+		return fmt.Errorf("the key exchange hasn't happened yet")
+	}
+
+	if _, err := v12.sendEphemeral(); err != nil {
+		return err
+	}
+	v12.pending = hs
+	return nil
+}
+
+// ReadHandshake completes the key exchange and reads the remote node's handshake
+func (v12 *ProtocolV12) ReadHandshake() (*Handshake, error) {
+	remote, err := v12.readEphemeral()
+	if err != nil {
+		return nil, err
+	}
+
+	if v12.initiator {
+		if v12.pending == nil {
+			return nil, fmt.Errorf("no handshake to send")
+		}
+		local, err := curve25519.X25519(v12.ephemeral[:], curve25519.Basepoint)
+		if err != nil {
+			return nil, err
+		}
+		if err := v12.deriveKeys(local, remote, remote); err != nil {
+			return nil, err
+		}
+		if err := v12.sendSignedHandshake(v12.pending); err != nil {
+			return nil, err
+		}
+		v12.pending = nil
+	} else {
+		local, err := v12.sendEphemeral()
+		if err != nil {
+			return nil, err
+		}
+		if err := v12.deriveKeys(remote, local, remote); err != nil {
+			return nil, err
+		}
+	}
+
+	return v12.readSignedHandshake()
+}
+
+func (v12 *ProtocolV12) writeCheck(data []byte) error {
+	if n, err := v12.rw.Write(data); err != nil {
+		return err
+	} else if n != len(data) {
+		return fmt.Errorf("unable to write data (%d of %d)", n, len(data))
+	}
+	return nil
+}
+
+func v12Nonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[chacha20poly1305.NonceSize-8:], counter)
+	return nonce
+}
+
+// writeFrame seals the payload and sends it with its length
+func (v12 *ProtocolV12) writeFrame(payload []byte) error {
+	size := len(payload) + v12.sendCipher.Overhead()
+	if size > V11MaxParcelSize {
+		return fmt.Errorf("trying to send a message that's too large %d bytes (max %d)", size, V11MaxParcelSize)
+	}
+
+	frame := make([]byte, 4, 4+size)
+	binary.BigEndian.PutUint32(frame, uint32(size))
+	frame = v12.sendCipher.Seal(frame, v12Nonce(v12.sendNonce), payload, frame[:4])
+	v12.sendNonce++
+
+	return v12.writeCheck(frame)
+}
+
+// readFrame reads a frame and opens it, a frame that was tampered with is an error
+func (v12 *ProtocolV12) readFrame() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(v12.rw, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+
+	if size > V11MaxParcelSize {
+		return nil, fmt.Errorf("peer attempted to send a frame of size %d (max %d)", size, V11MaxParcelSize)
+	}
+	if int(size) < v12.recvCipher.Overhead() {
+		return nil, fmt.Errorf("frame too short")
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(v12.rw, sealed); err != nil {
+		return nil, err
+	}
+
+	payload, err := v12.recvCipher.Open(sealed[:0], v12Nonce(v12.recvNonce), sealed, header)
+	if err != nil {
+		return nil, fmt.Errorf("unable to authenticate frame: %v", err)
+	}
+	v12.recvNonce++
+	return payload, nil
+}
+
+func (v12 *ProtocolV12) Send(p *Parcel) error {
+	msg := new(V11Msg)
+	msg.Type = uint32(p.ptype)
+	msg.Payload = p.Payload
+
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	return v12.writeFrame(data)
+}
+
+func (v12 *ProtocolV12) Receive() (*Parcel, error) {
+	data, err := v12.readFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := new(V11Msg)
+	if err := msg.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	// type validity is checked in parcel.Valid
+	return newParcel(ParcelType(msg.Type), msg.Payload), nil
+}
+
+func (v12 *ProtocolV12) Version() uint16 {
+	return 12
+}
+
+func (v12 *ProtocolV12) String() string {
+	return "12"
+}
+
+// MakePeerShare uses the V11 format
+func (v12 *ProtocolV12) MakePeerShare(ps []Endpoint) ([]byte, error) {
+	return new(ProtocolV11).MakePeerShare(ps)
+}
+
+// ParsePeerShare uses the V11 format
+func (v12 *ProtocolV12) ParsePeerShare(payload []byte) ([]Endpoint, error) {
+	return new(ProtocolV11).ParsePeerShare(payload)
+}
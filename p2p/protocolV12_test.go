@@ -0,0 +1,203 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testV12Handshake connects an initiator and a responder and runs the key exchange
+func testV12Handshake(t *testing.T) (*ProtocolV12, *ProtocolV12, net.Conn, net.Conn) {
+	_, initKey, _ := ed25519.GenerateKey(nil)
+	_, respKey, _ := ed25519.GenerateKey(nil)
+	network := NewNetworkID("test")
+
+	A, B := net.Pipe()
+	dl := time.Now().Add(time.Millisecond * 500)
+	A.SetDeadline(dl)
+	B.SetDeadline(dl)
+
+	initiator := newProtocolV12(A, initKey, network, true)
+	responder := newProtocolV12(B, respKey, network, false)
+
+	initShake := &Handshake{Network: network, Version: 12, Type: TypeHandshake, NodeID: 1, ListenPort: "8108", Loopback: 1}
+	respShake := &Handshake{Network: network, Version: 12, Type: TypeHandshake, NodeID: 2, ListenPort: "8109", Loopback: 2}
+
+	done := make(chan *Handshake, 1)
+	go func() {
+		if err := initiator.SendHandshake(initShake); err != nil {
+			t.Error(err)
+		}
+		hs, err := initiator.ReadHandshake()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- hs
+	}()
+
+	hs, err := responder.ReadHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.SendHandshake(respShake); err != nil {
+		t.Fatal(err)
+	}
+	reply := <-done
+	if reply == nil {
+		t.FailNow()
+	}
+
+	if !bytes.Equal(hs.PublicKey, initKey.Public().(ed25519.PublicKey)) {
+		t.Errorf("responder got wrong key. want = %x, got = %x", initKey.Public(), hs.PublicKey)
+	}
+	if !bytes.Equal(reply.PublicKey, respKey.Public().(ed25519.PublicKey)) {
+		t.Errorf("initiator got wrong key. want = %x, got = %x", respKey.Public(), reply.PublicKey)
+	}
+
+	hs.PublicKey, reply.PublicKey = nil, nil
+	if !reflect.DeepEqual(initShake, hs) {
+		t.Errorf("handshake differs. want = %+v, got = %+v", initShake, hs)
+	}
+	if !reflect.DeepEqual(respShake, reply) {
+		t.Errorf("handshake differs. want = %+v, got = %+v", respShake, reply)
+	}
+
+	return initiator, responder, A, B
+}
+
+func TestProtocolV12_Handshake(t *testing.T) {
+	initiator, responder, A, B := testV12Handshake(t)
+	defer A.Close()
+	defer B.Close()
+
+	if !bytes.Equal(initiator.PeerKey(), responder.key.Public().(ed25519.PublicKey)) {
+		t.Errorf("initiator has wrong peer key")
+	}
+	if !bytes.Equal(responder.PeerKey(), initiator.key.Public().(ed25519.PublicKey)) {
+		t.Errorf("responder has wrong peer key")
+	}
+}
+
+func TestProtocolV12_SendReceive(t *testing.T) {
+	initiator, responder, A, B := testV12Handshake(t)
+	defer A.Close()
+	defer B.Close()
+
+	parcels := make([]*Parcel, 64)
+	for i := range parcels {
+		payload := make([]byte, 1+rand.Intn(4095))
+		rand.Read(payload)
+		parcels[i] = newParcel(TypeMessage, payload)
+	}
+
+	// both directions
+	for _, dir := range [][2]*ProtocolV12{{initiator, responder}, {responder, initiator}} {
+		sender, reader := dir[0], dir[1]
+		go func() {
+			for _, p := range parcels {
+				if err := sender.Send(p); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+
+		for _, p := range parcels {
+			reply, err := reader.Receive()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.ptype != p.ptype || !bytes.Equal(reply.Payload, p.Payload) {
+				t.Errorf("received wrong parcel. sent = %+v, got = %+v", p, reply)
+			}
+		}
+	}
+}
+
+func TestProtocolV12_Tamper(t *testing.T) {
+	initiator, responder, A, B := testV12Handshake(t)
+	A.Close()
+	B.Close()
+
+	buf := new(bytes.Buffer)
+	initiator.rw = newTestRW(nil, buf)
+	if err := initiator.Send(newParcel(TypeMessage, []byte("foo"))); err != nil {
+		t.Fatal(err)
+	}
+	frame := buf.Bytes()
+
+	tampered := append([]byte{}, frame...)
+	tampered[len(tampered)-1] ^= 1
+	responder.rw = newTestRW(bytes.NewReader(tampered), nil)
+	if _, err := responder.Receive(); err == nil {
+		t.Errorf("tampered frame didn't give us an error")
+	}
+
+	responder.rw = newTestRW(bytes.NewReader(frame), nil)
+	if p, err := responder.Receive(); err != nil {
+		t.Errorf("valid frame gave an error: %v", err)
+	} else if !bytes.Equal(p.Payload, []byte("foo")) {
+		t.Errorf("wrong payload. got = %x", p.Payload)
+	}
+
+	// replaying a frame fails because the nonce moved on
+	responder.rw = newTestRW(bytes.NewReader(frame), nil)
+	if _, err := responder.Receive(); err == nil {
+		t.Errorf("replayed frame didn't give us an error")
+	}
+}
+
+func TestProtocolV12_ForgedKey(t *testing.T) {
+	initiator, responder, A, B := testV12Handshake(t)
+	A.Close()
+	B.Close()
+
+	// claim a key that didn't create the signature
+	other, _, _ := ed25519.GenerateKey(nil)
+	data, err := makeV11Handshake(&Handshake{Version: 12, ListenPort: "8108"}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := append([]byte{}, other...)
+	payload = append(payload, ed25519.Sign(initiator.key, initiator.signedTranscript(v12RoleInitiator))...)
+	payload = append(payload, data...)
+
+	buf := new(bytes.Buffer)
+	initiator.rw = newTestRW(nil, buf)
+	if err := initiator.writeFrame(payload); err != nil {
+		t.Fatal(err)
+	}
+
+	responder.rw = newTestRW(bytes.NewReader(buf.Bytes()), nil)
+	if _, err := responder.readSignedHandshake(); err == nil {
+		t.Errorf("forged key didn't give us an error")
+	}
+}
+
+func TestProtocolV12_WrongNetwork(t *testing.T) {
+	_, initKey, _ := ed25519.GenerateKey(nil)
+	_, respKey, _ := ed25519.GenerateKey(nil)
+
+	A, B := net.Pipe()
+	dl := time.Now().Add(time.Millisecond * 200)
+	A.SetDeadline(dl)
+	B.SetDeadline(dl)
+	defer A.Close()
+	defer B.Close()
+
+	initiator := newProtocolV12(A, initKey, NewNetworkID("foo"), true)
+	responder := newProtocolV12(B, respKey, NewNetworkID("bar"), false)
+
+	go func() {
+		initiator.SendHandshake(&Handshake{Version: 12, ListenPort: "8108"})
+		initiator.ReadHandshake()
+	}()
+
+	// the transcripts differ, so the keys don't match
+	if _, err := responder.ReadHandshake(); err == nil {
+		t.Errorf("handshake across different networks succeeded")
+	}
+}
@@ -223,8 +223,12 @@ func (v9 *ProtocolV9) MakePeerShare(ps []Endpoint) ([]byte, error) {
 	src := make(map[string]time.Time)
 	for _, ep := range ps {
 		loc := IP2LocationQuick(ep.IP)
+		addr := ep.IP
+		if ep.IsIPv6() { // legacy nodes append the port with a colon
+			addr = "[" + addr + "]"
+		}
 		conv = append(conv, V9Share{
-			Address:      ep.IP,
+			Address:      addr,
 			Port:         ep.Port,
 			QualityScore: 20,
 			NodeID:       1,
@@ -253,7 +257,7 @@ func (v9 *ProtocolV9) ParsePeerShare(payload []byte) ([]Endpoint, error) {
 	var conv []Endpoint
 	for _, s := range list {
 		conv = append(conv, Endpoint{
-			IP:   s.Address,
+			IP:   normalizeHost(s.Address),
 			Port: s.Port,
 		})
 	}
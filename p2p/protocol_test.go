@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"io"
 	"math/rand"
 	"net"
@@ -27,7 +28,7 @@ func testEqualEndpointList(a, b []Endpoint) bool {
 }
 
 func testRandomParcel() *Parcel {
-	return testParcel(ParcelType(rand.Intn(len(typeStrings))))
+	return testParcel(ParcelType(rand.Intn(int(TypeCompressed)))) // compressed parcels need a wrapped payload
 }
 func testParcel(typ ParcelType) *Parcel {
 	p := new(Parcel)
@@ -154,6 +155,7 @@ func TestProtocol_PeerShare(t *testing.T) {
 	for i := range shares {
 		shares[i] = testRandomEndpointList(rand.Intn(64))
 	}
+	shares = append(shares, []Endpoint{{"::1", "8108"}, {"2001:db8::8:800:200c:417a", "8090"}, {"127.0.0.1", "8108"}})
 
 	for _, prot := range []Protocol{testProtV9(nil), testProtV10(nil), testProtV11(nil)} {
 		for _, share := range shares {
@@ -172,3 +174,23 @@ func TestProtocol_PeerShare(t *testing.T) {
 		}
 	}
 }
+
+func TestProtocolV9_PeerShareIPv6(t *testing.T) {
+	share := []Endpoint{{"::1", "8108"}, {"127.0.0.1", "8108"}}
+	payload, err := testProtV9(nil).MakePeerShare(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var legacy []V9Share
+	if err := json.Unmarshal(payload, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	// legacy nodes dial Address + ":" + Port
+	if addr := legacy[0].Address + ":" + legacy[0].Port; addr != "[::1]:8108" {
+		t.Errorf("ipv6 address not dialable by legacy nodes. got = %s", addr)
+	}
+	if addr := legacy[1].Address + ":" + legacy[1].Port; addr != "127.0.0.1:8108" {
+		t.Errorf("ipv4 address changed. got = %s", addr)
+	}
+}
@@ -0,0 +1,184 @@
+package p2p
+
+import (
+	"io"
+	"math"
+	"net"
+	"time"
+)
+
+// Severity is the weight of a misbehavior reported against a peer
+type Severity uint
+
+const (
+	// SeverityMinor is for behavior that is harmless in small quantities, like duplicate messages
+	SeverityMinor Severity = 1
+	// SeverityModerate is for behavior that indicates a faulty node, like malformed peer shares
+	SeverityModerate Severity = 10
+	// SeveritySevere is for behavior that shouldn't happen with an honest node, like invalid parcels
+	SeveritySevere Severity = 50
+	// SeverityCritical immediately exceeds the default threshold
+	SeverityCritical Severity = 100
+)
+
+// peerScore is the decaying misbehavior score of a single address
+type peerScore struct {
+	Score   float64
+	Updated time.Time
+}
+
+// Offense keeps track of how often an address was banned automatically, which
+// determines the length of the next ban
+type Offense struct {
+	Count uint      `json:"count"`
+	Until time.Time `json:"until"` // end of the last ban
+}
+
+// decayedScore returns the score after applying the half-life since the last update
+func (s *peerScore) decayedScore(now time.Time, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return s.Score
+	}
+	elapsed := now.Sub(s.Updated)
+	if elapsed <= 0 {
+		return s.Score
+	}
+	return s.Score * math.Pow(0.5, float64(elapsed)/float64(halfLife))
+}
+
+// misbehaviorBanDuration is the length of the nth automatic ban, doubling with every offense
+func (c *controller) misbehaviorBanDuration(count uint) time.Duration {
+	conf := c.net.conf
+	duration := conf.MisbehaviorBan
+	for i := uint(1); i < count && duration < conf.MisbehaviorBanMax; i++ {
+		duration *= 2
+	}
+	if duration > conf.MisbehaviorBanMax {
+		duration = conf.MisbehaviorBanMax
+	}
+	return duration
+}
+
+// misbehavior adds the severity to the score of the address and bans the address
+// if the score crosses the threshold. Returns true if the address was banned.
+func (c *controller) misbehavior(ip string, severity Severity, reason string) bool {
+	conf := c.net.conf
+	if conf.MisbehaviorThreshold == 0 || severity == 0 {
+		return false
+	}
+
+	if c.net.prom != nil {
+		c.net.prom.Misbehavior.Inc()
+	}
+
+	now := c.net.clock.Now()
+	c.reputationMtx.Lock()
+	if c.scores == nil {
+		c.scores = make(map[string]*peerScore)
+	}
+	if c.offenses == nil {
+		c.offenses = make(map[string]*Offense)
+	}
+
+	score, ok := c.scores[ip]
+	if !ok {
+		score = new(peerScore)
+		c.scores[ip] = score
+	}
+	score.Score = score.decayedScore(now, conf.MisbehaviorHalfLife) + float64(severity)
+	score.Updated = now
+
+	c.logger.Debugf("Misbehavior of %s (%s), score is now %.1f", ip, reason, score.Score)
+
+	if score.Score < float64(conf.MisbehaviorThreshold) || c.isSpecialIP(ip) {
+		c.reputationMtx.Unlock()
+		return false
+	}
+	delete(c.scores, ip)
+
+	offense, ok := c.offenses[ip]
+	if !ok {
+		offense = new(Offense)
+		c.offenses[ip] = offense
+	}
+	// addresses that behaved for the length of the maximum ban start over
+	if now.Sub(offense.Until) > conf.MisbehaviorBanMax {
+		offense.Count = 0
+	}
+	offense.Count++
+	duration := c.misbehaviorBanDuration(offense.Count)
+	offense.Until = now.Add(duration)
+	c.reputationMtx.Unlock()
+
+	c.logger.Infof("Banning %s for %s after repeated misbehavior (%s), offense #%d", ip, duration, reason, offense.Count)
+	if c.net.prom != nil {
+		c.net.prom.MisbehaviorBans.Inc()
+	}
+	c.banIP(ip, duration)
+	return true
+}
+
+// peerMisbehavior reports misbehavior of a connected peer
+func (c *controller) peerMisbehavior(peer *Peer, severity Severity, reason string) {
+	// special peers may also be identified by their public key, which misbehavior doesn't know about
+	if c.isSpecialPeer(peer) {
+		c.logger.Debugf("Ignoring misbehavior of special peer %s (%s)", peer, reason)
+		return
+	}
+	if c.misbehavior(peer.Endpoint.IP, severity, reason) {
+		peer.Stop()
+	}
+}
+
+// reportMisbehavior reports misbehavior of the peer identified by the hash
+func (c *controller) reportMisbehavior(hash string, severity Severity) {
+	if peer := c.peers.Get(hash); peer != nil {
+		c.peerMisbehavior(peer, severity, "reported by application")
+	}
+}
+
+// banIP bans an address and disconnects all peers from it
+func (c *controller) banIP(ip string, duration time.Duration) {
+	c.banMtx.Lock()
+	end := c.net.clock.Now().Add(duration)
+	if existing, ok := c.bans[ip]; !ok || end.After(existing) {
+		c.bans[ip] = end
+	}
+	c.banMtx.Unlock()
+
+	for _, p := range c.peers.Slice() {
+		if p.Endpoint.IP == ip {
+			p.Stop()
+		}
+	}
+}
+
+// copyOffenses returns the offenses that still affect future bans
+func (c *controller) copyOffenses() map[string]*Offense {
+	c.reputationMtx.Lock()
+	defer c.reputationMtx.Unlock()
+
+	offenses := make(map[string]*Offense)
+	now := c.net.clock.Now()
+	for ip, o := range c.offenses {
+		if now.Sub(o.Until) > c.net.conf.MisbehaviorBanMax {
+			delete(c.offenses, ip)
+			continue
+		}
+		offense := *o
+		offenses[ip] = &offense
+	}
+	return offenses
+}
+
+// isProtocolError returns false for errors caused by the connection closing or timing out,
+// which a well behaved peer can cause too
+func isProtocolError(err error) bool {
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false
+	}
+	if _, ok := err.(net.Error); ok {
+		return false
+	}
+	return true
+}
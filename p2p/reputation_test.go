@@ -0,0 +1,187 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_peerScore_decayedScore(t *testing.T) {
+	now := time.Now()
+	s := &peerScore{Score: 100, Updated: now.Add(-time.Minute)}
+
+	if got := s.decayedScore(now, time.Minute); math.Abs(got-50) > 0.001 {
+		t.Errorf("one half-life: got = %f, want = 50", got)
+	}
+	if got := s.decayedScore(now, 0); got != 100 {
+		t.Errorf("no decay: got = %f, want = 100", got)
+	}
+	if got := s.decayedScore(now.Add(-time.Hour), time.Minute); got != 100 {
+		t.Errorf("time in the past: got = %f, want = 100", got)
+	}
+}
+
+func Test_controller_misbehaviorBanDuration(t *testing.T) {
+	n := testNetworkHarness(t)
+	n.conf.MisbehaviorBan = time.Minute
+	n.conf.MisbehaviorBanMax = time.Minute * 10
+
+	want := []time.Duration{time.Minute, time.Minute, time.Minute * 2, time.Minute * 4, time.Minute * 8, time.Minute * 10, time.Minute * 10}
+	for i, w := range want {
+		if got := n.controller.misbehaviorBanDuration(uint(i)); got != w {
+			t.Errorf("offense %d: got = %s, want = %s", i, got, w)
+		}
+	}
+}
+
+func Test_controller_misbehavior(t *testing.T) {
+	n := testNetworkHarness(t)
+	c := n.controller
+	n.conf.MisbehaviorThreshold = 100
+	n.conf.MisbehaviorBan = time.Minute
+	n.conf.MisbehaviorBanMax = time.Hour
+	n.conf.MisbehaviorHalfLife = 0
+
+	p := testRandomPeer(n)
+	p.conn, _ = net.Pipe()
+	c.peers.Add(p)
+
+	for i := 0; i < 99; i++ {
+		c.peerMisbehavior(p, SeverityMinor, "test")
+	}
+	if c.isBannedIP(p.Endpoint.IP) {
+		t.Fatalf("peer banned before reaching the threshold")
+	}
+
+	c.peerMisbehavior(p, SeverityMinor, "test")
+	if !c.isBannedIP(p.Endpoint.IP) {
+		t.Fatalf("peer not banned after reaching the threshold")
+	}
+	select {
+	case <-p.stop:
+	default:
+		t.Errorf("peer was not disconnected")
+	}
+
+	first := c.bans[p.Endpoint.IP]
+	if d := time.Until(first); d > time.Minute || d < time.Minute-time.Second {
+		t.Errorf("first ban has wrong duration %s", d)
+	}
+
+	// the score was reset, the next ban is twice as long
+	c.misbehavior(p.Endpoint.IP, SeveritySevere, "test")
+	if c.bans[p.Endpoint.IP] != first {
+		t.Errorf("ban changed before reaching the threshold again")
+	}
+	c.misbehavior(p.Endpoint.IP, SeveritySevere, "test")
+	if d := time.Until(c.bans[p.Endpoint.IP]); d > time.Minute*2 || d < time.Minute*2-time.Second {
+		t.Errorf("second ban has wrong duration %s", d)
+	}
+	if c.offenses[p.Endpoint.IP].Count != 2 {
+		t.Errorf("wrong offense count. got = %d, want = 2", c.offenses[p.Endpoint.IP].Count)
+	}
+
+	// an address that behaved long enough starts over
+	c.offenses[p.Endpoint.IP].Until = time.Now().Add(-time.Hour * 2)
+	c.misbehavior(p.Endpoint.IP, SeverityCritical, "test")
+	if c.offenses[p.Endpoint.IP].Count != 1 {
+		t.Errorf("offense count didn't reset. got = %d, want = 1", c.offenses[p.Endpoint.IP].Count)
+	}
+}
+
+func Test_controller_misbehaviorSpecial(t *testing.T) {
+	n := testNetworkHarness(t)
+	c := n.controller
+	c.setSpecial("127.0.0.1:8108")
+
+	if c.misbehavior("127.0.0.1", SeverityCritical, "test") {
+		t.Errorf("special peer was banned")
+	}
+	if c.isBannedIP("127.0.0.1") {
+		t.Errorf("special peer is banned")
+	}
+
+	// peers that are special by their public key are never scored
+	pub, _, _ := ed25519.GenerateKey(nil)
+	c.setSpecialKeys(hex.EncodeToString(pub))
+	p := testRandomPeer(n)
+	p.conn, _ = net.Pipe()
+	p.PublicKey = pub
+	c.peers.Add(p)
+	for i := 0; i < 10; i++ {
+		c.peerMisbehavior(p, SeverityCritical, "test")
+	}
+	if c.isBannedIP(p.Endpoint.IP) {
+		t.Errorf("peer with a special key is banned")
+	}
+	select {
+	case <-p.stop:
+		t.Errorf("peer with a special key was disconnected")
+	default:
+	}
+
+	n.conf.MisbehaviorThreshold = 0
+	if c.misbehavior("127.0.0.2", SeverityCritical, "test") {
+		t.Errorf("peer was banned with banning disabled")
+	}
+}
+
+func Test_controller_misbehaviorPersist(t *testing.T) {
+	f, err := ioutil.TempFile("", "peerfile*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	n := testNetworkHarness(t)
+	n.conf.PeerCacheFile = f.Name()
+	for i := 0; i < 3; i++ {
+		n.controller.misbehavior("1.2.3.4", SeverityCritical, "test")
+	}
+	n.controller.misbehavior("1.2.3.5", SeverityCritical, "test")
+	n.controller.offenses["1.2.3.5"].Until = time.Now().Add(-n.conf.MisbehaviorBanMax * 2)
+
+	if err := n.controller.writePeerCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	pc, err := loadPeerCache(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, ok := pc.Offenses["1.2.3.4"]; !ok || o.Count != 3 {
+		t.Errorf("offense was not persisted. got = %+v", o)
+	}
+	if _, ok := pc.Offenses["1.2.3.5"]; ok {
+		t.Errorf("expired offense was persisted")
+	}
+	if !time.Now().Before(pc.Bans["1.2.3.4"]) {
+		t.Errorf("ban was not persisted")
+	}
+}
+
+func Test_isProtocolError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{io.ErrUnexpectedEOF, false},
+		{&net.OpError{Op: "read", Err: fmt.Errorf("closed")}, false},
+		{fmt.Errorf("invalid connection signature"), true},
+	}
+	for _, tt := range tests {
+		if got := isProtocolError(tt.err); got != tt.want {
+			t.Errorf("isProtocolError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,63 +1,325 @@
 package p2p
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// seedMaxSize is the maximum size of a seed list or signature in bytes
+	seedMaxSize = 1 << 20
+	seedTimeout = time.Second * 10
+	// seedSignatureSuffix is appended to the location of a seed list to get its detached signature
+	seedSignatureSuffix = ".sig"
+	// seedDNSSignature is the prefix of the TXT record that holds the signature of a DNS seed
+	seedDNSSignature = "sig="
+)
+
+// seedSource is a single source of seed endpoints
+type seedSource interface {
+	// fetch retrieves the endpoints of the source. If keys is not empty, the list has to be
+	// signed by one of the keys
+	fetch(keys map[string]bool) ([]Endpoint, error)
+	String() string
+}
+
+// seed merges the endpoints of multiple seed sources
 type seed struct {
-	url       string
-	cache     []Endpoint
-	cacheTTL  time.Duration
-	cacheTime time.Time
+	sources      []seedSource
+	keys         map[string]bool
+	maxPerSource int
+	last         map[seedSource][]Endpoint // last successful result of each source
+	cache        []Endpoint
+	cacheTTL     time.Duration
+	cacheTime    time.Time
 
 	logger *log.Entry
 }
 
-func newSeed(url string, cacheTTL time.Duration) *seed {
+// newSeed creates a seed from the comma separated list of sources in conf.SeedURL.
+// A source is either a http(s) url, "dns:<name>[:port]" or a file path with an optional
+// "file:" prefix
+func newSeed(conf *Configuration) (*seed, error) {
 	s := new(seed)
-	s.url = url
-	s.logger = packageLogger.WithFields(log.Fields{"subpackage": "Seed", "url": url})
-	s.cacheTTL = cacheTTL
-	return s
+	s.logger = packageLogger.WithFields(log.Fields{"subpackage": "Seed"})
+	s.cacheTTL = conf.PeerReseedInterval
+	s.maxPerSource = int(conf.SeedMaxPerSource)
+	s.last = make(map[seedSource][]Endpoint)
+
+	keys, err := parsePublicKeys(conf.SeedKeys)
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+
+	resolver := net.DefaultResolver
+	if conf.SeedDNSServer != "" {
+		server := conf.SeedDNSServer
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	for _, raw := range strings.Split(conf.SeedURL, ",") {
+		raw = strings.TrimSpace(raw)
+		switch {
+		case raw == "":
+		case strings.HasPrefix(raw, "http://"), strings.HasPrefix(raw, "https://"):
+			s.sources = append(s.sources, &httpSeed{url: raw, client: &http.Client{Timeout: seedTimeout}})
+		case strings.HasPrefix(raw, "dns:"):
+			name, port := strings.TrimPrefix(strings.TrimPrefix(raw, "dns:"), "//"), conf.ListenPort
+			if h, p, err := net.SplitHostPort(name); err == nil {
+				name, port = h, p
+			}
+			s.sources = append(s.sources, &dnsSeed{name: name, port: port, resolver: resolver})
+		default:
+			path := strings.TrimPrefix(strings.TrimPrefix(raw, "file:"), "//")
+			s.sources = append(s.sources, &fileSeed{path: path})
+		}
+	}
+	return s, nil
 }
 
+// retrieve returns the merged endpoints of all sources without duplicates. Each source
+// contributes at most SeedMaxPerSource endpoints. A source that fails keeps contributing
+// the endpoints of its last successful retrieval
 func (s *seed) retrieve() []Endpoint {
 	if s.cache != nil && time.Since(s.cacheTime) <= s.cacheTTL {
 		return s.cache
 	}
 
 	eps := make([]Endpoint, 0)
-	err := WebScanner(s.url, func(line string) {
-		line = strings.TrimSpace(line)
+	seen := make(map[Endpoint]bool)
+	for _, src := range s.sources {
+		list, err := src.fetch(s.keys)
+		if err != nil {
+			s.logger.WithError(err).Errorf("unable to retrieve data from seed %s", src)
+			list = s.last[src]
+		} else {
+			s.last[src] = list
+		}
+
+		if s.maxPerSource > 0 && len(list) > s.maxPerSource {
+			s.logger.Warnf("seed %s has %d endpoints, only using %d", src, len(list), s.maxPerSource)
+			list = list[:s.maxPerSource]
+		}
+
+		for _, ep := range list {
+			if !seen[ep] {
+				seen[ep] = true
+				eps = append(eps, ep)
+			}
+		}
+	}
+
+	s.cacheTime = time.Now()
+	s.cache = eps
+	return eps
+}
+
+func (s *seed) size() int {
+	s.retrieve()
+	return len(s.cache)
+}
+
+// parseSeedList parses a list of endpoints, one "host:port" per line. Badly formatted
+// lines are skipped
+func parseSeedList(data []byte, logger *log.Entry) []Endpoint {
+	eps := make([]Endpoint, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
-			return
+			continue
 		}
 		host, port, err := net.SplitHostPort(line)
 		if err != nil {
-			s.logger.Errorf("Badly formatted line [%s]", line)
-			return
+			logger.Errorf("Badly formatted line [%s]", line)
+			continue
 		}
 		if ep, err := NewEndpoint(host, port); err != nil {
-			s.logger.WithError(err).Errorf("Bad peer [%s]", line)
+			logger.WithError(err).Errorf("Bad peer [%s]", line)
 		} else {
 			eps = append(eps, ep)
 		}
-	})
+	}
+	return eps
+}
 
+// verifySeedSignature checks the hex encoded signature of the data against the keys
+func verifySeedSignature(keys map[string]bool, data []byte, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimSpace(signature))
 	if err != nil {
-		s.logger.WithError(err).Errorf("unable to retrieve data from seed")
+		return fmt.Errorf("signature is not hex encoded: %v", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("signature has the wrong length %d", len(sig))
 	}
+	for key := range keys {
+		if ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature does not match any seed key")
+}
 
-	s.cacheTime = time.Now()
-	s.cache = eps
-	return eps
+// SignSeedList creates the hex encoded detached signature of a seed list. For http and
+// file seeds, the signature is stored next to the list with the ".sig" suffix
+func SignSeedList(key ed25519.PrivateKey, list []byte) string {
+	return hex.EncodeToString(ed25519.Sign(key, list))
 }
 
-func (s *seed) size() int {
-	s.retrieve()
-	return len(s.cache)
+// SignDNSSeed creates the TXT record that signs the endpoints of a DNS seed
+func SignDNSSeed(key ed25519.PrivateKey, eps []string) string {
+	return seedDNSSignature + SignSeedList(key, dnsSeedMessage(eps))
+}
+
+// dnsSeedMessage is the signed representation of a DNS seed's endpoints
+func dnsSeedMessage(eps []string) []byte {
+	sorted := append([]string(nil), eps...)
+	sort.Strings(sorted)
+	return []byte(strings.Join(sorted, "\n"))
+}
+
+// httpSeed is a seed list on a web server
+type httpSeed struct {
+	url    string
+	client *http.Client
+}
+
+func (h *httpSeed) String() string { return h.url }
+
+func (h *httpSeed) get(url string) ([]byte, error) {
+	resp, err := h.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid http status code: %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, seedMaxSize))
+}
+
+func (h *httpSeed) fetch(keys map[string]bool) ([]Endpoint, error) {
+	data, err := h.get(h.url)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		sig, err := h.get(h.url + seedSignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve signature: %v", err)
+		}
+		if err := verifySeedSignature(keys, data, string(sig)); err != nil {
+			return nil, err
+		}
+	}
+	return parseSeedList(data, packageLogger.WithField("seed", h.url)), nil
+}
+
+// fileSeed is a seed list on the local file system
+type fileSeed struct {
+	path string
+}
+
+func (f *fileSeed) String() string { return f.path }
+
+func (f *fileSeed) read(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(io.LimitReader(file, seedMaxSize))
+}
+
+func (f *fileSeed) fetch(keys map[string]bool) ([]Endpoint, error) {
+	data, err := f.read(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		sig, err := f.read(f.path + seedSignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signature: %v", err)
+		}
+		if err := verifySeedSignature(keys, data, string(sig)); err != nil {
+			return nil, err
+		}
+	}
+	return parseSeedList(data, packageLogger.WithField("seed", f.path)), nil
+}
+
+// dnsSeed reads endpoints from the TXT records of a domain, separated by whitespace,
+// and the A and AAAA records of the domain, which use the default port.
+// A signed DNS seed has a TXT record "sig=<signature>" that signs the sorted TXT endpoints.
+// Address records can't be signed and are ignored if keys are set
+type dnsSeed struct {
+	name     string
+	port     string
+	resolver *net.Resolver
+}
+
+func (d *dnsSeed) String() string { return "dns:" + d.name }
+
+func (d *dnsSeed) fetch(keys map[string]bool) ([]Endpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), seedTimeout)
+	defer cancel()
+
+	logger := packageLogger.WithField("seed", d.String())
+	var entries []string
+	signature := ""
+	records, txtErr := d.resolver.LookupTXT(ctx, d.name)
+	for _, record := range records {
+		if strings.HasPrefix(record, seedDNSSignature) {
+			signature = strings.TrimPrefix(record, seedDNSSignature)
+			continue
+		}
+		entries = append(entries, strings.Fields(record)...)
+	}
+
+	if len(keys) > 0 {
+		if txtErr != nil {
+			return nil, txtErr
+		}
+		if signature == "" {
+			return nil, fmt.Errorf("no signature record")
+		}
+		if err := verifySeedSignature(keys, dnsSeedMessage(entries), signature); err != nil {
+			return nil, err
+		}
+		return parseSeedList([]byte(strings.Join(entries, "\n")), logger), nil
+	}
+
+	eps := parseSeedList([]byte(strings.Join(entries, "\n")), logger)
+	addrs, ipErr := d.resolver.LookupIPAddr(ctx, d.name)
+	if txtErr != nil && ipErr != nil {
+		return nil, fmt.Errorf("no records found: %v", ipErr)
+	}
+	for _, addr := range addrs {
+		if ep, err := NewEndpoint(addr.IP.String(), d.port); err == nil {
+			eps = append(eps, ep)
+		}
+	}
+	return eps, nil
 }
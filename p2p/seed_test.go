@@ -1,8 +1,17 @@
 package p2p
 
 import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +26,9 @@ func testServer() {
 	mux.HandleFunc("/seedBad.txt", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("192.168.0.1:8088\n10.12.13.14:8110"))
 	})
+	mux.HandleFunc("/seed6.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[::1]:8108\n[2001:DB8::1]:8090\n::1:8108\n127.0.0.1:80"))
+	})
 	mux.HandleFunc("/seedBlank.txt", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(""))
 	})
@@ -28,15 +40,28 @@ func testServer() {
 	time.Sleep(time.Millisecond * 500)
 }
 
+func testSeed(t *testing.T, sources, keys string) *seed {
+	conf := DefaultP2PConfiguration()
+	conf.SeedURL = sources
+	conf.SeedKeys = keys
+	conf.PeerReseedInterval = 0
+	s, err := newSeed(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
 func Test_seed_retrieve(t *testing.T) {
 
 	testServer()
 
 	log.SetLevel(log.DebugLevel)
-	s := newSeed("http://localhost:8000/seed.txt", 0)
-	s2 := newSeed("http://localhost:8000/seedBad.txt", 0)
-	s3 := newSeed("http://localhost:8000/git.txt", 0)
-	s4 := newSeed("http://localhost:8000/seedBlank.txt", 0)
+	s := testSeed(t, "http://localhost:8000/seed.txt", "")
+	s2 := testSeed(t, "http://localhost:8000/seedBad.txt", "")
+	s3 := testSeed(t, "http://localhost:8000/git.txt", "")
+	s4 := testSeed(t, "http://localhost:8000/seedBlank.txt", "")
+	s5 := testSeed(t, "http://localhost:8000/seed6.txt", "")
 
 	tests := []struct {
 		name string
@@ -58,6 +83,7 @@ func Test_seed_retrieve(t *testing.T) {
 			{"34.248.6.133", "8108"},
 		}},
 		{"blank", s4, []Endpoint{}},
+		{"ipv6", s5, []Endpoint{{"::1", "8108"}, {"2001:db8::1", "8090"}, {"127.0.0.1", "80"}}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,3 +93,225 @@ func Test_seed_retrieve(t *testing.T) {
 		})
 	}
 }
+
+// testDNSServer is a stub DNS server that answers TXT, A, and AAAA queries for any name
+type testDNSServer struct {
+	conn net.PacketConn
+	txt  []string
+	a    []net.IP
+}
+
+func newTestDNSServer(t *testing.T, txt []string, a []net.IP) *testDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &testDNSServer{conn: conn, txt: txt, a: a}
+	go d.serve()
+	return d
+}
+
+func (d *testDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := d.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 12 {
+			continue
+		}
+		// question ends after the name's terminating zero, qtype, and qclass
+		end := 12
+		for end < n && buf[end] != 0 {
+			end += int(buf[end]) + 1
+		}
+		end += 5
+		if end > n {
+			continue
+		}
+		qtype := binary.BigEndian.Uint16(buf[end-4:])
+
+		var answers [][]byte
+		switch qtype {
+		case 16: // TXT
+			for _, txt := range d.txt {
+				answers = append(answers, append([]byte{byte(len(txt))}, txt...))
+			}
+		case 1: // A
+			for _, ip := range d.a {
+				if ip4 := ip.To4(); ip4 != nil {
+					answers = append(answers, ip4)
+				}
+			}
+		case 28: // AAAA
+			for _, ip := range d.a {
+				if ip.To4() == nil {
+					answers = append(answers, ip.To16())
+				}
+			}
+		}
+
+		resp := make([]byte, 12, 512)
+		copy(resp, buf[:2])                          // id
+		binary.BigEndian.PutUint16(resp[2:], 0x8180) // response, recursion desired and available
+		binary.BigEndian.PutUint16(resp[4:], 1)      // questions
+		binary.BigEndian.PutUint16(resp[6:], uint16(len(answers)))
+		resp = append(resp, buf[12:end]...)
+		for _, rdata := range answers {
+			rr := make([]byte, 12)
+			binary.BigEndian.PutUint16(rr, 0xC00C) // pointer to the question's name
+			binary.BigEndian.PutUint16(rr[2:], qtype)
+			binary.BigEndian.PutUint16(rr[4:], 1) // class IN
+			binary.BigEndian.PutUint32(rr[6:], 60)
+			binary.BigEndian.PutUint16(rr[10:], uint16(len(rdata)))
+			resp = append(append(resp, rr...), rdata...)
+		}
+		d.conn.WriteTo(resp, addr)
+	}
+}
+
+func testSeedServer(files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+}
+
+func Test_seed_merge(t *testing.T) {
+	a := testSeedServer(map[string]string{"/seed.txt": "127.0.0.1:80\n127.0.0.2:80\n[::1]:80"})
+	defer a.Close()
+	b := testSeedServer(map[string]string{"/seed.txt": "127.0.0.2:80\n127.0.0.3:80"})
+
+	dir, err := ioutil.TempDir("", "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "seed.txt")
+	if err := ioutil.WriteFile(file, []byte("127.0.0.3:80\n127.0.0.4:80\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := testSeed(t, a.URL+"/seed.txt, "+b.URL+"/seed.txt,"+a.URL+"/missing.txt,file:"+file, "")
+	want := []Endpoint{{"127.0.0.1", "80"}, {"127.0.0.2", "80"}, {"::1", "80"}, {"127.0.0.3", "80"}, {"127.0.0.4", "80"}}
+	if got := s.retrieve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("seed.retrieve() = %v, want %v", got, want)
+	}
+
+	// an unreachable source keeps its last list
+	b.Close()
+	if got := s.retrieve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("seed.retrieve() after failure = %v, want %v", got, want)
+	}
+
+	s.maxPerSource = 1
+	want = []Endpoint{{"127.0.0.1", "80"}, {"127.0.0.2", "80"}, {"127.0.0.3", "80"}}
+	if got := s.retrieve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("seed.retrieve() with limit = %v, want %v", got, want)
+	}
+}
+
+func Test_seed_signed(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	_, other, _ := ed25519.GenerateKey(nil)
+	key := hex.EncodeToString(pub)
+
+	list := "127.0.0.1:80\n127.0.0.2:80\n"
+	srv := testSeedServer(map[string]string{
+		"/signed.txt":       list,
+		"/signed.txt.sig":   SignSeedList(priv, []byte(list)),
+		"/tampered.txt":     list + "127.0.0.66:80\n",
+		"/tampered.txt.sig": SignSeedList(priv, []byte(list)),
+		"/other.txt":        list,
+		"/other.txt.sig":    SignSeedList(other, []byte(list)),
+		"/unsigned.txt":     list,
+	})
+	defer srv.Close()
+
+	want := []Endpoint{{"127.0.0.1", "80"}, {"127.0.0.2", "80"}}
+	tests := []struct {
+		name string
+		path string
+		want []Endpoint
+	}{
+		{"signed", "/signed.txt", want},
+		{"tampered", "/tampered.txt", []Endpoint{}},
+		{"wrong key", "/other.txt", []Endpoint{}},
+		{"unsigned", "/unsigned.txt", []Endpoint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testSeed(t, srv.URL+tt.path, key).retrieve(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("seed.retrieve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// signature checking is optional
+	if got := testSeed(t, srv.URL+"/unsigned.txt", "").retrieve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unsigned seed.retrieve() = %v, want %v", got, want)
+	}
+
+	dir, err := ioutil.TempDir("", "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "seed.txt")
+	ioutil.WriteFile(file, []byte(list), 0600)
+	ioutil.WriteFile(file+".sig", []byte(SignSeedList(priv, []byte(list))), 0600)
+	if got := testSeed(t, file, key).retrieve(); !reflect.DeepEqual(got, want) {
+		t.Errorf("signed file seed.retrieve() = %v, want %v", got, want)
+	}
+}
+
+func Test_seed_dns(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	entries := []string{"127.0.0.2:8110", "[::1]:8110"}
+
+	dns := newTestDNSServer(t, []string{strings.Join(entries, " "), SignDNSSeed(priv, entries)}, []net.IP{net.ParseIP("127.0.0.3"), net.ParseIP("::2")})
+	defer func() { dns.conn.Close() }()
+
+	testDNS := func(source, keys string) []Endpoint {
+		conf := DefaultP2PConfiguration()
+		conf.SeedURL = source
+		conf.SeedKeys = keys
+		conf.SeedDNSServer = dns.conn.LocalAddr().String()
+		conf.ListenPort = "8108"
+		s, err := newSeed(&conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.retrieve()
+	}
+
+	got := testDNS("dns:seed.example.", "")
+	want := []Endpoint{{"127.0.0.2", "8110"}, {"::1", "8110"}, {"127.0.0.3", "8108"}, {"::2", "8108"}}
+	if !testEqualEndpointList(got, want) {
+		t.Errorf("dns seed = %v, want %v", got, want)
+	}
+
+	got = testDNS("dns://seed.example.:9000", "")
+	want = []Endpoint{{"127.0.0.2", "8110"}, {"::1", "8110"}, {"127.0.0.3", "9000"}, {"::2", "9000"}}
+	if !testEqualEndpointList(got, want) {
+		t.Errorf("dns seed with port = %v, want %v", got, want)
+	}
+
+	// address records are not signed
+	got = testDNS("dns:seed.example.", hex.EncodeToString(pub))
+	want = []Endpoint{{"127.0.0.2", "8110"}, {"::1", "8110"}}
+	if !testEqualEndpointList(got, want) {
+		t.Errorf("signed dns seed = %v, want %v", got, want)
+	}
+
+	dns.conn.Close()
+	dns = newTestDNSServer(t, []string{strings.Join(append(entries, "127.0.0.66:8110"), " "), SignDNSSeed(priv, entries)}, nil)
+	if got = testDNS("dns:seed.example.", hex.EncodeToString(pub)); len(got) != 0 {
+		t.Errorf("tampered dns seed = %v, want none", got)
+	}
+}
@@ -0,0 +1,524 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// simBacklog is the number of connections a simulated listener queues before refusing dials
+const simBacklog = 128
+
+// simMaxRetransmits caps the retransmits of a single write, so a Loss of 1 doesn't stall forever
+const simMaxRetransmits = 10
+
+// simYield is the real time the goroutines of a simulation get to react to a step of the clock
+const simYield = time.Millisecond
+
+var errSimClosed = errors.New("use of closed network connection")
+
+// simTimeout is the error of a passed deadline
+type simTimeout struct{}
+
+func (simTimeout) Error() string   { return "i/o timeout" }
+func (simTimeout) Timeout() bool   { return true }
+func (simTimeout) Temporary() bool { return true }
+
+var errSimTimeout error = simTimeout{}
+
+// Link describes the connection between two hosts of a Simulation
+type Link struct {
+	// Latency is the one way delay of data
+	Latency time.Duration
+	// Jitter adds a random delay of up to this duration to every write. Data still
+	// arrives in order
+	Jitter time.Duration
+	// Loss is the probability (0 to 1) that a write or connection attempt is lost. Like in
+	// TCP, it is retransmitted after Simulation.Retransmit, so loss only adds delay
+	Loss float64
+}
+
+// Simulation is an in-process network of virtual hosts. Each host is identified by an ip
+// address and networks use the host's Transport instead of TCP, see Configure.
+//
+// Data between hosts is delayed according to their Link. Hosts in different groups of a
+// partition can't reach each other, dials fail after the timeout and existing connections
+// are reset when the partition is created.
+//
+// Hosts and configured networks share the virtual clock of the simulation. Time stands
+// still until Run advances it, so simulating hours of network activity takes minutes.
+type Simulation struct {
+	// Default is the link between hosts without a link of their own
+	Default Link
+	// Retransmit is the delay a lost write or connection attempt adds
+	Retransmit time.Duration
+	// Step is how far Run advances the clock at once. Timers fire at their exact time,
+	// but goroutines only get to react to them at the end of a step
+	Step time.Duration
+
+	clock     *simClock
+	mtx       sync.Mutex
+	rng       *rand.Rand
+	links     map[[2]string]Link
+	listeners map[string]*simListener // by host:port
+	groups    map[string]int          // partition group of each host, unlisted hosts are in 0
+	conns     map[*simConn]bool
+	ports     map[string]int // last ephemeral port of each host
+}
+
+// NewSimulation creates a simulation without latency, loss or partitions. The seed
+// determines the jitter, the loss, and the random seeds of configured networks
+func NewSimulation(seed int64) *Simulation {
+	s := new(Simulation)
+	s.Retransmit = time.Millisecond * 200
+	s.Step = time.Millisecond * 10
+	s.clock = newSimClock()
+	s.rng = rand.New(rand.NewSource(seed))
+	s.links = make(map[[2]string]Link)
+	s.listeners = make(map[string]*simListener)
+	s.groups = make(map[string]int)
+	s.conns = make(map[*simConn]bool)
+	s.ports = make(map[string]int)
+	return s
+}
+
+func simPair(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// SetLink sets the link between two hosts in both directions
+func (s *Simulation) SetLink(a, b string, l Link) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.links[simPair(a, b)] = l
+}
+
+// Partition splits the hosts into groups that can't reach each other. Hosts that aren't
+// listed form a group of their own. Connections between groups are reset
+func (s *Simulation) Partition(groups ...[]string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, host := range group {
+			s.groups[host] = i + 1
+		}
+	}
+	for c := range s.conns {
+		if !s.reachable(c.local.host(), c.remote.host()) {
+			c.reset()
+		}
+	}
+}
+
+// Heal removes the partition
+func (s *Simulation) Heal() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.groups = make(map[string]int)
+}
+
+// Now returns the virtual time of the simulation
+func (s *Simulation) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Clock returns the virtual clock of the simulation
+func (s *Simulation) Clock() Clock {
+	return s.clock
+}
+
+// Run advances the virtual time by the duration, one Step at a time. After each step, the
+// goroutines woken by timers get a moment of real time to react before time moves on
+func (s *Simulation) Run(d time.Duration) {
+	end := s.clock.Now().Add(d)
+	for now := s.clock.Now(); now.Before(end); now = s.clock.Now() {
+		next := now.Add(s.Step)
+		if next.After(end) {
+			next = end
+		}
+		s.clock.advance(next)
+		time.Sleep(simYield)
+	}
+}
+
+// Host returns the transport of a virtual host
+func (s *Simulation) Host(ip string) Transport {
+	return &simHost{sim: s, ip: normalizeHost(ip)}
+}
+
+// Configure sets up the configuration of a network to run on a virtual host
+func (s *Simulation) Configure(conf *Configuration, ip string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	conf.Transport = s.Host(ip)
+	conf.Clock = s.clock
+	conf.BindIP = ip
+	conf.RandomSeed = s.rng.Int63()
+	conf.EnablePrometheus = false // metrics can only be registered once per process
+}
+
+// Connections returns the number of open connections
+func (s *Simulation) Connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+func (s *Simulation) reachable(a, b string) bool {
+	return s.groups[a] == s.groups[b]
+}
+
+func (s *Simulation) link(a, b string) Link {
+	if l, ok := s.links[simPair(a, b)]; ok {
+		return l
+	}
+	return s.Default
+}
+
+// delay returns how long a single write over the link takes
+func (s *Simulation) delay(l Link) time.Duration {
+	d := l.Latency
+	if l.Jitter > 0 {
+		d += time.Duration(s.rng.Int63n(int64(l.Jitter)))
+	}
+	for i := 0; i < simMaxRetransmits && l.Loss > 0 && s.rng.Float64() < l.Loss; i++ {
+		d += s.Retransmit
+	}
+	return d
+}
+
+// simHost is the Transport of a single host
+type simHost struct {
+	sim *Simulation
+	ip  string
+}
+
+func (h *simHost) Dial(ep Endpoint, timeout time.Duration) (net.Conn, error) {
+	s := h.sim
+	remote := simAddr(ep.String())
+
+	s.mtx.Lock()
+	reachable := s.reachable(h.ip, ep.IP)
+	handshake := s.delay(s.link(h.ip, ep.IP)) * 2
+	s.ports[h.ip]++
+	local := simAddr(net.JoinHostPort(h.ip, strconv.Itoa(10000+s.ports[h.ip]%50000)))
+	s.mtx.Unlock()
+
+	if !reachable || handshake > timeout {
+		s.clock.Sleep(timeout)
+		return nil, &net.OpError{Op: "dial", Net: "sim", Source: local, Addr: remote, Err: errSimTimeout}
+	}
+	s.clock.Sleep(handshake)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	l := s.listeners[string(remote)]
+	if l == nil || !s.reachable(h.ip, ep.IP) {
+		return nil, &net.OpError{Op: "dial", Net: "sim", Source: local, Addr: remote, Err: syscall.ECONNREFUSED}
+	}
+
+	a, b := s.newConnPair(local, remote)
+	select {
+	case l.accept <- b:
+		return a, nil
+	default:
+		delete(s.conns, a)
+		delete(s.conns, b)
+		return nil, &net.OpError{Op: "dial", Net: "sim", Source: local, Addr: remote, Err: syscall.ECONNREFUSED}
+	}
+}
+
+// Listen listens to the port of the address on the host's ip, regardless of the address' host
+func (h *simHost) Listen(address string) (net.Listener, error) {
+	_, port, err := net.SplitHostPort(strings.TrimSpace(strings.Split(address, ",")[0]))
+	if err != nil {
+		return nil, err
+	}
+	addr := simAddr(net.JoinHostPort(h.ip, port))
+
+	h.sim.mtx.Lock()
+	defer h.sim.mtx.Unlock()
+	if _, ok := h.sim.listeners[string(addr)]; ok {
+		return nil, &net.OpError{Op: "listen", Net: "sim", Addr: addr, Err: syscall.EADDRINUSE}
+	}
+	l := &simListener{sim: h.sim, addr: addr, accept: make(chan net.Conn, simBacklog), closed: make(chan struct{})}
+	h.sim.listeners[string(addr)] = l
+	return l, nil
+}
+
+// newConnPair creates both ends of a connection, the caller holds the mutex
+func (s *Simulation) newConnPair(local, remote simAddr) (*simConn, *simConn) {
+	ab, ba := newSimPipe(s.clock), newSimPipe(s.clock)
+	a := &simConn{sim: s, local: local, remote: remote, in: ba, out: ab}
+	b := &simConn{sim: s, local: remote, remote: local, in: ab, out: ba}
+	a.peer, b.peer = b, a
+	s.conns[a] = true
+	s.conns[b] = true
+	return a, b
+}
+
+// simAddr is a host:port address of a virtual host
+type simAddr string
+
+func (a simAddr) Network() string { return "tcp" }
+func (a simAddr) String() string  { return string(a) }
+
+func (a simAddr) host() string {
+	host, _, _ := net.SplitHostPort(string(a))
+	return host
+}
+
+type simListener struct {
+	sim       *Simulation
+	addr      simAddr
+	accept    chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *simListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "sim", Addr: l.addr, Err: errListenerClosed}
+	}
+}
+
+func (l *simListener) Close() error {
+	l.closeOnce.Do(func() {
+		l.sim.mtx.Lock()
+		delete(l.sim.listeners, string(l.addr))
+		l.sim.mtx.Unlock()
+		close(l.closed)
+		for {
+			select {
+			case c := <-l.accept:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (l *simListener) Addr() net.Addr { return l.addr }
+
+// simChunk is a single write, readable once the time has come
+type simChunk struct {
+	data []byte
+	at   time.Time
+	eof  bool
+}
+
+// simPipe is one direction of a connection
+type simPipe struct {
+	clock    *simClock
+	mtx      sync.Mutex
+	chunks   []simChunk
+	last     time.Time // arrival of the last chunk, later chunks can't overtake it
+	err      error     // set when the connection is closed locally or reset
+	deadline time.Time
+	notify   chan struct{}
+}
+
+func newSimPipe(clock *simClock) *simPipe {
+	return &simPipe{clock: clock, notify: make(chan struct{}, 1)}
+}
+
+func (p *simPipe) signal() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *simPipe) push(c simChunk, delay time.Duration) {
+	p.mtx.Lock()
+	c.at = p.clock.Now().Add(delay)
+	if c.at.Before(p.last) {
+		c.at = p.last
+	}
+	p.last = c.at
+	p.chunks = append(p.chunks, c)
+	p.mtx.Unlock()
+	p.signal()
+}
+
+func (p *simPipe) fail(err error) {
+	p.mtx.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mtx.Unlock()
+	p.signal()
+}
+
+func (p *simPipe) setDeadline(t time.Time) {
+	p.mtx.Lock()
+	p.deadline = t
+	p.mtx.Unlock()
+	p.signal()
+}
+
+// read blocks until data arrives, the deadline passes, or the pipe fails
+func (p *simPipe) read(b []byte) (int, error) {
+	for {
+		p.mtx.Lock()
+		if p.err != nil {
+			p.mtx.Unlock()
+			return 0, p.err
+		}
+		now := p.clock.Now()
+		if len(p.chunks) > 0 && !p.chunks[0].at.After(now) {
+			c := &p.chunks[0]
+			if c.eof {
+				p.mtx.Unlock()
+				return 0, io.EOF
+			}
+			n := copy(b, c.data)
+			if c.data = c.data[n:]; len(c.data) == 0 {
+				p.chunks = p.chunks[1:]
+			}
+			p.mtx.Unlock()
+			return n, nil
+		}
+		if !p.deadline.IsZero() && !now.Before(p.deadline) {
+			p.mtx.Unlock()
+			return 0, errSimTimeout
+		}
+
+		wait := time.Duration(-1)
+		if len(p.chunks) > 0 {
+			wait = p.chunks[0].at.Sub(now)
+		}
+		if !p.deadline.IsZero() && (wait < 0 || p.deadline.Sub(now) < wait) {
+			wait = p.deadline.Sub(now)
+		}
+		p.mtx.Unlock()
+
+		if wait < 0 {
+			<-p.notify
+			continue
+		}
+		timer := p.clock.NewTimer(wait)
+		select {
+		case <-p.notify:
+		case <-timer.C():
+		}
+		timer.Stop()
+	}
+}
+
+// simConn is one end of a virtual connection
+type simConn struct {
+	sim           *Simulation
+	local, remote simAddr
+	in, out       *simPipe
+	peer          *simConn
+
+	mtx           sync.Mutex
+	closed        bool
+	writeDeadline time.Time
+}
+
+func (c *simConn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: "sim", Source: c.local, Addr: c.remote, Err: err}
+}
+
+func (c *simConn) Read(b []byte) (int, error) {
+	n, err := c.in.read(b)
+	if err != nil && err != io.EOF {
+		err = c.opError("read", err)
+	}
+	return n, err
+}
+
+func (c *simConn) Write(b []byte) (int, error) {
+	c.mtx.Lock()
+	closed, deadline := c.closed, c.writeDeadline
+	c.mtx.Unlock()
+	if closed {
+		return 0, c.opError("write", errSimClosed)
+	}
+	if !deadline.IsZero() && !c.sim.clock.Now().Before(deadline) {
+		return 0, c.opError("write", errSimTimeout)
+	}
+	c.out.mtx.Lock()
+	err := c.out.err
+	c.out.mtx.Unlock()
+	if err == syscall.ECONNRESET {
+		return 0, c.opError("write", err)
+	}
+
+	c.sim.mtx.Lock()
+	delay := c.sim.delay(c.sim.link(c.local.host(), c.remote.host()))
+	c.sim.mtx.Unlock()
+
+	c.out.push(simChunk{data: append([]byte(nil), b...)}, delay)
+	return len(b), nil
+}
+
+// Close closes the connection, the remote end reads EOF once the data in flight arrived
+func (c *simConn) Close() error {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return c.opError("close", errSimClosed)
+	}
+	c.closed = true
+	c.mtx.Unlock()
+
+	c.sim.mtx.Lock()
+	delete(c.sim.conns, c)
+	delay := c.sim.link(c.local.host(), c.remote.host()).Latency
+	c.sim.mtx.Unlock()
+
+	c.in.fail(errSimClosed)
+	c.out.push(simChunk{eof: true}, delay)
+	return nil
+}
+
+// reset breaks both ends of the connection immediately, the caller holds the simulation's mutex
+func (c *simConn) reset() {
+	c.in.fail(syscall.ECONNRESET)
+	c.out.fail(syscall.ECONNRESET)
+	delete(c.sim.conns, c)
+	delete(c.sim.conns, c.peer)
+}
+
+func (c *simConn) LocalAddr() net.Addr  { return c.local }
+func (c *simConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *simConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *simConn) SetReadDeadline(t time.Time) error {
+	c.in.setDeadline(t)
+	return nil
+}
+
+func (c *simConn) SetWriteDeadline(t time.Time) error {
+	c.mtx.Lock()
+	c.writeDeadline = t
+	c.mtx.Unlock()
+	return nil
+}
+
+func (c *simConn) String() string {
+	return fmt.Sprintf("%s -> %s", c.local, c.remote)
+}
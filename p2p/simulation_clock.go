@@ -0,0 +1,170 @@
+package p2p
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// simEpoch is the time virtual clocks start at
+var simEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// simClock is the virtual Clock of a Simulation. Time stands still until the simulation
+// advances it, timers that are due on the way fire in the order of their deadlines
+type simClock struct {
+	mtx    sync.Mutex
+	now    time.Time
+	timers simTimers
+	seq    uint64 // orders timers with the same deadline by creation
+}
+
+func newSimClock() *simClock {
+	return &simClock{now: simEpoch}
+}
+
+func (c *simClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *simClock) Since(t time.Time) time.Duration { return c.Now().Sub(t) }
+func (c *simClock) Sleep(d time.Duration)           { <-c.After(d) }
+
+func (c *simClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *simClock) NewTimer(d time.Duration) Timer {
+	return c.schedule(d, 0, nil)
+}
+
+func (c *simClock) NewTicker(d time.Duration) Timer {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return c.schedule(d, d, nil)
+}
+
+func (c *simClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.schedule(d, 0, f)
+}
+
+// schedule creates a timer, timers that are due already fire right away
+func (c *simClock) schedule(d, period time.Duration, f func()) *simTimer {
+	t := &simTimer{clock: c, period: period, fn: f, index: -1}
+	if f == nil {
+		t.c = make(chan time.Time, 1)
+	}
+
+	c.mtx.Lock()
+	t.at = c.now.Add(d)
+	if d <= 0 {
+		now := c.now
+		c.mtx.Unlock()
+		t.fire(now)
+		return t
+	}
+	c.seq++
+	t.seq = c.seq
+	heap.Push(&c.timers, t)
+	c.mtx.Unlock()
+	return t
+}
+
+// advance moves the clock forward to the given time, firing the timers that are due
+func (c *simClock) advance(to time.Time) {
+	for {
+		c.mtx.Lock()
+		if len(c.timers) == 0 || c.timers[0].at.After(to) {
+			if to.After(c.now) {
+				c.now = to
+			}
+			c.mtx.Unlock()
+			return
+		}
+
+		t := c.timers[0]
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+			heap.Fix(&c.timers, 0)
+		} else {
+			heap.Pop(&c.timers)
+		}
+		now := c.now
+		c.mtx.Unlock()
+
+		t.fire(now)
+	}
+}
+
+// simTimer is a timer or ticker of a simClock
+type simTimer struct {
+	clock  *simClock
+	at     time.Time
+	seq    uint64
+	period time.Duration // tickers only
+	c      chan time.Time
+	fn     func()
+	index  int // position in the clock's timers, -1 if not scheduled
+}
+
+func (t *simTimer) C() <-chan time.Time { return t.c }
+
+func (t *simTimer) Stop() bool {
+	t.clock.mtx.Lock()
+	defer t.clock.mtx.Unlock()
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&t.clock.timers, t.index)
+	return true
+}
+
+// fire delivers the time like the time package, ticks are dropped if the receiver is behind
+func (t *simTimer) fire(now time.Time) {
+	if t.fn != nil {
+		go t.fn()
+		return
+	}
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// simTimers is a heap of timers ordered by their deadline
+type simTimers []*simTimer
+
+func (h simTimers) Len() int { return len(h) }
+
+func (h simTimers) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h simTimers) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *simTimers) Push(x interface{}) {
+	t := x.(*simTimer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *simTimers) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,331 @@
+package p2p
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSimHosts(n int) []string {
+	hosts := make([]string, n)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("10.0.%d.%d", i/250, i%250+1)
+	}
+	return hosts
+}
+
+// testSimNetworks starts a network on every host. The first seeds hosts are in the seed file,
+// which is written to the directory
+func testSimNetworks(t *testing.T, sim *Simulation, dir string, hosts []string, seeds int, modify func(*Configuration)) []*Network {
+	var list []string
+	for _, h := range hosts[:seeds] {
+		list = append(list, net.JoinHostPort(h, "8108"))
+	}
+	seedFile := filepath.Join(dir, "seed.txt")
+	if err := ioutil.WriteFile(seedFile, []byte(strings.Join(list, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	networks := make([]*Network, len(hosts))
+	for i, h := range hosts {
+		conf := DefaultP2PConfiguration()
+		conf.Network = NewNetworkID("simulation")
+		conf.NodeName = fmt.Sprintf("SimNode-%d", i)
+		conf.SeedURL = seedFile
+		conf.Special = ""
+		conf.ProtocolVersion = 11
+		if modify != nil {
+			modify(&conf)
+		}
+		sim.Configure(&conf, h)
+
+		n, err := NewNetwork(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+		networks[i] = n
+	}
+	return networks
+}
+
+func testSimStop(sim *Simulation, networks []*Network) {
+	for _, n := range networks {
+		n.Stop()
+	}
+	// wait for the peers and listeners to shut down
+	sim.Run(time.Minute)
+}
+
+// testSimGraph returns the hosts each host is connected to
+func testSimGraph(networks []*Network) map[string]map[string]bool {
+	graph := make(map[string]map[string]bool)
+	for _, n := range networks {
+		host := n.conf.BindIP
+		graph[host] = make(map[string]bool)
+		for _, p := range n.controller.peers.Slice() {
+			graph[host][p.Endpoint.IP] = true
+		}
+	}
+	return graph
+}
+
+// testSimComponents counts the connected components of the graph
+func testSimComponents(graph map[string]map[string]bool) int {
+	seen := make(map[string]bool)
+	components := 0
+	for start := range graph {
+		if seen[start] {
+			continue
+		}
+		components++
+		queue := []string{start}
+		seen[start] = true
+		for len(queue) > 0 {
+			host := queue[0]
+			queue = queue[1:]
+			for next := range graph[host] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	return components
+}
+
+// testSimConnected runs the simulation until the networks form a single component.
+// Rounds drop peers, so a single snapshot may catch a temporary split
+func testSimConnected(sim *Simulation, networks []*Network, limit time.Duration) bool {
+	start := sim.Now()
+	for testSimComponents(testSimGraph(networks)) != 1 {
+		if sim.Clock().Since(start) > limit {
+			return false
+		}
+		sim.Run(time.Minute)
+	}
+	return true
+}
+
+// testSimTakes runs f while the simulation runs and checks that it returns once the
+// virtual duration passed, not before
+func testSimTakes(t *testing.T, sim *Simulation, want time.Duration, f func()) {
+	t.Helper()
+	timers := func() int {
+		sim.clock.mtx.Lock()
+		defer sim.clock.mtx.Unlock()
+		return len(sim.clock.timers)
+	}
+
+	before := timers()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+
+	// the clock may only advance once f waits for it
+	for wait := time.Now(); timers() == before; time.Sleep(simYield) {
+		if time.Since(wait) > time.Second*10 {
+			t.Fatalf("does not wait for the clock")
+		}
+	}
+	sim.Run(want - time.Millisecond)
+	select {
+	case <-done:
+		t.Errorf("returned before %s", want)
+		return
+	case <-time.After(simYield * 10):
+	}
+
+	sim.Run(time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second * 10):
+		t.Fatalf("did not return after %s", want)
+	}
+}
+
+func TestSimulation_conn(t *testing.T) {
+	sim := NewSimulation(1)
+	sim.Default = Link{Latency: time.Millisecond * 50}
+
+	l, err := sim.Host("10.0.0.1").Listen(":8108")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := sim.Host("10.0.0.1").Listen("10.0.0.1:8108"); err == nil {
+		t.Errorf("listened to the same port twice")
+	}
+
+	client := sim.Host("10.0.0.2")
+	testSimTakes(t, sim, time.Millisecond*100, func() {
+		if _, err := client.Dial(Endpoint{IP: "10.0.0.1", Port: "9999"}, time.Second); err == nil {
+			t.Errorf("dialed a port without listener")
+		}
+	})
+
+	// dials take one round trip
+	var a net.Conn
+	testSimTakes(t, sim, time.Millisecond*100, func() {
+		a, err = client.Dial(Endpoint{IP: "10.0.0.1", Port: "8108"}, time.Second)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host, _, _ := net.SplitHostPort(b.RemoteAddr().String()); host != "10.0.0.2" {
+		t.Errorf("remote address = %s", b.RemoteAddr())
+	}
+
+	// data takes the latency to arrive
+	a.Write([]byte("hello"))
+	buf := make([]byte, 16)
+	var n int
+	testSimTakes(t, sim, time.Millisecond*50, func() {
+		n, err = b.Read(buf)
+	})
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Errorf("read = %q, %v", buf[:n], err)
+	}
+
+	b.SetReadDeadline(sim.Now().Add(time.Second))
+	testSimTakes(t, sim, time.Second, func() {
+		_, err = b.Read(buf)
+	})
+	if err == nil || !err.(net.Error).Timeout() {
+		t.Errorf("read past the deadline returned %v", err)
+	}
+	b.SetReadDeadline(time.Time{})
+
+	a.Write([]byte("bye"))
+	a.Close()
+	testSimTakes(t, sim, time.Millisecond*50, func() {
+		n, err = b.Read(buf)
+	})
+	if err != nil || string(buf[:n]) != "bye" {
+		t.Errorf("data in flight was lost: %q, %v", buf[:n], err)
+	}
+	if _, err := b.Read(buf); err != io.EOF {
+		t.Errorf("read after close = %v, want EOF", err)
+	}
+	b.Close()
+
+	// partitions reset connections and make dials time out
+	testSimTakes(t, sim, time.Millisecond*100, func() {
+		a, _ = client.Dial(Endpoint{IP: "10.0.0.1", Port: "8108"}, time.Second)
+	})
+	b, _ = l.Accept()
+	sim.Partition([]string{"10.0.0.1"}, []string{"10.0.0.2"})
+	if _, err := b.Read(buf); err == nil || isProtocolError(err) {
+		t.Errorf("read on a partitioned connection = %v", err)
+	}
+	testSimTakes(t, sim, time.Second, func() {
+		if _, err := client.Dial(Endpoint{IP: "10.0.0.1", Port: "8108"}, time.Second); err == nil {
+			t.Errorf("dialed across a partition")
+		}
+	})
+	a.Close()
+	b.Close()
+
+	sim.Heal()
+	testSimTakes(t, sim, time.Millisecond*100, func() {
+		if c, err := client.Dial(Endpoint{IP: "10.0.0.1", Port: "8108"}, time.Second); err != nil {
+			t.Errorf("unable to dial after healing: %v", err)
+		} else {
+			c.Close()
+		}
+	})
+}
+
+func TestSimulation_convergence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("long simulation")
+	}
+	dir, err := ioutil.TempDir("", "simulation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sim := NewSimulation(2)
+	sim.Step = time.Millisecond * 50 // coarse enough to simulate hours quickly
+	sim.Default = Link{Latency: time.Millisecond * 40, Jitter: time.Millisecond * 20, Loss: 0.01}
+
+	hosts := testSimHosts(100)
+	networks := testSimNetworks(t, sim, dir, hosts, 5, nil)
+	defer testSimStop(sim, networks)
+
+	if !testSimConnected(sim, networks, time.Minute*30) {
+		t.Errorf("network did not converge within 30 minutes")
+	}
+	for _, n := range networks {
+		if total := n.Total(); total < int(n.conf.MinReseed) {
+			t.Errorf("%s has only %d peers", n.conf.NodeName, total)
+		}
+	}
+}
+
+func TestSimulation_partitionRecovery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("long simulation")
+	}
+	dir, err := ioutil.TempDir("", "simulation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sim := NewSimulation(3)
+	sim.Step = time.Millisecond * 50 // coarse enough to simulate hours quickly
+	sim.Default = Link{Latency: time.Millisecond * 40}
+
+	hosts := testSimHosts(40)
+	left, right := hosts[:20], hosts[20:]
+	// both sides have seeds, so nodes that reseed can reach the other side
+	hosts = append(append([]string{}, left[:3]...), right[:3]...)
+	hosts = append(hosts, left[3:]...)
+	hosts = append(hosts, right[3:]...)
+	networks := testSimNetworks(t, sim, dir, hosts, 6, func(conf *Configuration) {
+		conf.TargetPeers = 8
+		conf.MaxPeers = 12
+		conf.DropTo = 6
+		conf.MinReseed = 3
+		conf.RoundTime = time.Minute * 5
+		conf.PeerReseedInterval = time.Minute * 10
+	})
+	defer testSimStop(sim, networks)
+
+	if !testSimConnected(sim, networks, time.Minute*30) {
+		t.Fatalf("network did not converge before the partition")
+	}
+
+	sim.Partition(left, right)
+	sim.Run(time.Minute * 30)
+	graph := testSimGraph(networks)
+	for _, h := range left {
+		for _, r := range right {
+			if graph[h][r] {
+				t.Errorf("%s is connected to %s across the partition", h, r)
+			}
+		}
+	}
+
+	sim.Heal()
+	if !testSimConnected(sim, networks, time.Hour) {
+		t.Errorf("network did not recover within an hour of healing the partition")
+	}
+}
@@ -0,0 +1,15 @@
+package p2p
+
+import (
+	"net"
+	"time"
+)
+
+// Transport creates the connections of a network. By default, networks dial and listen
+// on TCP. A Simulation provides virtual connections between networks in the same process
+type Transport interface {
+	// Dial connects to the endpoint, giving up after the timeout
+	Dial(ep Endpoint, timeout time.Duration) (net.Conn, error)
+	// Listen listens to the address (host:port), or a comma separated list of addresses
+	Listen(address string) (net.Listener, error)
+}
@@ -0,0 +1,261 @@
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ssdpAddress is the multicast address of UPnP discovery
+	ssdpAddress    = "239.255.255.250:1900"
+	upnpGateway    = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	upnpConnection = "WANIPConnection"
+	upnpPPP        = "WANPPPConnection"
+	// upnpPermanentOnly is the error code of gateways that only support leases without expiration
+	upnpPermanentOnly = "725"
+)
+
+// upnp is the WAN connection service of a UPnP internet gateway device
+type upnp struct {
+	control  string // url of the control endpoint
+	service  string // service type
+	internal string // address of this node in the gateway's network
+	client   *http.Client
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+// findService searches the device tree for a WAN connection service
+func (d *upnpDevice) findService() *upnpService {
+	for i, s := range d.Services {
+		if strings.Contains(s.ServiceType, upnpConnection) || strings.Contains(s.ServiceType, upnpPPP) {
+			return &d.Services[i]
+		}
+	}
+	for i := range d.Devices {
+		if s := d.Devices[i].findService(); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// discoverUPnP searches for an internet gateway device via SSDP at the given address
+func discoverUPnP(ssdp string, timeout time.Duration) (*upnp, error) {
+	addr, err := net.ResolveUDPAddr("udp4", ssdp)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddress + "\r\n" +
+		"ST: " + upnpGateway + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(search), addr); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 2048)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, fmt.Errorf("no gateway responded: %v", err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		location := resp.Header.Get("Location")
+		if location == "" {
+			continue
+		}
+		if gw, err := newUPnP(location, time.Until(deadline)); err == nil {
+			return gw, nil
+		}
+	}
+}
+
+// newUPnP reads the device description at the location
+func newUPnP(location string, timeout time.Duration) (*upnp, error) {
+	u := new(upnp)
+	u.client = &http.Client{Timeout: timeout}
+
+	resp, err := u.client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	root := new(upnpRoot)
+	if err := xml.NewDecoder(resp.Body).Decode(root); err != nil {
+		return nil, fmt.Errorf("unable to parse device description: %v", err)
+	}
+	service := root.Device.findService()
+	if service == nil {
+		return nil, fmt.Errorf("%s is not an internet gateway", location)
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return nil, err
+		}
+	}
+	control, err := base.Parse(service.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+	u.control = control.String()
+	u.service = service.ServiceType
+
+	// the gateway needs our address in its network
+	local, err := net.Dial("udp4", control.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.internal = local.LocalAddr().(*net.UDPAddr).IP.String()
+	local.Close()
+
+	return u, nil
+}
+
+func (u *upnp) String() string {
+	return fmt.Sprintf("upnp gateway %s", u.control)
+}
+
+// call invokes a SOAP action of the service and returns the raw response
+func (u *upnp) call(action string, args [][2]string) ([]byte, error) {
+	body := new(bytes.Buffer)
+	body.WriteString(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(body, `<u:%s xmlns:u="%s">`, action, u.service)
+	for _, arg := range args {
+		fmt.Fprintf(body, "<%s>", arg[0])
+		xml.EscapeText(body, []byte(arg[1]))
+		fmt.Fprintf(body, "</%s>", arg[0])
+	}
+	fmt.Fprintf(body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	req, err := http.NewRequest("POST", u.control, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, u.service, action))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s failed with status %d, error code %s", action, resp.StatusCode, soapValue(data, "errorCode"))
+	}
+	return data, nil
+}
+
+// soapValue returns the text of the first element with the given name
+func soapValue(data []byte, name string) string {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == name {
+			var value string
+			if dec.DecodeElement(&value, &start) != nil {
+				return ""
+			}
+			return strings.TrimSpace(value)
+		}
+	}
+}
+
+// ExternalIP asks the gateway for its public address
+func (u *upnp) ExternalIP() (net.IP, error) {
+	resp, err := u.call("GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, err
+	}
+	raw := soapValue(resp, "NewExternalIPAddress")
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return nil, fmt.Errorf("gateway returned invalid address %q", raw)
+	}
+	return ip, nil
+}
+
+// AddPortMapping asks the gateway to forward the external TCP port. Gateways that only
+// support permanent mappings are asked for one of those instead
+func (u *upnp) AddPortMapping(internal, external int, lifetime time.Duration) (int, time.Duration, error) {
+	args := func(lease time.Duration) [][2]string {
+		return [][2]string{
+			{"NewRemoteHost", ""},
+			{"NewExternalPort", strconv.Itoa(external)},
+			{"NewProtocol", "TCP"},
+			{"NewInternalPort", strconv.Itoa(internal)},
+			{"NewInternalClient", u.internal},
+			{"NewEnabled", "1"},
+			{"NewPortMappingDescription", "factomd p2p"},
+			{"NewLeaseDuration", strconv.Itoa(int(lease / time.Second))},
+		}
+	}
+
+	_, err := u.call("AddPortMapping", args(lifetime))
+	if err != nil && strings.HasSuffix(err.Error(), upnpPermanentOnly) {
+		lifetime = 0
+		_, err = u.call("AddPortMapping", args(lifetime))
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return external, lifetime, nil
+}
+
+// DeletePortMapping removes the mapping of the external port
+func (u *upnp) DeletePortMapping(internal, external int) error {
+	_, err := u.call("DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(external)},
+		{"NewProtocol", "TCP"},
+	})
+	return err
+}
@@ -2,8 +2,10 @@ package p2p
 
 import (
 	"bufio"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
@@ -24,13 +26,20 @@ func IP2Location(addr string) (uint32, error) {
 		addr = ipAddress[0]
 		ip = net.ParseIP(addr)
 	}
-	if len(ip) == 16 { // If we got back an IP6 (16 byte) address, use the last 4 byte
-		ip = ip[12:]
-	}
+	ip = ipLocationBytes(ip)
 
 	return binary.BigEndian.Uint32(ip), nil
 }
 
+// ipLocationBytes returns the four bytes of an IPv4 address or the first four bytes
+// of the routing prefix of an IPv6 address
+func ipLocationBytes(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip[:4]
+}
+
 // IP2LocationQuick converts an ip address to a uint32 without a hostmask lookup
 func IP2LocationQuick(addr string) uint32 {
 	// Split the IPv4 octets
@@ -39,9 +48,7 @@ func IP2LocationQuick(addr string) uint32 {
 		return 0
 	}
 
-	if len(ip) == 16 { // If we got back an IP6 (16 byte) address, use the last 4 byte
-		ip = ip[12:]
-	}
+	ip = ipLocationBytes(ip)
 
 	return binary.BigEndian.Uint32(ip)
 }
@@ -85,3 +92,34 @@ func parseSpecial(raw string) ([]Endpoint, error) {
 	}
 	return eps, nil
 }
+
+// splitAddresses splits a comma separated list of addresses, skipping empty entries
+func splitAddresses(raw string) []string {
+	var addrs []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			addrs = append(addrs, normalizeHost(item))
+		}
+	}
+	return addrs
+}
+
+// parsePublicKeys parses a comma separated list of hex encoded public keys
+func parsePublicKeys(raw string) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, err := hex.DecodeString(item)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s has the wrong length", item)
+		}
+		keys[string(key)] = true
+	}
+	return keys, nil
+}
@@ -24,6 +24,8 @@ func TestIP2Location(t *testing.T) {
 		{"max ip", args{"255.255.255.255"}, 4294967295, false},
 		{"invalid hostname", args{"#"}, 0, true},
 		{"invalid ip", args{"256.0.0.0"}, 0, true},
+		{"ipv4 mapped", args{"::ffff:127.0.0.1"}, 2130706433, false},
+		{"ipv6 prefix", args{"2001:db8::1"}, 0x20010db8, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -129,6 +131,8 @@ func Test_parseSpecial(t *testing.T) {
 		{"just address", args{"127.0.0.1"}, nil, true},
 		{"hostname w/o port", args{"domain.com"}, nil, true},
 		{"hostname w port", args{"domain.com:80"}, []Endpoint{{"domain.com", "80"}}, false},
+		{"ipv6", args{"[::1]:8108, [2001:DB8::1]:80,127.0.0.1:80"}, []Endpoint{{"::1", "8108"}, {"2001:db8::1", "80"}, {"127.0.0.1", "80"}}, false},
+		{"ipv6 w/o brackets", args{"::1:8108"}, nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
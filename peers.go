@@ -0,0 +1,191 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+)
+
+const peersUsage = `Usage: factomd peers [flags] <command> [arguments]
+
+Manage the p2p network of a running factomd node through its debug API.
+Commands that change the network require the node to have an rpc user.
+
+Commands:
+  list                         the connected peers and the state of the network
+  connect <ip:port>            connect to a peer
+  disconnect <peer>            disconnect a peer by its hash
+  ban <peer|ip|ip:port> [sec]  ban the address of a peer, an ip address or an endpoint
+  unban <ip|ip:port>           lift a ban
+  bans                         the active bans
+  special <ip:port,...>        replace the special peers, "" to remove all
+
+Flags:
+`
+
+// runPeers implements the "peers" subcommand and returns the exit code
+func runPeers(args []string) int {
+	fs := flag.NewFlagSet("peers", flag.ContinueOnError)
+	server := fs.String("s", "localhost:8088", "The host:port of the factomd API")
+	user := fs.String("rpcuser", "", "Username of the factomd API")
+	pass := fs.String("rpcpass", "", "Password of the factomd API")
+	useTLS := fs.Bool("tls", false, "Use https to connect to the factomd API")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, peersUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	method, params, err := peersRequest(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		return 2
+	}
+
+	scheme := "http"
+	if *useTLS {
+		scheme = "https"
+	}
+	result, err := callDebug(fmt.Sprintf("%s://%s/debug", scheme, *server), *user, *pass, method, params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var out bytes.Buffer
+	json.Indent(&out, result, "", "  ")
+	fmt.Println(out.String())
+	return 0
+}
+
+// peersRequest turns the arguments of the subcommand into the debug API method and its params
+func peersRequest(args []string) (string, interface{}, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("no command")
+	}
+	command, args := args[0], args[1:]
+
+	want := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("wrong number of arguments for %s", command)
+		}
+		return nil
+	}
+
+	switch command {
+	case "list":
+		return "peers", nil, want(0, 0)
+	case "bans":
+		return "peer-bans", nil, want(0, 0)
+	case "connect":
+		if err := want(1, 1); err != nil {
+			return "", nil, err
+		}
+		return "peer-connect", wsapi.PeerConnectRequest{Address: args[0]}, nil
+	case "disconnect":
+		if err := want(1, 1); err != nil {
+			return "", nil, err
+		}
+		return "peer-disconnect", wsapi.PeerDisconnectRequest{Peer: args[0]}, nil
+	case "ban":
+		if err := want(1, 2); err != nil {
+			return "", nil, err
+		}
+		req := wsapi.PeerBanRequest{}
+		if isAddress(args[0]) {
+			req.Address = args[0]
+		} else {
+			req.Peer = args[0]
+		}
+		if len(args) == 2 {
+			sec, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || sec < 0 {
+				return "", nil, fmt.Errorf("invalid ban duration %s", args[1])
+			}
+			req.Duration = sec
+		}
+		return "peer-ban", req, nil
+	case "unban":
+		if err := want(1, 1); err != nil {
+			return "", nil, err
+		}
+		return "peer-unban", wsapi.PeerUnbanRequest{Address: args[0]}, nil
+	case "special":
+		if err := want(1, 1); err != nil {
+			return "", nil, err
+		}
+		return "special-peers-set", wsapi.SpecialPeersRequest{Peers: args[0]}, nil
+	}
+	return "", nil, fmt.Errorf("unknown command %s", command)
+}
+
+// isAddress checks if s is an ip address or an endpoint rather than the hash of a peer
+func isAddress(s string) bool {
+	if strings.Contains(s, " ") {
+		return false
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	return net.ParseIP(strings.Trim(s, "[]")) != nil
+}
+
+// callDebug sends the request to the debug API and returns the raw result
+func callDebug(url, user, pass, method string, params interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(primitives.NewJSON2Request(method, 0, params))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized, check -rpcuser and -rpcpass")
+	}
+
+	var r struct {
+		Result json.RawMessage       `json:"result"`
+		Error  *primitives.JSONError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("invalid response (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if r.Error != nil {
+		return nil, fmt.Errorf("%s (%d): %v", r.Error.Message, r.Error.Code, r.Error.Data)
+	}
+	return r.Result, nil
+}
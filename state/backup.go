@@ -0,0 +1,174 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/database/backup"
+	"github.com/FactomProject/factomd/database/integrityCheck"
+)
+
+// Backup writes a consistent snapshot of the database and the matching fast boot save state to a directory or a
+// tarball while the node keeps running. The save state is read under the lock of the state saver, which only
+// writes save states older than the database head, so the node fast boots from it and loads the newer blocks from
+// the database. Bolt copies its file for the snapshot, so writes that have to grow the database only wait for the copy.
+func Backup(s *State, path string, progress func(records int)) (*backup.Manifest, error) {
+	dbo, err := backupDB(s)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(backup.Manifest)
+	m.Network = s.Network
+	m.DBType = s.DBType
+
+	sss := &s.StateSaverStruct
+	sss.Mutex.Lock()
+	var raw []byte
+	if sss.FastBoot {
+		data, err := ioutil.ReadFile(NetworkIDToFilename(s.Network, sss.FastBootLocation))
+		if err != nil && !os.IsNotExist(err) {
+			sss.Mutex.Unlock()
+			return nil, err
+		}
+		raw = data
+	}
+	snap, err := dbo.Snapshot()
+	sss.Mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Release()
+
+	if raw != nil {
+		_, last, err := parseSnapshotState(s, raw)
+		if err != nil {
+			return nil, fmt.Errorf("fast boot save state: %v", err)
+		}
+		m.StateHeight = last.DirectoryBlock.GetDatabaseHeight()
+	}
+
+	if err := backup.Write(path, m, snap, raw, progress); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StartBackup runs a backup to the path in the background, only one backup runs at a time.
+// GetBackupStatus returns its progress.
+func (s *State) StartBackup(path string) error {
+	s.backupMutex.Lock()
+	defer s.backupMutex.Unlock()
+	if s.backupStatus.Running {
+		return fmt.Errorf("a backup to %s is running", s.backupStatus.Path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if s.DB == nil {
+		return fmt.Errorf("database is not open")
+	}
+
+	s.backupStatus = backup.Status{Running: true, Path: path, Started: time.Now()}
+	go func() {
+		m, err := Backup(s, path, func(records int) {
+			s.backupMutex.Lock()
+			s.backupStatus.Records = records
+			s.backupMutex.Unlock()
+		})
+
+		s.backupMutex.Lock()
+		defer s.backupMutex.Unlock()
+		s.backupStatus.Running = false
+		s.backupStatus.Finished = time.Now()
+		if err != nil {
+			s.backupStatus.Error = err.Error()
+			s.LogPrintf("backup", "Backup to %s failed: %v", path, err)
+			return
+		}
+		s.backupStatus.Records = m.Records
+		s.backupStatus.Height = m.Height
+		s.LogPrintf("backup", "Backed up %d records up to height %d to %s", m.Records, m.Height, path)
+	}()
+	return nil
+}
+
+// GetBackupStatus returns the progress of the running backup, or the result of the last one
+func (s *State) GetBackupStatus() backup.Status {
+	s.backupMutex.Lock()
+	defer s.backupMutex.Unlock()
+	return s.backupStatus
+}
+
+// RestoreBackup verifies a backup against its manifest and writes it to the empty database and the fast boot file,
+// then checks the integrity of the database. Progress and the problems found are written to the log.
+func RestoreBackup(s *State, path string, log io.Writer) (*backup.Manifest, *integrityCheck.Report, error) {
+	dbo, err := backupDB(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	head, err := dbo.FetchDBlockHead()
+	if err != nil {
+		return nil, nil, err
+	}
+	if head != nil {
+		return nil, nil, fmt.Errorf("database is not empty, it has blocks up to %d", head.GetDatabaseHeight())
+	}
+
+	m, err := backup.ReadManifest(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Network != s.Network {
+		return nil, nil, fmt.Errorf("backup is of the %s network, not %s", m.Network, s.Network)
+	}
+	// the records of a LevelDB have no buckets and those of the other databases can't be written as LevelDB keys
+	if m.DBType != s.DBType {
+		return nil, nil, fmt.Errorf("backup is of a %s database, not %s", m.DBType, s.DBType)
+	}
+
+	raw, err := backup.Restore(path, m, dbo, func(records int) {
+		fmt.Fprintf(log, "Restored %d/%d records\n", records, m.Records)
+	})
+	if err != nil {
+		return m, nil, fmt.Errorf("%v, the database has to be deleted before trying again", err)
+	}
+
+	head, err = dbo.FetchDBlockHead()
+	if err != nil {
+		return m, nil, err
+	}
+	if head == nil || head.GetDatabaseHeight() != m.Height || head.GetKeyMR().String() != m.KeyMR {
+		return m, nil, fmt.Errorf("directory block head does not match the manifest")
+	}
+
+	if raw != nil {
+		if _, _, err := parseSnapshotState(s, raw); err != nil {
+			return m, nil, fmt.Errorf("save state: %v", err)
+		}
+		fastboot := NetworkIDToFilename(s.Network, s.StateSaverStruct.FastBootLocation)
+		if err := ioutil.WriteFile(fastboot, raw, 0644); err != nil {
+			return m, nil, err
+		}
+	}
+
+	r, err := integrityCheck.CheckDatabase(dbo, log)
+	return m, r, err
+}
+
+// backupDB returns the database of the state with the methods to read and write all its records
+func backupDB(s *State) (interfaces.DBOverlay, error) {
+	dbo, ok := s.DB.(interfaces.DBOverlay)
+	if !ok {
+		return nil, fmt.Errorf("database does not support backups")
+	}
+	return dbo, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := testSnapshotState(t, dir, true)
+	source.DBType = "Map"
+	source.StateSaverStruct.FastBoot = true
+	saved := testSaveState(t, source, 5, nil)
+
+	for _, name := range []string{"backup", "backup.tar.gz"} {
+		path := filepath.Join(dir, name)
+		m, err := Backup(source, path, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if m.Height != uint32(testHelper.BlockCount-1) || m.StateHeight != 5 || m.Records == 0 {
+			t.Errorf("%s: height = %d, state height = %d, %d records", name, m.Height, m.StateHeight, m.Records)
+		}
+		if _, err := Backup(source, path, nil); err == nil {
+			t.Errorf("%s: overwrote a backup", name)
+		}
+
+		target := testSnapshotState(t, dir, false)
+		target.DBType = "Map"
+		_, r, err := RestoreBackup(target, path, ioutil.Discard)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !r.OK() || r.BlockSets != testHelper.BlockCount {
+			t.Errorf("%s: %d sets of blocks, problems %v", name, r.BlockSets, r.Problems)
+		}
+		head, err := target.DB.FetchDBlockHead()
+		if err != nil || head == nil || head.GetKeyMR().String() != m.KeyMR {
+			t.Errorf("%s: head %v, %v", name, head, err)
+		}
+		restored, err := ioutil.ReadFile(NetworkIDToFilename(target.Network, target.StateSaverStruct.FastBootLocation))
+		if err != nil || !bytes.Equal(restored, saved) {
+			t.Errorf("%s: save state was not restored: %v", name, err)
+		}
+
+		if _, _, err := RestoreBackup(target, path, ioutil.Discard); err == nil {
+			t.Errorf("%s: restored into a database that is not empty", name)
+		}
+		other := testSnapshotState(t, dir, false)
+		other.DBType = "LDB"
+		if _, _, err := RestoreBackup(other, path, ioutil.Discard); err == nil {
+			t.Errorf("%s: restored into another type of database", name)
+		}
+	}
+}
+
+func TestStartBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := testSnapshotState(t, dir, true)
+	s.DBType = "Map"
+	path := filepath.Join(dir, "backup.tgz")
+
+	if status := s.GetBackupStatus(); status.Running || status.Path != "" {
+		t.Errorf("status before a backup = %+v", status)
+	}
+	if err := s.StartBackup(path); err != nil {
+		t.Fatal(err)
+	}
+	status := s.GetBackupStatus()
+	for i := 0; status.Running && i < 100; i++ {
+		time.Sleep(50 * time.Millisecond)
+		status = s.GetBackupStatus()
+	}
+	if status.Running || status.Error != "" || status.Height != uint32(testHelper.BlockCount-1) || status.Records == 0 {
+		t.Errorf("status = %+v", status)
+	}
+	if err := s.StartBackup(path); err == nil {
+		t.Errorf("started a backup to an existing path")
+	}
+}
@@ -1582,6 +1582,12 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 	list.State.NumFCTTrans += len(d.FactoidBlock.GetTransactions()) - 1
 
 	list.SavedHeight = uint32(dbheight)
+	if list.State.BalanceCheckpoints && uint32(dbheight+1)%databaseOverlay.BalanceCheckpointInterval == 0 {
+		go list.State.UpdateBalanceCheckpoints()
+	}
+	if list.State.PruneDepth > 0 && uint32(dbheight+1)%databaseOverlay.PruneInterval == 0 {
+		go list.State.PruneEntries()
+	}
 	list.State.Saving = false
 	progress = true
 	d.ReadyToSave = false
@@ -222,10 +222,10 @@ func (es *EntrySync) SyncHeight() {
 
 // extract eblocks from the dblock
 func (es *EntrySync) syncDBlock(db interfaces.IDirectoryBlock) {
-	eblocks := db.GetEntryHashes()[3:] // skip f/c/a-block
+	eblocks := db.GetDBEntries()[3:] // skip f/c/a-block
 	if len(eblocks) > 0 {
-		for _, keymr := range eblocks {
-			for !es.syncEBlock(db.GetDatabaseHeight(), keymr, db.GetTimestamp()) {
+		for _, eb := range eblocks {
+			for !es.syncEBlock(db.GetDatabaseHeight(), eb.GetChainID(), eb.GetKeyMR(), db.GetTimestamp()) {
 				time.Sleep(time.Second)
 			}
 		}
@@ -241,15 +241,22 @@ func (es *EntrySync) syncNoEBlock(height uint32) {
 	es.eblocks <- ebsync
 }
 
-// process a single eblock, will call syncEntryHash() on each entry, and add an eblock to the queue
-func (es *EntrySync) syncEBlock(height uint32, keymr interfaces.IHash, ts interfaces.Timestamp) bool {
+// process a single eblock, will call syncEntryHash() on each entry, and add an eblock to the queue.
+// the entries of chains that are not kept by a pruned node are skipped
+func (es *EntrySync) syncEBlock(height uint32, chainID interfaces.IHash, keymr interfaces.IHash, ts interfaces.Timestamp) bool {
+	kept := es.s.DB.IsChainKept(chainID)
 	eblock, err := es.s.DB.FetchEBlock(keymr)
 	if err != nil { // database corrupt
 		panic(err)
 	}
 
 	if eblock == nil {
-		return false
+		if kept {
+			return false
+		}
+		// the eblock of a chain that is not kept has been pruned
+		es.syncNoEBlock(height)
+		return true
 	}
 
 	ebsync := new(entrySyncEBlock)
@@ -266,7 +273,7 @@ func (es *EntrySync) syncEBlock(height uint32, keymr interfaces.IHash, ts interf
 		update.Timestamp = ts
 		es.s.UpdateEntryHash <- update
 
-		if es.has(entryHash) {
+		if !kept || es.has(entryHash) {
 			continue
 		}
 		es.syncEntryHash(entryHash)
@@ -8,7 +8,10 @@ import (
 	"testing"
 
 	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 func TestGenerateGenesisBlocks(t *testing.T) {
@@ -27,3 +30,46 @@ func TestGenerateGenesisBlocks(t *testing.T) {
 		t.Errorf("Invalid DBlock")
 	}
 }
+
+func TestLoadDatabase_pruned(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestState()
+	dbo := s.DB.(interfaces.DBOverlay)
+	dbo.SetPruning(3, nil)
+	if pruned, err := dbo.PruneEntries(); err != nil || pruned == 0 {
+		t.Fatalf("PruneEntries() = %d, %v", pruned, err)
+	}
+	for len(s.MsgQueue()) > 0 {
+		<-s.MsgQueue()
+	}
+
+	// restart on the pruned database
+	start := s.GetDBHeightComplete() + 1
+	LoadDatabase(s)
+	if s.InMsgQueue().Length() != 0 {
+		t.Errorf("genesis generated for a pruned database")
+	}
+
+	head := uint32(testHelper.BlockCount - 1)
+	var pruned int
+	for height := start; height <= head; height++ {
+		if len(s.MsgQueue()) == 0 {
+			t.Fatalf("height %d not loaded", height)
+		}
+		dbstate := (<-s.MsgQueue()).(*messages.DBStateMsg)
+		if dbstate.DirectoryBlock.GetDatabaseHeight() != height {
+			t.Fatalf("loaded height %d, want %d", dbstate.DirectoryBlock.GetDatabaseHeight(), height)
+		}
+		if dbstate.IsLast != (height == head) {
+			t.Errorf("height %d IsLast = %v", height, dbstate.IsLast)
+		}
+		if dbstate.HasPrunedEBlocks(s.DB) {
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		t.Errorf("no pruned heights loaded")
+	}
+	if len(s.MsgQueue()) != 0 {
+		t.Errorf("%d DBStates loaded past the head", len(s.MsgQueue()))
+	}
+}
@@ -174,6 +174,9 @@ func (s *State) uploadDBState(sequence uint32) error {
 				return fmt.Errorf("msg is nil")
 			}
 			d := msg.(*messages.DBStateMsg)
+			if d.HasPrunedEBlocks(s.DB) {
+				return fmt.Errorf("entry blocks at height %d are pruned", base+i)
+			}
 			//fmt.Printf("Uploading DBState %d, Sigs: %d\n", d.DirectoryBlock.GetDatabaseHeight(), len(d.SignatureList.List))
 			block := NewWholeBlock()
 			block.DBlock = d.DirectoryBlock
@@ -40,6 +40,7 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "LogPath", state.LogPath)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "LdbPath", state.LdbPath)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "BoltDBPath", state.BoltDBPath)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "BadgerDBPath", state.BadgerDBPath)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "LogLevel", state.LogLevel)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ConsoleLogLevel", state.ConsoleLogLevel)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "NodeMode", state.NodeMode)
@@ -47,6 +48,11 @@ func PrintState(state *State) {
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "CloneDBType", state.CloneDBType)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ExportData", state.ExportData)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "ExportDataSubpath", state.ExportDataSubpath)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "ExtIDIndex", state.ExtIDIndex)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "AddressHistoryIndex", state.AddressHistoryIndex)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "BalanceCheckpoints", state.BalanceCheckpoints)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "PruneDepth", state.PruneDepth)
+	str = fmt.Sprintf("%s %35s = %+v\n", str, "PruneKeepChains", state.PruneKeepChains)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "LocalServerPrivKey", state.LocalServerPrivKey)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "DirectoryBlockInSeconds", state.DirectoryBlockInSeconds)
 	str = fmt.Sprintf("%s %35s = %+v\n", str, "PortNumber", state.PortNumber)
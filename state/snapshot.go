@@ -0,0 +1,746 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/FactomProject/factomd/common/adminBlock"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// A snapshot is a portable copy of a node's chain: every block and entry up to the database head,
+// and the save state the node fast boots from. The file starts with a header and a manifest of the
+// directory block KeyMRs, so the whole file can be verified against the trusted KeyMR of its highest
+// directory block before anything is written to the database.
+//
+// The records after the header each have a type (1 byte), a length (uint32) and the data:
+//   manifest, state, then for every height: dblock, ablock, ecblock, fblock, eblocks, entries, and end
+
+// SnapshotVersion is the version of the snapshot format
+const SnapshotVersion = 1
+
+const maxSnapshotRecord = 256 << 20
+
+var snapshotMagic = []byte("FCTSNAP\x00")
+
+const (
+	snapshotManifest byte = iota + 1
+	snapshotState
+	snapshotDBlock
+	snapshotABlock
+	snapshotECBlock
+	snapshotFBlock
+	snapshotEBlock
+	snapshotEntry
+	snapshotEnd
+)
+
+// SnapshotManifest describes the content of a snapshot
+type SnapshotManifest struct {
+	Version          uint32
+	NetworkID        uint32
+	SaveStateVersion uint32
+
+	Height      uint32             // Height of the highest directory block
+	StateHeight uint32             // Height of the save state
+	KeyMRs      []interfaces.IHash // Directory block KeyMRs from 0 to Height
+	BlocksRoot  interfaces.IHash   // Merkle root of the KeyMRs
+
+	FactoidBalanceHash interfaces.IHash // Hash of the factoid balances at StateHeight
+	ECBalanceHash      interfaces.IHash // Hash of the entry credit balances at StateHeight
+	AuthorityRoot      interfaces.IHash // Merkle root of the authority set at StateHeight
+	StateHash          interfaces.IHash // Hash of the save state
+}
+
+// Root is the merkle root of all the hashes of the manifest
+func (m *SnapshotManifest) Root() interfaces.IHash {
+	return primitives.ComputeMerkleRoot([]interfaces.IHash{m.BlocksRoot, m.FactoidBalanceHash, m.ECBalanceHash, m.AuthorityRoot, m.StateHash})
+}
+
+func (m *SnapshotManifest) MarshalBinary() ([]byte, error) {
+	buf := primitives.NewBuffer(nil)
+	for _, i := range []uint32{m.Version, m.NetworkID, m.SaveStateVersion, m.Height, m.StateHeight} {
+		if err := buf.PushUInt32(i); err != nil {
+			return nil, err
+		}
+	}
+	if err := buf.PushVarInt(uint64(len(m.KeyMRs))); err != nil {
+		return nil, err
+	}
+	for _, h := range append(m.KeyMRs, m.BlocksRoot, m.FactoidBalanceHash, m.ECBalanceHash, m.AuthorityRoot, m.StateHash) {
+		if err := buf.PushIHash(h); err != nil {
+			return nil, err
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
+func (m *SnapshotManifest) UnmarshalBinary(data []byte) (err error) {
+	buf := primitives.NewBuffer(data)
+	for _, i := range []*uint32{&m.Version, &m.NetworkID, &m.SaveStateVersion, &m.Height, &m.StateHeight} {
+		if *i, err = buf.PopUInt32(); err != nil {
+			return err
+		}
+	}
+	count, err := buf.PopVarInt()
+	if err != nil {
+		return err
+	}
+	if count != uint64(m.Height)+1 || count*32 > uint64(buf.Len()) {
+		return fmt.Errorf("manifest has %d KeyMRs for height %d", count, m.Height)
+	}
+	m.KeyMRs = make([]interfaces.IHash, count)
+	for i := range m.KeyMRs {
+		if m.KeyMRs[i], err = buf.PopIHash(); err != nil {
+			return err
+		}
+	}
+	for _, h := range []*interfaces.IHash{&m.BlocksRoot, &m.FactoidBalanceHash, &m.ECBalanceHash, &m.AuthorityRoot, &m.StateHash} {
+		if *h, err = buf.PopIHash(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotStateHashes sets the hashes of the save state in the manifest
+func snapshotStateHashes(m *SnapshotManifest, ss *SaveState) error {
+	var auths []interfaces.IHash
+	for _, a := range ss.IdentityControl.GetSortedAuthorities() {
+		data, err := a.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		auths = append(auths, primitives.Sha(data))
+	}
+	m.FactoidBalanceHash = GetMapHash(ss.FactoidBalancesP)
+	m.ECBalanceHash = GetMapHash(ss.ECBalancesP)
+	m.AuthorityRoot = primitives.ComputeMerkleRoot(auths)
+	return nil
+}
+
+// parseSnapshotState reads a fast boot file and returns the list and the save state it restores
+func parseSnapshotState(s *State, data []byte) (*DBStateList, *DBState, error) {
+	h := primitives.NewZeroHash()
+	rest, err := h.UnmarshalBinaryData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !primitives.Sha(rest).IsSameAs(h) {
+		return nil, nil, fmt.Errorf("save state does not match its hash")
+	}
+
+	list := &DBStateList{State: s}
+	if err := list.UnmarshalBinary(rest); err != nil {
+		return nil, nil, err
+	}
+	for i := len(list.DBStates) - 1; i >= 0; i-- {
+		if list.DBStates[i].SaveStruct != nil {
+			return list, list.DBStates[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("save state is empty")
+}
+
+type snapshotWriter struct {
+	w *bufio.Writer
+}
+
+func (sw *snapshotWriter) write(typ byte, data []byte) error {
+	var head [5]byte
+	head[0] = typ
+	binary.BigEndian.PutUint32(head[1:], uint32(len(data)))
+	if _, err := sw.w.Write(head[:]); err != nil {
+		return err
+	}
+	_, err := sw.w.Write(data)
+	return err
+}
+
+func (sw *snapshotWriter) writeBlock(typ byte, block interfaces.BinaryMarshallable) error {
+	data, err := block.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return sw.write(typ, data)
+}
+
+type snapshotReader struct {
+	r *bufio.Reader
+}
+
+func (sr *snapshotReader) read() (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(sr.r, head[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(head[1:])
+	if size > maxSnapshotRecord {
+		return 0, nil, fmt.Errorf("record of %d bytes is too large", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(sr.r, data); err != nil {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return head[0], data, nil
+}
+
+// openSnapshot reads the header, manifest and state of a snapshot
+func openSnapshot(filename string) (*os.File, *snapshotReader, *SnapshotManifest, []byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	sr := &snapshotReader{r: bufio.NewReaderSize(f, 1<<20)}
+
+	fail := func(err error) (*os.File, *snapshotReader, *SnapshotManifest, []byte, error) {
+		f.Close()
+		return nil, nil, nil, nil, err
+	}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(sr.r, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+		return fail(fmt.Errorf("%s is not a snapshot", filename))
+	}
+
+	typ, data, err := sr.read()
+	if err != nil {
+		return fail(err)
+	}
+	if typ != snapshotManifest {
+		return fail(fmt.Errorf("snapshot does not start with a manifest"))
+	}
+	m := new(SnapshotManifest)
+	if err := m.UnmarshalBinary(data); err != nil {
+		return fail(fmt.Errorf("invalid manifest: %v", err))
+	}
+	if m.Version != SnapshotVersion {
+		return fail(fmt.Errorf("snapshot version %d is not supported", m.Version))
+	}
+
+	typ, state, err := sr.read()
+	if err != nil {
+		return fail(err)
+	}
+	if typ != snapshotState {
+		return fail(fmt.Errorf("snapshot has no save state"))
+	}
+	return f, sr, m, state, nil
+}
+
+// ExportSnapshot writes all blocks and entries of the database and the node's fast boot
+// save state to a snapshot file. The save state has to be older than the database head.
+func ExportSnapshot(s *State, filename string) (*SnapshotManifest, error) {
+	// a snapshot has the entries of all chains
+	pruned, err := s.DB.FetchPrunedHeight()
+	if err != nil {
+		return nil, err
+	}
+	if pruned > 0 {
+		return nil, fmt.Errorf("database is pruned below height %d, a snapshot can only be exported from a node that keeps all entries", pruned)
+	}
+
+	fastboot := NetworkIDToFilename(s.Network, s.StateSaverStruct.FastBootLocation)
+	raw, err := ioutil.ReadFile(fastboot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the save state, a node saves one every %d blocks with fast boot enabled: %v", s.FastSaveRate, err)
+	}
+	_, last, err := parseSnapshotState(s, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fastboot, err)
+	}
+
+	head, err := s.DB.FetchDBlockHead()
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, fmt.Errorf("database is empty")
+	}
+
+	m := new(SnapshotManifest)
+	m.Version = SnapshotVersion
+	m.NetworkID = s.GetNetworkID()
+	m.SaveStateVersion = constants.SaveStateVersion
+	m.Height = head.GetDatabaseHeight()
+	m.StateHeight = last.DirectoryBlock.GetDatabaseHeight()
+	if m.StateHeight >= m.Height {
+		return nil, fmt.Errorf("save state at height %d is not older than the database head %d", m.StateHeight, m.Height)
+	}
+
+	for h := uint32(0); h <= m.Height; h++ {
+		keymr, err := s.DB.FetchDBKeyMRByHeight(h)
+		if err != nil {
+			return nil, err
+		}
+		if keymr == nil {
+			return nil, fmt.Errorf("directory block %d is missing", h)
+		}
+		m.KeyMRs = append(m.KeyMRs, keymr)
+	}
+	m.BlocksRoot = primitives.ComputeMerkleRoot(m.KeyMRs)
+	m.StateHash = primitives.Sha(raw)
+	if err := snapshotStateHashes(m, last.SaveStruct); err != nil {
+		return nil, err
+	}
+
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	sw := &snapshotWriter{w: bufio.NewWriterSize(f, 1<<20)}
+	if _, err := sw.w.Write(snapshotMagic); err != nil {
+		return nil, err
+	}
+	manifest, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if err := sw.write(snapshotManifest, manifest); err != nil {
+		return nil, err
+	}
+	if err := sw.write(snapshotState, raw); err != nil {
+		return nil, err
+	}
+	for h := uint32(0); h <= m.Height; h++ {
+		if err := exportSnapshotHeight(s, sw, h); err != nil {
+			return nil, fmt.Errorf("height %d: %v", h, err)
+		}
+	}
+	if err := sw.write(snapshotEnd, nil); err != nil {
+		return nil, err
+	}
+
+	if err := sw.w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return m, os.Rename(tmp, filename)
+}
+
+func exportSnapshotHeight(s *State, sw *snapshotWriter, height uint32) error {
+	dblk, err := s.DB.FetchDBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	if dblk == nil {
+		return fmt.Errorf("directory block is missing")
+	}
+	if err := sw.writeBlock(snapshotDBlock, dblk); err != nil {
+		return err
+	}
+
+	entries := dblk.GetDBEntries()
+	if len(entries) < 3 {
+		return fmt.Errorf("directory block has %d entries", len(entries))
+	}
+	ablk, err := s.DB.FetchABlock(entries[0].GetKeyMR())
+	if err != nil || ablk == nil {
+		return fmt.Errorf("admin block is missing: %v", err)
+	}
+	if err := sw.writeBlock(snapshotABlock, ablk); err != nil {
+		return err
+	}
+	ecblk, err := s.DB.FetchECBlock(entries[1].GetKeyMR())
+	if err != nil || ecblk == nil {
+		return fmt.Errorf("entry credit block is missing: %v", err)
+	}
+	if err := sw.writeBlock(snapshotECBlock, ecblk); err != nil {
+		return err
+	}
+	fblk, err := s.DB.FetchFBlock(entries[2].GetKeyMR())
+	if err != nil || fblk == nil {
+		return fmt.Errorf("factoid block is missing: %v", err)
+	}
+	if err := sw.writeBlock(snapshotFBlock, fblk); err != nil {
+		return err
+	}
+
+	written := make(map[[32]byte]bool)
+	for _, ebe := range dblk.GetEBlockDBEntries() {
+		eblk, err := s.DB.FetchEBlock(ebe.GetKeyMR())
+		if err != nil || eblk == nil {
+			return fmt.Errorf("entry block %x is missing: %v", ebe.GetKeyMR().Bytes()[:4], err)
+		}
+		if err := sw.writeBlock(snapshotEBlock, eblk); err != nil {
+			return err
+		}
+		for _, hash := range eblk.GetEntryHashes() {
+			if hash.IsMinuteMarker() || written[hash.Fixed()] {
+				continue
+			}
+			written[hash.Fixed()] = true
+			entry, err := s.DB.FetchEntry(hash)
+			if err != nil || entry == nil {
+				return fmt.Errorf("entry %x is missing: %v", hash.Bytes()[:4], err)
+			}
+			if err := sw.writeBlock(snapshotEntry, entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotHeight holds the blocks of one height while reading a snapshot
+type snapshotHeight struct {
+	dblk    interfaces.IDirectoryBlock
+	ablk    interfaces.IAdminBlock
+	ecblk   interfaces.IEntryCreditBlock
+	fblk    interfaces.IFBlock
+	eblocks map[[32]byte]bool // KeyMRs of entry blocks not read yet
+	entries map[[32]byte]bool // hashes of entries not read yet
+}
+
+func (h *snapshotHeight) complete() error {
+	switch {
+	case h.ablk == nil:
+		return fmt.Errorf("admin block is missing")
+	case h.ecblk == nil:
+		return fmt.Errorf("entry credit block is missing")
+	case h.fblk == nil:
+		return fmt.Errorf("factoid block is missing")
+	case len(h.eblocks) > 0:
+		return fmt.Errorf("%d entry blocks are missing", len(h.eblocks))
+	case len(h.entries) > 0:
+		return fmt.Errorf("%d entries are missing", len(h.entries))
+	}
+	return nil
+}
+
+// readSnapshotBlocks reads the blocks of a snapshot and checks them against the manifest.
+// The callback is called for every verified block, and with a nil block when a height is complete.
+func readSnapshotBlocks(sr *snapshotReader, m *SnapshotManifest, networkID uint32, block func(h *snapshotHeight, b interfaces.BinaryMarshallable) error) error {
+	var cur *snapshotHeight
+	next := uint32(0)
+
+	finish := func() error {
+		if cur == nil {
+			return nil
+		}
+		if err := cur.complete(); err != nil {
+			return fmt.Errorf("height %d: %v", next-1, err)
+		}
+		return block(cur, nil)
+	}
+
+	for {
+		typ, data, err := sr.read()
+		if err != nil {
+			return err
+		}
+
+		var b interfaces.BinaryMarshallable
+		switch typ {
+		case snapshotEnd:
+			if err := finish(); err != nil {
+				return err
+			}
+			if next != m.Height+1 {
+				return fmt.Errorf("snapshot ends at height %d, want %d", next, m.Height+1)
+			}
+			return nil
+		case snapshotDBlock:
+			if err := finish(); err != nil {
+				return err
+			}
+			if next > m.Height {
+				return fmt.Errorf("snapshot has more blocks than the manifest")
+			}
+			dblk := directoryBlock.NewDirectoryBlock(nil)
+			if err := dblk.UnmarshalBinary(data); err != nil {
+				return fmt.Errorf("height %d: %v", next, err)
+			}
+			if dblk.GetDatabaseHeight() != next || !dblk.GetKeyMR().IsSameAs(m.KeyMRs[next]) {
+				return fmt.Errorf("directory block %d does not match the manifest", next)
+			}
+			if next > 0 && !dblk.GetHeader().GetPrevKeyMR().IsSameAs(m.KeyMRs[next-1]) {
+				return fmt.Errorf("directory block %d does not link to the previous block", next)
+			}
+			if dblk.GetHeader().GetNetworkID() != networkID {
+				return fmt.Errorf("directory block %d is from network %x", next, dblk.GetHeader().GetNetworkID())
+			}
+			if len(dblk.GetDBEntries()) < 3 {
+				return fmt.Errorf("directory block %d has %d entries", next, len(dblk.GetDBEntries()))
+			}
+			cur = &snapshotHeight{dblk: dblk, eblocks: make(map[[32]byte]bool), entries: make(map[[32]byte]bool)}
+			for _, ebe := range dblk.GetEBlockDBEntries() {
+				cur.eblocks[ebe.GetKeyMR().Fixed()] = true
+			}
+			next++
+			b = dblk
+		case snapshotABlock, snapshotECBlock, snapshotFBlock, snapshotEBlock, snapshotEntry:
+			if cur == nil {
+				return fmt.Errorf("block before the first directory block")
+			}
+			if b, err = verifySnapshotBlock(cur, typ, data); err != nil {
+				return fmt.Errorf("height %d: %v", next-1, err)
+			}
+		default:
+			return fmt.Errorf("unknown record type %d", typ)
+		}
+
+		if err := block(cur, b); err != nil {
+			return err
+		}
+	}
+}
+
+// verifySnapshotBlock checks that a block belongs to the directory block of the height
+func verifySnapshotBlock(h *snapshotHeight, typ byte, data []byte) (interfaces.BinaryMarshallable, error) {
+	entries := h.dblk.GetDBEntries()
+	switch typ {
+	case snapshotABlock:
+		ablk := adminBlock.NewAdminBlock(nil)
+		if err := ablk.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		if h.ablk != nil || !ablk.DatabasePrimaryIndex().IsSameAs(entries[0].GetKeyMR()) {
+			return nil, fmt.Errorf("admin block does not match the directory block")
+		}
+		h.ablk = ablk
+		return ablk, nil
+	case snapshotECBlock:
+		ecblk := entryCreditBlock.NewECBlock()
+		if err := ecblk.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		if h.ecblk != nil || !ecblk.DatabasePrimaryIndex().IsSameAs(entries[1].GetKeyMR()) {
+			return nil, fmt.Errorf("entry credit block does not match the directory block")
+		}
+		h.ecblk = ecblk
+		return ecblk, nil
+	case snapshotFBlock:
+		fblk := factoid.NewFBlock(nil)
+		if err := fblk.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		if h.fblk != nil || !fblk.DatabasePrimaryIndex().IsSameAs(entries[2].GetKeyMR()) {
+			return nil, fmt.Errorf("factoid block does not match the directory block")
+		}
+		h.fblk = fblk
+		return fblk, nil
+	case snapshotEBlock:
+		eblk := entryBlock.NewEBlock()
+		if err := eblk.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		keymr, err := eblk.KeyMR()
+		if err != nil {
+			return nil, err
+		}
+		if !h.eblocks[keymr.Fixed()] {
+			return nil, fmt.Errorf("entry block %x is not in the directory block", keymr.Bytes()[:4])
+		}
+		delete(h.eblocks, keymr.Fixed())
+		for _, e := range eblk.GetEntryHashes() {
+			if !e.IsMinuteMarker() {
+				h.entries[e.Fixed()] = true
+			}
+		}
+		return eblk, nil
+	default: // snapshotEntry
+		entry := entryBlock.NewEntry()
+		if err := entry.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		hash := entry.GetHash()
+		if !h.entries[hash.Fixed()] {
+			return nil, fmt.Errorf("entry %x is not in an entry block", hash.Bytes()[:4])
+		}
+		delete(h.entries, hash.Fixed())
+		return entry, nil
+	}
+}
+
+// sameBalances returns true if both maps hold the same balances, addresses with a zero balance don't count
+func sameBalances(a, b map[[32]byte]int64) bool {
+	for _, m := range [][2]map[[32]byte]int64{{a, b}, {b, a}} {
+		for address, balance := range m[0] {
+			if m[1][address] != balance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// VerifySnapshot checks a snapshot against the trusted KeyMR of its highest directory block.
+// The KeyMR authenticates the chain of directory blocks and, through them, all other blocks and
+// entries. The save state has to match the hashes of the manifest and the directory blocks, and
+// its balances have to match the factoid and entry credit blocks up to its height.
+func VerifySnapshot(s *State, filename string, trusted interfaces.IHash) (*SnapshotManifest, error) {
+	f, sr, m, state, err := openSnapshot(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if m.NetworkID != s.GetNetworkID() {
+		return nil, fmt.Errorf("snapshot is from network %x, not %x", m.NetworkID, s.GetNetworkID())
+	}
+	if m.SaveStateVersion != constants.SaveStateVersion {
+		return nil, fmt.Errorf("snapshot has save state version %d, want %d", m.SaveStateVersion, constants.SaveStateVersion)
+	}
+	if trusted == nil || !m.KeyMRs[m.Height].IsSameAs(trusted) {
+		return nil, fmt.Errorf("KeyMR of directory block %d is %s, not the trusted KeyMR", m.Height, m.KeyMRs[m.Height].String())
+	}
+	if !primitives.ComputeMerkleRoot(m.KeyMRs).IsSameAs(m.BlocksRoot) {
+		return nil, fmt.Errorf("merkle root of the KeyMRs does not match the manifest")
+	}
+
+	if !primitives.Sha(state).IsSameAs(m.StateHash) {
+		return nil, fmt.Errorf("save state does not match the manifest")
+	}
+	list, last, err := parseSnapshotState(s, state)
+	if err != nil {
+		return nil, err
+	}
+	if last.DirectoryBlock.GetDatabaseHeight() != m.StateHeight || m.StateHeight >= m.Height {
+		return nil, fmt.Errorf("save state height %d does not match the manifest", last.DirectoryBlock.GetDatabaseHeight())
+	}
+	for _, d := range list.DBStates {
+		h := d.DirectoryBlock.GetDatabaseHeight()
+		if h > m.Height || !d.DirectoryBlock.GetKeyMR().IsSameAs(m.KeyMRs[h]) {
+			return nil, fmt.Errorf("save state block %d does not match the manifest", h)
+		}
+	}
+	check := *m
+	if err := snapshotStateHashes(&check, last.SaveStruct); err != nil {
+		return nil, err
+	}
+	if !check.FactoidBalanceHash.IsSameAs(m.FactoidBalanceHash) || !check.ECBalanceHash.IsSameAs(m.ECBalanceHash) || !check.AuthorityRoot.IsSameAs(m.AuthorityRoot) {
+		return nil, fmt.Errorf("balances or authorities of the save state do not match the manifest")
+	}
+
+	fct := make(map[[32]byte]int64)
+	ec := make(map[[32]byte]int64)
+	err = readSnapshotBlocks(sr, m, m.NetworkID, func(h *snapshotHeight, b interfaces.BinaryMarshallable) error {
+		if b == nil && h.dblk.GetDatabaseHeight() <= m.StateHeight {
+			databaseOverlay.ApplyBlockBalances(h.fblk, h.ecblk, fct, ec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !sameBalances(fct, last.SaveStruct.FactoidBalancesP) || !sameBalances(ec, last.SaveStruct.ECBalancesP) {
+		return nil, fmt.Errorf("balances of the save state do not match the blocks up to height %d", m.StateHeight)
+	}
+	return m, nil
+}
+
+// ImportSnapshot verifies a snapshot, writes its blocks and entries to the empty database and its
+// save state to the fast boot file. The node fast boots from the save state on the next start.
+func ImportSnapshot(s *State, filename string, trusted interfaces.IHash) (*SnapshotManifest, error) {
+	head, err := s.DB.FetchDBlockHead()
+	if err != nil {
+		return nil, err
+	}
+	if head != nil {
+		return nil, fmt.Errorf("database is not empty, it has blocks up to %d", head.GetDatabaseHeight())
+	}
+
+	verified, err := VerifySnapshot(s, filename, trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	// the file is read again to import it, it has to be the one that was verified
+	f, sr, m, state, err := openSnapshot(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	want, err := verified.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	got, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(got, want) || !primitives.Sha(state).IsSameAs(verified.StateHash) {
+		return nil, fmt.Errorf("snapshot changed after it was verified")
+	}
+
+	var eblocks []interfaces.IEntryBlock
+	var entries []interfaces.IEBEntry
+	err = readSnapshotBlocks(sr, m, m.NetworkID, func(h *snapshotHeight, b interfaces.BinaryMarshallable) error {
+		switch v := b.(type) {
+		case *entryBlock.EBlock:
+			eblocks = append(eblocks, v)
+			return nil
+		case *entryBlock.Entry:
+			entries = append(entries, v)
+			return nil
+		case nil:
+		default:
+			return nil
+		}
+
+		// the height is complete, the directory block goes last so the head only moves to complete heights
+		s.DB.StartMultiBatch()
+		if err := s.DB.ProcessABlockMultiBatch(h.ablk); err != nil {
+			return err
+		}
+		if err := s.DB.ProcessFBlockMultiBatch(h.fblk); err != nil {
+			return err
+		}
+		if err := s.DB.ProcessECBlockMultiBatch(h.ecblk, false); err != nil {
+			return err
+		}
+		for _, eb := range eblocks {
+			if err := s.DB.ProcessEBlockMultiBatch(eb, false); err != nil {
+				return err
+			}
+		}
+		for _, e := range entries {
+			if err := s.DB.InsertEntryMultiBatch(e); err != nil {
+				return err
+			}
+		}
+		if err := s.DB.ProcessDBlockMultiBatch(h.dblk); err != nil {
+			return err
+		}
+		eblocks, entries = nil, nil
+		return s.DB.ExecuteMultiBatch()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.SaveDatabaseEntryHeight(m.Height); err != nil {
+		return nil, err
+	}
+	fastboot := NetworkIDToFilename(s.Network, s.StateSaverStruct.FastBootLocation)
+	if err := ioutil.WriteFile(fastboot, state, 0644); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/identity"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	. "github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+// testSnapshotState returns a state with its fast boot file in a new directory under dir
+func testSnapshotState(t *testing.T, dir string, populate bool) *State {
+	s := new(State)
+	s.Network = "LOCAL"
+	s.NetworkNumber = constants.NETWORK_LOCAL
+	s.FastSaveRate = 1000
+	fastboot, err := ioutil.TempDir(dir, "fastboot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.StateSaverStruct.FastBootLocation = fastboot
+	if populate {
+		s.DB = testHelper.CreateAndPopulateTestDatabaseOverlay()
+	} else {
+		s.DB = testHelper.CreateEmptyTestDatabaseOverlay()
+	}
+	return s
+}
+
+// testSaveState writes a fast boot file with a save state at the given height, tamper changes the save state
+// after its balances are computed from the blocks
+func testSaveState(t *testing.T, s *State, height uint32, tamper func(*SaveState)) []byte {
+	d := new(DBState)
+	var err error
+	if d.DirectoryBlock, err = s.DB.FetchDBlockByHeight(height); err != nil {
+		t.Fatal(err)
+	}
+	entries := d.DirectoryBlock.GetDBEntries()
+	d.AdminBlock, _ = s.DB.FetchABlock(entries[0].GetKeyMR())
+	d.EntryCreditBlock, _ = s.DB.FetchECBlock(entries[1].GetKeyMR())
+	d.FactoidBlock, _ = s.DB.FetchFBlock(entries[2].GetKeyMR())
+	d.Saved, d.Locked, d.Signed = true, true, true
+
+	d.SaveStruct = new(SaveState)
+	d.SaveStruct.Init()
+	d.SaveStruct.DBHeight = height
+	d.SaveStruct.LeaderTimestamp = primitives.NewTimestampFromMilliseconds(0)
+	for h := uint32(0); h <= height; h++ {
+		dblk, _ := s.DB.FetchDBlockByHeight(h)
+		fblk, _ := s.DB.FetchFBlock(dblk.GetDBEntries()[2].GetKeyMR())
+		ecblk, _ := s.DB.FetchECBlock(dblk.GetDBEntries()[1].GetKeyMR())
+		databaseOverlay.ApplyBlockBalances(fblk, ecblk, d.SaveStruct.FactoidBalancesP, d.SaveStruct.ECBalancesP)
+	}
+	if tamper != nil {
+		tamper(d.SaveStruct)
+	}
+	auth := identity.NewAuthority()
+	auth.AuthorityChainID = primitives.Sha([]byte("authority"))
+	d.SaveStruct.IdentityControl.SetAuthority(auth.AuthorityChainID, auth)
+
+	list := &DBStateList{State: s, DBStates: []*DBState{d}}
+	data, err := list.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(primitives.Sha(data).Bytes(), data...)
+	if err := ioutil.WriteFile(NetworkIDToFilename(s.Network, s.StateSaverStruct.FastBootLocation), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := testSnapshotState(t, dir, true)
+	saved := testSaveState(t, source, 5, nil)
+	file := filepath.Join(dir, "chain.snapshot")
+
+	m, err := ExportSnapshot(source, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Height != uint32(testHelper.BlockCount-1) || m.StateHeight != 5 || len(m.KeyMRs) != testHelper.BlockCount {
+		t.Errorf("manifest height = %d, state height = %d, %d KeyMRs", m.Height, m.StateHeight, len(m.KeyMRs))
+	}
+	head, _ := source.DB.FetchDBlockHead()
+
+	target := testSnapshotState(t, dir, false)
+	if _, err := ImportSnapshot(target, file, primitives.Sha([]byte("untrusted"))); err == nil {
+		t.Errorf("imported a snapshot that doesn't match the trusted KeyMR")
+	}
+	if _, err := ImportSnapshot(target, file, head.GetKeyMR()); err != nil {
+		t.Fatal(err)
+	}
+
+	for h := uint32(0); h < uint32(testHelper.BlockCount); h++ {
+		want, _ := source.DB.FetchDBlockByHeight(h)
+		got, err := target.DB.FetchDBlockByHeight(h)
+		if err != nil || got == nil || !got.GetKeyMR().IsSameAs(want.GetKeyMR()) {
+			t.Fatalf("directory block %d was not imported: %v", h, err)
+		}
+		for _, ebe := range got.GetEBlockDBEntries() {
+			eblk, err := target.DB.FetchEBlock(ebe.GetKeyMR())
+			if err != nil || eblk == nil {
+				t.Fatalf("entry block %s was not imported: %v", ebe.GetKeyMR(), err)
+			}
+			for _, hash := range eblk.GetEntryHashes() {
+				if e, err := target.DB.FetchEntry(hash); !hash.IsMinuteMarker() && (err != nil || e == nil) {
+					t.Errorf("entry %s was not imported: %v", hash, err)
+				}
+			}
+		}
+	}
+	if height, _ := target.DB.FetchDatabaseEntryHeight(); height != m.Height {
+		t.Errorf("entry height = %d, want %d", height, m.Height)
+	}
+	restored, _ := ioutil.ReadFile(NetworkIDToFilename(target.Network, target.StateSaverStruct.FastBootLocation))
+	if !bytes.Equal(restored, saved) {
+		t.Errorf("save state was not restored")
+	}
+
+	if _, err := ImportSnapshot(target, file, head.GetKeyMR()); err == nil {
+		t.Errorf("imported a snapshot into a database that is not empty")
+	}
+}
+
+func TestExportSnapshot_pruned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := testSnapshotState(t, dir, true)
+	testSaveState(t, source, 5, nil)
+	dbo := source.DB.(*databaseOverlay.Overlay)
+	dbo.SetPruning(3, nil)
+	if _, err := dbo.PruneEntries(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "chain.snapshot")
+	if _, err := ExportSnapshot(source, file); err == nil || !strings.Contains(err.Error(), "database is pruned") {
+		t.Errorf("ExportSnapshot() = %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("snapshot of a pruned database was written")
+	}
+}
+
+func TestVerifySnapshot_tampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := testSnapshotState(t, dir, true)
+	testSaveState(t, source, 5, nil)
+	file := filepath.Join(dir, "chain.snapshot")
+	if _, err := ExportSnapshot(source, file); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := source.DB.FetchDBlockHead()
+	if _, err := VerifySnapshot(source, file, head.GetKeyMR()); err != nil {
+		t.Fatal(err)
+	}
+
+	original, _ := ioutil.ReadFile(file)
+	for _, offset := range []int{20, 200, len(original) / 2, len(original) - 10} {
+		data := append([]byte(nil), original...)
+		data[offset] ^= 0xff
+		tampered := filepath.Join(dir, "tampered.snapshot")
+		ioutil.WriteFile(tampered, data, 0644)
+		if _, err := VerifySnapshot(source, tampered, head.GetKeyMR()); err == nil {
+			t.Errorf("snapshot with byte %d of %d changed was verified", offset, len(data))
+		}
+	}
+
+	truncated := filepath.Join(dir, "truncated.snapshot")
+	ioutil.WriteFile(truncated, original[:len(original)-5], 0644)
+	if _, err := VerifySnapshot(source, truncated, head.GetKeyMR()); err == nil {
+		t.Errorf("truncated snapshot was verified")
+	}
+}
+
+func TestVerifySnapshot_balances(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := testSnapshotState(t, dir, true)
+	head, _ := source.DB.FetchDBlockHead()
+	file := filepath.Join(dir, "chain.snapshot")
+
+	tampers := map[string]func(*SaveState){
+		"new factoid address": func(ss *SaveState) { ss.FactoidBalancesP[[32]byte{1}] = 100 },
+		"new ec address":      func(ss *SaveState) { ss.ECBalancesP[[32]byte{2}] = 200 },
+		"changed balance": func(ss *SaveState) {
+			for address := range ss.FactoidBalancesP {
+				ss.FactoidBalancesP[address]++
+			}
+		},
+	}
+	for name, tamper := range tampers {
+		t.Run(name, func(t *testing.T) {
+			testSaveState(t, source, 5, tamper)
+			if _, err := ExportSnapshot(source, file); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := VerifySnapshot(source, file, head.GetKeyMR()); err == nil {
+				t.Errorf("snapshot with tampered balances was verified")
+			}
+			target := testSnapshotState(t, dir, false)
+			if _, err := ImportSnapshot(target, file, head.GetKeyMR()); err == nil {
+				t.Errorf("snapshot with tampered balances was imported")
+			}
+		})
+	}
+}
@@ -29,6 +29,8 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/backup"
+	"github.com/FactomProject/factomd/database/badgerdb"
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/leveldb"
@@ -64,6 +66,7 @@ type State struct {
 	LogPath         string
 	LdbPath         string
 	BoltDBPath      string
+	BadgerDBPath    string
 	LogLevel        string
 	ConsoleLogLevel string
 	NodeMode        string
@@ -72,9 +75,14 @@ type State struct {
 		CheckChainHeads bool
 		Fix             bool
 	}
-	CloneDBType       string
-	ExportData        bool
-	ExportDataSubpath string
+	CloneDBType         string
+	ExportData          bool
+	ExportDataSubpath   string
+	ExtIDIndex          bool
+	AddressHistoryIndex bool
+	BalanceCheckpoints  bool
+	PruneDepth          int
+	PruneKeepChains     string
 
 	LogBits int64 // Bit zero is for logging the Directory Block on DBSig [5]
 
@@ -391,6 +399,10 @@ type State struct {
 
 	StateSaverStruct StateSaverStruct
 
+	// Online backups, see StartBackup
+	backupMutex  sync.Mutex
+	backupStatus backup.Status
+
 	// Logstash
 	UseLogstash bool
 	LogstashURL string
@@ -498,6 +510,7 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.JournalFile = s.LogPath + "/journal" + number + ".log"
 	newState.Journaling = s.Journaling
 	newState.BoltDBPath = s.BoltDBPath + "/Sim" + number
+	newState.BadgerDBPath = s.BadgerDBPath + "/Sim" + number
 	newState.LogLevel = s.LogLevel
 	newState.ConsoleLogLevel = s.ConsoleLogLevel
 	newState.NodeMode = "FULL"
@@ -506,6 +519,11 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.CheckChainHeads = s.CheckChainHeads
 	newState.ExportData = s.ExportData
 	newState.ExportDataSubpath = s.ExportDataSubpath + "sim-" + number
+	newState.ExtIDIndex = s.ExtIDIndex
+	newState.AddressHistoryIndex = s.AddressHistoryIndex
+	newState.BalanceCheckpoints = s.BalanceCheckpoints
+	newState.PruneDepth = s.PruneDepth
+	newState.PruneKeepChains = s.PruneKeepChains
 	newState.Network = s.Network
 	newState.MainNetworkPort = s.MainNetworkPort
 	newState.PeersFile = s.PeersFile
@@ -586,6 +604,10 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 		newState.StateSaverStruct.FastBoot = s.StateSaverStruct.FastBoot
 		newState.StateSaverStruct.FastBootLocation = newState.BoltDBPath
 		break
+	case "Badger":
+		newState.StateSaverStruct.FastBoot = s.StateSaverStruct.FastBoot
+		newState.StateSaverStruct.FastBootLocation = newState.BadgerDBPath
+		break
 	}
 	if globals.Params.WriteProcessedDBStates {
 		path := filepath.Join(newState.LdbPath, newState.Network, "dbstates")
@@ -717,6 +739,10 @@ func (s *State) GetRpcUser() string {
 func (s *State) GetCorsDomains() []string {
 	return s.CorsDomains
 }
+
+func (s *State) GetRequestLimit() int {
+	return s.RequestLimit
+}
 func (s *State) GetRpcPass() string {
 	return s.RpcPass
 }
@@ -823,21 +849,31 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		// TODO: improve the paths after milestone 1
 		cfg.App.LdbPath = cfg.App.HomeDir + networkName + cfg.App.LdbPath
 		cfg.App.BoltDBPath = cfg.App.HomeDir + networkName + cfg.App.BoltDBPath
+		cfg.App.BadgerDBPath = cfg.App.HomeDir + networkName + cfg.App.BadgerDBPath
 		cfg.App.DataStorePath = cfg.App.HomeDir + networkName + cfg.App.DataStorePath
 		cfg.Log.LogPath = cfg.App.HomeDir + networkName + cfg.Log.LogPath
 		cfg.App.ExportDataSubpath = cfg.App.HomeDir + networkName + cfg.App.ExportDataSubpath
 		cfg.App.PeersFile = cfg.App.HomeDir + networkName + cfg.App.PeersFile
+		if cfg.App.P2PNodeKeyFile != "" {
+			cfg.App.P2PNodeKeyFile = cfg.App.HomeDir + networkName + cfg.App.P2PNodeKeyFile
+		}
 		cfg.App.ControlPanelFilesPath = cfg.App.HomeDir + cfg.App.ControlPanelFilesPath
 
 		s.LogPath = cfg.Log.LogPath + s.Prefix
 		s.LdbPath = cfg.App.LdbPath + s.Prefix
 		s.BoltDBPath = cfg.App.BoltDBPath + s.Prefix
+		s.BadgerDBPath = cfg.App.BadgerDBPath + s.Prefix
 		s.LogLevel = cfg.Log.LogLevel
 		s.ConsoleLogLevel = cfg.Log.ConsoleLogLevel
 		s.NodeMode = cfg.App.NodeMode
 		s.DBType = cfg.App.DBType
 		s.ExportData = cfg.App.ExportData // bool
 		s.ExportDataSubpath = cfg.App.ExportDataSubpath
+		s.ExtIDIndex = cfg.App.ExtIDIndex
+		s.AddressHistoryIndex = cfg.App.AddressHistoryIndex
+		s.BalanceCheckpoints = cfg.App.BalanceCheckpoints
+		s.PruneDepth = cfg.App.PruneDepth
+		s.PruneKeepChains = cfg.App.PruneKeepChains
 		s.MainNetworkPort = cfg.App.MainNetworkPort
 		s.PeersFile = cfg.App.PeersFile
 		s.MainSeedURL = cfg.App.MainSeedURL
@@ -944,6 +980,7 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.LogPath = "database/"
 		s.LdbPath = "database/ldb"
 		s.BoltDBPath = "database/bolt"
+		s.BadgerDBPath = "database/badger"
 		s.LogLevel = "none"
 		s.ConsoleLogLevel = "standard"
 		s.NodeMode = "SERVER"
@@ -1126,6 +1163,10 @@ func (s *State) Init() {
 		if err := s.InitBoltDB(); err != nil {
 			panic(fmt.Sprintf("Error initializing the database: %v", err))
 		}
+	case "Badger":
+		if err := s.InitBadgerDB(); err != nil {
+			panic(fmt.Sprintf("Error initializing the database: %v", err))
+		}
 	case "Map":
 		if err := s.InitMapDB(); err != nil {
 			panic(fmt.Sprintf("Error initializing the database: %v", err))
@@ -1162,7 +1203,15 @@ func (s *State) Init() {
 	if s.ExportData {
 		s.DB.SetExportData(s.ExportDataSubpath)
 	}
-
+	if s.ExtIDIndex {
+		s.DB.SetExtIDIndex(true)
+	}
+	if s.AddressHistoryIndex {
+		s.DB.SetAddressHistoryIndex(true)
+	}
+	if s.BalanceCheckpoints {
+		s.DB.SetBalanceCheckpoints(true)
+	}
 	// Cross Boot Replay
 	switch s.DBType {
 	case "Map":
@@ -1212,6 +1261,9 @@ func (s *State) Init() {
 		s.ExchangeRateAuthorityPublicKey = "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29"
 	}
 	// end of FER removal
+	if s.PruneDepth > 0 {
+		s.initPruning()
+	}
 	s.Starttime = time.Now()
 	// Allocate the MMR queues
 	s.asks = make(chan askRef, 50) // Should be > than the number of VMs so each VM can have at least one outstanding ask.
@@ -1348,6 +1400,58 @@ func (s *State) GetDB() interfaces.DBOverlaySimple {
 	return s.DB
 }
 
+// StartDatabaseMaintenance computes the missing balance checkpoints and prunes the database in the background.
+// It is not started by Init, so the database can be exported or replaced before the node starts.
+func (s *State) StartDatabaseMaintenance() {
+	if s.BalanceCheckpoints {
+		go s.UpdateBalanceCheckpoints()
+	}
+	if s.PruneDepth > 0 {
+		go s.PruneEntries()
+	}
+}
+
+// UpdateBalanceCheckpoints computes the balance checkpoints of the blocks saved since the last checkpoint
+func (s *State) UpdateBalanceCheckpoints() {
+	checkpoint, err := s.DB.UpdateBalanceCheckpoints()
+	if err != nil {
+		s.LogPrintf("dbstateprocess", "UpdateBalanceCheckpoints() failed after checkpoint %d: %v", checkpoint, err)
+		return
+	}
+	s.LogPrintf("dbstateprocess", "UpdateBalanceCheckpoints() checkpoint %d", checkpoint)
+}
+
+// initPruning configures the database to prune the chains that are not in PruneKeepChains, the exchange rate chain
+// is always kept
+func (s *State) initPruning() {
+	keep := []interfaces.IHash{}
+	for _, id := range strings.Split(s.PruneKeepChains, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		chainID, err := primitives.HexToHash(id)
+		if err != nil {
+			panic(fmt.Sprintf("Bad chain ID %q in PruneKeepChains in factomd.conf: %v", id, err))
+		}
+		keep = append(keep, chainID)
+	}
+	if fer, err := primitives.HexToHash(s.FERChainId); err == nil {
+		keep = append(keep, fer)
+	}
+	s.DB.SetPruning(uint32(s.PruneDepth), keep)
+}
+
+// PruneEntries deletes the entries of the chains that are not kept once they are PruneDepth blocks deep
+func (s *State) PruneEntries() {
+	pruned, err := s.DB.PruneEntries()
+	if err != nil {
+		s.LogPrintf("dbstateprocess", "PruneEntries() failed at height %d: %v", pruned, err)
+		return
+	}
+	s.LogPrintf("dbstateprocess", "PruneEntries() pruned below %d", pruned)
+}
+
 // Checks ChainIDs to determine if we need their entries to process entries and transactions.
 func (s *State) Needed(eb interfaces.IEntryBlock) bool {
 	id := []byte{0x88, 0x88, 0x88}
@@ -1452,6 +1556,7 @@ func (s *State) LoadDBState(dbheight uint32) (interfaces.IMsg, error) {
 	ebDBEntries := dblk.GetEBlockDBEntries()
 	if len(ebDBEntries) > 0 {
 		for _, v := range ebDBEntries {
+			// the entry blocks of pruned chains are left out, the DBState can be loaded but not shared
 			eBlock, err := s.DB.FetchEBlock(v.GetKeyMR())
 			if err == nil && eBlock != nil {
 				eBlocks = append(eBlocks, eBlock)
@@ -2481,6 +2586,11 @@ func (s *State) GetNetworkNumber() int {
 	return s.NetworkNumber
 }
 
+// GetNetworkController returns the p2p network of the node, nil if the node isn't networked
+func (s *State) GetNetworkController() *p2p.Network {
+	return s.NetworkController
+}
+
 func (s *State) GetNetworkName() string {
 	switch s.NetworkNumber {
 	case constants.NETWORK_MAIN:
@@ -2629,6 +2739,25 @@ func (s *State) InitBoltDB() error {
 	return nil
 }
 
+func (s *State) InitBadgerDB() error {
+	if s.DB != nil {
+		return nil
+	}
+
+	path := filepath.Join(s.BadgerDBPath, s.Network, "factoid_badger.db")
+
+	s.Println("Database:", path)
+	fmt.Fprintln(os.Stderr, "Database:", path)
+
+	dbase, err := badgerdb.NewBadgerDB(path, true)
+	if err != nil {
+		return err
+	}
+
+	s.DB = databaseOverlay.NewOverlayWithState(dbase, s)
+	return nil
+}
+
 func (s *State) InitMapDB() error {
 	if s.DB != nil {
 		return nil
@@ -23,6 +23,7 @@ import (
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/FactomProject/factomd/p2p"
 	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/factomd/util/atomic"
 
@@ -161,6 +162,7 @@ func (s *State) Validate(msg interfaces.IMsg) (validToSend int, validToExec int)
 		return 0, 0
 	}
 	if validToSend == -1 { // if the msg says drop then we drop...
+		s.reportMisbehavior(msg)
 		return -1, -1
 	}
 	if validToSend == -2 { // if the msg says New hold then we don't execute...
@@ -213,6 +215,16 @@ func (s *State) Validate(msg interfaces.IMsg) (validToSend int, validToExec int)
 	return 1, 1
 }
 
+// reportMisbehavior counts an invalid message against the peer that sent it. Honest peers relay messages that
+// turn invalid on the way, so a few of them don't get a peer banned.
+func (s *State) reportMisbehavior(msg interfaces.IMsg) {
+	if s.NetworkController == nil || msg.IsLocal() || msg.GetNetworkOrigin() == "" {
+		return
+	}
+	s.LogMessage("executeMsg", "report misbehavior of "+msg.GetNetworkOrigin(), msg)
+	s.NetworkController.ReportMisbehavior(msg.GetNetworkOrigin(), p2p.SeverityMinor)
+}
+
 func (s *State) executeMsg(msg interfaces.IMsg) (ret bool) {
 	// track how long we spend in executeMsg
 	preExecuteMsgTime := time.Now()
@@ -5,12 +5,15 @@
 package state_test
 
 import (
+	"net"
 	"testing"
 
 	"time"
 
 	"github.com/FactomProject/factomd/common/entryCreditBlock"
 	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/p2p"
 	. "github.com/FactomProject/factomd/state"
 	"github.com/FactomProject/factomd/testHelper"
 )
@@ -99,3 +102,70 @@ func testFactomSecond(t *testing.T, s *State, blktime int, second time.Duration)
 		}
 	}
 }
+
+func TestValidateReportsMisbehavior(t *testing.T) {
+	s := testHelper.CreateEmptyTestState()
+
+	newNetwork := func(name, port string) *p2p.Network {
+		conf := p2p.DefaultP2PConfiguration()
+		conf.NodeName = name
+		conf.Network = p2p.NewNetworkID("misbehavior-test")
+		conf.SeedURL = ""
+		conf.Special = ""
+		conf.EnablePrometheus = false
+		conf.BindIP = "127.0.0.1"
+		conf.ListenPort = port
+		conf.ListenLimit = 0
+		conf.MisbehaviorThreshold = 2
+		n, err := p2p.NewNetwork(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	remote := newNetwork("remote", "14261")
+	defer remote.Stop()
+	local := newNetwork("local", "14262")
+	defer local.Stop()
+	s.NetworkController = local
+
+	// the remote network starts listening in the background, dialing too early holds off the next dial
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", "127.0.0.1:14261"); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	hash, err := local.Connect(p2p.Endpoint{IP: "127.0.0.1", Port: "14261"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && local.Total() == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// nobody can answer a request for missing messages of a future block
+	msg := new(messages.MissingMsg)
+	msg.Timestamp = primitives.NewTimestampNow()
+	msg.Asking = primitives.RandomHash()
+	msg.DBHeight = s.GetLLeaderHeight() + 10
+	msg.SetNetworkOrigin(hash)
+
+	// the score decays a little between the reports, the third one crosses the threshold
+	for i := 0; i < 3; i++ {
+		if _, validToExec := s.Validate(msg); validToExec != -1 {
+			t.Fatalf("invalid message not dropped, got %d", validToExec)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if _, ok := local.Bans()["127.0.0.1"]; ok {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("peer not banned for invalid messages. bans = %v", local.Bans())
+}
@@ -25,9 +25,15 @@ type FactomdConfig struct {
 		DBType                                 string
 		LdbPath                                string
 		BoltDBPath                             string
+		BadgerDBPath                           string
 		DataStorePath                          string
 		DirectoryBlockInSeconds                int
 		ExportData                             bool
+		ExtIDIndex                             bool
+		AddressHistoryIndex                    bool
+		BalanceCheckpoints                     bool
+		PruneDepth                             int
+		PruneKeepChains                        string
 		ExportDataSubpath                      string
 		FastBoot                               bool
 		FastBootLocation                       string
@@ -63,6 +69,21 @@ type FactomdConfig struct {
 		CustomBootstrapKey      string
 		P2PIncoming             int
 		P2POutgoing             int
+		P2PProtocolVersion      int
+		P2PNodeKeyFile          string
+		P2PSpecialKeys          string
+		P2PBandwidthIn          int
+		P2PBandwidthOut         int
+		P2PPeerBandwidthIn      int
+		P2PPeerBandwidthOut     int
+		P2PGossip               bool
+		P2PExternalHost         string
+		P2PExternalPort         string
+		P2PPortMapping          string
+		P2PSeedKeys             string
+		P2PSeedDNSServer        string
+		P2PCompression          string
+		P2PCompressionThreshold int
 		FactomdTlsEnabled       bool
 		FactomdTlsPrivateKey    string
 		FactomdTlsPublicCert    string
@@ -120,7 +141,43 @@ type FactomdConfig struct {
 		EventSendStateChange     bool
 		EventBroadcastContent    string
 		PersistentReconnect      bool
+		EventFilterTypes         string
+		EventFilterChainIDs      string
+		EventDurableDelivery     bool
+		EventSpoolPath           string
+		EventSpoolMaxSize        int
+		EventReceiverPath        string
+		EventWebhookSecret       string
+		EventBatchSize           int
+		EventBatchInSeconds      int
+		EventFilePath            string
+		EventFileMaxSize         int
+		EventFileMaxBackups      int
 	}
+	LiveFeedReceiver map[string]*LiveFeedReceiverConfig
+}
+
+// LiveFeedReceiverConfig configures an additional live feed receiver, defined as a [LiveFeedReceiver "name"] section.
+type LiveFeedReceiverConfig struct {
+	EventReceiverProtocol string
+	EventReceiverHost     string
+	EventReceiverPort     int
+	EventSenderPort       int
+	EventFormat           string
+	EventSendStateChange  bool
+	EventBroadcastContent string
+	PersistentReconnect   bool
+	EventFilterTypes      string
+	EventFilterChainIDs   string
+	EventDurableDelivery  bool
+	EventSpoolMaxSize     int
+	EventReceiverPath     string
+	EventWebhookSecret    string
+	EventBatchSize        int
+	EventBatchInSeconds   int
+	EventFilePath         string
+	EventFileMaxSize      int
+	EventFileMaxBackups   int
 }
 
 // defaultConfig
@@ -134,19 +191,31 @@ HomeDir                               = ""
 ; --------------- ControlPanel disabled | readonly | readwrite
 ControlPanelSetting                   = readonly
 ControlPanelPort                      = 8090
-; --------------- DBType: LDB | Bolt | Map
+; --------------- DBType: LDB | Bolt | Badger | Map
 DBType                                = "LDB"
 LdbPath                               = "database/ldb"
 BoltDBPath                            = "database/bolt"
+BadgerDBPath                          = "database/badger"
 DataStorePath                         = "data/export"
 DirectoryBlockInSeconds               = 6
 ExportData                            = false
 ExportDataSubpath                     = "database/export/"
+; --------------- ExtIDIndex: index entries by their ExtIDs for the entries-by-extid API, existing entries are indexed with Utilities/ExtIDIndex
+ExtIDIndex                            = false
+; --------------- AddressHistoryIndex: index factoid and entry credit transactions by address for the address-history API, existing blocks are indexed with Utilities/AddressHistoryIndex
+AddressHistoryIndex                   = false
+; --------------- BalanceCheckpoints: store the balances of all addresses every 1000 blocks to answer balance queries at a past height
+BalanceCheckpoints                    = false
+; --------------- PruneDepth: delete the entry blocks and entries older than this many blocks, except for the PruneKeepChains, identity and anchor chains. 0 keeps everything
+PruneDepth                            = 0
+; --------------- PruneKeepChains: comma separated list of the chain IDs whose entries are kept
+PruneKeepChains                       = ""
 FastBoot                              = true
 FastBootLocation                      = ""
 ; --------------- Network: MAIN | TEST | LOCAL
 Network                               = MAIN
 PeersFile            = "peers.json"
+; Seed URLs are comma separated lists of http(s) urls, dns:<name>[:port] records, or seed file paths
 MainNetworkPort      = 8108
 MainSeedURL          = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/mainseed.txt"
 MainSpecialPeers     = ""
@@ -165,6 +234,33 @@ CustomBootstrapKey          = cc1985cdfae4e32b5a454dfda8ce5e1361558482684f336764
 P2PIncoming	= 200
 ; The maximum number of peers this node will attempt to dial into
 P2POutgoing	= 32
+; The p2p protocol used to dial other peers, 12 encrypts connections and authenticates peers by their node key
+P2PProtocolVersion   = 10
+; The file holding the key that identifies this node in protocol 12, it is created if it does not exist
+P2PNodeKeyFile       = "nodekey"
+; Comma separated hex public keys of peers that are special regardless of their address
+P2PSpecialKeys       = ""
+; Bandwidth limits in KiB/s for all peers combined and for every single peer, 0 for unlimited
+P2PBandwidthIn       = 0
+P2PBandwidthOut      = 0
+P2PPeerBandwidthIn   = 0
+P2PPeerBandwidthOut  = 0
+; Push broadcasts along a spanning tree of peers that support it instead of random peers
+P2PGossip            = false
+; The address other nodes should dial to reach this node, if it is behind NAT or port forwarding.
+; If empty, the address is learned from the port mapping or from peers
+P2PExternalHost      = ""
+P2PExternalPort      = ""
+; Map the listen port on the gateway: upnp, natpmp, auto, or empty to disable
+P2PPortMapping       = ""
+; Only use seed lists signed by one of these hex encoded ed25519 public keys, separated by comma
+P2PSeedKeys          = ""
+; The DNS server (host:port) to query for "dns:" seeds instead of the system's resolver
+P2PSeedDNSServer     = ""
+; Compress parcels to peers that support it, comma separated in order of preference: zstd, snappy. Empty to disable
+P2PCompression       = "zstd,snappy"
+; Parcels with a payload smaller than this many bytes are sent uncompressed
+P2PCompressionThreshold = 1024
 ; --------------- NodeMode: FULL | SERVER ----------------
 NodeMode                                = FULL
 LocalServerPrivKey                      = 4c38c72fc5cdad68f13b74674d3ffb1f3d63a112710868c9b08946553448d26d
@@ -193,7 +289,8 @@ FactomdRpcPass                        = ""
 RequestTimeout						= 30
 ; RequestLimit is the maximum number of pending requests for missing states.
 ; factomd will stop making DBStateMissing requests until current requests are
-; moved out of the waiting list
+; moved out of the waiting list. It also limits the number of requests in a
+; batched JSON-RPC call to the API.
 RequestLimit						= 200
 
 ; This paramater allows Cross-Origin Resource Sharing (CORS) so web browsers will use data returned from the API when called from the listed URLs
@@ -254,6 +351,24 @@ EventReplayDuringStartup              = false
 EventSendStateChange                  = false
 EventBroadcastContent                 = once
 PersistentReconnect                   = false
+; Spool events on disk and resend the events the receiver missed after a reconnect, only supported for tcp.
+; The receiver answers every connect with the last sequence number it received, see events/README.md.
+EventDurableDelivery                  = false
+; Directory of the spool, every receiver has its own subdirectory. Defaults to livefeed in the HomeDir.
+EventSpoolPath                        = ""
+; Maximum size of the spool of each receiver in MB, the oldest events are removed when the spool is full.
+EventSpoolMaxSize                     = 512
+; With EventReceiverProtocol http or https the events are posted in batches to EventReceiverHost:EventReceiverPort
+; at EventReceiverPath. The body is signed with HMAC-SHA256 when EventWebhookSecret is set.
+EventReceiverPath                     = /
+EventWebhookSecret                    = ""
+EventBatchSize                        = 100
+EventBatchInSeconds                   = 1
+; With EventReceiverProtocol file the events are appended as newline delimited json to EventFilePath,
+; which defaults to livefeed/<receiver name>.ndjson in the HomeDir. Files are rotated at EventFileMaxSize MB.
+EventFilePath                         = ""
+EventFileMaxSize                      = 100
+EventFileMaxBackups                   = 10
 `
 
 func (s *FactomdConfig) String() string {
@@ -269,10 +384,16 @@ func (s *FactomdConfig) String() string {
 	out.WriteString(fmt.Sprintf("\n    DBType                  %v", s.App.DBType))
 	out.WriteString(fmt.Sprintf("\n    LdbPath                 %v", s.App.LdbPath))
 	out.WriteString(fmt.Sprintf("\n    BoltDBPath              %v", s.App.BoltDBPath))
+	out.WriteString(fmt.Sprintf("\n    BadgerDBPath            %v", s.App.BadgerDBPath))
 	out.WriteString(fmt.Sprintf("\n    DataStorePath           %v", s.App.DataStorePath))
 	out.WriteString(fmt.Sprintf("\n    DirectoryBlockInSeconds %v", s.App.DirectoryBlockInSeconds))
 	out.WriteString(fmt.Sprintf("\n    ExportData              %v", s.App.ExportData))
 	out.WriteString(fmt.Sprintf("\n    ExportDataSubpath       %v", s.App.ExportDataSubpath))
+	out.WriteString(fmt.Sprintf("\n    ExtIDIndex              %v", s.App.ExtIDIndex))
+	out.WriteString(fmt.Sprintf("\n    AddressHistoryIndex     %v", s.App.AddressHistoryIndex))
+	out.WriteString(fmt.Sprintf("\n    BalanceCheckpoints      %v", s.App.BalanceCheckpoints))
+	out.WriteString(fmt.Sprintf("\n    PruneDepth              %v", s.App.PruneDepth))
+	out.WriteString(fmt.Sprintf("\n    PruneKeepChains         %v", s.App.PruneKeepChains))
 	out.WriteString(fmt.Sprintf("\n    Network                 %v", s.App.Network))
 	out.WriteString(fmt.Sprintf("\n    MainNetworkPort         %v", s.App.MainNetworkPort))
 	out.WriteString(fmt.Sprintf("\n    PeersFile               %v", s.App.PeersFile))
@@ -291,6 +412,21 @@ func (s *FactomdConfig) String() string {
 	out.WriteString(fmt.Sprintf("\n    CustomBootstrapKey      %v", s.App.CustomBootstrapKey))
 	out.WriteString(fmt.Sprintf("\n    P2PIncoming             %v", s.App.P2PIncoming))
 	out.WriteString(fmt.Sprintf("\n    P2POutgoing             %v", s.App.P2POutgoing))
+	out.WriteString(fmt.Sprintf("\n    P2PProtocolVersion      %v", s.App.P2PProtocolVersion))
+	out.WriteString(fmt.Sprintf("\n    P2PNodeKeyFile          %v", s.App.P2PNodeKeyFile))
+	out.WriteString(fmt.Sprintf("\n    P2PSpecialKeys          %v", s.App.P2PSpecialKeys))
+	out.WriteString(fmt.Sprintf("\n    P2PBandwidthIn          %v", s.App.P2PBandwidthIn))
+	out.WriteString(fmt.Sprintf("\n    P2PBandwidthOut         %v", s.App.P2PBandwidthOut))
+	out.WriteString(fmt.Sprintf("\n    P2PPeerBandwidthIn      %v", s.App.P2PPeerBandwidthIn))
+	out.WriteString(fmt.Sprintf("\n    P2PPeerBandwidthOut     %v", s.App.P2PPeerBandwidthOut))
+	out.WriteString(fmt.Sprintf("\n    P2PGossip               %v", s.App.P2PGossip))
+	out.WriteString(fmt.Sprintf("\n    P2PExternalHost         %v", s.App.P2PExternalHost))
+	out.WriteString(fmt.Sprintf("\n    P2PExternalPort         %v", s.App.P2PExternalPort))
+	out.WriteString(fmt.Sprintf("\n    P2PPortMapping          %v", s.App.P2PPortMapping))
+	out.WriteString(fmt.Sprintf("\n    P2PSeedKeys             %v", s.App.P2PSeedKeys))
+	out.WriteString(fmt.Sprintf("\n    P2PSeedDNSServer        %v", s.App.P2PSeedDNSServer))
+	out.WriteString(fmt.Sprintf("\n    P2PCompression          %v", s.App.P2PCompression))
+	out.WriteString(fmt.Sprintf("\n    P2PCompressionThreshold %v", s.App.P2PCompressionThreshold))
 	out.WriteString(fmt.Sprintf("\n    NodeMode                %v", s.App.NodeMode))
 	out.WriteString(fmt.Sprintf("\n    IdentityChainID         %v", s.App.IdentityChainID))
 	out.WriteString(fmt.Sprintf("\n    LocalServerPrivKey      %v", s.App.LocalServerPrivKey))
@@ -333,6 +469,41 @@ func (s *FactomdConfig) String() string {
 	out.WriteString(fmt.Sprintf("\n    EventSendStateChange     %v", s.LiveFeedAPI.EventSendStateChange))
 	out.WriteString(fmt.Sprintf("\n    EventReplayDuringStartup %v", s.LiveFeedAPI.EventReplayDuringStartup))
 	out.WriteString(fmt.Sprintf("\n    PersistentReconnect      %v", s.LiveFeedAPI.PersistentReconnect))
+	out.WriteString(fmt.Sprintf("\n    EventFilterTypes         %v", s.LiveFeedAPI.EventFilterTypes))
+	out.WriteString(fmt.Sprintf("\n    EventFilterChainIDs      %v", s.LiveFeedAPI.EventFilterChainIDs))
+	out.WriteString(fmt.Sprintf("\n    EventDurableDelivery     %v", s.LiveFeedAPI.EventDurableDelivery))
+	out.WriteString(fmt.Sprintf("\n    EventSpoolPath           %v", s.LiveFeedAPI.EventSpoolPath))
+	out.WriteString(fmt.Sprintf("\n    EventSpoolMaxSize        %v", s.LiveFeedAPI.EventSpoolMaxSize))
+	out.WriteString(fmt.Sprintf("\n    EventReceiverPath        %v", s.LiveFeedAPI.EventReceiverPath))
+	out.WriteString(fmt.Sprintf("\n    EventWebhookSecret       %v", len(s.LiveFeedAPI.EventWebhookSecret) > 0))
+	out.WriteString(fmt.Sprintf("\n    EventBatchSize           %v", s.LiveFeedAPI.EventBatchSize))
+	out.WriteString(fmt.Sprintf("\n    EventBatchInSeconds      %v", s.LiveFeedAPI.EventBatchInSeconds))
+	out.WriteString(fmt.Sprintf("\n    EventFilePath            %v", s.LiveFeedAPI.EventFilePath))
+	out.WriteString(fmt.Sprintf("\n    EventFileMaxSize         %v", s.LiveFeedAPI.EventFileMaxSize))
+	out.WriteString(fmt.Sprintf("\n    EventFileMaxBackups      %v", s.LiveFeedAPI.EventFileMaxBackups))
+
+	for name, receiver := range s.LiveFeedReceiver {
+		out.WriteString(fmt.Sprintf("\n  LiveFeedReceiver %s", name))
+		out.WriteString(fmt.Sprintf("\n    EventReceiverProtocol    %v", receiver.EventReceiverProtocol))
+		out.WriteString(fmt.Sprintf("\n    EventReceiverHost        %v", receiver.EventReceiverHost))
+		out.WriteString(fmt.Sprintf("\n    EventReceiverPort        %v", receiver.EventReceiverPort))
+		out.WriteString(fmt.Sprintf("\n    EventSenderPort          %v", receiver.EventSenderPort))
+		out.WriteString(fmt.Sprintf("\n    EventFormat              %v", receiver.EventFormat))
+		out.WriteString(fmt.Sprintf("\n    EventBroadcastContent    %v", receiver.EventBroadcastContent))
+		out.WriteString(fmt.Sprintf("\n    EventSendStateChange     %v", receiver.EventSendStateChange))
+		out.WriteString(fmt.Sprintf("\n    PersistentReconnect      %v", receiver.PersistentReconnect))
+		out.WriteString(fmt.Sprintf("\n    EventFilterTypes         %v", receiver.EventFilterTypes))
+		out.WriteString(fmt.Sprintf("\n    EventFilterChainIDs      %v", receiver.EventFilterChainIDs))
+		out.WriteString(fmt.Sprintf("\n    EventDurableDelivery     %v", receiver.EventDurableDelivery))
+		out.WriteString(fmt.Sprintf("\n    EventSpoolMaxSize        %v", receiver.EventSpoolMaxSize))
+		out.WriteString(fmt.Sprintf("\n    EventReceiverPath        %v", receiver.EventReceiverPath))
+		out.WriteString(fmt.Sprintf("\n    EventWebhookSecret       %v", len(receiver.EventWebhookSecret) > 0))
+		out.WriteString(fmt.Sprintf("\n    EventBatchSize           %v", receiver.EventBatchSize))
+		out.WriteString(fmt.Sprintf("\n    EventBatchInSeconds      %v", receiver.EventBatchInSeconds))
+		out.WriteString(fmt.Sprintf("\n    EventFilePath            %v", receiver.EventFilePath))
+		out.WriteString(fmt.Sprintf("\n    EventFileMaxSize         %v", receiver.EventFileMaxSize))
+		out.WriteString(fmt.Sprintf("\n    EventFileMaxBackups      %v", receiver.EventFileMaxBackups))
+	}
 
 	return out.String()
 }
@@ -196,6 +196,36 @@ WalletEncrypted                       = false
 
 }
 
+func TestLoadLiveFeedReceivers(t *testing.T) {
+	var receiverConfig string = `
+	[LiveFeedReceiver "archive"]
+	EventReceiverHost = archive.local
+	EventReceiverPort = 9000
+	EventFilterTypes = entry-reveal,directory-block
+
+	[LiveFeedReceiver "alerting"]
+	EventReceiverHost = alerting.local
+	EventReceiverPort = 9001
+	`
+	cfg := new(FactomdConfig)
+	err := gcfg.ReadStringInto(cfg, receiverConfig)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if len(cfg.LiveFeedReceiver) != 2 {
+		t.Fatalf("Wrong number of receivers read - %v", len(cfg.LiveFeedReceiver))
+	}
+	if cfg.LiveFeedReceiver["archive"].EventReceiverPort != 9000 {
+		t.Errorf("Wrong variable read - %v", cfg.LiveFeedReceiver["archive"].EventReceiverPort)
+	}
+	if cfg.LiveFeedReceiver["archive"].EventFilterTypes != "entry-reveal,directory-block" {
+		t.Errorf("Wrong variable read - %v", cfg.LiveFeedReceiver["archive"].EventFilterTypes)
+	}
+	if cfg.LiveFeedReceiver["alerting"].EventReceiverHost != "alerting.local" {
+		t.Errorf("Wrong variable read - %v", cfg.LiveFeedReceiver["alerting"].EventReceiverHost)
+	}
+}
+
 func TestReadConfig(t *testing.T) {
 	fconfig := ReadConfig("")
 	if fconfig == nil {
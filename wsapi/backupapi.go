@@ -0,0 +1,81 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"path/filepath"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/backup"
+)
+
+// backupState is a state that backs up its database
+type backupState interface {
+	StartBackup(path string) error
+	GetBackupStatus() backup.Status
+}
+
+// BackupRequest starts a backup to a directory, or to a tarball if the path ends in .tar, .tar.gz or .tgz.
+// The path is on the node's file system and must not exist.
+type BackupRequest struct {
+	Path string `json:"path"`
+}
+
+func init() {
+	for _, m := range BackupMethods.Methods() {
+		DebugMethods.Register(m)
+	}
+}
+
+// BackupMethods are the methods of the debug API that back up the database while the node runs. They are also
+// available on the main network if an rpc user is configured
+var BackupMethods = NewMethodRegistry("factomd backup API", API_VERSION,
+	&Method{"backup", "Start a backup of the database and the fast boot save state", BackupRequest{}, backup.Status{}, HandleBackup},
+	&Method{"backup-status", "The progress of the running backup or the result of the last one", nil, backup.Status{}, HandleBackupStatus},
+)
+
+// getBackupState returns the state as a backupState. Backups write to the node's disk, so they need an rpc user to
+// be configured
+func getBackupState(state interfaces.IState, change bool) (backupState, *primitives.JSONError) {
+	if change && state.GetRpcUser() == "" {
+		return nil, NewAuthenticationRequiredError()
+	}
+	bs, ok := state.(backupState)
+	if !ok {
+		return nil, NewBackupError("backups are not supported")
+	}
+	return bs, nil
+}
+
+func HandleBackup(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	bs, jsonError := getBackupState(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(BackupRequest)
+	if err := MapToObject(params, request); err != nil || request.Path == "" {
+		return nil, NewInvalidParamsError()
+	}
+	if !filepath.IsAbs(request.Path) {
+		return nil, NewCustomInvalidParamsError("path has to be absolute")
+	}
+
+	if err := bs.StartBackup(filepath.Clean(request.Path)); err != nil {
+		return nil, NewBackupError(err.Error())
+	}
+	status := bs.GetBackupStatus()
+	return &status, nil
+}
+
+func HandleBackupStatus(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	bs, jsonError := getBackupState(state, false)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+	status := bs.GetBackupStatus()
+	return &status, nil
+}
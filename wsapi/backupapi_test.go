@@ -0,0 +1,71 @@
+package wsapi_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/backup"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestBackupMethods(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.tar.gz")
+
+	resp, jErr := HandleDebugRequest(state, primitives.NewJSON2Request("backup-status", 1, nil))
+	if assert.Nil(t, jErr) {
+		assert.False(t, resp.Result.(*backup.Status).Running)
+	}
+
+	// backups require an rpc user
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("backup", 1, BackupRequest{Path: path}))
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewAuthenticationRequiredError().Code, jErr.Code)
+	}
+	state.RpcUser = "user"
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("backup", 1, BackupRequest{Path: "relative"}))
+	assert.NotNil(t, jErr)
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("backup", 1, BackupRequest{Path: path}))
+	if !assert.Nil(t, jErr) {
+		return
+	}
+	var status *backup.Status
+	for i := 0; i < 100; i++ {
+		resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("backup-status", 1, nil))
+		if !assert.Nil(t, jErr) {
+			return
+		}
+		if status = resp.Result.(*backup.Status); !status.Running {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.False(t, status.Running)
+	assert.Empty(t, status.Error)
+	assert.Equal(t, path, status.Path)
+	assert.NotZero(t, status.Records)
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("backup", 1, BackupRequest{Path: path}))
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewBackupError(nil).Code, jErr.Code)
+	}
+
+	_, ok := OperatorMethods.Lookup("backup")
+	assert.True(t, ok)
+	_, ok = OperatorMethods.Lookup("peers")
+	assert.True(t, ok)
+}
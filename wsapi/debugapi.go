@@ -25,6 +25,20 @@ type success struct {
 }
 
 func HandleDebug(writer http.ResponseWriter, request *http.Request) {
+	serveMethods(writer, request, DebugMethods)
+}
+
+// OperatorMethods are the peer and backup methods of the debug API, which node operators use on networks that don't
+// run the full debug API
+var OperatorMethods = NewMethodRegistry("factomd operator API", API_VERSION,
+	append(append([]*Method{}, PeerMethods.Methods()...), BackupMethods.Methods()...)...)
+
+// HandleOperatorDebug serves only the OperatorMethods of the debug API
+func HandleOperatorDebug(writer http.ResponseWriter, request *http.Request) {
+	serveMethods(writer, request, OperatorMethods)
+}
+
+func serveMethods(writer http.ResponseWriter, request *http.Request, registry *MethodRegistry) {
 	_ = globals.Params
 
 	state, err := GetState(request)
@@ -51,7 +65,7 @@ func HandleDebug(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	jsonResp, jsonError := HandleDebugRequest(state, j)
+	jsonResp, jsonError := handleMethodRequest(state, j, registry)
 
 	if jsonError != nil {
 		HandleV2Error(writer, j, jsonError)
@@ -62,84 +76,19 @@ func HandleDebug(writer http.ResponseWriter, request *http.Request) {
 }
 
 func HandleDebugRequest(state interfaces.IState, j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
+	return handleMethodRequest(state, j, DebugMethods)
+}
+
+func handleMethodRequest(state interfaces.IState, j *primitives.JSON2Request, registry *MethodRegistry) (*primitives.JSON2Response, *primitives.JSONError) {
 	var resp interface{}
 	var jsonError *primitives.JSONError
 	params := j.Params
 	wsDebugLog.Printf("request %v", j.String())
 
-	switch j.Method {
-	case "audit-servers":
-		resp, jsonError = HandleAuditServers(state, params)
-		break
-	case "authorities":
-		resp, jsonError = HandleAuthorities(state, params)
-		break
-	case "configuration":
-		resp, jsonError = HandleConfig(state, params)
-		break
-	case "current-minute":
-		resp, jsonError = HandleCurrentMinute(state, params)
-		break
-	case "delay":
-		resp, jsonError = HandleDelay(state, params)
-		break
-	case "set-delay":
-		resp, jsonError = HandleSetDelay(state, params)
-		break
-	case "drop-rate":
-		resp, jsonError = HandleDropRate(state, params)
-		break
-	case "set-drop-rate":
-		resp, jsonError = HandleSetDropRate(state, params)
-		break
-	case "federated-servers":
-		resp, jsonError = HandleFedServers(state, params)
-		break
-	case "holding-queue":
-		resp, jsonError = HandleHoldingQueue(state, params)
-		break
-	case "messages":
-		resp, jsonError = HandleMessages(state, params)
-		break
-	case "network-info":
-		resp, jsonError = HandleNetworkInfo(state, params)
-		break
-	case "summary":
-		resp, jsonError = HandleSummary(state, params)
-		break
-	case "predictive-fer":
-		resp, jsonError = HandlePredictiveFER(state, params)
-		break
-	case "process-list":
-		resp, jsonError = HandleProcessList(state, params)
-		break
-	case "write-configuration":
-		resp, jsonError = HandleWriteConfig(state, params)
-		break
-	case "reload-configuration":
-		resp, jsonError = HandleReloadConfig(state, params)
-		break
-	case "sim-ctrl":
-		resp, jsonError = HandleSimControl(state, params)
-		break
-	case "wait-blocks":
-		resp, jsonError = HandleWaitBlocks(state, params)
-		break
-	case "wait-for-block":
-		resp, jsonError = HandleWaitForBlock(state, params)
-		break
-	case "wait-minutes":
-		resp, jsonError = HandleWaitMinutes(state, params)
-		break
-	case "wait-for-minute":
-		resp, jsonError = HandleWaitForMinute(state, params)
-		break
-	case "message-filter":
-		resp, jsonError = HandleMessageFilter(state, params)
-		break
-	default:
+	if m, ok := registry.Lookup(j.Method); ok {
+		resp, jsonError = m.Handler(state, params)
+	} else {
 		jsonError = NewMethodNotFoundError()
-		break
 	}
 	if jsonError != nil {
 		wsDebugLog.Printf("error %v", jsonError)
@@ -5,6 +5,8 @@
 package wsapi
 
 import (
+	"fmt"
+
 	"github.com/FactomProject/factomd/common/primitives"
 )
 
@@ -103,3 +105,33 @@ func NewReceiptError() *primitives.JSONError {
 func NewRepeatCommitError(data interface{}) *primitives.JSONError {
 	return primitives.NewJSONError(-32011, "Repeated Commit", data)
 }
+func NewExtIDIndexDisabledError() *primitives.JSONError {
+	return primitives.NewJSONError(-32012, "ExtID index is not enabled", nil)
+}
+func NewAddressHistoryIndexDisabledError() *primitives.JSONError {
+	return primitives.NewJSONError(-32013, "Address history index is not enabled", nil)
+}
+func NewBalanceCheckpointsDisabledError() *primitives.JSONError {
+	return primitives.NewJSONError(-32014, "Balance checkpoints are not enabled", nil)
+}
+func NewBatchTooLargeError(limit int) *primitives.JSONError {
+	return primitives.NewJSONError(-32015, "Batch contains too many requests", fmt.Sprintf("the limit is %d requests", limit))
+}
+func NewNetworkUnavailableError() *primitives.JSONError {
+	return primitives.NewJSONError(-32016, "P2P network is not available", nil)
+}
+func NewAuthenticationRequiredError() *primitives.JSONError {
+	return primitives.NewJSONError(-32017, "Authentication required", "an rpc user has to be configured")
+}
+func NewPeerError(data interface{}) *primitives.JSONError {
+	return primitives.NewJSONError(-32018, "Peer operation failed", data)
+}
+func NewPrunedDataError(data interface{}) *primitives.JSONError {
+	return primitives.NewJSONError(-32019, "Pruned data", data)
+}
+func NewBackupError(data interface{}) *primitives.JSONError {
+	return primitives.NewJSONError(-32020, "Backup failed", data)
+}
+func NewBalanceCheckpointsNotReadyError() *primitives.JSONError {
+	return primitives.NewJSONError(-32021, "Balance checkpoints are not computed yet", nil)
+}
@@ -189,6 +189,26 @@ var (
 		Name: "factomd_wsapi_v2_api_call_tpsrate_ns",
 		Help: "Time it takes to compelete a tpsrate",
 	})
+
+	HandleV2APICallEntriesByExtID = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_entriesbyextid_ns",
+		Help: "Time it takes to compelete an entries-by-extid",
+	})
+
+	HandleV2APICallAddressHistory = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_addresshistory_ns",
+		Help: "Time it takes to compelete an address-history",
+	})
+
+	HandleV2APICallChainEntries = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_chainentries_ns",
+		Help: "Time it takes to compelete a chain-entries",
+	})
+
+	HandleV2APICallBatch = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_batch_ns",
+		Help: "Time it takes to compelete a batch of requests",
+	})
 )
 
 var registered = false
@@ -236,4 +256,8 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HandleV2APICallTpsRate)
 	prometheus.MustRegister(HandleV2APICallAblock)
 	prometheus.MustRegister(HandleV2APICallFblock)
+	prometheus.MustRegister(HandleV2APICallEntriesByExtID)
+	prometheus.MustRegister(HandleV2APICallAddressHistory)
+	prometheus.MustRegister(HandleV2APICallChainEntries)
+	prometheus.MustRegister(HandleV2APICallBatch)
 }
@@ -0,0 +1,259 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// MethodHandler executes a JSON-RPC method of the v2 or debug API
+type MethodHandler func(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError)
+
+// Method describes a JSON-RPC method. Params and Result are values of the request and response types the
+// schema of the method is generated from, a nil Params means the method has no params and a nil Result
+// means the result isn't described.
+type Method struct {
+	Name    string
+	Summary string
+	Params  interface{}
+	Result  interface{}
+	Handler MethodHandler
+}
+
+// MethodRegistry holds the methods of an API, it dispatches the requests and describes the API
+type MethodRegistry struct {
+	Title   string
+	Version string
+
+	methods []*Method
+	byName  map[string]*Method
+}
+
+func NewMethodRegistry(title string, version string, methods ...*Method) *MethodRegistry {
+	r := new(MethodRegistry)
+	r.Title = title
+	r.Version = version
+	r.byName = map[string]*Method{}
+	for _, m := range methods {
+		r.Register(m)
+	}
+	return r
+}
+
+// Register adds the method to the registry, registering a name twice is a programming error
+func (r *MethodRegistry) Register(m *Method) {
+	if _, ok := r.byName[m.Name]; ok {
+		panic(fmt.Sprintf("method %s is registered twice", m.Name))
+	}
+	r.methods = append(r.methods, m)
+	r.byName[m.Name] = m
+}
+
+func (r *MethodRegistry) Lookup(name string) (*Method, bool) {
+	m, ok := r.byName[name]
+	return m, ok
+}
+
+// Methods returns the methods in the order they were registered
+func (r *MethodRegistry) Methods() []*Method {
+	return r.methods
+}
+
+// Route describes an endpoint of the v1 API. Body and Result are values of the request and response types,
+// path params are written as {name} or {name:regexp}.
+type Route struct {
+	Path    string
+	Method  string
+	Summary string
+	Body    interface{}
+	Result  interface{}
+	Handler http.HandlerFunc
+}
+
+type v1Message struct {
+	Response string
+	Success  bool
+}
+
+// V1Routes are the endpoints of the v1 API, most of them call the v2 method of the same name
+var V1Routes = []*Route{
+	{"/v1/factoid-submit/", "POST", "Submit a factoid transaction", struct{ Transaction string }{}, v1Message{}, HandleFactoidSubmit},
+	{"/v1/commit-chain/", "POST", "Commit a new chain", struct{ CommitChainMsg string }{}, nil, HandleCommitChain},
+	{"/v1/reveal-chain/", "POST", "Reveal the first entry of a new chain", struct{ Entry string }{}, nil, HandleRevealChain},
+	{"/v1/commit-entry/", "POST", "Commit an entry", struct{ CommitEntryMsg string }{}, nil, HandleCommitEntry},
+	{"/v1/reveal-entry/", "POST", "Reveal an entry", struct{ Entry string }{}, nil, HandleRevealEntry},
+
+	{"/v1/directory-block-head/", "GET", "The key merkle root of the directory block head", nil, struct{ KeyMR string }{}, HandleDirectoryBlockHead},
+	{"/v1/get-raw-data/{hash}", "GET", "The raw data of a block or entry", nil, RawDataResponse{}, HandleGetRaw},
+	{"/v1/get-receipt/{hash}", "GET", "The receipt of an entry", nil, ReceiptResponse{}, HandleGetReceipt},
+	{"/v1/directory-block-by-keymr/{keymr}", "GET", "A directory block by its key merkle root", nil, struct {
+		Header struct {
+			PrevBlockKeyMR string
+			SequenceNumber int64
+			Timestamp      int64
+		}
+		EntryBlockList []struct{ ChainID, KeyMR string }
+	}{}, HandleDirectoryBlock},
+	{"/v1/directory-block-height/", "GET", "The height of the directory block head", nil, struct{ Height int64 }{}, HandleDirectoryBlockHeight},
+	{"/v1/entry-block-by-keymr/{keymr}", "GET", "An entry block by its key merkle root", nil, struct {
+		Header struct {
+			BlockSequenceNumber int64
+			ChainID             string
+			PrevKeyMR           string
+			Timestamp           int64
+			DBHeight            int64
+		}
+		EntryList []EntryAddr
+	}{}, HandleEntryBlock},
+	{"/v1/entry-by-hash/{hash}", "GET", "An entry by its hash", nil, EntryStruct{}, HandleEntry},
+	{"/v1/chain-head/{chainid}", "GET", "The key merkle root of the last entry block of a chain", nil, struct{ ChainHead string }{}, HandleChainHead},
+	{"/v1/entry-credit-balance/{address}", "GET", "The balance of an entry credit address", nil, v1Message{}, HandleEntryCreditBalance},
+	{"/v1/factoid-balance/{address}", "GET", "The balance of a factoid address", nil, v1Message{}, HandleFactoidBalance},
+	{"/v1/factoid-get-fee/", "GET", "The number of factoshis per entry credit", nil, struct{ Fee int64 }{}, HandleGetFee},
+	{"/v1/properties/", "GET", "The version of factomd", nil, struct{ Protocol_Version, Factomd_Version string }{}, HandleProperties},
+	{"/v1/heights/", "GET", "The heights of the blocks known to the node", nil, HeightsResponse{}, HandleHeights},
+
+	{"/v1/dblock-by-height/{height:[0-9]+}", "GET", "A directory block by its height", nil, BlockHeightResponse{}, HandleDBlockByHeight},
+	{"/v1/ecblock-by-height/{height:[0-9]+}", "GET", "An entry credit block by its height", nil, BlockHeightResponse{}, HandleECBlockByHeight},
+	{"/v1/fblock-by-height/{height:[0-9]+}", "GET", "A factoid block by its height", nil, BlockHeightResponse{}, HandleFBlockByHeight},
+	{"/v1/ablock-by-height/{height:[0-9]+}", "GET", "An admin block by its height", nil, BlockHeightResponse{}, HandleABlockByHeight},
+}
+
+// V2Methods are the methods of the v2 API
+var V2Methods = NewMethodRegistry("factomd v2 API", API_VERSION,
+	&Method{"replay-from-height", "Emit the events of the directory blocks in a range of heights", ReplayRequest{}, SendReplayMessageResponse{}, HandleV2ReplayDBFromHeight},
+	&Method{"anchors", "The bitcoin and ethereum anchors of a directory block", HeightOrHashRequest{}, AnchorsResponse{}, HandleV2Anchors},
+	&Method{"chain-head", "The key merkle root of the last entry block of a chain", ChainIDRequest{}, ChainHeadResponse{}, HandleV2ChainHead},
+	&Method{"chain-entries", "The entries of a chain within a range of heights", ChainEntriesRequest{}, ChainEntriesResponse{}, HandleV2ChainEntries},
+	&Method{"commit-chain", "Commit a new chain", MessageRequest{}, CommitChainResponse{}, HandleV2CommitChain},
+	&Method{"commit-entry", "Commit an entry", MessageRequest{}, CommitEntryResponse{}, HandleV2CommitEntry},
+	&Method{"current-minute", "The current minute and block times of the node", nil, CurrentMinuteResponse{}, HandleV2CurrentMinute},
+	&Method{"directory-block", "A directory block by its key merkle root", KeyMRRequest{}, DirectoryBlockResponse{}, HandleV2DirectoryBlock},
+	&Method{"directory-block-head", "The key merkle root of the directory block head", nil, DirectoryBlockHeadResponse{}, HandleV2DirectoryBlockHead},
+	&Method{"entry-block", "An entry block by its key merkle root", KeyMRRequest{}, EntryBlockResponse{}, HandleV2EntryBlock},
+	&Method{"admin-block", "An admin block by its key merkle root", KeyMRRequest{}, BlockHeightResponse{}, HandleV2AdminBlock},
+	&Method{"factoid-block", "A factoid block by its key merkle root", KeyMRRequest{}, BlockHeightResponse{}, HandleV2FactoidBlock},
+	&Method{"entrycredit-block", "An entry credit block by its key merkle root", KeyMRRequest{}, EntryCreditBlockResponse{}, HandleV2EntryCreditBlock},
+	&Method{"entry", "An entry by its hash", HashRequest{}, EntryResponse{}, HandleV2Entry},
+	&Method{"entries-by-extid", "The entries with the leading external ids", EntriesByExtIDRequest{}, EntriesByExtIDResponse{}, HandleV2EntriesByExtID},
+	&Method{"entry-credit-balance", "The balance of an entry credit address", AddressRequest{}, EntryCreditBalanceResponse{}, HandleV2EntryCreditBalance},
+	&Method{"entry-credit-rate", "The number of factoshis per entry credit", nil, EntryCreditRateResponse{}, HandleV2EntryCreditRate},
+	&Method{"factoid-balance", "The balance of a factoid address", AddressRequest{}, FactoidBalanceResponse{}, HandleV2FactoidBalance},
+	&Method{"address-history", "The transactions of a factoid or entry credit address", AddressHistoryRequest{}, AddressHistoryResponse{}, HandleV2AddressHistory},
+	&Method{"factoid-submit", "Submit a factoid transaction", TransactionRequest{}, FactoidSubmitResponse{}, HandleV2FactoidSubmit},
+	&Method{"heights", "The heights of the blocks known to the node", nil, HeightsResponse{}, HandleV2Heights},
+	&Method{"properties", "The versions of factomd and the API", nil, PropertiesResponse{}, HandleV2Properties},
+	&Method{"raw-data", "The raw data of a block or entry", HashRequest{}, RawDataResponse{}, HandleV2RawData},
+	&Method{"receipt", "The receipt of an entry", ReceiptRequest{}, ReceiptResponse{}, HandleV2Receipt},
+	&Method{"reveal-chain", "Reveal the first entry of a new chain", EntryRequest{}, RevealEntryResponse{}, HandleV2RevealChain},
+	&Method{"reveal-entry", "Reveal an entry", EntryRequest{}, RevealEntryResponse{}, HandleV2RevealEntry},
+	&Method{"factoid-ack", "The status of a factoid transaction", AckRequest{}, FactoidTxStatus{}, HandleV2FactoidACK},
+	&Method{"entry-ack", "The status of an entry", AckRequest{}, EntryStatus{}, HandleV2EntryACK},
+	&Method{"pending-entries", "The entries that are not in a block yet", ChainIDRequest{}, []interfaces.IPendingEntry{}, HandleV2GetPendingEntries},
+	&Method{"pending-transactions", "The factoid transactions that are not in a block yet", AddressRequest{}, []interfaces.IPendingTransaction{}, HandleV2GetPendingTransactions},
+	&Method{"send-raw-message", "Send a raw message to the network", SendRawMessageRequest{}, SendRawMessageResponse{}, HandleV2SendRawMessage},
+	&Method{"transaction", "A factoid or entry credit transaction by its id", HashRequest{}, TransactionResponse{}, HandleV2GetTranasction},
+	&Method{"dblock-by-height", "A directory block by its height", HeightRequest{}, BlockHeightResponse{}, HandleV2DBlockByHeight},
+	&Method{"ecblock-by-height", "An entry credit block by its height", HeightRequest{}, EntryCreditBlockResponse{}, HandleV2ECBlockByHeight},
+	&Method{"fblock-by-height", "A factoid block by its height", HeightRequest{}, BlockHeightResponse{}, HandleV2FBlockByHeight},
+	&Method{"ablock-by-height", "An admin block by its height", HeightRequest{}, BlockHeightResponse{}, HandleV2ABlockByHeight},
+	&Method{"authorities", "The authority set", nil, struct {
+		Authorities []interfaces.IAuthority `json:"authorities"`
+	}{}, HandleAuthorities},
+	&Method{"tps-rate", "The transaction rate of the node", nil, TransactionRateResponse{}, HandleV2TransactionRate},
+	&Method{"ack", "The status of an entry or factoid transaction", EntryAckWithChainRequest{}, EntryStatus{}, HandleV2ACKWithChain},
+	&Method{"multiple-fct-balances", "The balances of several factoid addresses", MultipleBalancesRequest{}, MultipleBalancesResponse{}, HandleV2MultipleFCTBalances},
+	&Method{"multiple-ec-balances", "The balances of several entry credit addresses", MultipleBalancesRequest{}, MultipleBalancesResponse{}, HandleV2MultipleECBalances},
+	&Method{"diagnostics", "The role, sync and election status of the node", nil, DiagnosticsResponse{}, HandleV2Diagnostics},
+)
+
+type blocksRequest struct {
+	Blocks int `json:"blocks"`
+}
+
+type blockRequest struct {
+	Block int `json:"block"`
+}
+
+type minutesRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+type minuteRequest struct {
+	Minute int `json:"minute"`
+}
+
+type messageFilterRequest struct {
+	OutputRegEx string `json:"output-regex"`
+	InputRegEx  string `json:"input-regex"`
+}
+
+// DebugMethods are the methods of the debug API
+var DebugMethods = NewMethodRegistry("factomd debug API", API_VERSION,
+	&Method{"audit-servers", "The audit servers", nil, struct{ AuditServers []interfaces.IServer }{}, HandleAuditServers},
+	&Method{"authorities", "The authority set", nil, struct {
+		Authorities []interfaces.IAuthority `json:"authorities"`
+	}{}, HandleAuthorities},
+	&Method{"configuration", "The configuration of the node", nil, nil, HandleConfig},
+	&Method{"current-minute", "The current minute", nil, struct{ Minute int }{}, HandleCurrentMinute},
+	&Method{"delay", "The network delay", nil, struct{ Delay int64 }{}, HandleDelay},
+	&Method{"set-delay", "Set the network delay", SetDelayRequest{}, struct{ Delay int64 }{}, HandleSetDelay},
+	&Method{"drop-rate", "The message drop rate", nil, struct{ DropRate int }{}, HandleDropRate},
+	&Method{"set-drop-rate", "Set the message drop rate", SetDropRateRequest{}, struct{ DropRate int }{}, HandleSetDropRate},
+	&Method{"federated-servers", "The federated servers", nil, struct{ FederatedServers []interfaces.IServer }{}, HandleFedServers},
+	&Method{"holding-queue", "The messages in the holding queue", nil, struct{ Messages []interfaces.IMsg }{}, HandleHoldingQueue},
+	&Method{"messages", "The messages of the journal", nil, struct{ Messages []json.RawMessage }{}, HandleMessages},
+	&Method{"network-info", "The name, role and network of the node", nil, struct {
+		NodeName      string
+		Role          string
+		NetworkNumber int
+		NetworkName   string
+		NetworkID     uint32
+	}{}, HandleNetworkInfo},
+	&Method{"summary", "The summary of the state", nil, struct{ Summary string }{}, HandleSummary},
+	&Method{"predictive-fer", "The predictive factoid to entry credit rate", nil, struct{ PredictiveFER uint64 }{}, HandlePredictiveFER},
+	&Method{"process-list", "The process list", nil, struct{ ProcessList string }{}, HandleProcessList},
+	&Method{"write-configuration", "Overwrite the configuration file", struct{ Config string }{}, success{}, HandleWriteConfig},
+	&Method{"reload-configuration", "Reload the configuration file", nil, nil, HandleReloadConfig},
+	&Method{"sim-ctrl", "Run simulator commands", GetCommands{}, success{}, HandleSimControl},
+	&Method{"wait-blocks", "Wait for a number of blocks", blocksRequest{}, success{}, HandleWaitBlocks},
+	&Method{"wait-for-block", "Wait for a block", blockRequest{}, success{}, HandleWaitForBlock},
+	&Method{"wait-minutes", "Wait for a number of minutes", minutesRequest{}, success{}, HandleWaitMinutes},
+	&Method{"wait-for-minute", "Wait for a minute", minuteRequest{}, success{}, HandleWaitForMinute},
+	&Method{"message-filter", "Filter the input and output messages", messageFilterRequest{}, MessageFilter{}, HandleMessageFilter},
+)
+
+func init() {
+	V2Methods.Register(&Method{"rpc.discover", "The OpenRPC document of the v2 API, or the OpenAPI document of the v1 API", DiscoverRequest{}, nil, HandleV2Discover})
+	DebugMethods.Register(&Method{"rpc.discover", "The OpenRPC document of the debug API", nil, nil, HandleDebugDiscover})
+}
+
+// HandleV2Discover describes the v2 API, or the v1 API when it is asked for
+func HandleV2Discover(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	request := new(DiscoverRequest)
+	if params != nil {
+		if err := MapToObject(params, request); err != nil {
+			return nil, NewInvalidParamsError()
+		}
+	}
+
+	switch request.API {
+	case "", "v2":
+		return V2Methods.OpenRPC(), nil
+	case "v1":
+		return OpenAPI("factomd v1 API", "1.0", V1Routes), nil
+	default:
+		return nil, NewCustomInvalidParamsError("api must be v1 or v2")
+	}
+}
+
+func HandleDebugDiscover(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	return DebugMethods.OpenRPC(), nil
+}
@@ -0,0 +1,140 @@
+package wsapi_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+// decodeDocument round trips the document through JSON the way a client sees it
+func decodeDocument(t *testing.T, document interface{}) map[string]interface{} {
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// checkRefs fails the test when a $ref of the document points to a missing component
+func checkRefs(t *testing.T, document map[string]interface{}) {
+	schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, value := range v {
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(document)
+}
+
+func findMethod(document map[string]interface{}, name string) map[string]interface{} {
+	for _, m := range document["methods"].([]interface{}) {
+		method := m.(map[string]interface{})
+		if method["name"] == name {
+			return method
+		}
+	}
+	return nil
+}
+
+func TestV2MethodsOpenRPC(t *testing.T) {
+	document := decodeDocument(t, V2Methods.OpenRPC())
+	assert.Equal(t, "1.2.6", document["openrpc"])
+	checkRefs(t, document)
+
+	for _, m := range V2Methods.Methods() {
+		assert.NotNil(t, findMethod(document, m.Name), "method %s is not described", m.Name)
+		assert.NotNil(t, m.Handler, "method %s has no handler", m.Name)
+	}
+
+	method := findMethod(document, "chain-entries")
+	if assert.NotNil(t, method) {
+		params := map[string]map[string]interface{}{}
+		for _, p := range method["params"].([]interface{}) {
+			param := p.(map[string]interface{})
+			params[param["name"].(string)] = param
+		}
+		assert.Equal(t, true, params["chainid"]["required"])
+		assert.Equal(t, "string", params["chainid"]["schema"].(map[string]interface{})["type"])
+		assert.Equal(t, false, params["limit"]["required"])
+
+		ref := method["result"].(map[string]interface{})["schema"].(map[string]interface{})["$ref"]
+		assert.Equal(t, "#/components/schemas/ChainEntriesResponse", ref)
+		schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		properties := schemas["ChainEntriesResponse"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Equal(t, "array", properties["entries"].(map[string]interface{})["type"])
+	}
+
+	method = findMethod(document, "heights")
+	if assert.NotNil(t, method) {
+		assert.Empty(t, method["params"])
+	}
+}
+
+func TestDebugMethodsOpenRPC(t *testing.T) {
+	document := decodeDocument(t, DebugMethods.OpenRPC())
+	checkRefs(t, document)
+	assert.NotNil(t, findMethod(document, "wait-blocks"))
+	assert.NotNil(t, findMethod(document, "rpc.discover"))
+	assert.Nil(t, findMethod(document, "heights"))
+}
+
+func TestHandleV2Discover(t *testing.T) {
+	state := testHelper.CreateEmptyTestState()
+
+	resp, jErr := HandleV2JSONRequest(state, primitives.NewJSON2Request("rpc.discover", 1, nil))
+	if assert.Nil(t, jErr) {
+		document := decodeDocument(t, resp.Result)
+		assert.Equal(t, "1.2.6", document["openrpc"])
+		assert.NotNil(t, findMethod(document, "rpc.discover"))
+	}
+
+	resp, jErr = HandleV2JSONRequest(state, primitives.NewJSON2Request("rpc.discover", 1, DiscoverRequest{API: "v1"}))
+	if assert.Nil(t, jErr) {
+		document := decodeDocument(t, resp.Result)
+		assert.Equal(t, "3.0.3", document["openapi"])
+		checkRefs(t, document)
+
+		paths := document["paths"].(map[string]interface{})
+		assert.Equal(t, len(V1Routes), len(paths))
+		operation := paths["/v1/dblock-by-height/{height}"].(map[string]interface{})["get"].(map[string]interface{})
+		parameter := operation["parameters"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "height", parameter["name"])
+		assert.Equal(t, "integer", parameter["schema"].(map[string]interface{})["type"])
+
+		operation = paths["/v1/factoid-submit/"].(map[string]interface{})["post"].(map[string]interface{})
+		assert.NotNil(t, operation["requestBody"])
+	}
+
+	_, jErr = HandleV2JSONRequest(state, primitives.NewJSON2Request("rpc.discover", 1, DiscoverRequest{API: "v3"}))
+	assert.NotNil(t, jErr)
+
+	resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("rpc.discover", 1, nil))
+	if assert.Nil(t, jErr) {
+		document := decodeDocument(t, resp.Result)
+		assert.NotNil(t, findMethod(document, "sim-ctrl"))
+	}
+}
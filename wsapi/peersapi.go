@@ -0,0 +1,237 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"sort"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/p2p"
+)
+
+// networkState is a state that runs a p2p network
+type networkState interface {
+	GetNetworkController() *p2p.Network
+}
+
+type PeersResponse struct {
+	Info       p2p.Info          `json:"info"`
+	Peers      []p2p.PeerMetrics `json:"peers"`
+	Special    []p2p.Endpoint    `json:"special"`
+	Advertised p2p.Endpoint      `json:"advertised"`
+}
+
+type PeerConnectRequest struct {
+	Address string `json:"address"` // ip:port
+}
+
+type PeerConnectResponse struct {
+	Hash string `json:"hash"`
+}
+
+type PeerDisconnectRequest struct {
+	Peer string `json:"peer"` // hash of the peer
+}
+
+// PeerBanRequest bans either the address of a connected peer or an ip address or endpoint.
+// Duration is in seconds, 0 for the node's default
+type PeerBanRequest struct {
+	Peer     string `json:"peer,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Duration int64  `json:"duration,omitempty"`
+}
+
+type PeerUnbanRequest struct {
+	Address string `json:"address"`
+}
+
+type PeerUnbanResponse struct {
+	Unbanned bool `json:"unbanned"`
+}
+
+type PeerBan struct {
+	Address string    `json:"address"`
+	Until   time.Time `json:"until"`
+}
+
+type PeerBansResponse struct {
+	Bans []PeerBan `json:"bans"`
+}
+
+type SpecialPeersRequest struct {
+	Peers string `json:"peers"` // comma separated list of ip:port
+}
+
+type SpecialPeersResponse struct {
+	Special []p2p.Endpoint `json:"special"`
+}
+
+func init() {
+	for _, m := range PeerMethods.Methods() {
+		DebugMethods.Register(m)
+	}
+}
+
+// PeerMethods are the methods of the debug API that manage the p2p network. They are also
+// available on the main network if an rpc user is configured
+var PeerMethods = NewMethodRegistry("factomd peer API", API_VERSION,
+	&Method{"peers", "The connected peers and the state of the p2p network", nil, PeersResponse{}, HandlePeers},
+	&Method{"peer-connect", "Connect to a peer", PeerConnectRequest{}, PeerConnectResponse{}, HandlePeerConnect},
+	&Method{"peer-disconnect", "Disconnect a peer, it may connect again", PeerDisconnectRequest{}, success{}, HandlePeerDisconnect},
+	&Method{"peer-ban", "Ban the address of a peer, an ip address or an endpoint", PeerBanRequest{}, PeerBansResponse{}, HandlePeerBan},
+	&Method{"peer-unban", "Lift the ban of an ip address or an endpoint", PeerUnbanRequest{}, PeerUnbanResponse{}, HandlePeerUnban},
+	&Method{"peer-bans", "The banned ip addresses and endpoints", nil, PeerBansResponse{}, HandlePeerBans},
+	&Method{"special-peers-set", "Replace the special peers until the configuration is reloaded", SpecialPeersRequest{}, SpecialPeersResponse{}, HandleSpecialPeersSet},
+)
+
+// getNetwork returns the p2p network of the state. Methods that change the network need an rpc
+// user to be configured, so that the api isn't open to anyone
+func getNetwork(state interfaces.IState, change bool) (*p2p.Network, *primitives.JSONError) {
+	if change && state.GetRpcUser() == "" {
+		return nil, NewAuthenticationRequiredError()
+	}
+	ns, ok := state.(networkState)
+	if !ok || ns.GetNetworkController() == nil {
+		return nil, NewNetworkUnavailableError()
+	}
+	return ns.GetNetworkController(), nil
+}
+
+func HandlePeers(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, false)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	r := new(PeersResponse)
+	r.Info = network.GetInfo()
+	for _, m := range network.GetPeerMetrics() {
+		r.Peers = append(r.Peers, m)
+	}
+	sort.Slice(r.Peers, func(i, j int) bool { return r.Peers[i].Hash < r.Peers[j].Hash })
+	r.Special = network.SpecialEndpoints()
+	r.Advertised = network.AdvertisedEndpoint()
+	return r, nil
+}
+
+func HandlePeerConnect(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(PeerConnectRequest)
+	if err := MapToObject(params, request); err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	ep, err := p2p.ParseEndpoint(request.Address)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+
+	hash, err := network.Connect(ep)
+	if err != nil {
+		return nil, NewPeerError(err.Error())
+	}
+	return &PeerConnectResponse{Hash: hash}, nil
+}
+
+func HandlePeerDisconnect(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(PeerDisconnectRequest)
+	if err := MapToObject(params, request); err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if _, ok := network.GetPeerMetrics()[request.Peer]; !ok {
+		return nil, NewPeerError("peer not found")
+	}
+
+	network.Disconnect(request.Peer)
+	return &success{Status: "Success!"}, nil
+}
+
+func HandlePeerBan(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(PeerBanRequest)
+	if err := MapToObject(params, request); err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if (request.Peer == "") == (request.Address == "") || request.Duration < 0 {
+		return nil, NewCustomInvalidParamsError("either peer or address has to be set")
+	}
+
+	duration := time.Duration(request.Duration) * time.Second
+	var err error
+	if request.Peer != "" {
+		err = network.BanPeer(request.Peer, duration)
+	} else {
+		err = network.BanAddress(request.Address, duration)
+	}
+	if err != nil {
+		return nil, NewPeerError(err.Error())
+	}
+	return makePeerBans(network), nil
+}
+
+func HandlePeerUnban(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(PeerUnbanRequest)
+	if err := MapToObject(params, request); err != nil || request.Address == "" {
+		return nil, NewInvalidParamsError()
+	}
+	return &PeerUnbanResponse{Unbanned: network.Unban(request.Address)}, nil
+}
+
+func HandlePeerBans(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, false)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+	return makePeerBans(network), nil
+}
+
+func makePeerBans(network *p2p.Network) *PeerBansResponse {
+	r := new(PeerBansResponse)
+	r.Bans = make([]PeerBan, 0)
+	for addr, until := range network.Bans() {
+		r.Bans = append(r.Bans, PeerBan{Address: addr, Until: until})
+	}
+	sort.Slice(r.Bans, func(i, j int) bool { return r.Bans[i].Address < r.Bans[j].Address })
+	return r
+}
+
+func HandleSpecialPeersSet(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	network, jsonError := getNetwork(state, true)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+
+	request := new(SpecialPeersRequest)
+	if err := MapToObject(params, request); err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	special, err := network.ReplaceSpecial(request.Peers)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	r := new(SpecialPeersResponse)
+	r.Special = append(make([]p2p.Endpoint, 0), special...)
+	return r, nil
+}
@@ -0,0 +1,83 @@
+package wsapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/p2p"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestPeerMethods(t *testing.T) {
+	state := testHelper.CreateEmptyTestState()
+
+	_, jErr := HandleDebugRequest(state, primitives.NewJSON2Request("peers", 1, nil))
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewNetworkUnavailableError().Code, jErr.Code)
+	}
+
+	conf := p2p.DefaultP2PConfiguration()
+	conf.SeedURL = ""
+	conf.Special = ""
+	conf.EnablePrometheus = false
+	conf.Network = p2p.NewNetworkID("wsapi-unit-test")
+	network, err := p2p.NewNetwork(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.NetworkController = network
+
+	resp, jErr := HandleDebugRequest(state, primitives.NewJSON2Request("peers", 1, nil))
+	if assert.Nil(t, jErr) {
+		peers := resp.Result.(*PeersResponse)
+		assert.Empty(t, peers.Peers)
+		assert.Equal(t, network.GetInfo(), peers.Info)
+	}
+
+	// changes require an rpc user
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-ban", 1, PeerBanRequest{Address: "10.0.0.1"}))
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewAuthenticationRequiredError().Code, jErr.Code)
+	}
+	state.RpcUser = "user"
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-ban", 1, PeerBanRequest{}))
+	assert.NotNil(t, jErr)
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-ban", 1, PeerBanRequest{Peer: "unknown"}))
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewPeerError(nil).Code, jErr.Code)
+	}
+
+	resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-ban", 1, PeerBanRequest{Address: "10.0.0.1", Duration: 60}))
+	if assert.Nil(t, jErr) {
+		bans := resp.Result.(*PeerBansResponse)
+		if assert.Len(t, bans.Bans, 1) {
+			assert.Equal(t, "10.0.0.1", bans.Bans[0].Address)
+		}
+	}
+
+	resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-unban", 1, PeerUnbanRequest{Address: "10.0.0.1"}))
+	if assert.Nil(t, jErr) {
+		assert.True(t, resp.Result.(*PeerUnbanResponse).Unbanned)
+	}
+	resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-bans", 1, nil))
+	if assert.Nil(t, jErr) {
+		assert.Empty(t, resp.Result.(*PeerBansResponse).Bans)
+	}
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("special-peers-set", 1, SpecialPeersRequest{Peers: "10.0.0.2:8108,bad"}))
+	assert.NotNil(t, jErr)
+	resp, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("special-peers-set", 1, SpecialPeersRequest{Peers: "10.0.0.2:8108, [2001:db8::1]:8108"}))
+	if assert.Nil(t, jErr) {
+		assert.Len(t, resp.Result.(*SpecialPeersResponse).Special, 2)
+		assert.Len(t, network.SpecialEndpoints(), 2)
+	}
+
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-disconnect", 1, PeerDisconnectRequest{Peer: "unknown"}))
+	assert.NotNil(t, jErr)
+	_, jErr = HandleDebugRequest(state, primitives.NewJSON2Request("peer-connect", 1, PeerConnectRequest{Address: "nowhere"}))
+	assert.NotNil(t, jErr)
+}
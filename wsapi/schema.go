@@ -0,0 +1,286 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Schema is a JSON Schema object as used by the OpenRPC and OpenAPI documents
+type Schema map[string]interface{}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	hashType          = reflect.TypeOf((*interfaces.IHash)(nil)).Elem()
+)
+
+// schemaGenerator builds the schemas of the request and response structs, named structs are added once
+// to the components of the document and referenced from everywhere else
+type schemaGenerator struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	g := new(schemaGenerator)
+	g.components = map[string]Schema{}
+	g.names = map[reflect.Type]string{}
+	return g
+}
+
+// SchemaOf returns the schema of the value, a nil value may hold anything
+func (g *schemaGenerator) SchemaOf(value interface{}) Schema {
+	if value == nil {
+		return Schema{}
+	}
+	return g.schema(reflect.TypeOf(value))
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return Schema{}
+	case t == hashType:
+		return Schema{"type": "string", "pattern": "^[0-9a-f]{64}$"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// The encoding is up to the type, the hashes and blocks marshal to strings and objects
+		return Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return Schema{"$ref": schemaRefPrefix + g.component(t)}
+	default:
+		// interfaces, functions and channels
+		return Schema{}
+	}
+}
+
+// component adds the named struct to the components and returns its name
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, ok := g.components[name]; ok {
+		// Types of different packages with the same name are told apart by their package
+		name = strings.Title(path.Base(t.PkgPath())) + name
+	}
+	g.names[t] = name
+
+	// Reserve the name before the fields are visited so that recursive types end up as references
+	g.components[name] = Schema{}
+	g.components[name] = g.structSchema(t)
+	return name
+}
+
+type schemaField struct {
+	Name     string
+	Required bool
+	Schema   Schema
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) Schema {
+	properties := Schema{}
+	required := []string{}
+	for _, field := range g.fields(t) {
+		properties[field.Name] = field.Schema
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields returns the fields of the struct the way encoding/json sees them, fields without omitempty
+// are always present and thus required
+func (g *schemaGenerator) fields(t reflect.Type) []schemaField {
+	fields := []schemaField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, options = tag[:idx], tag[idx+1:]
+		}
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, g.fields(embedded)...)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		omitempty := false
+		for _, option := range strings.Split(options, ",") {
+			omitempty = omitempty || option == "omitempty"
+		}
+		fields = append(fields, schemaField{Name: name, Required: !omitempty, Schema: g.schema(f.Type)})
+	}
+	return fields
+}
+
+// OpenRPC returns the OpenRPC document describing the methods of the registry
+func (r *MethodRegistry) OpenRPC() map[string]interface{} {
+	g := newSchemaGenerator()
+
+	methods := []interface{}{}
+	for _, m := range r.Methods() {
+		params := []interface{}{}
+		if m.Params != nil {
+			t := reflect.TypeOf(m.Params)
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Struct {
+				for _, field := range g.fields(t) {
+					params = append(params, map[string]interface{}{
+						"name":     field.Name,
+						"required": field.Required,
+						"schema":   field.Schema,
+					})
+				}
+			} else {
+				params = append(params, map[string]interface{}{"name": "params", "schema": g.SchemaOf(m.Params)})
+			}
+		}
+
+		methods = append(methods, map[string]interface{}{
+			"name":           m.Name,
+			"summary":        m.Summary,
+			"paramStructure": "by-name",
+			"params":         params,
+			"result":         map[string]interface{}{"name": "result", "schema": g.SchemaOf(m.Result)},
+		})
+	}
+
+	return map[string]interface{}{
+		"openrpc":    "1.2.6",
+		"info":       map[string]interface{}{"title": r.Title, "version": r.Version},
+		"methods":    methods,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+var routeParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// OpenAPI returns the OpenAPI document describing the routes
+func OpenAPI(title string, version string, routes []*Route) map[string]interface{} {
+	g := newSchemaGenerator()
+
+	paths := map[string]map[string]interface{}{}
+	for _, route := range routes {
+		parameters := []interface{}{}
+		for _, match := range routeParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			schema := Schema{"type": "string"}
+			if strings.Contains(match[2], "[0-9]") {
+				schema = Schema{"type": "integer", "minimum": 0}
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   schema,
+			})
+		}
+
+		operation := map[string]interface{}{
+			"summary":    route.Summary,
+			"parameters": parameters,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Success",
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": g.SchemaOf(route.Result)}},
+				},
+				"400": map[string]interface{}{"description": "Invalid request"},
+			},
+		}
+		if route.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.SchemaOf(route.Body)}},
+			}
+		}
+
+		p := routeParamPattern.ReplaceAllString(route.Path, "{$1}")
+		if paths[p] == nil {
+			paths[p] = map[string]interface{}{}
+		}
+		paths[p][strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas":         g.components,
+			"securitySchemes": map[string]interface{}{"basicAuth": map[string]interface{}{"type": "http", "scheme": "basic"}},
+		},
+		// The credentials are only needed when the node is configured with a rpc user
+		"security": []interface{}{map[string]interface{}{}, map[string]interface{}{"basicAuth": []string{}}},
+	}
+}
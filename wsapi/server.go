@@ -149,8 +149,11 @@ func (server *Server) AddRootEndpoints() {
 	server.router.MethodNotAllowedHandler = methodNotAllowedHandler()
 
 	// start the debugging api if we are not on the main network
+	// on the main network, only the peer and backup methods are served and only if an rpc user is configured
 	if state.GetNetworkName() != "MAIN" {
 		server.addRoute("/debug", HandleDebug).Methods("GET", "POST")
+	} else if state.GetRpcUser() != "" {
+		server.addRoute("/debug", HandleOperatorDebug).Methods("GET", "POST")
 	}
 }
 
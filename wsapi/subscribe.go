@@ -0,0 +1,223 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events/eventservices"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	TopicDirectoryBlock = "directory-block"
+	TopicEntry          = "entry"
+	TopicFactoidAck     = "factoid-ack"
+	TopicEntryAck       = "entry-ack"
+
+	maxSubscriptionsPerConnection = 32
+)
+
+func (server *Server) AddSubscribeEndpoints() {
+	server.addRoute("/v2/subscribe", HandleV2Subscribe)
+}
+
+// HandleV2Subscribe upgrades the request to a websocket on which the client sends JSON-RPC 2.0
+// `subscribe` and `unsubscribe` requests. Events matching a subscription are pushed to the client
+// as `subscription` notifications which carry the factom event as JSON.
+func HandleV2Subscribe(writer http.ResponseWriter, request *http.Request) {
+	state, err := GetState(request)
+	if err != nil {
+		wsLog.Errorf("failed to extract port from request: %s", err)
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := checkAuthHeader(state, request); err != nil {
+		handleUnauthorized(request, writer)
+		return
+	}
+
+	wsServer := websocket.Server{Handler: func(conn *websocket.Conn) {
+		session := newSubscriptionSession(conn, eventservices.GetEventHub())
+		session.Run()
+	}}
+	wsServer.ServeHTTP(writer, request)
+}
+
+type subscriptionSession struct {
+	conn          *websocket.Conn
+	subscriber    *eventservices.EventSubscriber
+	writeMutex    sync.Mutex
+	mutex         sync.RWMutex
+	subscriptions map[string]*SubscribeRequest
+	nextID        int
+}
+
+func newSubscriptionSession(conn *websocket.Conn, hub *eventservices.EventHub) *subscriptionSession {
+	return &subscriptionSession{
+		conn:          conn,
+		subscriber:    hub.Subscribe(),
+		subscriptions: make(map[string]*SubscribeRequest),
+	}
+}
+
+// Run handles the requests of the client until the connection is closed
+func (session *subscriptionSession) Run() {
+	defer session.subscriber.Close()
+	go session.forwardEvents()
+
+	for {
+		j := new(primitives.JSON2Request)
+		if err := websocket.JSON.Receive(session.conn, j); err != nil {
+			wsLog.Debugf("subscription connection closed: %v", err)
+			return
+		}
+		if j.JSONRPC != "2.0" {
+			session.send(errorResponse(j, NewInvalidRequestError()))
+			continue
+		}
+
+		var resp interface{}
+		var jsonError *primitives.JSONError
+		switch j.Method {
+		case "subscribe":
+			resp, jsonError = session.subscribe(j.Params)
+		case "unsubscribe":
+			resp, jsonError = session.unsubscribe(j.Params)
+		default:
+			jsonError = NewMethodNotFoundError()
+		}
+		if jsonError != nil {
+			session.send(errorResponse(j, jsonError))
+			continue
+		}
+
+		jsonResp := primitives.NewJSON2Response()
+		jsonResp.ID = j.ID
+		jsonResp.Result = resp
+		session.send(jsonResp)
+	}
+}
+
+func (session *subscriptionSession) subscribe(params interface{}) (interface{}, *primitives.JSONError) {
+	subscribeRequest := new(SubscribeRequest)
+	err := MapToObject(params, subscribeRequest)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	switch subscribeRequest.Topic {
+	case TopicDirectoryBlock, TopicEntry, TopicFactoidAck, TopicEntryAck:
+	default:
+		return nil, NewCustomInvalidParamsError("Unknown topic")
+	}
+	if len(subscribeRequest.ChainID) > 0 {
+		if subscribeRequest.Topic != TopicEntry && subscribeRequest.Topic != TopicEntryAck {
+			return nil, NewCustomInvalidParamsError("Topic does not support a chain id")
+		}
+		chainID, err := primitives.HexToHash(subscribeRequest.ChainID)
+		if err != nil {
+			return nil, NewInvalidHashError()
+		}
+		subscribeRequest.chainID = chainID.Bytes()
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	if len(session.subscriptions) >= maxSubscriptionsPerConnection {
+		return nil, NewCustomInvalidParamsError("Too many subscriptions")
+	}
+	session.nextID++
+	id := fmt.Sprintf("%d", session.nextID)
+	session.subscriptions[id] = subscribeRequest
+
+	return &SubscribeResponse{Subscription: id}, nil
+}
+
+func (session *subscriptionSession) unsubscribe(params interface{}) (interface{}, *primitives.JSONError) {
+	unsubscribeRequest := new(UnsubscribeRequest)
+	err := MapToObject(params, unsubscribeRequest)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	if _, ok := session.subscriptions[unsubscribeRequest.Subscription]; !ok {
+		return nil, NewCustomInvalidParamsError("Unknown subscription")
+	}
+	delete(session.subscriptions, unsubscribeRequest.Subscription)
+
+	return &UnsubscribeResponse{Success: true}, nil
+}
+
+func (session *subscriptionSession) forwardEvents() {
+	for event := range session.subscriber.Events() {
+		session.mutex.RLock()
+		var notifications []*SubscriptionNotification
+		for id, subscription := range session.subscriptions {
+			if subscription.matches(event) {
+				notifications = append(notifications, &SubscriptionNotification{Subscription: id, Topic: subscription.Topic, Event: event.FactomEvent})
+			}
+		}
+		session.mutex.RUnlock()
+
+		for _, notification := range notifications {
+			if err := session.send(primitives.NewJSON2Request("subscription", nil, notification)); err != nil {
+				// stop receiving events for the broken connection, closing it also ends the reading side
+				wsLog.Debugf("failed to send subscription notification: %v", err)
+				session.subscriber.Close()
+				session.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (session *subscriptionSession) send(msg interface{}) error {
+	session.writeMutex.Lock()
+	defer session.writeMutex.Unlock()
+	return websocket.JSON.Send(session.conn, msg)
+}
+
+func errorResponse(j *primitives.JSON2Request, jsonError *primitives.JSONError) *primitives.JSON2Response {
+	resp := primitives.NewJSON2Response()
+	resp.ID = j.ID
+	resp.Error = jsonError
+	return resp
+}
+
+// matches returns true if the event belongs to the topic, and chain if one is given, of the subscription
+func (subscription *SubscribeRequest) matches(event *eventservices.SubscriberEvent) bool {
+	switch subscription.Topic {
+	case TopicDirectoryBlock:
+		return event.GetDirectoryBlockCommit() != nil
+	case TopicEntry:
+		if reveal := event.GetEntryReveal(); reveal != nil && reveal.Entry != nil {
+			return subscription.matchesChain(reveal.Entry.ChainID)
+		}
+	case TopicEntryAck:
+		if event.GetChainCommit() != nil || event.GetEntryCommit() != nil {
+			return len(subscription.chainID) == 0
+		}
+		if reveal := event.GetEntryReveal(); reveal != nil && reveal.Entry != nil {
+			return subscription.matchesChain(reveal.Entry.ChainID)
+		}
+	case TopicFactoidAck:
+		_, factoidTransaction := event.Payload.(*messages.FactoidTransaction)
+		return factoidTransaction && event.GetStateChange() != nil
+	}
+	return false
+}
+
+func (subscription *SubscribeRequest) matchesChain(chainID []byte) bool {
+	return len(subscription.chainID) == 0 || bytes.Equal(subscription.chainID, chainID)
+}
@@ -0,0 +1,102 @@
+package wsapi_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
+	"github.com/FactomProject/factomd/events/eventservices"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestHandleV2Subscribe(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	delayedStart(t, state)
+
+	url := fmt.Sprintf("ws://localhost:%d/v2/subscribe", state.GetPort())
+	conn, err := websocket.Dial(url, "", fmt.Sprintf("http://localhost:%d/", state.GetPort()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	chainID := "888888b1255ea1cc6b9d6bb4a6b6b58d6b0e1e1d3c2d1e5d84e3e7a5a3b2c1d0"
+	requests := []*primitives.JSON2Request{
+		primitives.NewJSON2Request("subscribe", 1, SubscribeRequest{Topic: TopicDirectoryBlock}),
+		primitives.NewJSON2Request("subscribe", 2, SubscribeRequest{Topic: TopicEntry, ChainID: chainID}),
+		primitives.NewJSON2Request("subscribe", 3, SubscribeRequest{Topic: "unknown"}),
+		primitives.NewJSON2Request("subscribe", 4, SubscribeRequest{Topic: TopicDirectoryBlock, ChainID: chainID}),
+		primitives.NewJSON2Request("unknown-method", 5, nil),
+		primitives.NewJSON2Request("subscribe", 6, SubscribeRequest{Topic: TopicFactoidAck}),
+	}
+	for _, request := range requests {
+		assert.NoError(t, websocket.JSON.Send(conn, request))
+	}
+
+	responses := make([]*primitives.JSON2Response, len(requests))
+	for i := range responses {
+		responses[i] = new(primitives.JSON2Response)
+		assert.NoError(t, websocket.JSON.Receive(conn, responses[i]))
+	}
+	assert.Nil(t, responses[0].Error)
+	assert.Nil(t, responses[1].Error)
+	assert.Equal(t, NewCustomInvalidParamsError("Unknown topic"), responses[2].Error)
+	assert.Equal(t, NewCustomInvalidParamsError("Topic does not support a chain id"), responses[3].Error)
+	assert.Equal(t, NewMethodNotFoundError(), responses[4].Error)
+	assert.Nil(t, responses[5].Error)
+
+	chainIDBytes, _ := primitives.HexToHash(chainID)
+	stateChange := func(hash string) *eventmessages.FactomEvent {
+		return &eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_StateChange{StateChange: &eventmessages.StateChange{EntityHash: []byte(hash)}}}
+	}
+	events := []struct {
+		event   *eventmessages.FactomEvent
+		payload interfaces.IMsg
+	}{
+		{&eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_NodeMessage{NodeMessage: &eventmessages.NodeMessage{MessageText: "not subscribed"}}}, nil},
+		{&eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_EntryReveal{EntryReveal: &eventmessages.EntryReveal{Entry: &eventmessages.EntryBlockEntry{ChainID: primitives.NewZeroHash().Bytes()}}}}, nil},
+		{&eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_EntryReveal{EntryReveal: &eventmessages.EntryReveal{Entry: &eventmessages.EntryBlockEntry{ChainID: chainIDBytes.Bytes()}}}}, nil},
+		{&eventmessages.FactomEvent{Event: &eventmessages.FactomEvent_DirectoryBlockCommit{DirectoryBlockCommit: &eventmessages.DirectoryBlockCommit{}}}, nil},
+		{stateChange("commit"), messages.NewCommitEntryMsg()},
+		{stateChange("transaction"), new(messages.FactoidTransaction)},
+	}
+	// the hub subscription is created when the connection is accepted, wait for it before publishing
+	hub := eventservices.GetEventHub()
+	for start := time.Now(); !hub.HasSubscribers() && time.Since(start) < 5*time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, e := range events {
+		hub.Publish(e.event, e.payload)
+	}
+
+	notifications := make([]struct {
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+	}, 3)
+	for i := range notifications {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		assert.NoError(t, websocket.JSON.Receive(conn, &notifications[i]))
+		assert.Equal(t, "subscription", notifications[i].Method)
+	}
+	assert.Equal(t, TopicEntry, notifications[0].Params["topic"])
+	assert.Equal(t, TopicDirectoryBlock, notifications[1].Params["topic"])
+	// only the state change of the factoid transaction is a factoid ack
+	assert.Equal(t, TopicFactoidAck, notifications[2].Params["topic"])
+	if event, ok := notifications[2].Params["event"].(map[string]interface{}); assert.True(t, ok) {
+		assert.Contains(t, fmt.Sprint(event), base64.StdEncoding.EncodeToString([]byte("transaction")))
+	}
+
+	// after unsubscribing the connection should still be usable
+	assert.NoError(t, websocket.JSON.Send(conn, primitives.NewJSON2Request("unsubscribe", 7, UnsubscribeRequest{Subscription: "1"})))
+	response := new(primitives.JSON2Response)
+	assert.NoError(t, websocket.JSON.Receive(conn, response))
+	assert.Nil(t, response.Error)
+}
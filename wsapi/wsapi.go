@@ -43,6 +43,7 @@ func Start(state interfaces.IState) {
 		server.AddRootEndpoints()
 		server.AddV1Endpoints()
 		server.AddV2Endpoints()
+		server.AddSubscribeEndpoints()
 
 		Servers[port] = server
 
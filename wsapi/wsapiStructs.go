@@ -7,6 +7,7 @@ package wsapi
 import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/events/eventmessages/generated/eventmessages"
 	"github.com/FactomProject/factomd/receipts"
 )
 
@@ -140,6 +141,67 @@ type EntryResponse struct {
 	ExtIDs  []string `json:"extids"`
 }
 
+type EntriesByExtIDRequest struct {
+	ChainID        string   `json:"chainid,omitempty"`
+	ExtIDs         []string `json:"extids"`
+	Cursor         string   `json:"cursor,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+	IncludeContent bool     `json:"includecontent,omitempty"`
+}
+
+type EntryByExtID struct {
+	EntryHash string   `json:"entryhash"`
+	ChainID   string   `json:"chainid"`
+	ExtIDs    []string `json:"extids"`
+	Content   string   `json:"content,omitempty"`
+}
+
+type EntriesByExtIDResponse struct {
+	Entries    []EntryByExtID `json:"entries"`
+	NextCursor string         `json:"nextcursor,omitempty"`
+}
+
+type AddressHistoryRequest struct {
+	Address string `json:"address"`
+	Cursor  string `json:"cursor,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+type AddressHistoryTransaction struct {
+	Kind      string `json:"kind"`
+	Height    uint32 `json:"height"`
+	TxID      string `json:"txid"`
+	Amount    uint64 `json:"amount"`
+	EntryHash string `json:"entryhash,omitempty"`
+}
+
+type AddressHistoryResponse struct {
+	Transactions []AddressHistoryTransaction `json:"transactions"`
+	NextCursor   string                      `json:"nextcursor,omitempty"`
+}
+
+type ChainEntriesRequest struct {
+	ChainID        string  `json:"chainid"`
+	FromHeight     *uint32 `json:"from-height,omitempty"`
+	ToHeight       *uint32 `json:"to-height,omitempty"`
+	Cursor         string  `json:"cursor,omitempty"`
+	Limit          int     `json:"limit,omitempty"`
+	Reverse        bool    `json:"reverse,omitempty"`
+	IncludeContent bool    `json:"includecontent,omitempty"`
+}
+
+type ChainEntry struct {
+	EntryHash string   `json:"entryhash"`
+	DBHeight  uint32   `json:"dbheight"`
+	ExtIDs    []string `json:"extids,omitempty"`
+	Content   string   `json:"content,omitempty"`
+}
+
+type ChainEntriesResponse struct {
+	Entries    []ChainEntry `json:"entries"`
+	NextCursor string       `json:"nextcursor,omitempty"`
+}
+
 type ChainHeadResponse struct {
 	ChainHead          string `json:"chainhead"`
 	ChainInProcessList bool   `json:"chaininprocesslist"`
@@ -268,7 +330,8 @@ type BlockHeightResponse struct {
 //Requests
 
 type AddressRequest struct {
-	Address string `json:"address"`
+	Address string  `json:"address"`
+	Height  *uint32 `json:"height,omitempty"`
 }
 
 type ReplayRequest struct {
@@ -363,6 +426,18 @@ type MultipleECBalances struct {
 	Balances        []interface{} `json:"balances"`
 }
 
+// MultipleBalancesRequest and MultipleBalancesResponse describe the multiple-fct-balances and
+// multiple-ec-balances methods
+type MultipleBalancesRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+type MultipleBalancesResponse struct {
+	CurrentHeight   uint32                            `json:"currentheight"`
+	LastSavedHeight uint32                            `json:"lastsavedheight"`
+	Balances        []interfaces.StructToReturnValues `json:"balances"`
+}
+
 type DiagnosticsResponse struct {
 	Name      string `json:"name"`
 	ID        string `json:"id,omitempty"`
@@ -418,3 +493,31 @@ type AuditStatus struct {
 type MessageFilter struct {
 	Params string `json:"params"`
 }
+
+type DiscoverRequest struct {
+	API string `json:"api,omitempty"`
+}
+
+type SubscribeRequest struct {
+	Topic   string `json:"topic"`
+	ChainID string `json:"chainid,omitempty"`
+	chainID []byte
+}
+
+type SubscribeResponse struct {
+	Subscription string `json:"subscription"`
+}
+
+type UnsubscribeRequest struct {
+	Subscription string `json:"subscription"`
+}
+
+type UnsubscribeResponse struct {
+	Success bool `json:"success"`
+}
+
+type SubscriptionNotification struct {
+	Subscription string                     `json:"subscription"`
+	Topic        string                     `json:"topic"`
+	Event        *eventmessages.FactomEvent `json:"event"`
+}
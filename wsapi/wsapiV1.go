@@ -13,31 +13,9 @@ import (
 )
 
 func (server *Server) AddV1Endpoints() {
-	server.addRoute("/v1/factoid-submit/", HandleFactoidSubmit, CheckHttpPasswordOkV1Middleware()).Methods("POST")
-	server.addRoute("/v1/commit-chain/", HandleCommitChain, CheckHttpPasswordOkV1Middleware()).Methods("POST")
-	server.addRoute("/v1/reveal-chain/", HandleRevealChain, CheckHttpPasswordOkV1Middleware()).Methods("POST")
-	server.addRoute("/v1/commit-entry/", HandleCommitEntry, CheckHttpPasswordOkV1Middleware()).Methods("POST")
-	server.addRoute("/v1/reveal-entry/", HandleRevealEntry, CheckHttpPasswordOkV1Middleware()).Methods("POST")
-
-	server.addRoute("/v1/directory-block-head/", HandleDirectoryBlockHead, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/get-raw-data/{hash}", HandleGetRaw, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/get-receipt/{hash}", HandleGetReceipt, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/directory-block-by-keymr/{keymr}", HandleDirectoryBlock, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/directory-block-height/", HandleDirectoryBlockHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/entry-block-by-keymr/{keymr}", HandleEntryBlock, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/entry-by-hash/{hash}", HandleEntry, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/chain-head/{chainid}", HandleChainHead, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/entry-credit-balance/{address}", HandleEntryCreditBalance, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/factoid-balance/{address}", HandleFactoidBalance, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/factoid-get-fee/", HandleGetFee, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/properties/", HandleProperties, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/heights/", HandleHeights, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-
-	server.addRoute("/v1/dblock-by-height/{height:[0-9]+}", HandleDBlockByHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/ecblock-by-height/{height:[0-9]+}", HandleECBlockByHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/fblock-by-height/{height:[0-9]+}", HandleFBlockByHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/ablock-by-height/{height:[0-9]+}", HandleABlockByHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
-	server.addRoute("/v1/dblock-by-height/{height:[0-9]+}", HandleDBlockByHeight, CheckHttpPasswordOkV1Middleware()).Methods("GET")
+	for _, route := range V1Routes {
+		server.addRoute(route.Path, route.Handler, CheckHttpPasswordOkV1Middleware()).Methods(route.Method)
+	}
 }
 
 // Check authentication header
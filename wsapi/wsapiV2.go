@@ -5,14 +5,19 @@
 package wsapi
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"os"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/FactomProject/factomd/anchor"
@@ -25,11 +30,26 @@ import (
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/receipts"
 )
 
 const API_VERSION string = "2.0"
 
+const maxEntriesByExtIDLimit = 1000
+
+const maxAddressHistoryLimit = 1000
+
+const maxChainEntriesLimit = 1000
+
+var addressHistoryKinds = map[uint8]string{
+	interfaces.AddressHistoryInput:       "input",
+	interfaces.AddressHistoryOutput:      "output",
+	interfaces.AddressHistoryECPurchase:  "purchase",
+	interfaces.AddressHistoryCommitChain: "commit-chain",
+	interfaces.AddressHistoryCommitEntry: "commit-entry",
+}
+
 func (server *Server) AddV2Endpoints() {
 	server.addRoute("/v2", HandleV2)
 }
@@ -56,6 +76,11 @@ func HandleV2(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if isBatchRequest(body) {
+		HandleV2Batch(writer, state, body)
+		return
+	}
+
 	j, err := primitives.ParseJSON2Request(string(body))
 	if err != nil {
 		HandleV2Error(writer, nil, NewInvalidRequestError())
@@ -85,93 +110,115 @@ func HandleV2Request(_ http.ResponseWriter, request *http.Request, j *primitives
 	return HandleV2JSONRequest(state, j)
 }
 
+// isBatchRequest returns true when the body holds an array of requests
+func isBatchRequest(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// HandleV2Batch answers a batch of requests with an array of responses. The number of requests in a batch
+// is limited by the RequestLimit of the state. Notifications are not answered, a batch of only notifications
+// gets an empty reply.
+func HandleV2Batch(writer http.ResponseWriter, state interfaces.IState, body []byte) {
+	n := time.Now()
+	defer func() { HandleV2APICallBatch.Observe(float64(time.Since(n).Nanoseconds())) }()
+
+	var requests []json.RawMessage
+	if err := json.Unmarshal(body, &requests); err != nil {
+		HandleV2Error(writer, nil, NewParseError())
+		return
+	}
+	if len(requests) == 0 {
+		HandleV2Error(writer, nil, NewInvalidRequestError())
+		return
+	}
+	if limit := state.GetRequestLimit(); limit > 0 && len(requests) > limit {
+		HandleV2Error(writer, nil, NewBatchTooLargeError(limit))
+		return
+	}
+
+	responses := HandleV2BatchJSONRequest(state, requests)
+	if len(responses) == 0 {
+		return
+	}
+	data, err := json.Marshal(responses)
+	if err != nil {
+		wsLog.Errorf("failed to marshal batch response: %v", err)
+		HandleV2Error(writer, nil, NewInternalError())
+		return
+	}
+
+	_, err = writer.Write(data)
+	if err != nil {
+		wsLog.Errorf("failed to write response: %v", err)
+	}
+}
+
+// HandleV2BatchJSONRequest executes the requests of a batch in parallel and returns the responses in the
+// order of the requests. A request that can't be parsed or fails is answered with an error response, a
+// notification, a request without an id, is executed without a response.
+func HandleV2BatchJSONRequest(state interfaces.IState, requests []json.RawMessage) []*primitives.JSON2Response {
+	responses := make([]*primitives.JSON2Response, len(requests))
+
+	workers := runtime.NumCPU()
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+	next := make(chan int, len(requests))
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				responses[i] = handleV2BatchItem(state, requests[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	answered := responses[:0]
+	for _, resp := range responses {
+		if resp != nil {
+			answered = append(answered, resp)
+		}
+	}
+	return answered
+}
+
+func handleV2BatchItem(state interfaces.IState, request json.RawMessage) *primitives.JSON2Response {
+	j, err := primitives.ParseJSON2Request(string(request))
+	if err != nil {
+		resp := primitives.NewJSON2Response()
+		resp.Error = NewInvalidRequestError()
+		return resp
+	}
+
+	resp, jsonError := HandleV2JSONRequest(state, j)
+	if j.ID == nil {
+		return nil
+	}
+	if jsonError != nil {
+		resp = primitives.NewJSON2Response()
+		resp.ID = j.ID
+		resp.Error = jsonError
+	}
+	return resp
+}
+
 func HandleV2JSONRequest(state interfaces.IState, j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
 	var resp interface{}
 	var jsonError *primitives.JSONError
 	params := j.Params
 	wsLog.Infof("request %v", j.String())
-	switch j.Method {
-	case "replay-from-height":
-		resp, jsonError = HandleV2ReplayDBFromHeight(state, params)
-	case "anchors":
-		resp, jsonError = HandleV2Anchors(state, params)
-	case "chain-head":
-		resp, jsonError = HandleV2ChainHead(state, params)
-	case "commit-chain":
-		resp, jsonError = HandleV2CommitChain(state, params)
-	case "commit-entry":
-		resp, jsonError = HandleV2CommitEntry(state, params)
-	case "current-minute":
-		resp, jsonError = HandleV2CurrentMinute(state, params)
-	case "directory-block":
-		resp, jsonError = HandleV2DirectoryBlock(state, params)
-	case "directory-block-head":
-		resp, jsonError = HandleV2DirectoryBlockHead(state, params)
-	case "entry-block":
-		resp, jsonError = HandleV2EntryBlock(state, params)
-	case "admin-block":
-		resp, jsonError = HandleV2AdminBlock(state, params)
-	case "factoid-block":
-		resp, jsonError = HandleV2FactoidBlock(state, params)
-	case "entrycredit-block":
-		resp, jsonError = HandleV2EntryCreditBlock(state, params)
-	case "entry":
-		resp, jsonError = HandleV2Entry(state, params)
-	case "entry-credit-balance":
-		resp, jsonError = HandleV2EntryCreditBalance(state, params)
-	case "entry-credit-rate":
-		resp, jsonError = HandleV2EntryCreditRate(state, params)
-	case "factoid-balance":
-		resp, jsonError = HandleV2FactoidBalance(state, params)
-	case "factoid-submit":
-		resp, jsonError = HandleV2FactoidSubmit(state, params)
-	case "heights":
-		resp, jsonError = HandleV2Heights(state, params)
-	case "properties":
-		resp, jsonError = HandleV2Properties(state, params)
-	case "raw-data":
-		resp, jsonError = HandleV2RawData(state, params)
-	case "receipt":
-		resp, jsonError = HandleV2Receipt(state, params)
-	case "reveal-chain":
-		resp, jsonError = HandleV2RevealChain(state, params)
-	case "reveal-entry":
-		resp, jsonError = HandleV2RevealEntry(state, params)
-	case "factoid-ack":
-		resp, jsonError = HandleV2FactoidACK(state, params)
-	case "entry-ack":
-		resp, jsonError = HandleV2EntryACK(state, params)
-	case "pending-entries":
-		resp, jsonError = HandleV2GetPendingEntries(state, params)
-	case "pending-transactions":
-		resp, jsonError = HandleV2GetPendingTransactions(state, params)
-	case "send-raw-message":
-		resp, jsonError = HandleV2SendRawMessage(state, params)
-	case "transaction":
-		resp, jsonError = HandleV2GetTranasction(state, params)
-	case "dblock-by-height":
-		resp, jsonError = HandleV2DBlockByHeight(state, params)
-	case "ecblock-by-height":
-		resp, jsonError = HandleV2ECBlockByHeight(state, params)
-	case "fblock-by-height":
-		resp, jsonError = HandleV2FBlockByHeight(state, params)
-	case "ablock-by-height":
-		resp, jsonError = HandleV2ABlockByHeight(state, params)
-	case "authorities":
-		resp, jsonError = HandleAuthorities(state, params)
-	case "tps-rate":
-		resp, jsonError = HandleV2TransactionRate(state, params)
-	case "ack":
-		resp, jsonError = HandleV2ACKWithChain(state, params)
-	case "multiple-fct-balances":
-		resp, jsonError = HandleV2MultipleFCTBalances(state, params)
-	case "multiple-ec-balances":
-		resp, jsonError = HandleV2MultipleECBalances(state, params)
-	case "diagnostics":
-		resp, jsonError = HandleV2Diagnostics(state, params)
-		//case "factoid-accounts":
-		// resp, jsonError = HandleV2Accounts(state, params)
-	default:
+	if m, ok := V2Methods.Lookup(j.Method); ok {
+		resp, jsonError = m.Handler(state, params)
+	} else {
 		jsonError = NewMethodNotFoundError()
 	}
 	if jsonError != nil {
@@ -756,6 +803,8 @@ func HandleV2RawData(state interfaces.IState, params interface{}) (interface{},
 			b, _ = block.MarshalBinary()
 		} else if block, _ = dbase.FetchEntry(h); block != nil {
 			b, _ = block.MarshalBinary()
+		} else if jsonError := prunedDataError(dbase, h); jsonError != nil {
+			return nil, jsonError
 		} else {
 			return nil, NewObjectNotFoundError()
 		}
@@ -991,6 +1040,9 @@ func HandleV2EntryBlock(state interfaces.IState, params interface{}) (interface{
 			return nil, NewInvalidHashError()
 		}
 		if block == nil {
+			if jsonError := prunedDataError(dbase, h); jsonError != nil {
+				return nil, jsonError
+			}
 			return nil, NewBlockNotFoundError()
 		}
 	}
@@ -1062,6 +1114,9 @@ func HandleV2Entry(state interfaces.IState, params interface{}) (interface{}, *p
 			return nil, NewInvalidHashError()
 		}
 		if entry == nil {
+			if jsonError := prunedDataError(dbase, h); jsonError != nil {
+				return nil, jsonError
+			}
 			return nil, NewEntryNotFoundError()
 		}
 
@@ -1088,6 +1143,254 @@ func HandleV2Entry(state interfaces.IState, params interface{}) (interface{}, *p
 	return e, nil
 }
 
+// HandleV2EntriesByExtID returns the entries that start with the given ExtIDs in a chain, or the entries of all
+// chains that have the single given ExtID when no chain is given. The ExtID index needs to be enabled.
+func HandleV2EntriesByExtID(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer func() { HandleV2APICallEntriesByExtID.Observe(float64(time.Since(n).Nanoseconds())) }()
+
+	request := new(EntriesByExtIDRequest)
+	err := MapToObject(params, request)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	dbase := state.GetDB()
+	if !dbase.IsExtIDIndexEnabled() {
+		return nil, NewExtIDIndexDisabledError()
+	}
+
+	var chainID interfaces.IHash
+	if len(request.ChainID) > 0 {
+		chainID, err = primitives.HexToHash(request.ChainID)
+		if err != nil {
+			return nil, NewInvalidHashError()
+		}
+	}
+	if len(request.ExtIDs) == 0 || len(request.ExtIDs) > databaseOverlay.MaxIndexedExtIDs {
+		return nil, NewCustomInvalidParamsError(fmt.Sprintf("Between 1 and %d extids are required", databaseOverlay.MaxIndexedExtIDs))
+	}
+	if chainID == nil && len(request.ExtIDs) > 1 {
+		return nil, NewCustomInvalidParamsError("A single extid is required without a chainid")
+	}
+	extIDs := make([][]byte, len(request.ExtIDs))
+	for i, extID := range request.ExtIDs {
+		extIDs[i], err = hex.DecodeString(extID)
+		if err != nil {
+			return nil, NewCustomInvalidParamsError("Invalid extid")
+		}
+	}
+
+	var after interfaces.IHash
+	if len(request.Cursor) > 0 {
+		after, err = primitives.HexToHash(request.Cursor)
+		if err != nil {
+			return nil, NewCustomInvalidParamsError("Invalid cursor")
+		}
+	}
+	limit := request.Limit
+	if limit <= 0 || limit > maxEntriesByExtIDLimit {
+		limit = maxEntriesByExtIDLimit
+	}
+
+	hashes, more, err := dbase.FetchEntryHashesByExtID(chainID, extIDs, after, limit)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(EntriesByExtIDResponse)
+	resp.Entries = []EntryByExtID{}
+	for _, hash := range hashes {
+		entry, err := dbase.FetchEntry(hash)
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		if entry == nil {
+			continue
+		}
+		item := EntryByExtID{EntryHash: hash.String(), ChainID: entry.GetChainID().String()}
+		for _, extID := range entry.ExternalIDs() {
+			item.ExtIDs = append(item.ExtIDs, hex.EncodeToString(extID))
+		}
+		if request.IncludeContent {
+			item.Content = hex.EncodeToString(entry.GetContent())
+		}
+		resp.Entries = append(resp.Entries, item)
+	}
+	if more && len(hashes) > 0 {
+		resp.NextCursor = hashes[len(hashes)-1].String()
+	}
+	return resp, nil
+}
+
+// HandleV2ChainEntries returns the entries of the chain in the entry blocks between two directory block heights.
+// The cursor is the directory block height and the position in the entry block of the last returned entry.
+func HandleV2ChainEntries(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer func() { HandleV2APICallChainEntries.Observe(float64(time.Since(n).Nanoseconds())) }()
+
+	request := new(ChainEntriesRequest)
+	err := MapToObject(params, request)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	chainID, err := primitives.HexToHash(request.ChainID)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	from := uint32(0)
+	if request.FromHeight != nil {
+		from = *request.FromHeight
+	}
+	to := uint32(math.MaxUint32)
+	if request.ToHeight != nil {
+		to = *request.ToHeight
+	}
+	if from > to {
+		return nil, NewCustomInvalidParamsError("from-height is above to-height")
+	}
+
+	var cursorHeight, cursorIndex uint32
+	hasCursor := len(request.Cursor) > 0
+	if hasCursor {
+		cursor, err := hex.DecodeString(request.Cursor)
+		if err != nil || len(cursor) != 8 {
+			return nil, NewCustomInvalidParamsError("Invalid cursor")
+		}
+		cursorHeight = binary.BigEndian.Uint32(cursor[:4])
+		cursorIndex = binary.BigEndian.Uint32(cursor[4:])
+	}
+	limit := request.Limit
+	if limit <= 0 || limit > maxChainEntriesLimit {
+		limit = maxChainEntriesLimit
+	}
+
+	dbase := state.GetDB()
+	head, err := dbase.FetchHeadIndexByChainID(chainID)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if head == nil {
+		return nil, NewMissingChainHeadError()
+	}
+	if !dbase.IsChainKept(chainID) {
+		pruned, err := dbase.FetchPrunedHeight()
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		if from < pruned {
+			return nil, NewPrunedDataError(fmt.Sprintf("the entries of the chain below height %d are pruned", pruned))
+		}
+	}
+
+	// the page starts at the height of the cursor, every entry block after it holds at least one entry
+	if hasCursor && !request.Reverse && cursorHeight > from {
+		from = cursorHeight
+	}
+	if hasCursor && request.Reverse && cursorHeight < to {
+		to = cursorHeight
+	}
+	heightLimit := limit + 2
+	heights, err := dbase.FetchEBlockHeightsByChain(chainID, from, to, request.Reverse, heightLimit)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(ChainEntriesResponse)
+	resp.Entries = []ChainEntry{}
+	var last []byte
+	for _, height := range heights {
+		eblock, err := dbase.FetchEBlockByChainHeight(chainID, height)
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		if eblock == nil {
+			continue
+		}
+
+		hashes := eblock.GetEntryHashes()
+		for k := range hashes {
+			i := uint32(k)
+			if request.Reverse {
+				i = uint32(len(hashes) - 1 - k)
+			}
+			if hashes[i].IsMinuteMarker() {
+				continue
+			}
+			if hasCursor && height == cursorHeight && ((!request.Reverse && i <= cursorIndex) || (request.Reverse && i >= cursorIndex)) {
+				continue
+			}
+			if len(resp.Entries) == limit {
+				resp.NextCursor = hex.EncodeToString(last)
+				return resp, nil
+			}
+
+			item := ChainEntry{EntryHash: hashes[i].String(), DBHeight: height}
+			if request.IncludeContent {
+				entry, err := dbase.FetchEntry(hashes[i])
+				if err != nil {
+					return nil, NewInternalDatabaseError()
+				}
+				if entry != nil {
+					item.ExtIDs = []string{}
+					for _, extID := range entry.ExternalIDs() {
+						item.ExtIDs = append(item.ExtIDs, hex.EncodeToString(extID))
+					}
+					item.Content = hex.EncodeToString(entry.GetContent())
+				}
+			}
+			resp.Entries = append(resp.Entries, item)
+
+			last = make([]byte, 8)
+			binary.BigEndian.PutUint32(last[:4], height)
+			binary.BigEndian.PutUint32(last[4:], i)
+		}
+	}
+	// entry blocks that are missing may leave the page short of the limit, there can still be more heights
+	if len(heights) == heightLimit {
+		scanned := heights[len(heights)-1]
+		if last == nil || binary.BigEndian.Uint32(last[:4]) != scanned {
+			// the cursor is past every entry of the last scanned height, so the next page moves on
+			last = make([]byte, 8)
+			binary.BigEndian.PutUint32(last[:4], scanned)
+			if !request.Reverse {
+				binary.BigEndian.PutUint32(last[4:], math.MaxUint32)
+			}
+		}
+		resp.NextCursor = hex.EncodeToString(last)
+	}
+	return resp, nil
+}
+
+// prunedDataError returns an error if the entry or entry block of the hash has been deleted by a pruned node.
+// Pruned nodes keep the IncludedIn records of the entries and entry blocks they delete.
+func prunedDataError(dbase interfaces.DBOverlaySimple, hash interfaces.IHash) *primitives.JSONError {
+	if dbase.GetPruneDepth() == 0 {
+		return nil
+	}
+	included, err := dbase.FetchIncludedIn(hash)
+	if err != nil || included == nil {
+		return nil
+	}
+
+	// entries are included in entry blocks and entry blocks in directory blocks, a missing entry block is pruned
+	var chainID interfaces.IHash
+	if eblock, err := dbase.FetchEBlock(included); err == nil && eblock != nil {
+		chainID = eblock.GetChainID()
+	} else if dblock, err := dbase.FetchDBlock(included); err == nil && dblock != nil {
+		for _, e := range dblock.GetDBEntries() {
+			if e.GetKeyMR().IsSameAs(hash) {
+				chainID = e.GetChainID()
+			}
+		}
+	}
+	if chainID != nil && dbase.IsChainKept(chainID) {
+		return nil
+	}
+	return NewPrunedDataError(fmt.Sprintf("the node keeps only the last %d blocks of the chains that are not in its allow-list", dbase.GetPruneDepth()))
+}
+
 func HandleV2ChainHead(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallChainHead.Observe(float64(time.Since(n).Nanoseconds()))
@@ -1184,6 +1487,9 @@ func HandleV2EntryCreditBalance(state interfaces.IState, params interface{}) (in
 	if err != nil {
 		return nil, NewInvalidAddressError()
 	}
+	if ecadr.Height != nil {
+		return balanceAtHeight(state, *ecadr.Height, address, true)
+	}
 	resp := new(EntryCreditBalanceResponse)
 	resp.Balance = state.GetFactoidState().GetECBalance(address.Fixed())
 	return resp, nil
@@ -1259,12 +1565,119 @@ func HandleV2FactoidBalance(state interfaces.IState, params interface{}) (interf
 	if len(adr) != constants.HASH_LENGTH {
 		return nil, NewInvalidAddressError()
 	}
+	if fadr.Height != nil {
+		return balanceAtHeight(state, *fadr.Height, primitives.NewHash(adr), false)
+	}
 
 	resp := new(FactoidBalanceResponse)
 	resp.Balance = state.GetFactoidState().GetFactoidBalance(factoid.NewAddress(adr).Fixed())
 	return resp, nil
 }
 
+func HandleV2AddressHistory(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer func() { HandleV2APICallAddressHistory.Observe(float64(time.Since(n).Nanoseconds())) }()
+
+	request := new(AddressHistoryRequest)
+	err := MapToObject(params, request)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	dbase := state.GetDB()
+	if !dbase.IsAddressHistoryIndexEnabled() {
+		return nil, NewAddressHistoryIndexDisabledError()
+	}
+
+	var adr []byte
+	if primitives.ValidateFUserStr(request.Address) || primitives.ValidateECUserStr(request.Address) {
+		adr = primitives.ConvertUserStrToAddress(request.Address)
+	} else {
+		adr, err = hex.DecodeString(request.Address)
+		if err != nil {
+			return nil, NewInvalidAddressError()
+		}
+	}
+	if len(adr) != constants.HASH_LENGTH {
+		return nil, NewInvalidAddressError()
+	}
+
+	var after []byte
+	if len(request.Cursor) > 0 {
+		after, err = hex.DecodeString(request.Cursor)
+		if err != nil {
+			return nil, NewCustomInvalidParamsError("Invalid cursor")
+		}
+	}
+	limit := request.Limit
+	if limit <= 0 || limit > maxAddressHistoryLimit {
+		limit = maxAddressHistoryLimit
+	}
+
+	records, next, err := dbase.FetchAddressHistory(primitives.NewHash(adr), after, limit)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(AddressHistoryResponse)
+	resp.Transactions = []AddressHistoryTransaction{}
+	for _, record := range records {
+		item := AddressHistoryTransaction{
+			Kind:   addressHistoryKinds[record.Kind],
+			Height: record.Height,
+			TxID:   record.TxID.String(),
+			Amount: record.Amount,
+		}
+		if record.EntryHash != nil {
+			item.EntryHash = record.EntryHash.String()
+		}
+		resp.Transactions = append(resp.Transactions, item)
+	}
+	if next != nil {
+		resp.NextCursor = hex.EncodeToString(next)
+	}
+	return resp, nil
+}
+
+// balanceAtHeight returns the balance of the address after the directory block at the height
+func balanceAtHeight(state interfaces.IState, height uint32, address interfaces.IHash, ec bool) (interface{}, *primitives.JSONError) {
+	dbase := state.GetDB()
+	if !dbase.IsBalanceCheckpointsEnabled() {
+		return nil, NewBalanceCheckpointsDisabledError()
+	}
+	head, err := dbase.FetchDBlockHead()
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if head == nil || height > head.GetDatabaseHeight() {
+		return nil, NewBlockNotFoundError()
+	}
+
+	if ec {
+		_, balances, err := dbase.FetchBalancesAtHeight(height, nil, []interfaces.IHash{address})
+		if err == databaseOverlay.ErrBalanceCheckpointsNotReady {
+			return nil, NewBalanceCheckpointsNotReadyError()
+		}
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		resp := new(EntryCreditBalanceResponse)
+		resp.Balance = balances[0]
+		return resp, nil
+	}
+
+	balances, _, err := dbase.FetchBalancesAtHeight(height, []interfaces.IHash{address}, nil)
+	if err == databaseOverlay.ErrBalanceCheckpointsNotReady {
+		return nil, NewBalanceCheckpointsNotReadyError()
+	}
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	resp := new(FactoidBalanceResponse)
+	resp.Balance = balances[0]
+	return resp, nil
+}
+
 func HandleV2Heights(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallHeights.Observe(float64(time.Since(n).Nanoseconds()))
@@ -2,8 +2,10 @@ package wsapi_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
@@ -15,6 +17,7 @@ import (
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/receipts"
 	"github.com/FactomProject/factomd/testHelper"
 	. "github.com/FactomProject/factomd/wsapi"
@@ -513,3 +516,336 @@ func v2Request(req *primitives.JSON2Request, port int) (*primitives.JSON2Respons
 func number(n string) json.Number {
 	return json.Number(n)
 }
+
+func TestHandleV2EntriesByExtID(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	request := &EntriesByExtIDRequest{ExtIDs: []string{hex.EncodeToString([]byte("ExtID 1"))}, IncludeContent: true}
+
+	_, jErr := HandleV2EntriesByExtID(state, request)
+	assert.Equal(t, NewExtIDIndexDisabledError(), jErr)
+
+	dbo := state.GetDB().(interfaces.DBOverlay)
+	dbo.SetExtIDIndex(true)
+	defer dbo.SetExtIDIndex(false)
+	_, err := dbo.RebuildExtIDIndex()
+	assert.Nil(t, err)
+
+	resp, jErr := HandleV2EntriesByExtID(state, request)
+	assert.Nil(t, jErr)
+	entries := resp.(*EntriesByExtIDResponse).Entries
+	if assert.Equal(t, 1, len(entries)) {
+		entry := testHelper.CreateTestEntry(1)
+		assert.Equal(t, entry.GetHash().String(), entries[0].EntryHash)
+		assert.Equal(t, entry.GetChainID().String(), entries[0].ChainID)
+		assert.Equal(t, request.ExtIDs, entries[0].ExtIDs)
+		assert.Equal(t, hex.EncodeToString(entry.GetContent()), entries[0].Content)
+	}
+	assert.Empty(t, resp.(*EntriesByExtIDResponse).NextCursor)
+
+	request.ExtIDs = append(request.ExtIDs, request.ExtIDs[0])
+	_, jErr = HandleV2EntriesByExtID(state, request)
+	assert.NotNil(t, jErr)
+}
+
+func TestHandleV2AddressHistory(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+	var output interfaces.ITransAddress
+	for _, tx := range blocks[len(blocks)-1].FBlock.GetTransactions() {
+		if len(tx.GetOutputs()) > 0 {
+			output = tx.GetOutputs()[0]
+		}
+	}
+	request := &AddressHistoryRequest{Address: primitives.ConvertFctAddressToUserStr(output.GetAddress())}
+
+	_, jErr := HandleV2AddressHistory(state, request)
+	assert.Equal(t, NewAddressHistoryIndexDisabledError(), jErr)
+
+	dbo := state.GetDB().(interfaces.DBOverlay)
+	dbo.SetAddressHistoryIndex(true)
+	defer dbo.SetAddressHistoryIndex(false)
+	_, err := dbo.RebuildAddressHistoryIndex()
+	assert.Nil(t, err)
+
+	resp, jErr := HandleV2AddressHistory(state, request)
+	assert.Nil(t, jErr)
+	history := resp.(*AddressHistoryResponse).Transactions
+	if assert.NotEmpty(t, history) {
+		last := history[len(history)-1]
+		assert.Equal(t, "output", last.Kind)
+		assert.Equal(t, uint32(blocks[len(blocks)-1].Height), last.Height)
+		assert.Equal(t, output.GetAmount(), last.Amount)
+	}
+
+	// the pages hold the same transactions
+	request.Limit = 1
+	var paged []AddressHistoryTransaction
+	for i := 0; i <= len(history); i++ {
+		resp, jErr = HandleV2AddressHistory(state, request)
+		assert.Nil(t, jErr)
+		paged = append(paged, resp.(*AddressHistoryResponse).Transactions...)
+		if resp.(*AddressHistoryResponse).NextCursor == "" {
+			break
+		}
+		request.Cursor = resp.(*AddressHistoryResponse).NextCursor
+	}
+	assert.Equal(t, history, paged)
+
+	request.Address = "invalid"
+	_, jErr = HandleV2AddressHistory(state, request)
+	assert.Equal(t, NewInvalidAddressError(), jErr)
+}
+
+func TestHandleV2BalanceAtHeight(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	height := state.GetHighestSavedBlk()
+	fctRequest := &AddressRequest{Address: testHelper.NewFactoidRCDAddressString(0), Height: &height}
+	ecRequest := &AddressRequest{Address: testHelper.NewECAddressPublicKeyString(0), Height: &height}
+
+	_, jErr := HandleV2FactoidBalance(state, fctRequest)
+	assert.Equal(t, NewBalanceCheckpointsDisabledError(), jErr)
+
+	dbo := state.GetDB()
+	dbo.SetBalanceCheckpoints(true)
+	defer dbo.SetBalanceCheckpoints(false)
+
+	// the balances at the highest saved block match the current balances
+	for _, request := range []*AddressRequest{fctRequest, ecRequest} {
+		handler := HandleV2FactoidBalance
+		if request == ecRequest {
+			handler = HandleV2EntryCreditBalance
+		}
+		current, jErr := handler(state, &AddressRequest{Address: request.Address})
+		assert.Nil(t, jErr)
+		resp, jErr := handler(state, request)
+		assert.Nil(t, jErr)
+		assert.Equal(t, current, resp)
+	}
+
+	above := uint32(testHelper.BlockCount)
+	fctRequest.Height = &above
+	_, jErr = HandleV2FactoidBalance(state, fctRequest)
+	assert.Equal(t, NewBlockNotFoundError(), jErr)
+}
+
+func TestHandleV2ChainEntries(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	var expected []string
+	for _, block := range blocks {
+		for _, hash := range block.EBlock.GetEntryHashes() {
+			if !hash.IsMinuteMarker() {
+				expected = append(expected, hash.String())
+			}
+		}
+	}
+	entryHashes := func(resp interface{}) []string {
+		hashes := []string{}
+		for _, entry := range resp.(*ChainEntriesResponse).Entries {
+			hashes = append(hashes, entry.EntryHash)
+		}
+		return hashes
+	}
+
+	request := &ChainEntriesRequest{ChainID: blocks[0].EBlock.GetChainID().String()}
+	resp, jErr := HandleV2ChainEntries(state, request)
+	assert.Nil(t, jErr)
+	assert.Equal(t, expected, entryHashes(resp))
+	assert.Empty(t, resp.(*ChainEntriesResponse).NextCursor)
+
+	// the pages hold the same entries, in reverse order when asked
+	for _, reverse := range []bool{false, true} {
+		request = &ChainEntriesRequest{ChainID: request.ChainID, Limit: 3, Reverse: reverse}
+		var paged []string
+		for i := 0; i <= len(expected); i++ {
+			resp, jErr = HandleV2ChainEntries(state, request)
+			assert.Nil(t, jErr)
+			paged = append(paged, entryHashes(resp)...)
+			if resp.(*ChainEntriesResponse).NextCursor == "" {
+				break
+			}
+			request.Cursor = resp.(*ChainEntriesResponse).NextCursor
+		}
+		if reverse {
+			for i, j := 0, len(paged)-1; i < j; i, j = i+1, j-1 {
+				paged[i], paged[j] = paged[j], paged[i]
+			}
+		}
+		assert.Equal(t, expected, paged)
+	}
+
+	from, to := uint32(2), uint32(4)
+	request = &ChainEntriesRequest{ChainID: request.ChainID, FromHeight: &from, ToHeight: &to, IncludeContent: true}
+	resp, jErr = HandleV2ChainEntries(state, request)
+	assert.Nil(t, jErr)
+	entries := resp.(*ChainEntriesResponse).Entries
+	if assert.Equal(t, 3, len(entries)) {
+		for i, entry := range entries {
+			assert.Equal(t, from+uint32(i), entry.DBHeight)
+			assert.Equal(t, hex.EncodeToString(blocks[from+uint32(i)].Entries[0].GetContent()), entry.Content)
+		}
+	}
+
+	request = &ChainEntriesRequest{ChainID: primitives.NewZeroHash().String()}
+	_, jErr = HandleV2ChainEntries(state, request)
+	assert.Equal(t, NewMissingChainHeadError(), jErr)
+}
+
+func TestHandleV2ChainEntries_missingEBlocks(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+	chainID := blocks[0].EBlock.GetChainID()
+
+	// a page of heights that all miss their entry block still has a cursor to the heights after it
+	dbo := state.GetDB().(interfaces.DBOverlay)
+	for _, block := range blocks[:6] {
+		assert.Nil(t, dbo.Delete(databaseOverlay.ENTRYBLOCK, block.EBlock.DatabasePrimaryIndex().Bytes()))
+	}
+	var expected []string
+	for _, block := range blocks[6:] {
+		expected = append(expected, block.Entries[0].GetHash().String())
+	}
+
+	for _, reverse := range []bool{false, true} {
+		request := &ChainEntriesRequest{ChainID: chainID.String(), Limit: 3, Reverse: reverse}
+		var paged []string
+		for i := 0; i <= len(blocks); i++ {
+			resp, jErr := HandleV2ChainEntries(state, request)
+			assert.Nil(t, jErr)
+			for _, entry := range resp.(*ChainEntriesResponse).Entries {
+				paged = append(paged, entry.EntryHash)
+			}
+			if resp.(*ChainEntriesResponse).NextCursor == "" {
+				break
+			}
+			request.Cursor = resp.(*ChainEntriesResponse).NextCursor
+		}
+		if reverse {
+			for i, j := 0, len(paged)-1; i < j; i, j = i+1, j-1 {
+				paged[i], paged[j] = paged[j], paged[i]
+			}
+		}
+		assert.Equal(t, expected, paged)
+	}
+}
+
+func TestHandleV2_pruned(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+	chainID := blocks[0].EBlock.GetChainID()
+
+	dbo := state.GetDB().(interfaces.DBOverlay)
+	dbo.SetPruning(3, nil)
+	pruned, err := dbo.PruneEntries()
+	assert.Nil(t, err)
+
+	old, recent := blocks[0], blocks[len(blocks)-1]
+	_, jErr := HandleV2Entry(state, &HashRequest{Hash: old.Entries[0].GetHash().String()})
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewPrunedDataError(nil).Code, jErr.Code)
+	}
+	_, jErr = HandleV2EntryBlock(state, &KeyMRRequest{KeyMR: old.EBlock.DatabasePrimaryIndex().String()})
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewPrunedDataError(nil).Code, jErr.Code)
+	}
+	_, jErr = HandleV2RawData(state, &HashRequest{Hash: old.Entries[0].GetHash().String()})
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewPrunedDataError(nil).Code, jErr.Code)
+	}
+
+	// unknown and recent data are not reported as pruned
+	_, jErr = HandleV2Entry(state, &HashRequest{Hash: primitives.Sha([]byte("unknown")).String()})
+	assert.Equal(t, NewEntryNotFoundError(), jErr)
+	_, jErr = HandleV2Entry(state, &HashRequest{Hash: recent.Entries[0].GetHash().String()})
+	assert.Nil(t, jErr)
+
+	_, jErr = HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: chainID.String()})
+	if assert.NotNil(t, jErr) {
+		assert.Equal(t, NewPrunedDataError(nil).Code, jErr.Code)
+	}
+	resp, jErr := HandleV2ChainEntries(state, &ChainEntriesRequest{ChainID: chainID.String(), FromHeight: &pruned})
+	assert.Nil(t, jErr)
+	assert.Equal(t, len(blocks)-int(pruned), len(resp.(*ChainEntriesResponse).Entries))
+}
+
+func TestHandleV2Batch(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	delayedStart(t, state)
+
+	post := func(body string) (*http.Response, []byte) {
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/v2", state.GetPort()), "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, data
+	}
+
+	chainHead := primitives.NewJSON2Request("chain-head", 3, ChainIDRequest{ChainID: "6e7e64ac45ff57edbf8537a0c99fba2e9ee351ef3d3f4abd93af9f01107e592c"})
+	requests := []interface{}{
+		primitives.NewJSON2Request("heights", 1, nil),
+		primitives.NewJSON2Request("no-such-method", 2, nil),
+		map[string]interface{}{"jsonrpc": "1.0", "id": 4, "method": "heights"},
+		primitives.NewJSON2Request("heights", nil, nil),
+		chainHead,
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, data := post(string(body))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var responses []*primitives.JSON2Response
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatal(err)
+	}
+	// the notification is not answered
+	if assert.Equal(t, len(requests)-1, len(responses)) {
+		assert.Equal(t, float64(1), responses[0].ID)
+		assert.Nil(t, responses[0].Error)
+		assert.NotNil(t, responses[0].Result)
+
+		assert.Equal(t, float64(2), responses[1].ID)
+		assert.Equal(t, NewMethodNotFoundError(), responses[1].Error)
+
+		assert.Nil(t, responses[2].ID)
+		assert.Equal(t, NewInvalidRequestError(), responses[2].Error)
+
+		assert.Equal(t, float64(3), responses[3].ID)
+		assert.Nil(t, responses[3].Error)
+		single, err := v2Request(chainHead, state.GetPort())
+		assert.Nil(t, err)
+		assert.Equal(t, single.Result.(map[string]interface{})["chainhead"], responses[3].Result.(map[string]interface{})["chainhead"])
+	}
+
+	notifications, err := json.Marshal([]interface{}{
+		primitives.NewJSON2Request("heights", nil, nil),
+		map[string]interface{}{"jsonrpc": "2.0", "method": "no-such-method"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, data = post(string(notifications))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, data)
+
+	resp, data = post("[]")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	single := primitives.NewJSON2Response()
+	assert.Nil(t, json.Unmarshal(data, single))
+	assert.Equal(t, NewInvalidRequestError(), single.Error)
+
+	defer func(limit int) { state.RequestLimit = limit }(state.RequestLimit)
+	state.RequestLimit = len(requests) - 1
+	resp, data = post(string(body))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	single = primitives.NewJSON2Response()
+	assert.Nil(t, json.Unmarshal(data, single))
+	assert.Equal(t, NewBatchTooLargeError(len(requests)-1).Code, single.Error.Code)
+}